@@ -11,8 +11,10 @@ import (
 	"time"
 
 	"cryptoportfolio/internal/api/routes"
+	"cryptoportfolio/internal/app"
 	"cryptoportfolio/internal/config"
 	"cryptoportfolio/internal/database"
+	"cryptoportfolio/internal/metrics"
 	"cryptoportfolio/pkg/logger"
 
 	"github.com/gin-gonic/gin"
@@ -72,8 +74,17 @@ func main() {
 		gin.SetMode(gin.ReleaseMode)
 	}
 
+	// Wire up the services; background jobs run here unless a separate worker runs them
+	application := app.New(db, appLogger, cfg)
+	application.Start(context.Background())
+	if cfg.Server.RunJobs {
+		application.StartJobs(context.Background())
+	} else {
+		log.Println("Background jobs disabled, run cmd/worker to process them")
+	}
+
 	// Setup router
-	router := routes.Setup(db, appLogger, cfg)
+	router := routes.Setup(application)
 
 	// Create server
 	srv := &http.Server{
@@ -89,12 +100,27 @@ func main() {
 		}
 	}()
 
+	// Serve metrics apart from the API, so they are not public with it
+	var metricsServer *http.Server
+	if cfg.Metrics.Enabled {
+		metricsServer = metrics.NewServer(cfg.Metrics.APIPort)
+		go func() {
+			log.Printf("Metrics listening on port %d", cfg.Metrics.APIPort)
+			if err := metricsServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+				log.Fatalf("Failed to start metrics server: %v", err)
+			}
+		}()
+	}
+
 	// Wait for interrupt signal to gracefully shutdown the server
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
 	<-quit
 	log.Println("Shutting down server...")
 
+	// Stop background jobs and end open balance streams so the server can drain
+	application.Shutdown()
+
 	// The context is used to inform the server it has 5 seconds to finish
 	// the request it is currently handling
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
@@ -102,6 +128,11 @@ func main() {
 	if err := srv.Shutdown(ctx); err != nil {
 		log.Fatal("Server forced to shutdown:", err)
 	}
+	if metricsServer != nil {
+		if err := metricsServer.Shutdown(ctx); err != nil {
+			log.Printf("Metrics server forced to shutdown: %v", err)
+		}
+	}
 
 	log.Println("Server exiting")
 }
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"cryptoportfolio/internal/app"
+	"cryptoportfolio/internal/config"
+	"cryptoportfolio/internal/database"
+	"cryptoportfolio/internal/metrics"
+	"cryptoportfolio/pkg/logger"
+)
+
+// The worker runs the background jobs without the HTTP API: balance fetching
+// and cleanup, Transfer event watching, queued refreshes, historical backfills,
+// transaction ingestion and webhook delivery. Run the API server with
+// SERVER_RUN_JOBS=false next to it.
+func main() {
+	// Load configuration
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatalf("Failed to load config: %v", err)
+	}
+
+	// Initialize logger
+	appLogger := logger.New()
+
+	// Initialize database
+	db, err := database.New(cfg.Database)
+	if err != nil {
+		log.Fatalf("Failed to connect to database: %v", err)
+	}
+
+	// Wire up the same services as the API server and start the jobs
+	application := app.New(db, appLogger, cfg)
+	application.Start(context.Background())
+	application.StartJobs(context.Background())
+	log.Println("Worker started")
+
+	// Serve the fetcher, RPC and cache metrics, since the worker has no API
+	var metricsServer *http.Server
+	if cfg.Metrics.Enabled {
+		metricsServer = metrics.NewServer(cfg.Metrics.WorkerPort)
+		go func() {
+			log.Printf("Metrics listening on port %d", cfg.Metrics.WorkerPort)
+			if err := metricsServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+				log.Fatalf("Failed to start metrics server: %v", err)
+			}
+		}()
+	}
+
+	// Wait for interrupt signal to gracefully stop the jobs
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
+	<-quit
+	log.Println("Shutting down worker...")
+
+	application.Shutdown()
+
+	if metricsServer != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := metricsServer.Shutdown(ctx); err != nil {
+			log.Printf("Metrics server forced to shutdown: %v", err)
+		}
+	}
+
+	log.Println("Worker exiting")
+}
@@ -0,0 +1,112 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"cryptoportfolio/internal/models"
+	"cryptoportfolio/internal/services"
+	"cryptoportfolio/pkg/logger"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AdminHandler handles administration HTTP requests
+type AdminHandler struct {
+	fetchRunService services.FetchRunService
+	logger          *logger.Logger
+}
+
+// NewAdminHandler creates a new admin handler
+func NewAdminHandler(fetchRunService services.FetchRunService, logger *logger.Logger) *AdminHandler {
+	return &AdminHandler{
+		fetchRunService: fetchRunService,
+		logger:          logger,
+	}
+}
+
+// GetFetchRuns godoc
+// @Summary List balance fetch runs
+// @Description List balance fetch runs across all users, newest first, with how many pairs were stored or failed. Runs are kept for WEB3_FETCH_RUN_RETENTION_DAYS. Requires an admin user.
+// @Tags Admin
+// @Produce json
+// @Param trigger query string false "Filter by trigger: schedule, refresh or transfer"
+// @Param limit query int false "Number of records to return (default: 50, max: 100)"
+// @Param offset query int false "Number of records to skip (default: 0)"
+// @Security BearerAuth
+// @Success 200 {object} services.FetchRunPage
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/admin/fetch-runs [get]
+func (h *AdminHandler) GetFetchRuns() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		trigger := c.Query("trigger")
+		switch trigger {
+		case "", models.FetchRunTriggerSchedule, models.FetchRunTriggerRefresh, models.FetchRunTriggerTransfer:
+		default:
+			c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid trigger"})
+			return
+		}
+
+		limit, err := strconv.Atoi(c.DefaultQuery("limit", "50"))
+		if err != nil || limit <= 0 {
+			limit = 50
+		}
+		if limit > 100 {
+			limit = 100
+		}
+
+		offset, err := strconv.Atoi(c.DefaultQuery("offset", "0"))
+		if err != nil || offset < 0 {
+			c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid offset"})
+			return
+		}
+
+		page, err := h.fetchRunService.ListRuns(c.Request.Context(), trigger, limit, offset)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to list fetch runs"})
+			return
+		}
+
+		c.JSON(http.StatusOK, page)
+	}
+}
+
+// GetFetchRun godoc
+// @Summary Get balance fetch run
+// @Description Get a fetch run with the block read on each chain and every pair that failed, up to 500 per run. Requires an admin user.
+// @Tags Admin
+// @Produce json
+// @Param id path int true "Fetch run ID"
+// @Security BearerAuth
+// @Success 200 {object} models.FetchRun
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/admin/fetch-runs/{id} [get]
+func (h *AdminHandler) GetFetchRun() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		runID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid fetch run ID"})
+			return
+		}
+
+		run, err := h.fetchRunService.GetRun(c.Request.Context(), uint(runID))
+		if err != nil {
+			switch err {
+			case services.ErrFetchRunNotFound:
+				c.JSON(http.StatusNotFound, ErrorResponse{Error: "Fetch run not found"})
+			default:
+				c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to get fetch run"})
+			}
+			return
+		}
+
+		c.JSON(http.StatusOK, run)
+	}
+}
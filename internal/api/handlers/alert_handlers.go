@@ -0,0 +1,243 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"cryptoportfolio/internal/services"
+	"cryptoportfolio/pkg/logger"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AlertHandler handles alert rule and fired alert HTTP requests
+type AlertHandler struct {
+	alertService services.AlertService
+	logger       *logger.Logger
+}
+
+// NewAlertHandler creates a new alert handler
+func NewAlertHandler(alertService services.AlertService, logger *logger.Logger) *AlertHandler {
+	return &AlertHandler{
+		alertService: alertService,
+		logger:       logger,
+	}
+}
+
+// CreateRule godoc
+// @Summary Create alert rule
+// @Description Create a rule checked against every newly fetched balance of the wallet. balance_drop fires when a balance falls by more than threshold percent from the previous snapshot; balance_below and balance_above when it crosses threshold tokens; outgoing_transfer on each ingested outgoing transfer, or on any decrease when transactions are not ingested; usd_value_cross when the USD value crosses threshold in either direction. A rule fires at most once per cooldown for each token.
+// @Tags Alerts
+// @Accept json
+// @Produce json
+// @Param rule body services.AlertRuleRequest true "Alert rule"
+// @Security BearerAuth
+// @Success 201 {object} services.AlertRuleResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/alerts [post]
+func (h *AlertHandler) CreateRule() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req services.AlertRuleRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid request body"})
+			return
+		}
+
+		userID := c.GetUint("user_id")
+		rule, err := h.alertService.CreateRule(c.Request.Context(), userID, &req)
+		if err != nil {
+			h.handleRuleError(c, err, userID, "Failed to create alert rule")
+			return
+		}
+
+		c.JSON(http.StatusCreated, rule)
+	}
+}
+
+// GetRules godoc
+// @Summary Get alert rules
+// @Description Retrieve all of the user's alert rules
+// @Tags Alerts
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {array} services.AlertRuleResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/alerts [get]
+func (h *AlertHandler) GetRules() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID := c.GetUint("user_id")
+		rules, err := h.alertService.GetRules(c.Request.Context(), userID)
+		if err != nil {
+			h.logger.Error("Failed to get alert rules", "error", err, "user_id", userID)
+			c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to get alert rules"})
+			return
+		}
+
+		c.JSON(http.StatusOK, rules)
+	}
+}
+
+// GetRule godoc
+// @Summary Get alert rule
+// @Description Retrieve one of the user's alert rules
+// @Tags Alerts
+// @Produce json
+// @Param id path int true "Alert rule ID"
+// @Security BearerAuth
+// @Success 200 {object} services.AlertRuleResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/alerts/{id} [get]
+func (h *AlertHandler) GetRule() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ruleID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid alert rule ID"})
+			return
+		}
+
+		userID := c.GetUint("user_id")
+		rule, err := h.alertService.GetRule(c.Request.Context(), userID, uint(ruleID))
+		if err != nil {
+			h.handleRuleError(c, err, userID, "Failed to get alert rule")
+			return
+		}
+
+		c.JSON(http.StatusOK, rule)
+	}
+}
+
+// UpdateRule godoc
+// @Summary Update alert rule
+// @Description Replace the settings of one of the user's alert rules
+// @Tags Alerts
+// @Accept json
+// @Produce json
+// @Param id path int true "Alert rule ID"
+// @Param rule body services.AlertRuleRequest true "Alert rule"
+// @Security BearerAuth
+// @Success 200 {object} services.AlertRuleResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/alerts/{id} [put]
+func (h *AlertHandler) UpdateRule() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ruleID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid alert rule ID"})
+			return
+		}
+
+		var req services.AlertRuleRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid request body"})
+			return
+		}
+
+		userID := c.GetUint("user_id")
+		rule, err := h.alertService.UpdateRule(c.Request.Context(), userID, uint(ruleID), &req)
+		if err != nil {
+			h.handleRuleError(c, err, userID, "Failed to update alert rule")
+			return
+		}
+
+		c.JSON(http.StatusOK, rule)
+	}
+}
+
+// DeleteRule godoc
+// @Summary Delete alert rule
+// @Description Remove one of the user's alert rules. Alerts it already fired are kept.
+// @Tags Alerts
+// @Produce json
+// @Param id path int true "Alert rule ID"
+// @Security BearerAuth
+// @Success 200 {object} SuccessResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/alerts/{id} [delete]
+func (h *AlertHandler) DeleteRule() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ruleID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid alert rule ID"})
+			return
+		}
+
+		userID := c.GetUint("user_id")
+		if err := h.alertService.DeleteRule(c.Request.Context(), userID, uint(ruleID)); err != nil {
+			h.handleRuleError(c, err, userID, "Failed to delete alert rule")
+			return
+		}
+
+		c.JSON(http.StatusOK, SuccessResponse{Message: "Alert rule removed"})
+	}
+}
+
+// GetAlerts godoc
+// @Summary Get fired alerts
+// @Description List the alerts fired by the user's rules, newest first
+// @Tags Alerts
+// @Produce json
+// @Param limit query int false "Number of records to return (default: 50, max: 100)"
+// @Param offset query int false "Number of records to skip (default: 0)"
+// @Security BearerAuth
+// @Success 200 {object} services.AlertPage
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/alerts/history [get]
+func (h *AlertHandler) GetAlerts() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		limit, err := strconv.Atoi(c.DefaultQuery("limit", "50"))
+		if err != nil || limit <= 0 {
+			limit = 50
+		}
+		if limit > 100 {
+			limit = 100
+		}
+
+		offset, err := strconv.Atoi(c.DefaultQuery("offset", "0"))
+		if err != nil || offset < 0 {
+			c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid offset"})
+			return
+		}
+
+		userID := c.GetUint("user_id")
+		page, err := h.alertService.GetAlerts(c.Request.Context(), userID, limit, offset)
+		if err != nil {
+			h.logger.Error("Failed to get alerts", "error", err, "user_id", userID)
+			c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to get alerts"})
+			return
+		}
+
+		c.JSON(http.StatusOK, page)
+	}
+}
+
+// handleRuleError maps alert rule errors to responses
+func (h *AlertHandler) handleRuleError(c *gin.Context, err error, userID uint, message string) {
+	switch err {
+	case services.ErrInvalidAlertRule:
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid alert rule"})
+	case services.ErrAlertRuleNotFound:
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "Alert rule not found"})
+	case services.ErrWalletNotFound:
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "Wallet not found"})
+	case services.ErrTokenNotFound:
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "Token not found"})
+	default:
+		h.logger.Error(message, "error", err, "user_id", userID)
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: message})
+	}
+}
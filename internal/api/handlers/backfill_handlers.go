@@ -0,0 +1,87 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"cryptoportfolio/internal/services"
+	"cryptoportfolio/pkg/logger"
+
+	"github.com/gin-gonic/gin"
+)
+
+// BackfillHandler handles historical balance backfill HTTP requests
+type BackfillHandler struct {
+	backfillService services.BackfillService
+	logger          *logger.Logger
+}
+
+// NewBackfillHandler creates a new backfill handler
+func NewBackfillHandler(backfillService services.BackfillService, logger *logger.Logger) *BackfillHandler {
+	return &BackfillHandler{
+		backfillService: backfillService,
+		logger:          logger,
+	}
+}
+
+// GetBackfills godoc
+// @Summary List balance backfills
+// @Description List the historical balance backfill jobs started for the user's wallet/token pairs, newest first
+// @Tags Watchlist
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {array} services.BackfillJobResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/watchlist/backfills [get]
+func (h *BackfillHandler) GetBackfills() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID := c.GetUint("user_id")
+		jobs, err := h.backfillService.GetJobs(c.Request.Context(), userID)
+		if err != nil {
+			h.logger.Error("Failed to get backfills", "error", err, "user_id", userID)
+			c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to get backfills"})
+			return
+		}
+
+		c.JSON(http.StatusOK, jobs)
+	}
+}
+
+// GetBackfill godoc
+// @Summary Get balance backfill progress
+// @Description Get the status and progress of a historical balance backfill job
+// @Tags Watchlist
+// @Produce json
+// @Param id path int true "Backfill job ID"
+// @Security BearerAuth
+// @Success 200 {object} services.BackfillJobResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/watchlist/backfills/{id} [get]
+func (h *BackfillHandler) GetBackfill() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		jobID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid backfill ID"})
+			return
+		}
+
+		userID := c.GetUint("user_id")
+		job, err := h.backfillService.GetJob(c.Request.Context(), userID, uint(jobID))
+		if err != nil {
+			switch err {
+			case services.ErrBackfillJobNotFound:
+				c.JSON(http.StatusNotFound, ErrorResponse{Error: "Backfill not found"})
+			default:
+				h.logger.Error("Failed to get backfill", "error", err, "user_id", userID, "job_id", jobID)
+				c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to get backfill"})
+			}
+			return
+		}
+
+		c.JSON(http.StatusOK, job)
+	}
+}
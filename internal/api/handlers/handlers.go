@@ -54,6 +54,10 @@ type UpdateUserRequest struct {
 	Name string `json:"name" binding:"required,min=2" example:"John Doe Updated"`
 }
 
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token" binding:"required" example:"3f8a1c..."`
+}
+
 // Response types for Swagger documentation
 type UserResponse struct {
 	ID        uint      `json:"id" example:"1"`
@@ -64,9 +68,17 @@ type UserResponse struct {
 }
 
 type AuthResponse struct {
-	Message string       `json:"message" example:"User registered successfully"`
-	Token   string       `json:"token" example:"eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9..."`
-	User    UserResponse `json:"user"`
+	Message      string       `json:"message" example:"User registered successfully"`
+	Token        string       `json:"token" example:"eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9..."`
+	RefreshToken string       `json:"refresh_token" example:"3f8a1c..."`
+	ExpiresIn    int          `json:"expires_in" example:"900"`
+	User         UserResponse `json:"user"`
+}
+
+type RefreshResponse struct {
+	Token        string `json:"token" example:"eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9..."`
+	RefreshToken string `json:"refresh_token" example:"3f8a1c..."`
+	ExpiresIn    int    `json:"expires_in" example:"900"`
 }
 
 type ErrorResponse struct {
@@ -171,6 +183,103 @@ func (h *Handler) Login() gin.HandlerFunc {
 	}
 }
 
+// Refresh exchanges a refresh token for a new token pair
+// @Summary Refresh access token
+// @Description Exchange a refresh token for a new access token and refresh token. Each refresh token can be used once; presenting a used one again revokes the whole session.
+// @Tags Authentication
+// @Accept json
+// @Produce json
+// @Param request body RefreshRequest true "Refresh token"
+// @Success 200 {object} RefreshResponse "Tokens refreshed"
+// @Failure 400 {object} ErrorResponse "Invalid request data"
+// @Failure 401 {object} ErrorResponse "Invalid, expired or reused refresh token"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /api/v1/auth/refresh [post]
+func (h *Handler) Refresh() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req RefreshRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			errorResponse(c, http.StatusBadRequest, "Invalid request data")
+			return
+		}
+
+		// Call service layer
+		ctx := c.Request.Context()
+		response, err := h.userService.Refresh(ctx, req.RefreshToken)
+		if err != nil {
+			switch err {
+			case services.ErrInvalidRefreshToken:
+				errorResponse(c, http.StatusUnauthorized, "Invalid or expired refresh token")
+			case services.ErrRefreshTokenReused:
+				errorResponse(c, http.StatusUnauthorized, "Refresh token reused, session revoked")
+			default:
+				errorResponse(c, http.StatusInternalServerError, "Failed to refresh token")
+			}
+			return
+		}
+
+		c.JSON(http.StatusOK, response)
+	}
+}
+
+// Logout ends the current session
+// @Summary Log out
+// @Description Revoke the current access token and the refresh tokens of its session
+// @Tags Authentication
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} SuccessResponse "Logged out"
+// @Failure 401 {object} ErrorResponse "User not authenticated"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /api/v1/auth/logout [post]
+func (h *Handler) Logout() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, exists := c.Get("user_id")
+		if !exists {
+			errorResponse(c, http.StatusUnauthorized, "User not authenticated")
+			return
+		}
+
+		// Call service layer
+		ctx := c.Request.Context()
+		if err := h.userService.Logout(ctx, userID.(uint), c.GetString("token_id"), c.GetTime("token_expires_at")); err != nil {
+			errorResponse(c, http.StatusInternalServerError, "Failed to log out")
+			return
+		}
+
+		c.JSON(http.StatusOK, SuccessResponse{Message: "Logged out successfully"})
+	}
+}
+
+// LogoutAll ends every session of the current user
+// @Summary Log out of all sessions
+// @Description Revoke every access and refresh token of the current user, on every device
+// @Tags Authentication
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} SuccessResponse "Logged out of all sessions"
+// @Failure 401 {object} ErrorResponse "User not authenticated"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /api/v1/auth/logout-all [post]
+func (h *Handler) LogoutAll() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, exists := c.Get("user_id")
+		if !exists {
+			errorResponse(c, http.StatusUnauthorized, "User not authenticated")
+			return
+		}
+
+		// Call service layer
+		ctx := c.Request.Context()
+		if err := h.userService.LogoutAll(ctx, userID.(uint), c.GetString("token_id"), c.GetTime("token_expires_at")); err != nil {
+			errorResponse(c, http.StatusInternalServerError, "Failed to log out")
+			return
+		}
+
+		c.JSON(http.StatusOK, SuccessResponse{Message: "Logged out of all sessions"})
+	}
+}
+
 // GetCurrentUser retrieves the current authenticated user's profile
 // @Summary Get current user profile
 // @Description Retrieve the profile information of the currently authenticated user
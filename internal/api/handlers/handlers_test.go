@@ -38,6 +38,32 @@ func (m *MockUserService) Login(ctx context.Context, req *services.LoginRequest)
 	return args.Get(0).(*services.AuthResponse), args.Error(1)
 }
 
+func (m *MockUserService) Refresh(ctx context.Context, refreshToken string) (*services.RefreshResponse, error) {
+	args := m.Called(ctx, refreshToken)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*services.RefreshResponse), args.Error(1)
+}
+
+func (m *MockUserService) Logout(ctx context.Context, userID uint, tokenID string, expiresAt time.Time) error {
+	args := m.Called(ctx, userID, tokenID, expiresAt)
+	return args.Error(0)
+}
+
+func (m *MockUserService) LogoutAll(ctx context.Context, userID uint, tokenID string, expiresAt time.Time) error {
+	args := m.Called(ctx, userID, tokenID, expiresAt)
+	return args.Error(0)
+}
+
+func (m *MockUserService) SignInWithWallet(ctx context.Context, currentUserID uint, address string) (*services.AuthResponse, error) {
+	args := m.Called(ctx, currentUserID, address)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*services.AuthResponse), args.Error(1)
+}
+
 func (m *MockUserService) GetUserByID(ctx context.Context, userID uint) (*services.UserResponse, error) {
 	args := m.Called(ctx, userID)
 	if args.Get(0) == nil {
@@ -95,6 +121,7 @@ func setupTestRouter(handler *Handler) *gin.Engine {
 	router.GET("/health", handler.HealthCheck)
 	router.POST("/auth/register", handler.Register())
 	router.POST("/auth/login", handler.Login())
+	router.POST("/auth/refresh", handler.Refresh())
 	router.GET("/users/me", handler.GetCurrentUser())
 	router.PUT("/users/me", handler.UpdateUser())
 	
@@ -165,3 +192,39 @@ func TestHealthCheck(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Equal(t, "ok", response["status"])
 }
+
+func TestRefreshHandler(t *testing.T) {
+	handler, mockService := setupTestHandler()
+	router := setupTestRouter(handler)
+
+	mockService.On("Refresh", mock.Anything, "valid").Return(&services.RefreshResponse{
+		Token:        "new-jwt-token",
+		RefreshToken: "new-refresh-token",
+		ExpiresIn:    900,
+	}, nil)
+	mockService.On("Refresh", mock.Anything, "reused").Return(nil, services.ErrRefreshTokenReused)
+
+	refresh := func(token string) *httptest.ResponseRecorder {
+		jsonBody, _ := json.Marshal(RefreshRequest{RefreshToken: token})
+		req := httptest.NewRequest("POST", "/auth/refresh", bytes.NewBuffer(jsonBody))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	w := refresh("valid")
+	assert.Equal(t, http.StatusOK, w.Code)
+	var response map[string]interface{}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, "new-jwt-token", response["token"])
+	assert.Equal(t, "new-refresh-token", response["refresh_token"])
+
+	w = refresh("reused")
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+	w = refresh("")
+	assert.Equal(t, http.StatusBadRequest, w.Code, "a refresh token is required")
+
+	mockService.AssertExpectations(t)
+}
@@ -0,0 +1,63 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"cryptoportfolio/internal/services"
+	"cryptoportfolio/pkg/logger"
+
+	"github.com/gin-gonic/gin"
+)
+
+// JobHandler handles queued job HTTP requests
+type JobHandler struct {
+	jobService services.JobService
+	logger     *logger.Logger
+}
+
+// NewJobHandler creates a new job handler
+func NewJobHandler(jobService services.JobService, logger *logger.Logger) *JobHandler {
+	return &JobHandler{
+		jobService: jobService,
+		logger:     logger,
+	}
+}
+
+// GetJob godoc
+// @Summary Get job status
+// @Description Report the status and progress of a queued job, such as a balance refresh. Status is pending, running, completed or failed; a completed refresh counts pairs that could not be fetched in failed_items.
+// @Tags Watchlist
+// @Produce json
+// @Param id path int true "Job ID"
+// @Security BearerAuth
+// @Success 200 {object} services.JobResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/jobs/{id} [get]
+func (h *JobHandler) GetJob() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		jobID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid job ID"})
+			return
+		}
+
+		userID := c.GetUint("user_id")
+		job, err := h.jobService.GetJob(c.Request.Context(), userID, uint(jobID))
+		if err != nil {
+			switch err {
+			case services.ErrJobNotFound:
+				c.JSON(http.StatusNotFound, ErrorResponse{Error: "Job not found"})
+			default:
+				h.logger.Error("Failed to get job", "error", err, "user_id", userID, "job_id", jobID)
+				c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to get job"})
+			}
+			return
+		}
+
+		c.JSON(http.StatusOK, job)
+	}
+}
@@ -0,0 +1,165 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"cryptoportfolio/internal/services"
+	"cryptoportfolio/pkg/logger"
+
+	"github.com/gin-gonic/gin"
+)
+
+// PnLHandler handles cost basis and profit and loss HTTP requests
+type PnLHandler struct {
+	pnlService services.PnLService
+	logger     *logger.Logger
+}
+
+// NewPnLHandler creates a new PnL handler
+func NewPnLHandler(pnlService services.PnLService, logger *logger.Logger) *PnLHandler {
+	return &PnLHandler{
+		pnlService: pnlService,
+		logger:     logger,
+	}
+}
+
+// GetPnL godoc
+// @Summary Get realized and unrealized PnL
+// @Description Realized and unrealized profit and loss per token and per wallet, matching disposals to lots from the wallets' transfer history and manual lots. Transfers are valued at the price stamped on the nearest balance snapshot; transfers between the user's own wallets move lots without realizing gains.
+// @Tags Portfolio
+// @Produce json
+// @Param method query string false "Cost basis method: fifo (default), lifo or average"
+// @Security BearerAuth
+// @Success 200 {object} services.PnLReport
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/portfolio/pnl [get]
+func (h *PnLHandler) GetPnL() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID := c.GetUint("user_id")
+		report, err := h.pnlService.GetPnL(c.Request.Context(), userID, c.Query("method"))
+		if err != nil {
+			switch err {
+			case services.ErrUnsupportedCostBasis:
+				c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Unsupported cost basis method"})
+			default:
+				h.logger.Error("Failed to get PnL", "error", err, "user_id", userID)
+				c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to get PnL"})
+			}
+			return
+		}
+
+		c.JSON(http.StatusOK, report)
+	}
+}
+
+// AddLot godoc
+// @Summary Add a manual lot
+// @Description Enter the cost of tokens the system cannot price. With transaction_id the lot prices that incoming transfer; without it the lot is an acquisition with no recorded transfer, such as a balance held before the wallet was added.
+// @Tags Portfolio
+// @Accept json
+// @Produce json
+// @Param lot body services.AddLotRequest true "Lot information"
+// @Security BearerAuth
+// @Success 201 {object} services.LotResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/portfolio/lots [post]
+func (h *PnLHandler) AddLot() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req services.AddLotRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid request body"})
+			return
+		}
+
+		userID := c.GetUint("user_id")
+		lot, err := h.pnlService.AddLot(c.Request.Context(), userID, &req)
+		if err != nil {
+			switch err {
+			case services.ErrInvalidLot:
+				c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid lot"})
+			case services.ErrWalletNotFound:
+				c.JSON(http.StatusNotFound, ErrorResponse{Error: "Wallet not found"})
+			case services.ErrTokenNotFound:
+				c.JSON(http.StatusNotFound, ErrorResponse{Error: "Token not found"})
+			case services.ErrTransactionNotFound:
+				c.JSON(http.StatusNotFound, ErrorResponse{Error: "Incoming transaction not found"})
+			case services.ErrLotAlreadyExists:
+				c.JSON(http.StatusConflict, ErrorResponse{Error: "Transaction already has a lot"})
+			default:
+				h.logger.Error("Failed to add lot", "error", err, "user_id", userID)
+				c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to add lot"})
+			}
+			return
+		}
+
+		c.JSON(http.StatusCreated, lot)
+	}
+}
+
+// GetLots godoc
+// @Summary Get manual lots
+// @Description Retrieve the user's manually entered lots, oldest first
+// @Tags Portfolio
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {array} services.LotResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/portfolio/lots [get]
+func (h *PnLHandler) GetLots() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID := c.GetUint("user_id")
+		lots, err := h.pnlService.GetLots(c.Request.Context(), userID)
+		if err != nil {
+			h.logger.Error("Failed to get lots", "error", err, "user_id", userID)
+			c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to get lots"})
+			return
+		}
+
+		c.JSON(http.StatusOK, lots)
+	}
+}
+
+// DeleteLot godoc
+// @Summary Delete a manual lot
+// @Description Remove a manually entered lot
+// @Tags Portfolio
+// @Produce json
+// @Param id path int true "Lot ID"
+// @Security BearerAuth
+// @Success 200 {object} SuccessResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/portfolio/lots/{id} [delete]
+func (h *PnLHandler) DeleteLot() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		lotID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid lot ID"})
+			return
+		}
+
+		userID := c.GetUint("user_id")
+		if err := h.pnlService.DeleteLot(c.Request.Context(), userID, uint(lotID)); err != nil {
+			switch err {
+			case services.ErrLotNotFound:
+				c.JSON(http.StatusNotFound, ErrorResponse{Error: "Lot not found"})
+			default:
+				h.logger.Error("Failed to delete lot", "error", err, "user_id", userID, "lot_id", lotID)
+				c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to delete lot"})
+			}
+			return
+		}
+
+		c.JSON(http.StatusOK, SuccessResponse{Message: "Lot removed"})
+	}
+}
@@ -0,0 +1,55 @@
+package handlers
+
+import (
+	"net/http"
+
+	"cryptoportfolio/internal/services"
+	"cryptoportfolio/pkg/logger"
+
+	"github.com/gin-gonic/gin"
+)
+
+// PortfolioHandler handles portfolio valuation HTTP requests
+type PortfolioHandler struct {
+	portfolioService services.PortfolioService
+	logger           *logger.Logger
+}
+
+// NewPortfolioHandler creates a new portfolio handler
+func NewPortfolioHandler(portfolioService services.PortfolioService, logger *logger.Logger) *PortfolioHandler {
+	return &PortfolioHandler{
+		portfolioService: portfolioService,
+		logger:           logger,
+	}
+}
+
+// GetSummary godoc
+// @Summary Get portfolio summary
+// @Description Total portfolio value with per-wallet and per-token totals and percentage allocation, based on the latest balances
+// @Tags Portfolio
+// @Produce json
+// @Param quote query string false "Currency to report values in: USD (default) or the symbol of a tracked token"
+// @Security BearerAuth
+// @Success 200 {object} services.PortfolioSummary
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/portfolio/summary [get]
+func (h *PortfolioHandler) GetSummary() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID := c.GetUint("user_id")
+		summary, err := h.portfolioService.GetSummary(c.Request.Context(), userID, c.Query("quote"))
+		if err != nil {
+			switch err {
+			case services.ErrUnsupportedQuote:
+				c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Unsupported quote currency"})
+			default:
+				h.logger.Error("Failed to get portfolio summary", "error", err, "user_id", userID)
+				c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to get portfolio summary"})
+			}
+			return
+		}
+
+		c.JSON(http.StatusOK, summary)
+	}
+}
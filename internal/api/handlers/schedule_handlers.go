@@ -0,0 +1,98 @@
+package handlers
+
+import (
+	"net/http"
+
+	"cryptoportfolio/internal/services"
+	"cryptoportfolio/pkg/logger"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ScheduleHandler handles fetch schedule HTTP requests
+type ScheduleHandler struct {
+	scheduler services.FetchScheduler
+	logger    *logger.Logger
+}
+
+// NewScheduleHandler creates a new schedule handler
+func NewScheduleHandler(scheduler services.FetchScheduler, logger *logger.Logger) *ScheduleHandler {
+	return &ScheduleHandler{
+		scheduler: scheduler,
+		logger:    logger,
+	}
+}
+
+// GetSchedule godoc
+// @Summary Get balance fetch schedule
+// @Description List when each wallet/token pair is fetched next, soonest first. Intervals grow while a balance stays unchanged and drop to the minimum after a change or when balances are viewed.
+// @Tags Watchlist
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} services.FetchScheduleResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/watchlist/schedule [get]
+func (h *ScheduleHandler) GetSchedule() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID := c.GetUint("user_id")
+		schedule, err := h.scheduler.GetSchedule(c.Request.Context(), userID)
+		if err != nil {
+			switch err {
+			case services.ErrUserNotFound:
+				c.JSON(http.StatusNotFound, ErrorResponse{Error: "User not found"})
+			default:
+				h.logger.Error("Failed to get fetch schedule", "error", err, "user_id", userID)
+				c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to get fetch schedule"})
+			}
+			return
+		}
+
+		c.JSON(http.StatusOK, schedule)
+	}
+}
+
+// UpdateSchedule godoc
+// @Summary Set minimum fetch interval
+// @Description Set the shortest interval in seconds balances are fetched at. Only available on tiers with a configured minimum, and not below it; 0 restores the default.
+// @Tags Watchlist
+// @Accept json
+// @Produce json
+// @Param request body services.UpdateScheduleRequest true "Minimum interval"
+// @Security BearerAuth
+// @Success 200 {object} services.FetchScheduleResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/watchlist/schedule [put]
+func (h *ScheduleHandler) UpdateSchedule() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req services.UpdateScheduleRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid request body"})
+			return
+		}
+
+		userID := c.GetUint("user_id")
+		schedule, err := h.scheduler.UpdateSchedule(c.Request.Context(), userID, &req)
+		if err != nil {
+			switch err {
+			case services.ErrInvalidMinInterval:
+				c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+			case services.ErrMinIntervalNotAllowed:
+				c.JSON(http.StatusForbidden, ErrorResponse{Error: err.Error()})
+			case services.ErrUserNotFound:
+				c.JSON(http.StatusNotFound, ErrorResponse{Error: "User not found"})
+			default:
+				h.logger.Error("Failed to update fetch schedule", "error", err, "user_id", userID)
+				c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to update fetch schedule"})
+			}
+			return
+		}
+
+		c.JSON(http.StatusOK, schedule)
+	}
+}
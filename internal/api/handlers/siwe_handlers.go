@@ -0,0 +1,104 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"cryptoportfolio/internal/services"
+	"cryptoportfolio/pkg/logger"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SIWEVerifyRequest is a signed Sign-In with Ethereum message
+type SIWEVerifyRequest struct {
+	Message   string `json:"message" binding:"required" example:"localhost:8080 wants you to sign in with your Ethereum account:\n0x52908400098527886E0F7030069857D2E4169EE7\n\nSign in to Crypto Portfolio\n\nURI: http://localhost:8080\nVersion: 1\nChain ID: 1\nNonce: 8f1c2a9e4b7d3f60a5e2c1d0b9a87654\nIssued At: 2024-01-01T00:00:00Z"`
+	Signature string `json:"signature" binding:"required" example:"0x..."`
+}
+
+// SIWEHandler handles Sign-In with Ethereum HTTP requests
+type SIWEHandler struct {
+	siweService services.SIWEService
+	logger      *logger.Logger
+}
+
+// NewSIWEHandler creates a new Sign-In with Ethereum handler
+func NewSIWEHandler(siweService services.SIWEService, logger *logger.Logger) *SIWEHandler {
+	return &SIWEHandler{
+		siweService: siweService,
+		logger:      logger,
+	}
+}
+
+// GetNonce godoc
+// @Summary Get a Sign-In with Ethereum nonce
+// @Description Issue a single-use nonce to put in an EIP-4361 message. Nonces expire after SIWE_NONCE_TTL seconds.
+// @Tags Authentication
+// @Produce json
+// @Success 200 {object} services.SIWENonceResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/auth/siwe/nonce [get]
+func (h *SIWEHandler) GetNonce() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		nonce, err := h.siweService.Nonce(c.Request.Context())
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to issue nonce"})
+			return
+		}
+
+		c.JSON(http.StatusOK, nonce)
+	}
+}
+
+// Verify godoc
+// @Summary Sign in with Ethereum
+// @Description Verify an EIP-4361 message signed with personal_sign and sign in the user linked to its address. An address not linked yet is linked to the signed-in user when a bearer token is sent, or to a new user otherwise. The address is added to the user's watchlist as a verified wallet on the message's chain.
+// @Tags Authentication
+// @Accept json
+// @Produce json
+// @Param request body SIWEVerifyRequest true "Signed SIWE message"
+// @Success 200 {object} AuthResponse
+// @Failure 400 {object} ErrorResponse "Malformed message, wrong domain, expired message or unsupported chain"
+// @Failure 401 {object} ErrorResponse "Invalid signature or nonce"
+// @Failure 409 {object} ErrorResponse "Address or user already linked"
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/auth/siwe/verify [post]
+func (h *SIWEHandler) Verify() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req SIWEVerifyRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid request data"})
+			return
+		}
+
+		// Set by OptionalAuth when the caller is signed in, 0 otherwise
+		currentUserID := c.GetUint("user_id")
+
+		response, err := h.siweService.Verify(c.Request.Context(), currentUserID, &services.SIWEVerifyRequest{
+			Message:   req.Message,
+			Signature: req.Signature,
+		})
+		if err != nil {
+			switch {
+			case errors.Is(err, services.ErrInvalidSIWEMessage):
+				c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+			case errors.Is(err, services.ErrUnsupportedChain):
+				c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Unsupported chain"})
+			case errors.Is(err, services.ErrInvalidSignature):
+				c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "Invalid signature"})
+			case errors.Is(err, services.ErrInvalidNonce):
+				c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "Invalid or expired nonce"})
+			case errors.Is(err, services.ErrWalletLinkedToOtherUser):
+				c.JSON(http.StatusConflict, ErrorResponse{Error: "Wallet is linked to another user"})
+			case errors.Is(err, services.ErrUserHasOtherWallet):
+				c.JSON(http.StatusConflict, ErrorResponse{Error: "User is already linked to another wallet"})
+			default:
+				h.logger.Error("Failed to sign in with Ethereum", "error", err)
+				c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to sign in"})
+			}
+			return
+		}
+
+		c.JSON(http.StatusOK, response)
+	}
+}
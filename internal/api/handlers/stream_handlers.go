@@ -0,0 +1,192 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"cryptoportfolio/internal/config"
+	"cryptoportfolio/internal/services"
+	"cryptoportfolio/pkg/logger"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+)
+
+// streamWriteTimeout bounds a single write to a WebSocket client
+const streamWriteTimeout = 10 * time.Second
+
+// balanceStreamUpgrader accepts WebSocket connections from any origin, matching
+// the CORS policy; streams are authorized by the bearer token, not by cookies
+var balanceStreamUpgrader = websocket.Upgrader{
+	CheckOrigin: func(r *http.Request) bool { return true },
+}
+
+// BalanceStreamMessage is a message on the balance WebSocket. The first message
+// has type "snapshot" with every latest balance; each later one has type
+// "balance" with a single balance that changed.
+type BalanceStreamMessage struct {
+	Type string      `json:"type"`
+	Data interface{} `json:"data"`
+}
+
+// StreamHandler handles live balance stream requests
+type StreamHandler struct {
+	watchlistService services.WatchlistService
+	balanceHub       services.BalanceHub
+	heartbeat        time.Duration
+	logger           *logger.Logger
+}
+
+// NewStreamHandler creates a new stream handler
+func NewStreamHandler(watchlistService services.WatchlistService, balanceHub services.BalanceHub, cfg *config.Config, logger *logger.Logger) *StreamHandler {
+	heartbeat := time.Duration(cfg.Stream.Heartbeat) * time.Second
+	if heartbeat <= 0 {
+		heartbeat = 15 * time.Second
+	}
+	return &StreamHandler{
+		watchlistService: watchlistService,
+		balanceHub:       balanceHub,
+		heartbeat:        heartbeat,
+		logger:           logger,
+	}
+}
+
+// StreamBalances godoc
+// @Summary Stream balance updates (SSE)
+// @Description Server-Sent Events stream of the user's balances. The first event, "snapshot", carries every latest balance as in GET /watchlist/balances; each "balance" event then carries one balance as soon as a fetch stores a changed amount or USD value. A comment line is sent on idle streams every STREAM_HEARTBEAT seconds. The stream is closed if the client falls behind; reconnecting starts from a new snapshot. Browsers' EventSource cannot set headers, so the token may be passed as access_token.
+// @Tags Watchlist
+// @Produce text/event-stream
+// @Param access_token query string false "JWT, when the Authorization header cannot be set"
+// @Security BearerAuth
+// @Success 200 {array} services.BalanceResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/watchlist/balances/stream [get]
+func (h *StreamHandler) StreamBalances() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID := c.GetUint("user_id")
+
+		// Subscribe before reading the snapshot so no update stored in between is missed
+		updates, unsubscribe := h.balanceHub.Subscribe(userID)
+		defer unsubscribe()
+
+		balances, err := h.watchlistService.GetBalances(c.Request.Context(), userID)
+		if err != nil {
+			h.logger.Error("Failed to get balances for stream", "error", err, "user_id", userID)
+			c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to get balances"})
+			return
+		}
+
+		c.Header("Content-Type", "text/event-stream")
+		c.Header("Cache-Control", "no-cache")
+		c.Header("Connection", "keep-alive")
+		c.Header("X-Accel-Buffering", "no")
+
+		c.SSEvent("snapshot", balances)
+		c.Writer.Flush()
+
+		heartbeat := time.NewTicker(h.heartbeat)
+		defer heartbeat.Stop()
+
+		for {
+			select {
+			case balance, ok := <-updates:
+				if !ok {
+					return
+				}
+				c.SSEvent("balance", balance)
+			case <-heartbeat.C:
+				fmt.Fprint(c.Writer, ": ping\n\n")
+			case <-c.Request.Context().Done():
+				return
+			}
+			c.Writer.Flush()
+		}
+	}
+}
+
+// StreamBalancesWS godoc
+// @Summary Stream balance updates (WebSocket)
+// @Description WebSocket stream of the user's balances, sent as BalanceStreamMessage JSON: a "snapshot" message with every latest balance, then a "balance" message for each changed balance. The server pings every STREAM_HEARTBEAT seconds and closes the connection with code 1013 if the client falls behind. Browsers cannot set headers on WebSocket connections, so the token may be passed as access_token.
+// @Tags Watchlist
+// @Param access_token query string false "JWT, when the Authorization header cannot be set"
+// @Security BearerAuth
+// @Success 101 {object} BalanceStreamMessage
+// @Failure 401 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/watchlist/balances/ws [get]
+func (h *StreamHandler) StreamBalancesWS() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID := c.GetUint("user_id")
+
+		// Subscribe before reading the snapshot so no update stored in between is missed
+		updates, unsubscribe := h.balanceHub.Subscribe(userID)
+		defer unsubscribe()
+
+		balances, err := h.watchlistService.GetBalances(c.Request.Context(), userID)
+		if err != nil {
+			h.logger.Error("Failed to get balances for stream", "error", err, "user_id", userID)
+			c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to get balances"})
+			return
+		}
+
+		conn, err := balanceStreamUpgrader.Upgrade(c.Writer, c.Request, nil)
+		if err != nil {
+			// The upgrader has already replied with an error status
+			h.logger.Debug("Failed to upgrade balance stream", "error", err, "user_id", userID)
+			return
+		}
+		defer conn.Close()
+
+		// Reads only process control frames and notice the client going away;
+		// a client that stops answering pings times out
+		readTimeout := 2 * h.heartbeat
+		conn.SetReadDeadline(time.Now().Add(readTimeout))
+		conn.SetPongHandler(func(string) error {
+			return conn.SetReadDeadline(time.Now().Add(readTimeout))
+		})
+		closed := make(chan struct{})
+		go func() {
+			defer close(closed)
+			for {
+				if _, _, err := conn.ReadMessage(); err != nil {
+					return
+				}
+			}
+		}()
+
+		write := func(msg BalanceStreamMessage) error {
+			conn.SetWriteDeadline(time.Now().Add(streamWriteTimeout))
+			return conn.WriteJSON(msg)
+		}
+
+		if err := write(BalanceStreamMessage{Type: "snapshot", Data: balances}); err != nil {
+			return
+		}
+
+		heartbeat := time.NewTicker(h.heartbeat)
+		defer heartbeat.Stop()
+
+		for {
+			select {
+			case balance, ok := <-updates:
+				if !ok {
+					conn.WriteControl(websocket.CloseMessage,
+						websocket.FormatCloseMessage(websocket.CloseTryAgainLater, "stream fell behind"),
+						time.Now().Add(streamWriteTimeout))
+					return
+				}
+				if err := write(BalanceStreamMessage{Type: "balance", Data: balance}); err != nil {
+					return
+				}
+			case <-heartbeat.C:
+				if err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(streamWriteTimeout)); err != nil {
+					return
+				}
+			case <-closed:
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,79 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"cryptoportfolio/internal/services"
+	"cryptoportfolio/pkg/logger"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TransactionHandler handles wallet transaction history HTTP requests
+type TransactionHandler struct {
+	transactionService services.TransactionService
+	logger             *logger.Logger
+}
+
+// NewTransactionHandler creates a new transaction handler
+func NewTransactionHandler(transactionService services.TransactionService, logger *logger.Logger) *TransactionHandler {
+	return &TransactionHandler{
+		transactionService: transactionService,
+		logger:             logger,
+	}
+}
+
+// GetTransactions godoc
+// @Summary List wallet transactions
+// @Description List the native and ERC-20 transfers into and out of a watched wallet, newest first. Transfers are recorded from the block the wallet was added at.
+// @Tags Watchlist
+// @Produce json
+// @Param wallet_id path int true "Wallet ID"
+// @Param limit query int false "Number of records to return (default: 50, max: 100)"
+// @Param offset query int false "Number of records to skip (default: 0)"
+// @Security BearerAuth
+// @Success 200 {object} services.TransactionPage
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/watchlist/wallets/{wallet_id}/transactions [get]
+func (h *TransactionHandler) GetTransactions() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		walletID, err := strconv.ParseUint(c.Param("wallet_id"), 10, 32)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid wallet ID"})
+			return
+		}
+
+		limit, err := strconv.Atoi(c.DefaultQuery("limit", "50"))
+		if err != nil || limit <= 0 {
+			limit = 50
+		}
+		if limit > 100 {
+			limit = 100
+		}
+
+		offset, err := strconv.Atoi(c.DefaultQuery("offset", "0"))
+		if err != nil || offset < 0 {
+			c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid offset"})
+			return
+		}
+
+		userID := c.GetUint("user_id")
+		page, err := h.transactionService.GetTransactions(c.Request.Context(), userID, uint(walletID), limit, offset)
+		if err != nil {
+			switch err {
+			case services.ErrWalletNotFound:
+				c.JSON(http.StatusNotFound, ErrorResponse{Error: "Wallet not found"})
+			default:
+				h.logger.Error("Failed to get transactions", "error", err, "user_id", userID, "wallet_id", walletID)
+				c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to get transactions"})
+			}
+			return
+		}
+
+		c.JSON(http.StatusOK, page)
+	}
+}
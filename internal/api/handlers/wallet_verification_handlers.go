@@ -0,0 +1,115 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"cryptoportfolio/internal/services"
+	"cryptoportfolio/pkg/logger"
+
+	"github.com/gin-gonic/gin"
+)
+
+// WalletVerificationHandler handles wallet ownership verification HTTP requests
+type WalletVerificationHandler struct {
+	verificationService services.WalletVerificationService
+	logger              *logger.Logger
+}
+
+// NewWalletVerificationHandler creates a new wallet verification handler
+func NewWalletVerificationHandler(verificationService services.WalletVerificationService, logger *logger.Logger) *WalletVerificationHandler {
+	return &WalletVerificationHandler{
+		verificationService: verificationService,
+		logger:              logger,
+	}
+}
+
+// CreateChallenge godoc
+// @Summary Get a wallet ownership challenge
+// @Description Issue a challenge to sign with a watched wallet, as a personal_sign message and as EIP-712 typed data. A new challenge replaces the wallet's pending one; challenges expire after WALLET_CHALLENGE_TTL seconds.
+// @Tags Watchlist
+// @Produce json
+// @Param id path int true "Wallet ID"
+// @Security BearerAuth
+// @Success 200 {object} services.WalletChallengeResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/watchlist/wallets/{id}/challenge [post]
+func (h *WalletVerificationHandler) CreateChallenge() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		walletID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid wallet ID"})
+			return
+		}
+
+		userID := c.GetUint("user_id")
+		challenge, err := h.verificationService.CreateChallenge(c.Request.Context(), userID, uint(walletID))
+		if err != nil {
+			if errors.Is(err, services.ErrWalletNotFound) {
+				c.JSON(http.StatusNotFound, ErrorResponse{Error: "Wallet not found"})
+				return
+			}
+			h.logger.Error("Failed to create wallet challenge", "error", err, "user_id", userID, "wallet_id", walletID)
+			c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to create challenge"})
+			return
+		}
+
+		c.JSON(http.StatusOK, challenge)
+	}
+}
+
+// VerifyWallet godoc
+// @Summary Verify wallet ownership
+// @Description Verify the signature of the wallet's pending challenge and mark the wallet verified. signature_type is personal_sign (default) or eip712. Smart contract wallets are checked with EIP-1271 isValidSignature.
+// @Tags Watchlist
+// @Accept json
+// @Produce json
+// @Param id path int true "Wallet ID"
+// @Param request body services.VerifyWalletRequest true "Challenge signature"
+// @Security BearerAuth
+// @Success 200 {object} services.WalletResponse
+// @Failure 400 {object} ErrorResponse "Invalid signature type or no pending challenge"
+// @Failure 401 {object} ErrorResponse "Invalid signature"
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/watchlist/wallets/{id}/verify [post]
+func (h *WalletVerificationHandler) VerifyWallet() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		walletID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid wallet ID"})
+			return
+		}
+
+		var req services.VerifyWalletRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid request body"})
+			return
+		}
+
+		userID := c.GetUint("user_id")
+		wallet, err := h.verificationService.VerifyWallet(c.Request.Context(), userID, uint(walletID), &req)
+		if err != nil {
+			switch {
+			case errors.Is(err, services.ErrWalletNotFound):
+				c.JSON(http.StatusNotFound, ErrorResponse{Error: "Wallet not found"})
+			case errors.Is(err, services.ErrChallengeNotFound):
+				c.JSON(http.StatusBadRequest, ErrorResponse{Error: "No pending challenge for wallet"})
+			case errors.Is(err, services.ErrInvalidSignatureType):
+				c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid signature type"})
+			case errors.Is(err, services.ErrInvalidSignature):
+				c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "Invalid signature"})
+			default:
+				h.logger.Error("Failed to verify wallet", "error", err, "user_id", userID, "wallet_id", walletID)
+				c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to verify wallet"})
+			}
+			return
+		}
+
+		c.JSON(http.StatusOK, wallet)
+	}
+}
@@ -57,6 +57,8 @@ func (h *WatchlistHandler) AddWallet() gin.HandlerFunc {
 			switch err {
 			case services.ErrInvalidAddress:
 				c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid wallet address"})
+			case services.ErrUnsupportedChain:
+				c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Unsupported chain"})
 			case services.ErrWalletAlreadyExists:
 				c.JSON(http.StatusConflict, ErrorResponse{Error: "Wallet already exists in watchlist"})
 			default:
@@ -129,7 +131,7 @@ func (h *WatchlistHandler) DeleteWallet() gin.HandlerFunc {
 
 // AddToken godoc
 // @Summary Add token to watchlist
-// @Description Add a new token to the user's tracked tokens
+// @Description Add a new token to the user's tracked tokens. For ERC-20 tokens the symbol, name and decimals are read from the contract; the native token requires token_symbol and token_name.
 // @Tags Watchlist
 // @Accept json
 // @Produce json
@@ -155,6 +157,12 @@ func (h *WatchlistHandler) AddToken() gin.HandlerFunc {
 			switch err {
 			case services.ErrInvalidAddress:
 				c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid token address"})
+			case services.ErrNotERC20:
+				c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Address is not an ERC-20 token contract"})
+			case services.ErrTokenInfoRequired:
+				c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Token symbol and name are required"})
+			case services.ErrUnsupportedChain:
+				c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Unsupported chain"})
 			case services.ErrTokenAlreadyExists:
 				c.JSON(http.StatusConflict, ErrorResponse{Error: "Token already exists in watchlist"})
 			default:
@@ -304,24 +312,30 @@ func (h *WatchlistHandler) GetBalanceHistory() gin.HandlerFunc {
 
 // RefreshBalances godoc
 // @Summary Refresh wallet balances
-// @Description Trigger a manual refresh of wallet balances from the blockchain
+// @Description Queue a refresh of all wallet balances from the blockchain and return the job to poll at GET /api/v1/jobs/{id}. While a refresh is still queued, further requests return the same job. Each user may queue REFRESH_RATE_LIMIT refreshes per REFRESH_RATE_WINDOW seconds.
 // @Tags Watchlist
 // @Produce json
 // @Security BearerAuth
-// @Success 200 {object} SuccessResponse
+// @Success 202 {object} services.JobResponse
 // @Failure 401 {object} ErrorResponse
+// @Failure 429 {object} ErrorResponse
 // @Failure 500 {object} ErrorResponse
 // @Router /api/v1/watchlist/balances/refresh [post]
 func (h *WatchlistHandler) RefreshBalances() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		userID := c.GetUint("user_id")
-		err := h.watchlistService.RefreshBalances(c.Request.Context(), userID)
+		job, err := h.watchlistService.RefreshBalances(c.Request.Context(), userID)
 		if err != nil {
-			h.logger.Error("Failed to refresh balances", "error", err, "user_id", userID)
-			c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to refresh balances"})
+			switch err {
+			case services.ErrRefreshRateLimited:
+				c.JSON(http.StatusTooManyRequests, ErrorResponse{Error: "Too many balance refreshes, try again later"})
+			default:
+				h.logger.Error("Failed to refresh balances", "error", err, "user_id", userID)
+				c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to refresh balances"})
+			}
 			return
 		}
 
-		c.JSON(http.StatusOK, SuccessResponse{Message: "Balance refresh initiated"})
+		c.JSON(http.StatusAccepted, job)
 	}
 } 
\ No newline at end of file
@@ -0,0 +1,297 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"cryptoportfolio/internal/models"
+	"cryptoportfolio/internal/services"
+	"cryptoportfolio/pkg/logger"
+
+	"github.com/gin-gonic/gin"
+)
+
+// WebhookHandler handles webhook endpoint and delivery HTTP requests
+type WebhookHandler struct {
+	webhookService services.WebhookService
+	logger         *logger.Logger
+}
+
+// NewWebhookHandler creates a new webhook handler
+func NewWebhookHandler(webhookService services.WebhookService, logger *logger.Logger) *WebhookHandler {
+	return &WebhookHandler{
+		webhookService: webhookService,
+		logger:         logger,
+	}
+}
+
+// CreateEndpoint godoc
+// @Summary Register webhook endpoint
+// @Description Register a URL that balance.changed, alert.fired and fetch.failed events are POSTed to. Each request carries X-Webhook-Timestamp and X-Webhook-Signature: "sha256=" followed by the hex HMAC-SHA256 of "<timestamp>.<body>" keyed with the endpoint secret. The secret is only returned in this response.
+// @Tags Webhooks
+// @Accept json
+// @Produce json
+// @Param endpoint body services.WebhookEndpointRequest true "Webhook endpoint"
+// @Security BearerAuth
+// @Success 201 {object} services.WebhookEndpointResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/webhooks [post]
+func (h *WebhookHandler) CreateEndpoint() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req services.WebhookEndpointRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid request body"})
+			return
+		}
+
+		userID := c.GetUint("user_id")
+		endpoint, err := h.webhookService.CreateEndpoint(c.Request.Context(), userID, &req)
+		if err != nil {
+			h.handleError(c, err, userID, "Failed to create webhook endpoint")
+			return
+		}
+
+		c.JSON(http.StatusCreated, endpoint)
+	}
+}
+
+// GetEndpoints godoc
+// @Summary Get webhook endpoints
+// @Description Retrieve the user's webhook endpoints
+// @Tags Webhooks
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {array} services.WebhookEndpointResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/webhooks [get]
+func (h *WebhookHandler) GetEndpoints() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID := c.GetUint("user_id")
+		endpoints, err := h.webhookService.GetEndpoints(c.Request.Context(), userID)
+		if err != nil {
+			h.logger.Error("Failed to get webhook endpoints", "error", err, "user_id", userID)
+			c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to get webhook endpoints"})
+			return
+		}
+
+		c.JSON(http.StatusOK, endpoints)
+	}
+}
+
+// GetEndpoint godoc
+// @Summary Get webhook endpoint
+// @Description Retrieve one of the user's webhook endpoints
+// @Tags Webhooks
+// @Produce json
+// @Param id path int true "Webhook endpoint ID"
+// @Security BearerAuth
+// @Success 200 {object} services.WebhookEndpointResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/webhooks/{id} [get]
+func (h *WebhookHandler) GetEndpoint() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		endpointID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid webhook endpoint ID"})
+			return
+		}
+
+		userID := c.GetUint("user_id")
+		endpoint, err := h.webhookService.GetEndpoint(c.Request.Context(), userID, uint(endpointID))
+		if err != nil {
+			h.handleError(c, err, userID, "Failed to get webhook endpoint")
+			return
+		}
+
+		c.JSON(http.StatusOK, endpoint)
+	}
+}
+
+// UpdateEndpoint godoc
+// @Summary Update webhook endpoint
+// @Description Replace the URL, events and enabled state of one of the user's webhook endpoints. The signing secret is kept.
+// @Tags Webhooks
+// @Accept json
+// @Produce json
+// @Param id path int true "Webhook endpoint ID"
+// @Param endpoint body services.WebhookEndpointRequest true "Webhook endpoint"
+// @Security BearerAuth
+// @Success 200 {object} services.WebhookEndpointResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/webhooks/{id} [put]
+func (h *WebhookHandler) UpdateEndpoint() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		endpointID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid webhook endpoint ID"})
+			return
+		}
+
+		var req services.WebhookEndpointRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid request body"})
+			return
+		}
+
+		userID := c.GetUint("user_id")
+		endpoint, err := h.webhookService.UpdateEndpoint(c.Request.Context(), userID, uint(endpointID), &req)
+		if err != nil {
+			h.handleError(c, err, userID, "Failed to update webhook endpoint")
+			return
+		}
+
+		c.JSON(http.StatusOK, endpoint)
+	}
+}
+
+// DeleteEndpoint godoc
+// @Summary Delete webhook endpoint
+// @Description Remove one of the user's webhook endpoints together with its deliveries
+// @Tags Webhooks
+// @Produce json
+// @Param id path int true "Webhook endpoint ID"
+// @Security BearerAuth
+// @Success 200 {object} SuccessResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/webhooks/{id} [delete]
+func (h *WebhookHandler) DeleteEndpoint() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		endpointID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid webhook endpoint ID"})
+			return
+		}
+
+		userID := c.GetUint("user_id")
+		if err := h.webhookService.DeleteEndpoint(c.Request.Context(), userID, uint(endpointID)); err != nil {
+			h.handleError(c, err, userID, "Failed to delete webhook endpoint")
+			return
+		}
+
+		c.JSON(http.StatusOK, SuccessResponse{Message: "Webhook endpoint removed"})
+	}
+}
+
+// GetDeliveries godoc
+// @Summary List webhook deliveries
+// @Description List an endpoint's deliveries with every attempt made, newest first
+// @Tags Webhooks
+// @Produce json
+// @Param id path int true "Webhook endpoint ID"
+// @Param status query string false "Filter by status: pending, delivered or dead"
+// @Param limit query int false "Number of records to return (default: 50, max: 100)"
+// @Param offset query int false "Number of records to skip (default: 0)"
+// @Security BearerAuth
+// @Success 200 {object} services.WebhookDeliveryPage
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/webhooks/{id}/deliveries [get]
+func (h *WebhookHandler) GetDeliveries() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		endpointID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid webhook endpoint ID"})
+			return
+		}
+
+		status := c.Query("status")
+		switch status {
+		case "", models.WebhookDeliveryPending, models.WebhookDeliveryDelivered, models.WebhookDeliveryDead:
+		default:
+			c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid status"})
+			return
+		}
+
+		limit, err := strconv.Atoi(c.DefaultQuery("limit", "50"))
+		if err != nil || limit <= 0 {
+			limit = 50
+		}
+		if limit > 100 {
+			limit = 100
+		}
+
+		offset, err := strconv.Atoi(c.DefaultQuery("offset", "0"))
+		if err != nil || offset < 0 {
+			c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid offset"})
+			return
+		}
+
+		userID := c.GetUint("user_id")
+		page, err := h.webhookService.GetDeliveries(c.Request.Context(), userID, uint(endpointID), status, limit, offset)
+		if err != nil {
+			h.handleError(c, err, userID, "Failed to get webhook deliveries")
+			return
+		}
+
+		c.JSON(http.StatusOK, page)
+	}
+}
+
+// ReplayDelivery godoc
+// @Summary Replay webhook delivery
+// @Description Queue a delivery again with a fresh set of attempts, e.g. one that was dead-lettered while the endpoint was down
+// @Tags Webhooks
+// @Produce json
+// @Param id path int true "Webhook endpoint ID"
+// @Param delivery_id path int true "Delivery ID"
+// @Security BearerAuth
+// @Success 202 {object} models.WebhookDelivery
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/webhooks/{id}/deliveries/{delivery_id}/replay [post]
+func (h *WebhookHandler) ReplayDelivery() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		endpointID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid webhook endpoint ID"})
+			return
+		}
+		deliveryID, err := strconv.ParseUint(c.Param("delivery_id"), 10, 32)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid delivery ID"})
+			return
+		}
+
+		userID := c.GetUint("user_id")
+		delivery, err := h.webhookService.ReplayDelivery(c.Request.Context(), userID, uint(endpointID), uint(deliveryID))
+		if err != nil {
+			h.handleError(c, err, userID, "Failed to replay webhook delivery")
+			return
+		}
+
+		c.JSON(http.StatusAccepted, delivery)
+	}
+}
+
+// handleError maps webhook errors to responses
+func (h *WebhookHandler) handleError(c *gin.Context, err error, userID uint, message string) {
+	switch err {
+	case services.ErrInvalidWebhook:
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid webhook endpoint: url must be http(s) and events must be known event types"})
+	case services.ErrWebhookTargetNotAllowed:
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid webhook endpoint: url must not point to a private, loopback or link-local address"})
+	case services.ErrWebhookNotFound:
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "Webhook endpoint not found"})
+	case services.ErrWebhookDeliveryNotFound:
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "Webhook delivery not found"})
+	default:
+		h.logger.Error(message, "error", err, "user_id", userID)
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: message})
+	}
+}
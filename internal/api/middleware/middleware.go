@@ -2,33 +2,98 @@ package middleware
 
 import (
 	"net/http"
+	"net/url"
+	"strconv"
 	"strings"
 	"time"
 
+	"cryptoportfolio/internal/cache"
 	"cryptoportfolio/internal/config"
+	"cryptoportfolio/internal/metrics"
+	"cryptoportfolio/internal/tracing"
 	"cryptoportfolio/pkg/logger"
 
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v5"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.34.0"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // Logger middleware for request logging
 func Logger(log *logger.Logger) gin.HandlerFunc {
 	return gin.LoggerWithFormatter(func(param gin.LogFormatterParams) string {
 		if log != nil {
-			log.Info("HTTP Request",
+			fields := []interface{}{
+				"HTTP Request",
 				"method", param.Method,
-				"path", param.Path,
+				"path", redactAccessToken(param.Path),
 				"status", param.StatusCode,
 				"latency", param.Latency,
 				"client_ip", param.ClientIP,
 				"user_agent", param.Request.UserAgent(),
-			)
+			}
+			// Errors attached by middleware that answered the request itself
+			if param.ErrorMessage != "" {
+				fields = append(fields, "error", param.ErrorMessage)
+			}
+			log.Info(fields...)
 		}
 		return ""
 	})
 }
 
+// Metrics records request counts and latency by route template, so paths
+// with IDs share a series; requests that match no route are grouped as "unmatched"
+func Metrics() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+		status := strconv.Itoa(c.Writer.Status())
+		metrics.HTTPRequests.WithLabelValues(c.Request.Method, route, status).Inc()
+		metrics.HTTPRequestDuration.WithLabelValues(c.Request.Method, route, status).Observe(time.Since(start).Seconds())
+	}
+}
+
+// Tracing starts a server span for each request, continuing the caller's
+// trace when a W3C traceparent header is sent. Handlers pass the span on
+// through c.Request.Context().
+func Tracing() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx := otel.GetTextMapPropagator().Extract(c.Request.Context(), propagation.HeaderCarrier(c.Request.Header))
+
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+		ctx, span := tracing.Tracer().Start(ctx, c.Request.Method+" "+route,
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				semconv.HTTPRequestMethodKey.String(c.Request.Method),
+				semconv.HTTPRoute(route),
+				semconv.URLPath(c.Request.URL.Path),
+			),
+		)
+		defer span.End()
+
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+
+		status := c.Writer.Status()
+		span.SetAttributes(semconv.HTTPResponseStatusCode(status))
+		if status >= http.StatusInternalServerError {
+			span.SetStatus(codes.Error, http.StatusText(status))
+		}
+	}
+}
+
 // CORS middleware for cross-origin requests
 func CORS() gin.HandlerFunc {
 	return func(c *gin.Context) {
@@ -47,8 +112,44 @@ func CORS() gin.HandlerFunc {
 	}
 }
 
-// Auth middleware for JWT authentication
-func Auth(cfg *config.Config) gin.HandlerFunc {
+// accessTokenParam is the query parameter QueryToken reads a bearer token from
+const accessTokenParam = "access_token"
+
+// QueryToken lets clients that cannot set headers, such as browser EventSource
+// and WebSocket connections, pass their token as ?access_token=. It must run
+// before Auth; a request that already has an Authorization header is left alone.
+func QueryToken() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		query := c.Request.URL.Query()
+		if token := query.Get(accessTokenParam); token != "" && c.GetHeader("Authorization") == "" {
+			c.Request.Header.Set("Authorization", "Bearer "+token)
+		}
+		if query.Has(accessTokenParam) {
+			query.Del(accessTokenParam)
+			c.Request.URL.RawQuery = query.Encode()
+		}
+		c.Next()
+	}
+}
+
+// redactAccessToken hides a query string token so it does not end up in logs
+func redactAccessToken(path string) string {
+	i := strings.IndexByte(path, '?')
+	if i < 0 {
+		return path
+	}
+	query, err := url.ParseQuery(path[i+1:])
+	if err != nil || !query.Has(accessTokenParam) {
+		return path
+	}
+	query.Set(accessTokenParam, "REDACTED")
+	return path[:i+1] + query.Encode()
+}
+
+// Auth middleware for JWT authentication. Tokens revoked by a logout are
+// rejected until they expire; when the denylist cannot be read the token is
+// accepted, as access tokens are short-lived and the API runs without Redis.
+func Auth(cfg *config.Config, denylist cache.TokenDenylistProvider) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		authHeader := c.GetHeader("Authorization")
 		if authHeader == "" {
@@ -129,12 +230,77 @@ func Auth(cfg *config.Config) gin.HandlerFunc {
 			return
 		}
 
-		// Set user ID in context
+		// Extract token ID from claims
+		tokenID, ok := claims["jti"].(string)
+		if !ok || tokenID == "" {
+			c.JSON(http.StatusUnauthorized, gin.H{
+				"error": "Invalid token ID in token",
+			})
+			c.Abort()
+			return
+		}
+
+		// Check if the token was revoked. Without the denylist a logged out
+		// token cannot be told apart, so the request is refused rather than
+		// let through.
+		revoked, err := denylist.IsRevoked(c.Request.Context(), tokenID)
+		if err != nil {
+			c.Error(err)
+			c.JSON(http.StatusServiceUnavailable, gin.H{
+				"error": "Unable to verify token",
+			})
+			c.Abort()
+			return
+		}
+		if revoked {
+			c.JSON(http.StatusUnauthorized, gin.H{
+				"error": "Token has been revoked",
+			})
+			c.Abort()
+			return
+		}
+
+		// Set user and token in context
 		c.Set("user_id", uint(userID))
+		c.Set("token_id", tokenID)
+		if exp, ok := claims["exp"].(float64); ok {
+			c.Set("token_expires_at", time.Unix(int64(exp), 0))
+		}
 		c.Next()
 	}
 }
 
+// OptionalAuth authenticates requests that carry an Authorization header like
+// Auth does, and lets requests without one through anonymously
+func OptionalAuth(cfg *config.Config, denylist cache.TokenDenylistProvider) gin.HandlerFunc {
+	auth := Auth(cfg, denylist)
+	return func(c *gin.Context) {
+		if c.GetHeader("Authorization") == "" {
+			c.Next()
+			return
+		}
+		auth(c)
+	}
+}
+
+// Admin restricts a route to the users listed in ADMIN_USER_IDS. It must run after Auth.
+func Admin(cfg *config.Config) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID := c.GetUint("user_id")
+		for _, adminID := range cfg.Admin.UserIDs {
+			if userID == adminID {
+				c.Next()
+				return
+			}
+		}
+
+		c.JSON(http.StatusForbidden, gin.H{
+			"error": "Admin access required",
+		})
+		c.Abort()
+	}
+}
+
 // RateLimit middleware for basic rate limiting
 func RateLimit(requestsPerMinute int) gin.HandlerFunc {
 	// Simple in-memory rate limiter
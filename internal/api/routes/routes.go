@@ -1,70 +1,45 @@
 package routes
 
 import (
-	"context"
 	"cryptoportfolio/internal/api/handlers"
 	"cryptoportfolio/internal/api/middleware"
-	"cryptoportfolio/internal/cache"
-	"cryptoportfolio/internal/config"
-	"cryptoportfolio/internal/repository"
-	"cryptoportfolio/internal/services"
-	"cryptoportfolio/pkg/logger"
-	"fmt"
+	"cryptoportfolio/internal/app"
 
 	"github.com/gin-gonic/gin"
 	swaggerFiles "github.com/swaggo/files"
 	ginSwagger "github.com/swaggo/gin-swagger"
-	"gorm.io/gorm"
 )
 
-func Setup(db *gorm.DB, log *logger.Logger, cfg *config.Config) *gin.Engine {
-	// Initialize Redis
-	redisAddr := fmt.Sprintf("%s:%d", cfg.Redis.Host, cfg.Redis.Port)
-	redisClient := cache.NewRedisClient(redisAddr, cfg.Redis.Password, cfg.Redis.DB, log)
-	
-	// Test Redis connection
-	if err := redisClient.Ping(context.Background()); err != nil {
-		log.Warn("Redis connection failed, continuing without cache", "error", err)
-	} else {
-		log.Info("Redis connected successfully")
-	}
-	
-	// Initialize cache service
-	cacheService := cache.NewCacheService(redisClient, log)
-	userCache := cache.NewUserCache(cacheService)
-	
-	// Initialize repositories
-	userRepo := repository.NewUserRepository(db)
-	watchlistRepo := repository.NewWatchlistRepository(db)
-	
-	// Initialize services with repositories and cache
-	userService := services.NewUserService(userRepo, userCache, cfg, log)
-	
-	// Initialize Web3 service
-	web3Service, err := services.NewWeb3Service(cfg, log)
-	if err != nil {
-		log.Error("Failed to initialize Web3 service", "error", err)
-		// Continue without Web3 service for now
-	}
-	
-	// Initialize balance fetcher service
-	balanceFetcher := services.NewBalanceFetcherService(watchlistRepo, web3Service, cacheService, log, cfg)
-	
-	// Start the background balance fetcher
-	balanceFetcher.Start(context.Background())
-	
-	// Initialize watchlist service
-	watchlistService := services.NewWatchlistService(watchlistRepo, web3Service, balanceFetcher, cacheService, log)
+// Setup builds the router over the application's services
+func Setup(a *app.App) *gin.Engine {
+	cfg := a.Config
+	log := a.Logger
 	
 	// Initialize handlers with services
-	handler := handlers.NewHandler(userService)
-	watchlistHandler := handlers.NewWatchlistHandler(watchlistService, log)
+	handler := handlers.NewHandler(a.UserService)
+	watchlistHandler := handlers.NewWatchlistHandler(a.WatchlistService, log)
+	walletVerificationHandler := handlers.NewWalletVerificationHandler(a.WalletVerifier, log)
+	portfolioHandler := handlers.NewPortfolioHandler(a.PortfolioService, log)
+	backfillHandler := handlers.NewBackfillHandler(a.BackfillService, log)
+	transactionHandler := handlers.NewTransactionHandler(a.TransactionService, log)
+	pnlHandler := handlers.NewPnLHandler(a.PnLService, log)
+	alertHandler := handlers.NewAlertHandler(a.AlertService, log)
+	webhookHandler := handlers.NewWebhookHandler(a.WebhookService, log)
+	jobHandler := handlers.NewJobHandler(a.JobService, log)
+	scheduleHandler := handlers.NewScheduleHandler(a.FetchScheduler, log)
+	adminHandler := handlers.NewAdminHandler(a.FetchRunService, log)
+	siweHandler := handlers.NewSIWEHandler(a.SIWEService, log)
+	streamHandler := handlers.NewStreamHandler(a.WatchlistService, a.BalanceHub, cfg, log)
 
 	router := gin.New()
 
 	// Middleware
 	router.Use(gin.Recovery())
+	router.Use(middleware.Tracing())
 	router.Use(middleware.Logger(log))
+	if cfg.Metrics.Enabled {
+		router.Use(middleware.Metrics())
+	}
 	router.Use(middleware.CORS())
 
 	// Health check
@@ -79,11 +54,17 @@ func Setup(db *gorm.DB, log *logger.Logger, cfg *config.Config) *gin.Engine {
 		// Public routes
 		v1.POST("/auth/register", handler.Register())
 		v1.POST("/auth/login", handler.Login())
+		v1.POST("/auth/refresh", handler.Refresh())
+		v1.GET("/auth/siwe/nonce", siweHandler.GetNonce())
+		v1.POST("/auth/siwe/verify", middleware.OptionalAuth(cfg, a.TokenDenylist), siweHandler.Verify())
 
 		// Protected routes
 		protected := v1.Group("/")
-		protected.Use(middleware.Auth(cfg))
+		protected.Use(middleware.Auth(cfg, a.TokenDenylist))
 		{
+			protected.POST("/auth/logout", handler.Logout())
+			protected.POST("/auth/logout-all", handler.LogoutAll())
+
 			protected.GET("/users/me", handler.GetCurrentUser())
 			protected.PUT("/users/me", handler.UpdateUser())
 			
@@ -95,6 +76,10 @@ func Setup(db *gorm.DB, log *logger.Logger, cfg *config.Config) *gin.Engine {
 				watchlist.GET("/wallets", watchlistHandler.GetWallets())
 				watchlist.DELETE("/wallets/:id", watchlistHandler.DeleteWallet())
 				
+				// Wallet ownership verification
+				watchlist.POST("/wallets/:id/challenge", walletVerificationHandler.CreateChallenge())
+				watchlist.POST("/wallets/:id/verify", walletVerificationHandler.VerifyWallet())
+				
 				// Token management
 				watchlist.POST("/tokens", watchlistHandler.AddToken())
 				watchlist.GET("/tokens", watchlistHandler.GetTokens())
@@ -104,9 +89,74 @@ func Setup(db *gorm.DB, log *logger.Logger, cfg *config.Config) *gin.Engine {
 				watchlist.GET("/balances", watchlistHandler.GetBalances())
 				watchlist.POST("/balances/refresh", watchlistHandler.RefreshBalances())
 				
+				// Adaptive fetch schedule
+				watchlist.GET("/schedule", scheduleHandler.GetSchedule())
+				watchlist.PUT("/schedule", scheduleHandler.UpdateSchedule())
+				
 				// Balance history
 				watchlist.GET("/wallets/:wallet_id/tokens/:token_id/history", watchlistHandler.GetBalanceHistory())
+				
+				// Transaction history
+				watchlist.GET("/wallets/:wallet_id/transactions", transactionHandler.GetTransactions())
+				
+				// Historical backfill progress
+				watchlist.GET("/backfills", backfillHandler.GetBackfills())
+				watchlist.GET("/backfills/:id", backfillHandler.GetBackfill())
 			}
+			
+			// Queued job status
+			protected.GET("/jobs/:id", jobHandler.GetJob())
+			
+			// Portfolio routes
+			portfolio := protected.Group("/portfolio")
+			{
+				portfolio.GET("/summary", portfolioHandler.GetSummary())
+				
+				// Cost basis and profit and loss
+				portfolio.GET("/pnl", pnlHandler.GetPnL())
+				portfolio.GET("/lots", pnlHandler.GetLots())
+				portfolio.POST("/lots", pnlHandler.AddLot())
+				portfolio.DELETE("/lots/:id", pnlHandler.DeleteLot())
+			}
+			
+			// Alert rule routes
+			alerts := protected.Group("/alerts")
+			{
+				alerts.POST("", alertHandler.CreateRule())
+				alerts.GET("", alertHandler.GetRules())
+				alerts.GET("/history", alertHandler.GetAlerts())
+				alerts.GET("/:id", alertHandler.GetRule())
+				alerts.PUT("/:id", alertHandler.UpdateRule())
+				alerts.DELETE("/:id", alertHandler.DeleteRule())
+			}
+			
+			// Webhook endpoint routes
+			webhooks := protected.Group("/webhooks")
+			{
+				webhooks.POST("", webhookHandler.CreateEndpoint())
+				webhooks.GET("", webhookHandler.GetEndpoints())
+				webhooks.GET("/:id", webhookHandler.GetEndpoint())
+				webhooks.PUT("/:id", webhookHandler.UpdateEndpoint())
+				webhooks.DELETE("/:id", webhookHandler.DeleteEndpoint())
+				webhooks.GET("/:id/deliveries", webhookHandler.GetDeliveries())
+				webhooks.POST("/:id/deliveries/:delivery_id/replay", webhookHandler.ReplayDelivery())
+			}
+			
+			// Admin routes, limited to ADMIN_USER_IDS
+			admin := protected.Group("/admin")
+			admin.Use(middleware.Admin(cfg))
+			{
+				admin.GET("/fetch-runs", adminHandler.GetFetchRuns())
+				admin.GET("/fetch-runs/:id", adminHandler.GetFetchRun())
+			}
+		}
+		
+		// Live balance streams, which also accept the token as a query parameter
+		streams := v1.Group("/watchlist/balances")
+		streams.Use(middleware.QueryToken(), middleware.Auth(cfg, a.TokenDenylist))
+		{
+			streams.GET("/stream", streamHandler.StreamBalances())
+			streams.GET("/ws", streamHandler.StreamBalancesWS())
 		}
 	}
 
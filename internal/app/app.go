@@ -0,0 +1,233 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"cryptoportfolio/internal/cache"
+	"cryptoportfolio/internal/config"
+	"cryptoportfolio/internal/repository"
+	"cryptoportfolio/internal/services"
+	"cryptoportfolio/internal/tracing"
+	"cryptoportfolio/pkg/logger"
+
+	"gorm.io/gorm"
+)
+
+// App holds the services shared by the API server and the background worker,
+// so both binaries are wired up the same way
+type App struct {
+	Redis         *cache.RedisClient
+	Cache         cache.CacheProvider
+	TokenDenylist cache.TokenDenylistProvider // access tokens revoked by a logout
+	Logger        *logger.Logger
+	Config        *config.Config
+
+	UserService        services.UserService
+	SIWEService        services.SIWEService
+	WatchlistService   services.WatchlistService
+	WalletVerifier     services.WalletVerificationService
+	PortfolioService   services.PortfolioService
+	PnLService         services.PnLService
+	AlertService       services.AlertService
+	WebhookService     services.WebhookService
+	BackfillService    services.BackfillService
+	JobService         services.JobService
+	FetchScheduler     services.FetchScheduler
+	FetchRunService    services.FetchRunService
+	TransactionService services.TransactionService
+	BalanceFetcher     services.BalanceFetcherService
+	BalanceHub         services.BalanceHub
+
+	leader          services.LeaderElector
+	transferWatcher services.TransferWatcher
+	jobs            []job // background jobs started by StartJobs, in start order
+
+	shutdownTracing func(context.Context) error
+}
+
+// job is a background process with a Start/Stop lifecycle
+type job interface {
+	Start(ctx context.Context)
+	Stop()
+}
+
+// New constructs the repositories and services; nothing runs until Start and StartJobs
+func New(db *gorm.DB, log *logger.Logger, cfg *config.Config) *App {
+	// Initialize tracing before the clients it instruments are used
+	shutdownTracing, err := tracing.Setup(context.Background(), cfg.Tracing)
+	if err != nil {
+		log.Error("Failed to initialize tracing, continuing without it", "error", err)
+		shutdownTracing = func(context.Context) error { return nil }
+	}
+	if err := db.Use(tracing.GormPlugin{}); err != nil {
+		log.Error("Failed to instrument database queries", "error", err)
+	}
+
+	// Initialize Redis
+	redisAddr := fmt.Sprintf("%s:%d", cfg.Redis.Host, cfg.Redis.Port)
+	redisClient := cache.NewRedisClient(redisAddr, cfg.Redis.Password, cfg.Redis.DB, log)
+
+	// Test Redis connection
+	if err := redisClient.Ping(context.Background()); err != nil {
+		log.Warn("Redis connection failed, continuing without cache", "error", err)
+	} else {
+		log.Info("Redis connected successfully")
+	}
+
+	// Initialize cache service
+	cacheService := cache.NewCacheService(redisClient, log)
+	userCache := cache.NewUserCache(cacheService)
+	tokenDenylist := cache.NewTokenDenylist(cacheService)
+
+	// Initialize repositories
+	userRepo := repository.NewUserRepository(db)
+	refreshTokenRepo := repository.NewRefreshTokenRepository(db)
+	watchlistRepo := repository.NewWatchlistRepository(db)
+	backfillRepo := repository.NewBackfillRepository(db)
+	transactionRepo := repository.NewTransactionRepository(db)
+	lotRepo := repository.NewLotRepository(db)
+	alertRepo := repository.NewAlertRepository(db)
+	webhookRepo := repository.NewWebhookRepository(db)
+	jobRepo := repository.NewJobRepository(db)
+	scheduleRepo := repository.NewScheduleRepository(db)
+	fetchRunRepo := repository.NewFetchRunRepository(db)
+
+	// Initialize services with repositories and cache
+	userService := services.NewUserService(userRepo, refreshTokenRepo, userCache, tokenDenylist, cfg, log)
+
+	// Initialize Web3 services, one per configured chain
+	web3Registry, err := services.NewWeb3Registry(cfg, log)
+	if err != nil {
+		log.Error("Failed to initialize Web3 services", "error", err)
+		// Continue without Web3 services for now
+	}
+
+	// Initialize price service
+	priceSources, err := services.NewPriceSources(cfg, web3Registry)
+	if err != nil {
+		log.Error("Failed to initialize price sources", "error", err)
+	}
+	priceService := services.NewPriceService(priceSources, cacheService, time.Duration(cfg.Price.CacheTTL)*time.Second, log)
+
+	// Initialize the webhook outbox
+	webhookService := services.NewWebhookService(webhookRepo, cacheService, log, cfg)
+
+	// Initialize alert rules, evaluated against every stored balance
+	alertService := services.NewAlertService(alertRepo, watchlistRepo, web3Registry, webhookService, cacheService, log, cfg)
+
+	// Initialize the live balance stream hub, relayed through Redis when several instances serve streams
+	var balancePubSub cache.PubSubProvider
+	if cfg.Stream.RedisFanout {
+		balancePubSub = redisClient
+	}
+	balanceHub := services.NewBalanceHub(balancePubSub, log, cfg)
+
+	// Elect one instance to run background balance fetching when several are deployed
+	var leader services.LeaderElector
+	if cfg.Leader.Enabled {
+		leader = services.NewLeaderElector(redisClient, log, cfg)
+	}
+
+	// Schedule each wallet/token pair by how often its balance changes
+	fetchScheduler := services.NewFetchScheduler(scheduleRepo, watchlistRepo, userRepo, cacheService, log, cfg)
+
+	// Initialize balance fetcher service
+	balanceFetcher := services.NewBalanceFetcherService(watchlistRepo, fetchRunRepo, web3Registry, priceService, alertService, webhookService, balanceHub, leader, fetchScheduler, cacheService, log, cfg)
+
+	// Re-fetch balances as soon as Transfer events touch a watched wallet
+	var transferWatcher services.TransferWatcher
+	if cfg.Web3.WatchTransfers {
+		transferWatcher = services.NewTransferWatcher(watchlistRepo, web3Registry, balanceFetcher, leader, log, cfg)
+	}
+
+	// Initialize the queue of on-demand balance refreshes
+	jobService := services.NewJobService(jobRepo, balanceFetcher, log, cfg)
+
+	// Initialize the historical balance backfill worker
+	backfillService := services.NewBackfillService(backfillRepo, watchlistRepo, web3Registry, log, cfg)
+
+	// Initialize the transaction history service
+	transactionService := services.NewTransactionService(transactionRepo, watchlistRepo, web3Registry, alertService, cacheService, log, cfg)
+
+	// Initialize the watchlist, and Sign-In with Ethereum and wallet verification, which mark wallets in it verified
+	watchlistService := services.NewWatchlistService(watchlistRepo, web3Registry, jobService, backfillService, fetchScheduler, cacheService, log)
+	siweService := services.NewSIWEService(userService, watchlistService, web3Registry, cacheService, log, cfg)
+	walletVerifier := services.NewWalletVerificationService(watchlistRepo, watchlistService, web3Registry, cacheService, log, cfg)
+
+	return &App{
+		Redis:              redisClient,
+		Cache:              cacheService,
+		TokenDenylist:      tokenDenylist,
+		Logger:             log,
+		Config:             cfg,
+		UserService:        userService,
+		SIWEService:        siweService,
+		WatchlistService:   watchlistService,
+		WalletVerifier:     walletVerifier,
+		PortfolioService:   services.NewPortfolioService(watchlistRepo, web3Registry, priceService, cacheService, log),
+		PnLService:         services.NewPnLService(watchlistRepo, transactionRepo, lotRepo, web3Registry, priceService, cacheService, log),
+		AlertService:       alertService,
+		WebhookService:     webhookService,
+		BackfillService:    backfillService,
+		JobService:         jobService,
+		FetchScheduler:     fetchScheduler,
+		FetchRunService:    services.NewFetchRunService(fetchRunRepo, log),
+		TransactionService: transactionService,
+		BalanceFetcher:     balanceFetcher,
+		BalanceHub:         balanceHub,
+		leader:             leader,
+		transferWatcher:    transferWatcher,
+		shutdownTracing:    shutdownTracing,
+	}
+}
+
+// Start runs what every process needs, currently the balance stream relay
+func (a *App) Start(ctx context.Context) {
+	a.BalanceHub.Start(ctx)
+}
+
+// StartJobs starts the background jobs: balance fetching and cleanup, Transfer
+// event watching, queued refreshes, historical backfills, transaction ingestion
+// and webhook delivery
+func (a *App) StartJobs(ctx context.Context) {
+	a.Logger.Info("Starting background jobs")
+
+	// The leader is elected before the fetcher's first cycle
+	if a.leader != nil {
+		a.jobs = append(a.jobs, a.leader)
+	}
+	a.jobs = append(a.jobs, a.WebhookService, a.BalanceFetcher)
+	if a.transferWatcher != nil {
+		a.jobs = append(a.jobs, a.transferWatcher)
+	}
+	a.jobs = append(a.jobs, a.JobService, a.BackfillService)
+
+	// Ingestion is opt-in since it reads every block
+	if a.Config.Web3.IngestTransactions {
+		a.jobs = append(a.jobs, a.TransactionService)
+	}
+
+	for _, j := range a.jobs {
+		j.Start(ctx)
+	}
+}
+
+// Shutdown stops the background jobs in reverse start order, then the stream
+// relay, which ends the open balance streams, and flushes buffered spans
+func (a *App) Shutdown() {
+	for i := len(a.jobs) - 1; i >= 0; i-- {
+		a.jobs[i].Stop()
+	}
+	a.jobs = nil
+
+	a.BalanceHub.Stop()
+
+	// Flush the spans of the stopped jobs
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := a.shutdownTracing(ctx); err != nil {
+		a.Logger.Error("Failed to flush traces", "error", err)
+	}
+}
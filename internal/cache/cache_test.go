@@ -18,9 +18,10 @@ func TestUserCache_Operations(t *testing.T) {
 	ctx := context.Background()
 	
 	// Test user
+	email := "test@example.com"
 	user := &models.User{
 		ID:       1,
-		Email:    "test@example.com",
+		Email:    &email,
 		Name:     "Test User",
 		Password: "hashedpassword",
 		CreatedAt: time.Now(),
@@ -46,13 +47,13 @@ func TestUserCache_Operations(t *testing.T) {
 	err = mockCache.SetUserByEmail(ctx, user)
 	require.NoError(t, err)
 	
-	retrievedUser, err = mockCache.GetUserByEmail(ctx, user.Email)
+	retrievedUser, err = mockCache.GetUserByEmail(ctx, *user.Email)
 	require.NoError(t, err)
 	assert.Equal(t, user.ID, retrievedUser.ID)
 	assert.Equal(t, user.Email, retrievedUser.Email)
 	
 	// Test InvalidateUser
-	err = mockCache.InvalidateUser(ctx, user.ID, user.Email)
+	err = mockCache.InvalidateUser(ctx, user.ID, *user.Email)
 	require.NoError(t, err)
 	
 	// Should be cache miss after invalidation
@@ -60,7 +61,7 @@ func TestUserCache_Operations(t *testing.T) {
 	assert.Error(t, err)
 	assert.Equal(t, ErrCacheMiss, err)
 	
-	_, err = mockCache.GetUserByEmail(ctx, user.Email)
+	_, err = mockCache.GetUserByEmail(ctx, *user.Email)
 	assert.Error(t, err)
 	assert.Equal(t, ErrCacheMiss, err)
 }
@@ -98,7 +99,7 @@ func (m *MockUserCache) GetUserByEmail(ctx context.Context, email string) (*mode
 }
 
 func (m *MockUserCache) SetUserByEmail(ctx context.Context, user *models.User) error {
-	m.emails[user.Email] = user
+	m.emails[*user.Email] = user
 	return nil
 }
 
@@ -17,10 +17,29 @@ var (
 type CacheProvider interface {
 	Set(ctx context.Context, key string, value interface{}, expiration time.Duration) error
 	Get(ctx context.Context, key string, dest interface{}) error
+	// GetDel retrieves a value and deletes its key in one atomic step, so
+	// only one of several concurrent callers gets it; the others get ErrCacheMiss
+	GetDel(ctx context.Context, key string, dest interface{}) error
 	Delete(ctx context.Context, key string) error
 	DeletePattern(ctx context.Context, pattern string) error
 }
 
+// PubSubProvider defines publish/subscribe operations
+type PubSubProvider interface {
+	Publish(ctx context.Context, channel string, message interface{}) error
+	Subscribe(ctx context.Context, channel string) (<-chan []byte, error)
+}
+
+// LockProvider defines lease-based lock operations. A lock is held by an
+// owner token until its TTL runs out unless the owner renews it.
+type LockProvider interface {
+	// AcquireLock takes the lock, or extends it when owner already holds it,
+	// and reports whether owner holds it afterwards
+	AcquireLock(ctx context.Context, key, owner string, ttl time.Duration) (bool, error)
+	// ReleaseLock frees the lock if owner holds it
+	ReleaseLock(ctx context.Context, key, owner string) error
+}
+
 // UserCacheProvider defines user-specific cache operations
 type UserCacheProvider interface {
 	GetUserByID(ctx context.Context, userID uint) (*models.User, error)
@@ -29,4 +48,10 @@ type UserCacheProvider interface {
 	SetUserByEmail(ctx context.Context, user *models.User) error
 	InvalidateUser(ctx context.Context, userID uint, email string) error
 	InvalidateAllUsers(ctx context.Context) error
-} 
\ No newline at end of file
+} 
+// TokenDenylistProvider records revoked access tokens by their jti claim
+type TokenDenylistProvider interface {
+	// Revoke denylists a token for ttl, which should cover its remaining lifetime
+	Revoke(ctx context.Context, tokenID string, ttl time.Duration) error
+	IsRevoked(ctx context.Context, tokenID string) (bool, error)
+}
@@ -5,6 +5,8 @@ import (
 	"encoding/json"
 	"time"
 
+	"cryptoportfolio/internal/metrics"
+	"cryptoportfolio/internal/tracing"
 	"cryptoportfolio/pkg/logger"
 
 	"github.com/redis/go-redis/v9"
@@ -23,6 +25,7 @@ func NewRedisClient(addr, password string, db int, logger *logger.Logger) *Redis
 		Password: password,
 		DB:       db,
 	})
+	client.AddHook(tracing.RedisHook{})
 
 	return &RedisClient{
 		client: client,
@@ -53,23 +56,53 @@ func (r *RedisClient) Get(ctx context.Context, key string, dest interface{}) err
 	data, err := r.client.Get(ctx, key).Bytes()
 	if err != nil {
 		if err == redis.Nil {
+			metrics.CacheRequests.WithLabelValues(metrics.CacheMiss).Inc()
 			r.logger.Debug("Cache miss", "key", key)
 			return ErrCacheMiss
 		}
+		metrics.CacheRequests.WithLabelValues(metrics.CacheError).Inc()
 		r.logger.Error("Failed to get cache value", "error", err, "key", key)
 		return err
 	}
 
 	err = json.Unmarshal(data, dest)
 	if err != nil {
+		metrics.CacheRequests.WithLabelValues(metrics.CacheError).Inc()
 		r.logger.Error("Failed to unmarshal cached value", "error", err, "key", key)
 		return err
 	}
 
+	metrics.CacheRequests.WithLabelValues(metrics.CacheHit).Inc()
 	r.logger.Debug("Cache hit", "key", key)
 	return nil
 }
 
+// GetDel atomically retrieves a value and deletes its key, unmarshalling the value into the provided interface
+func (r *RedisClient) GetDel(ctx context.Context, key string, dest interface{}) error {
+	data, err := r.client.GetDel(ctx, key).Bytes()
+	if err != nil {
+		if err == redis.Nil {
+			metrics.CacheRequests.WithLabelValues(metrics.CacheMiss).Inc()
+			r.logger.Debug("Cache miss", "key", key)
+			return ErrCacheMiss
+		}
+		metrics.CacheRequests.WithLabelValues(metrics.CacheError).Inc()
+		r.logger.Error("Failed to get and delete cache value", "error", err, "key", key)
+		return err
+	}
+
+	err = json.Unmarshal(data, dest)
+	if err != nil {
+		metrics.CacheRequests.WithLabelValues(metrics.CacheError).Inc()
+		r.logger.Error("Failed to unmarshal cached value", "error", err, "key", key)
+		return err
+	}
+
+	metrics.CacheRequests.WithLabelValues(metrics.CacheHit).Inc()
+	r.logger.Debug("Cache hit and key deleted", "key", key)
+	return nil
+}
+
 // Delete removes a key from cache
 func (r *RedisClient) Delete(ctx context.Context, key string) error {
 	err := r.client.Del(ctx, key).Err()
@@ -102,6 +135,100 @@ func (r *RedisClient) DeletePattern(ctx context.Context, pattern string) error {
 	return nil
 }
 
+// Publish marshals a message and publishes it to a channel
+func (r *RedisClient) Publish(ctx context.Context, channel string, message interface{}) error {
+	data, err := json.Marshal(message)
+	if err != nil {
+		r.logger.Error("Failed to marshal message for publish", "error", err, "channel", channel)
+		return err
+	}
+
+	if err := r.client.Publish(ctx, channel, data).Err(); err != nil {
+		r.logger.Error("Failed to publish message", "error", err, "channel", channel)
+		return err
+	}
+	return nil
+}
+
+// Subscribe delivers the payloads published to a channel until ctx is done.
+// The returned channel is closed when the subscription ends; go-redis
+// resubscribes by itself after a dropped connection.
+func (r *RedisClient) Subscribe(ctx context.Context, channel string) (<-chan []byte, error) {
+	pubsub := r.client.Subscribe(ctx, channel)
+	if _, err := pubsub.Receive(ctx); err != nil {
+		pubsub.Close()
+		r.logger.Error("Failed to subscribe to channel", "error", err, "channel", channel)
+		return nil, err
+	}
+
+	payloads := make(chan []byte, 256)
+	go func() {
+		defer close(payloads)
+		defer pubsub.Close()
+
+		messages := pubsub.Channel()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case msg, ok := <-messages:
+				if !ok {
+					return
+				}
+				select {
+				case payloads <- []byte(msg.Payload):
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+
+	return payloads, nil
+}
+
+// acquireLockScript sets the lock when it is free and extends it when the
+// caller already owns it, so taking and renewing a lease is one atomic step
+var acquireLockScript = redis.NewScript(`
+local owner = redis.call("GET", KEYS[1])
+if owner == ARGV[1] then
+	redis.call("PEXPIRE", KEYS[1], ARGV[2])
+	return 1
+end
+if not owner then
+	redis.call("SET", KEYS[1], ARGV[1], "PX", ARGV[2])
+	return 1
+end
+return 0
+`)
+
+// releaseLockScript deletes the lock only when the caller owns it
+var releaseLockScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0
+`)
+
+// AcquireLock takes or renews a lease on key for owner
+func (r *RedisClient) AcquireLock(ctx context.Context, key, owner string, ttl time.Duration) (bool, error) {
+	held, err := acquireLockScript.Run(ctx, r.client, []string{key}, owner, ttl.Milliseconds()).Int()
+	if err != nil {
+		r.logger.Error("Failed to acquire lock", "error", err, "key", key)
+		return false, err
+	}
+	return held == 1, nil
+}
+
+// ReleaseLock frees a lease on key if owner holds it
+func (r *RedisClient) ReleaseLock(ctx context.Context, key, owner string) error {
+	if err := releaseLockScript.Run(ctx, r.client, []string{key}, owner).Err(); err != nil {
+		r.logger.Error("Failed to release lock", "error", err, "key", key)
+		return err
+	}
+	return nil
+}
+
 // Ping tests the Redis connection
 func (r *RedisClient) Ping(ctx context.Context) error {
 	_, err := r.client.Ping(ctx).Result()
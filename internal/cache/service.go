@@ -31,6 +31,11 @@ func (cs *CacheService) Get(ctx context.Context, key string, dest interface{}) e
 	return cs.redis.Get(ctx, key, dest)
 }
 
+// GetDel atomically retrieves a value and deletes its key
+func (cs *CacheService) GetDel(ctx context.Context, key string, dest interface{}) error {
+	return cs.redis.GetDel(ctx, key, dest)
+}
+
 // Delete removes a key from cache
 func (cs *CacheService) Delete(ctx context.Context, key string) error {
 	return cs.redis.Delete(ctx, key)
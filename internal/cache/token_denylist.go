@@ -0,0 +1,45 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// TokenDenylist keeps the IDs of revoked access tokens until the tokens expire
+type TokenDenylist struct {
+	cacheService CacheProvider
+}
+
+// NewTokenDenylist creates a new token denylist
+func NewTokenDenylist(cacheService CacheProvider) *TokenDenylist {
+	return &TokenDenylist{
+		cacheService: cacheService,
+	}
+}
+
+// Revoke denylists a token for ttl. Tokens that already expired are skipped.
+func (d *TokenDenylist) Revoke(ctx context.Context, tokenID string, ttl time.Duration) error {
+	if ttl <= 0 {
+		return nil
+	}
+	return d.cacheService.Set(ctx, denylistKey(tokenID), true, ttl)
+}
+
+// IsRevoked reports whether a token was revoked
+func (d *TokenDenylist) IsRevoked(ctx context.Context, tokenID string) (bool, error) {
+	var revoked bool
+	err := d.cacheService.Get(ctx, denylistKey(tokenID), &revoked)
+	if errors.Is(err, ErrCacheMiss) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return revoked, nil
+}
+
+func denylistKey(tokenID string) string {
+	return fmt.Sprintf("revoked_token:%s", tokenID)
+}
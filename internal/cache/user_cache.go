@@ -54,7 +54,10 @@ func (uc *UserCache) GetUserByEmail(ctx context.Context, email string) (*models.
 
 // SetUserByEmail stores a user in cache by email
 func (uc *UserCache) SetUserByEmail(ctx context.Context, user *models.User) error {
-	key := fmt.Sprintf("user:email:%s", user.Email)
+	if user.Email == nil {
+		return nil
+	}
+	key := fmt.Sprintf("user:email:%s", *user.Email)
 	return uc.cacheService.Set(ctx, key, user, 30*time.Minute)
 }
 
@@ -4,21 +4,34 @@ import (
 	"fmt"
 	"os"
 	"strconv"
+	"strings"
 
 	"github.com/joho/godotenv"
 )
 
 type Config struct {
-	Environment string
-	Server      ServerConfig
-	Database    DatabaseConfig
-	Redis       RedisConfig
-	Web3        Web3Config
-	JWT         JWTConfig
+	Environment        string
+	Server             ServerConfig
+	Database           DatabaseConfig
+	Redis              RedisConfig
+	Web3               Web3Config
+	Price              PriceConfig
+	Webhook            WebhookConfig
+	Stream             StreamConfig
+	Leader             LeaderConfig
+	Jobs               JobsConfig
+	Schedule           ScheduleConfig
+	Admin              AdminConfig
+	Metrics            MetricsConfig
+	Tracing            TracingConfig
+	JWT                JWTConfig
+	SIWE               SIWEConfig
+	WalletVerification WalletVerificationConfig
 }
 
 type ServerConfig struct {
-	Port int
+	Port    int
+	RunJobs bool // Run background jobs in the API server; turn off when cmd/worker runs them
 }
 
 type DatabaseConfig struct {
@@ -43,10 +56,156 @@ type Web3Config struct {
 	RateLimit   int // Requests per second
 	MaxWorkers  int // Number of concurrent balance fetch workers
 	FetchInterval int // Balance fetch interval in minutes
+	MulticallBatchSize int // Number of balance calls aggregated into one Multicall3 request
+	ProviderCooldown int // Seconds an unhealthy RPC endpoint is ejected from its pool
+	ProviderMaxFailures int // Consecutive failures before an RPC endpoint is ejected
+	BalanceRetentionDays int // Days balance snapshots are kept before cleanup
+	FetchRunRetentionDays int // Days fetch run history is kept before cleanup
+	BackfillDays int // Days of daily history backfilled for new wallet/token pairs; 0 disables backfill
+	Confirmations int // Blocks behind the chain head each fetch cycle reads at
+	WatchTransfers bool // Re-fetch balances as soon as a Transfer event touches a watched wallet
+	LogPollInterval int // Seconds between eth_getLogs polls when no WebSocket subscription is active
+	IngestTransactions bool // Record native and ERC-20 transfers of watched wallets
+	TransactionScanInterval int // Seconds between transfer ingestion cycles
+	TransactionScanBlocks int // Maximum blocks scanned per wallet in one ingestion cycle
+	Chains      []ChainConfig // Chains with a configured RPC endpoint, default chain first
 }
 
+// ChainConfig describes a single EVM network balances can be read from
+type ChainConfig struct {
+	ChainID      int64
+	Name         string
+	NativeSymbol string
+	RPCEndpoints []RPCEndpointConfig
+	ArchiveRPCEndpoints []RPCEndpointConfig // Archive-capable endpoints for historical reads; RPCEndpoints are used when empty
+	WSEndpoint string // WebSocket endpoint for new head subscriptions; logs are polled when empty
+	Multicall3Address string // Empty when Multicall3 is not deployed on the chain
+}
+
+// RPCEndpointConfig is a single RPC provider in a chain's provider pool
+type RPCEndpointConfig struct {
+	URL    string
+	Weight int // Relative share of requests sent to this endpoint
+}
+
+// DefaultMulticall3Address is the address Multicall3 is deployed at on most EVM chains
+const DefaultMulticall3Address = "0xcA11bde05977b3631167028862bE2a173976CA11"
+
+// knownChains lists the networks that can be enabled with a WEB3_RPC_ENDPOINT_<NAME> variable
+var knownChains = []ChainConfig{
+	{ChainID: 1, Name: "ethereum", NativeSymbol: "ETH"},
+	{ChainID: 10, Name: "optimism", NativeSymbol: "ETH"},
+	{ChainID: 56, Name: "bsc", NativeSymbol: "BNB"},
+	{ChainID: 137, Name: "polygon", NativeSymbol: "POL"},
+	{ChainID: 8453, Name: "base", NativeSymbol: "ETH"},
+	{ChainID: 42161, Name: "arbitrum", NativeSymbol: "ETH"},
+	{ChainID: 43114, Name: "avalanche", NativeSymbol: "AVAX"},
+}
+
+// Chain returns the configuration for the given chain ID
+func (c Web3Config) Chain(chainID int64) (ChainConfig, bool) {
+	for _, chain := range c.Chains {
+		if chain.ChainID == chainID {
+			return chain, true
+		}
+	}
+	return ChainConfig{}, false
+}
+
+// PriceConfig configures the token price oracle
+type PriceConfig struct {
+	Sources    []string // Price sources in priority order: chainlink, uniswap, static
+	FeedsFile  string   // JSON file with per-token feed definitions; built-in feeds are used when empty
+	CacheTTL   int      // Seconds a resolved price is cached
+	MaxAge     int      // Seconds after which a Chainlink answer is considered stale
+	TWAPWindow int      // Default Uniswap V3 TWAP window in seconds
+}
+
+// WebhookConfig configures outbound webhook delivery
+type WebhookConfig struct {
+	PollInterval int // Seconds between outbox polls for due deliveries
+	Timeout      int // Seconds a single delivery request may take
+	MaxAttempts  int // Attempts before a delivery is dead-lettered
+	RetryBase    int // Seconds before the first retry; doubled after every failed attempt
+	RetryMax     int // Upper bound in seconds for the delay between attempts
+
+	// AllowPrivateTargets permits endpoints on loopback, private and
+	// link-local addresses; only for development against local receivers
+	AllowPrivateTargets bool
+}
+
+// StreamConfig configures the live balance stream
+type StreamConfig struct {
+	RedisFanout bool // Relay balance updates through Redis pub/sub so every server instance receives them
+	Heartbeat   int  // Seconds between keep-alive messages on idle streams
+	BufferSize  int  // Updates queued per subscriber before a slow client is disconnected
+}
+
+// LeaderConfig configures leader election between server instances, so
+// background balance fetching runs on one of them at a time
+type LeaderConfig struct {
+	Enabled  bool   // Elect a leader through a Redis lease; when off every instance fetches
+	Key      string // Redis key holding the lease
+	LeaseTTL int    // Seconds a lease lasts without renewal; renewed every third of it
+}
+
+// JobsConfig configures the queue of on-demand jobs such as balance refreshes
+type JobsConfig struct {
+	PollInterval  int // Seconds between queue polls when no job was enqueued in-process
+	Concurrency   int // Jobs run at the same time per process
+	RefreshLimit  int // Balance refreshes a user may queue per window
+	RefreshWindow int // Length of the refresh rate limit window in seconds
+}
+
+// ScheduleConfig configures adaptive per-pair balance fetching. A new pair is
+// fetched every WEB3_FETCH_INTERVAL minutes; the interval is multiplied by
+// BackoffFactor after every unchanged fetch up to MaxInterval, and drops to
+// the owner's minimum after a change or when the owner views their balances.
+type ScheduleConfig struct {
+	Tick             int            // Seconds between checks for pairs that are due
+	MinInterval      int            // Default minimum interval in seconds
+	MaxInterval      int            // Upper bound in seconds for a dormant pair's interval
+	BackoffFactor    int            // Interval multiplier after a fetch that found no change
+	TierMinIntervals map[string]int // Lowest minimum interval in seconds users of each tier may set; other tiers use MinInterval
+}
+
+// MetricsConfig configures the Prometheus metrics endpoint
+type MetricsConfig struct {
+	Enabled    bool // Serve /metrics on a listener separate from the API
+	APIPort    int  // Port the API server serves /metrics on
+	WorkerPort int  // Port cmd/worker serves /metrics on
+}
+
+// TracingConfig configures OpenTelemetry tracing
+type TracingConfig struct {
+	Exporter     string  // none, otlp or stdout
+	OTLPEndpoint string  // host:port of an OTLP/HTTP collector
+	OTLPInsecure bool    // Send spans to the collector over plain HTTP
+	ServiceName  string  // service.name of the exported spans
+	SampleRatio  float64 // Fraction of new traces sampled; requests carrying a trace follow its decision
+}
+
+// AdminConfig configures access to the administration endpoints
+type AdminConfig struct {
+	UserIDs []uint // Users allowed to call /api/v1/admin endpoints
+}
+
+// SIWEConfig configures Sign-In with Ethereum (EIP-4361)
+type SIWEConfig struct {
+	Domain   string // Domain SIWE messages must be issued for, i.e. the host serving the sign-in page
+	NonceTTL int    // Seconds a sign-in nonce stays valid
+}
+
+// WalletVerificationConfig configures proving ownership of watched wallets
+type WalletVerificationConfig struct {
+	ChallengeTTL int // Seconds a wallet ownership challenge stays valid
+}
+
+// JWTConfig configures access and refresh tokens
 type JWTConfig struct {
-	Secret string
+	Secret          string
+	AccessTokenTTL  int // Seconds an access token is valid
+	RefreshTokenTTL int // Seconds a refresh token is valid; every refresh issues a new one
 }
 
 func Load() (*Config, error) {
@@ -58,7 +217,8 @@ func Load() (*Config, error) {
 	config := &Config{
 		Environment: getEnv("ENVIRONMENT", "development"),
 		Server: ServerConfig{
-			Port: getEnvAsInt("SERVER_PORT", 8080),
+			Port:    getEnvAsInt("SERVER_PORT", 8080),
+			RunJobs: getEnvAsBool("SERVER_RUN_JOBS", true),
 		},
 		Database: DatabaseConfig{
 			Host:     getEnv("DATABASE_HOST", "localhost"),
@@ -80,12 +240,89 @@ func Load() (*Config, error) {
 			RateLimit:   getEnvAsInt("WEB3_RATE_LIMIT", 5),
 			MaxWorkers:  getEnvAsInt("WEB3_MAX_WORKERS", 3),
 			FetchInterval: getEnvAsInt("WEB3_FETCH_INTERVAL", 5),
+			MulticallBatchSize: getEnvAsInt("WEB3_MULTICALL_BATCH_SIZE", 100),
+			ProviderCooldown: getEnvAsInt("WEB3_PROVIDER_COOLDOWN", 60),
+			ProviderMaxFailures: getEnvAsInt("WEB3_PROVIDER_MAX_FAILURES", 3),
+			BalanceRetentionDays: getEnvAsInt("WEB3_BALANCE_RETENTION_DAYS", 30),
+			FetchRunRetentionDays: getEnvAsInt("WEB3_FETCH_RUN_RETENTION_DAYS", 7),
+			BackfillDays: getEnvAsInt("WEB3_BACKFILL_DAYS", 30),
+			Confirmations: getEnvAsInt("WEB3_CONFIRMATIONS", 0),
+			WatchTransfers: getEnvAsBool("WEB3_WATCH_TRANSFERS", false),
+			LogPollInterval: getEnvAsInt("WEB3_LOG_POLL_INTERVAL", 15),
+			IngestTransactions: getEnvAsBool("WEB3_INGEST_TRANSACTIONS", false),
+			TransactionScanInterval: getEnvAsInt("WEB3_TX_SCAN_INTERVAL", 30),
+			TransactionScanBlocks: getEnvAsInt("WEB3_TX_SCAN_BLOCKS", 100),
+		},
+		Price: PriceConfig{
+			Sources:    parseList(getEnv("PRICE_SOURCES", "chainlink,uniswap,static")),
+			FeedsFile:  getEnv("PRICE_FEEDS_FILE", ""),
+			CacheTTL:   getEnvAsInt("PRICE_CACHE_TTL", 300),
+			MaxAge:     getEnvAsInt("PRICE_MAX_AGE", 86400),
+			TWAPWindow: getEnvAsInt("PRICE_TWAP_WINDOW", 1800),
+		},
+		Webhook: WebhookConfig{
+			PollInterval: getEnvAsInt("WEBHOOK_POLL_INTERVAL", 5),
+			Timeout:      getEnvAsInt("WEBHOOK_TIMEOUT", 10),
+			MaxAttempts:  getEnvAsInt("WEBHOOK_MAX_ATTEMPTS", 8),
+			RetryBase:    getEnvAsInt("WEBHOOK_RETRY_BASE", 30),
+			RetryMax:     getEnvAsInt("WEBHOOK_RETRY_MAX", 21600),
+
+			AllowPrivateTargets: getEnvAsBool("WEBHOOK_ALLOW_PRIVATE_TARGETS", false),
+		},
+		Stream: StreamConfig{
+			RedisFanout: getEnvAsBool("STREAM_REDIS_FANOUT", false),
+			Heartbeat:   getEnvAsInt("STREAM_HEARTBEAT", 15),
+			BufferSize:  getEnvAsInt("STREAM_BUFFER_SIZE", 64),
+		},
+		Leader: LeaderConfig{
+			Enabled:  getEnvAsBool("LEADER_ELECTION_ENABLED", false),
+			Key:      getEnv("LEADER_ELECTION_KEY", "balance_fetcher_leader"),
+			LeaseTTL: getEnvAsInt("LEADER_LEASE_TTL", 30),
+		},
+		Jobs: JobsConfig{
+			PollInterval:  getEnvAsInt("JOBS_POLL_INTERVAL", 2),
+			Concurrency:   getEnvAsInt("JOBS_CONCURRENCY", 2),
+			RefreshLimit:  getEnvAsInt("REFRESH_RATE_LIMIT", 5),
+			RefreshWindow: getEnvAsInt("REFRESH_RATE_WINDOW", 300),
+		},
+		Schedule: ScheduleConfig{
+			Tick:             getEnvAsInt("SCHEDULE_TICK", 15),
+			MinInterval:      getEnvAsInt("SCHEDULE_MIN_INTERVAL", 60),
+			MaxInterval:      getEnvAsInt("SCHEDULE_MAX_INTERVAL", 21600),
+			BackoffFactor:    getEnvAsInt("SCHEDULE_BACKOFF_FACTOR", 2),
+			TierMinIntervals: parseTierIntervals(getEnv("SCHEDULE_TIER_MIN_INTERVALS", "pro:15")),
+		},
+		Metrics: MetricsConfig{
+			Enabled:    getEnvAsBool("METRICS_ENABLED", true),
+			APIPort:    getEnvAsInt("METRICS_API_PORT", 9091),
+			WorkerPort: getEnvAsInt("METRICS_WORKER_PORT", 9090),
+		},
+		Tracing: TracingConfig{
+			Exporter:     getEnv("TRACING_EXPORTER", "none"),
+			OTLPEndpoint: getEnv("TRACING_OTLP_ENDPOINT", "localhost:4318"),
+			OTLPInsecure: getEnvAsBool("TRACING_OTLP_INSECURE", true),
+			ServiceName:  getEnv("TRACING_SERVICE_NAME", "cryptoportfolio"),
+			SampleRatio:  getEnvAsFloat("TRACING_SAMPLE_RATIO", 1),
+		},
+		Admin: AdminConfig{
+			UserIDs: parseIDList(getEnv("ADMIN_USER_IDS", "")),
 		},
 		JWT: JWTConfig{
-			Secret: getEnv("JWT_SECRET", "your-super-secret-jwt-key-change-in-production"),
+			Secret:          getEnv("JWT_SECRET", "your-super-secret-jwt-key-change-in-production"),
+			AccessTokenTTL:  getEnvAsInt("JWT_ACCESS_TOKEN_TTL", 900),
+			RefreshTokenTTL: getEnvAsInt("JWT_REFRESH_TOKEN_TTL", 2592000),
+		},
+		SIWE: SIWEConfig{
+			Domain:   getEnv("SIWE_DOMAIN", "localhost:8080"),
+			NonceTTL: getEnvAsInt("SIWE_NONCE_TTL", 300),
+		},
+		WalletVerification: WalletVerificationConfig{
+			ChallengeTTL: getEnvAsInt("WALLET_CHALLENGE_TTL", 300),
 		},
 	}
 
+	config.Web3.Chains = loadChains(config.Web3.ChainID, config.Web3.RPCEndpoint)
+
 	// Debug: Print what values were loaded
 	fmt.Printf("Loaded config - JWT Secret: %s\n", config.JWT.Secret)
 	fmt.Printf("Loaded config - Environment: %s\n", config.Environment)
@@ -99,6 +336,116 @@ func Load() (*Config, error) {
 	return config, nil
 }
 
+// loadChains builds the list of enabled chains. The default chain uses
+// WEB3_RPC_ENDPOINT, every other known chain is enabled by setting
+// WEB3_RPC_ENDPOINT_<NAME> (e.g. WEB3_RPC_ENDPOINT_ARBITRUM). Each variable
+// holds a comma-separated list of endpoints, optionally weighted with a
+// "|weight" suffix (e.g. "https://a.example|3,https://b.example"). The Multicall3
+// address can be overridden per chain with WEB3_MULTICALL3_ADDRESS_<NAME>;
+// setting it to "none" disables batching for that chain. Archive nodes used
+// for historical reads are configured the same way with
+// WEB3_ARCHIVE_RPC_ENDPOINT and WEB3_ARCHIVE_RPC_ENDPOINT_<NAME>, and
+// WebSocket endpoints with WEB3_WS_ENDPOINT and WEB3_WS_ENDPOINT_<NAME>.
+func loadChains(defaultChainID int64, defaultEndpoint string) []ChainConfig {
+	defaultChain := ChainConfig{
+		ChainID:      defaultChainID,
+		Name:         fmt.Sprintf("chain-%d", defaultChainID),
+		NativeSymbol: "ETH",
+		RPCEndpoints: parseRPCEndpoints(defaultEndpoint),
+		ArchiveRPCEndpoints: parseRPCEndpoints(getEnv("WEB3_ARCHIVE_RPC_ENDPOINT", "")),
+		WSEndpoint:   getEnv("WEB3_WS_ENDPOINT", ""),
+	}
+	for _, known := range knownChains {
+		if known.ChainID == defaultChainID {
+			defaultChain.Name = known.Name
+			defaultChain.NativeSymbol = known.NativeSymbol
+		}
+	}
+
+	chains := []ChainConfig{defaultChain}
+	for _, known := range knownChains {
+		if known.ChainID == defaultChainID {
+			continue
+		}
+		endpoint := getEnv("WEB3_RPC_ENDPOINT_"+strings.ToUpper(known.Name), "")
+		if endpoint == "" {
+			continue
+		}
+		known.RPCEndpoints = parseRPCEndpoints(endpoint)
+		known.ArchiveRPCEndpoints = parseRPCEndpoints(getEnv("WEB3_ARCHIVE_RPC_ENDPOINT_"+strings.ToUpper(known.Name), ""))
+		known.WSEndpoint = getEnv("WEB3_WS_ENDPOINT_"+strings.ToUpper(known.Name), "")
+		chains = append(chains, known)
+	}
+
+	for i := range chains {
+		address := getEnv("WEB3_MULTICALL3_ADDRESS_"+strings.ToUpper(chains[i].Name), DefaultMulticall3Address)
+		if strings.EqualFold(address, "none") {
+			address = ""
+		}
+		chains[i].Multicall3Address = address
+	}
+
+	return chains
+}
+
+// parseRPCEndpoints parses a comma-separated list of "url" or "url|weight" entries
+func parseRPCEndpoints(value string) []RPCEndpointConfig {
+	var endpoints []RPCEndpointConfig
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		endpoint := RPCEndpointConfig{URL: entry, Weight: 1}
+		if i := strings.LastIndex(entry, "|"); i != -1 {
+			endpoint.URL = strings.TrimSpace(entry[:i])
+			if weight, err := strconv.Atoi(strings.TrimSpace(entry[i+1:])); err == nil && weight > 0 {
+				endpoint.Weight = weight
+			}
+		}
+		endpoints = append(endpoints, endpoint)
+	}
+	return endpoints
+}
+
+// parseTierIntervals parses a comma-separated list of "tier:seconds" entries
+func parseTierIntervals(value string) map[string]int {
+	intervals := make(map[string]int)
+	for _, entry := range parseList(value) {
+		tier, seconds, ok := strings.Cut(entry, ":")
+		if !ok {
+			continue
+		}
+		if interval, err := strconv.Atoi(strings.TrimSpace(seconds)); err == nil && interval > 0 {
+			intervals[strings.TrimSpace(tier)] = interval
+		}
+	}
+	return intervals
+}
+
+// parseIDList parses a comma-separated list of IDs, dropping invalid entries
+func parseIDList(value string) []uint {
+	var ids []uint
+	for _, item := range parseList(value) {
+		if id, err := strconv.ParseUint(item, 10, 32); err == nil && id > 0 {
+			ids = append(ids, uint(id))
+		}
+	}
+	return ids
+}
+
+// parseList parses a comma-separated list, dropping empty entries
+func parseList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
 // Helper functions to get environment variables with defaults
 func getEnv(key, defaultValue string) string {
 	if value := os.Getenv(key); value != "" {
@@ -124,3 +471,21 @@ func getEnvAsInt64(key string, defaultValue int64) int64 {
 	}
 	return defaultValue
 }
+
+func getEnvAsFloat(key string, defaultValue float64) float64 {
+	if value := os.Getenv(key); value != "" {
+		if floatValue, err := strconv.ParseFloat(value, 64); err == nil {
+			return floatValue
+		}
+	}
+	return defaultValue
+}
+
+func getEnvAsBool(key string, defaultValue bool) bool {
+	if value := os.Getenv(key); value != "" {
+		if boolValue, err := strconv.ParseBool(value); err == nil {
+			return boolValue
+		}
+	}
+	return defaultValue
+}
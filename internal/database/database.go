@@ -27,6 +27,21 @@ func New(cfg config.DatabaseConfig) (*gorm.DB, error) {
 		&models.WatchlistWallet{},
 		&models.TrackedToken{},
 		&models.WalletBalance{},
+		&models.BackfillJob{},
+		&models.Transaction{},
+		&models.TransactionCursor{},
+		&models.ManualLot{},
+		&models.AlertRule{},
+		&models.Alert{},
+		&models.WebhookEndpoint{},
+		&models.WebhookDelivery{},
+		&models.WebhookAttempt{},
+		&models.Job{},
+		&models.FetchSchedule{},
+		&models.FetchRun{},
+		&models.FetchRunChain{},
+		&models.FetchRunError{},
+		&models.RefreshToken{},
 	); err != nil {
 		return nil, err
 	}
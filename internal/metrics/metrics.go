@@ -0,0 +1,251 @@
+// Package metrics defines the Prometheus metrics exposed on /metrics. The
+// collectors are registered with the default registry, which also carries the
+// Go runtime and process metrics. They are served on a listener of their own,
+// kept off the public API so it can be firewalled to the scraper.
+package metrics
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// namespace prefixes every metric name
+const namespace = "cryptoportfolio"
+
+// Cache lookup results
+const (
+	CacheHit   = "hit"
+	CacheMiss  = "miss"
+	CacheError = "error"
+)
+
+// RPC call results
+const (
+	RPCSuccess = "success"
+	RPCError   = "error"
+)
+
+// Fetched pair results
+const (
+	PairStored = "stored"
+	PairFailed = "failed"
+)
+
+var (
+	// HTTPRequests counts API requests by route template and status
+	HTTPRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "http",
+		Name:      "requests_total",
+		Help:      "HTTP requests by method, route and status code.",
+	}, []string{"method", "route", "status"})
+
+	// HTTPRequestDuration measures API request latency by route template and status
+	HTTPRequestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "http",
+		Name:      "request_duration_seconds",
+		Help:      "HTTP request latency by method, route and status code.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route", "status"})
+
+	// RPCRequests counts calls sent to RPC endpoints, including each failover attempt
+	RPCRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "rpc",
+		Name:      "requests_total",
+		Help:      "JSON-RPC calls sent to endpoints by chain, method and result.",
+	}, []string{"chain_id", "method", "result"})
+
+	// RPCRequestDuration measures the latency of calls sent to RPC endpoints
+	RPCRequestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "rpc",
+		Name:      "request_duration_seconds",
+		Help:      "JSON-RPC call latency by chain and method.",
+		Buckets:   []float64{.01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10, 30},
+	}, []string{"chain_id", "method"})
+
+	// RPCRetries counts calls retried after every endpoint of the pool failed
+	RPCRetries = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "rpc",
+		Name:      "retries_total",
+		Help:      "JSON-RPC calls retried with backoff by chain and method.",
+	}, []string{"chain_id", "method"})
+
+	// RPCRateLimited counts calls an endpoint rejected with a 429
+	RPCRateLimited = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "rpc",
+		Name:      "rate_limited_total",
+		Help:      "JSON-RPC calls rejected for rate limiting by chain and method.",
+	}, []string{"chain_id", "method"})
+
+	// CacheRequests counts Redis cache lookups by result
+	CacheRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "cache",
+		Name:      "requests_total",
+		Help:      "Redis cache lookups by result: hit, miss or error.",
+	}, []string{"result"})
+
+	// FetchDuration measures balance fetches from start to the last stored pair
+	FetchDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "fetcher",
+		Name:      "run_duration_seconds",
+		Help:      "Balance fetch duration by trigger: schedule, refresh or transfer.",
+		Buckets:   []float64{.1, .5, 1, 2.5, 5, 10, 30, 60, 120, 300, 600},
+	}, []string{"trigger"})
+
+	// FetchedPairs counts wallet/token pairs fetched by trigger and result
+	FetchedPairs = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "fetcher",
+		Name:      "pairs_total",
+		Help:      "Wallet/token pairs fetched by trigger and result: stored or failed.",
+	}, []string{"trigger", "result"})
+
+	// FetchQueueDepth is the number of pairs of running fetches not read yet
+	FetchQueueDepth = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: "fetcher",
+		Name:      "queue_depth",
+		Help:      "Wallet/token pairs waiting to be read by running fetches.",
+	})
+
+	// PairStaleness reports how long ago each watched pair was last fetched
+	PairStaleness = newStalenessCollector()
+)
+
+func init() {
+	prometheus.MustRegister(PairStaleness)
+}
+
+// Handler serves the registered metrics in the Prometheus text format
+func Handler() http.Handler {
+	return promhttp.Handler()
+}
+
+// NewServer returns a server for /metrics on the given port
+func NewServer(port int) *http.Server {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", Handler())
+	return &http.Server{
+		Addr:    fmt.Sprintf(":%d", port),
+		Handler: mux,
+	}
+}
+
+// ChainLabel formats a chain ID as a label value
+func ChainLabel(chainID int64) string {
+	return strconv.FormatInt(chainID, 10)
+}
+
+// Pair identifies a watched wallet/token pair tracked for staleness. Only
+// its chain is exported as a label, so the series count does not grow with
+// the number of watched pairs; per-pair status is served by the API.
+type Pair struct {
+	ChainID  int64
+	WalletID uint
+	TokenID  uint
+}
+
+// stalenessBuckets are the upper bounds in seconds of the staleness histogram,
+// from a minute up to a day
+var stalenessBuckets = []float64{60, 300, 900, 3600, 6 * 3600, 24 * 3600}
+
+// StalenessCollector exports per chain how long ago the watched pairs were
+// last fetched successfully, as a histogram and a maximum. Both are computed
+// when scraped so they keep growing between fetches.
+type StalenessCollector struct {
+	histogramDesc *prometheus.Desc
+	maxDesc       *prometheus.Desc
+
+	mu          sync.Mutex
+	lastSuccess map[Pair]time.Time
+}
+
+func newStalenessCollector() *StalenessCollector {
+	return &StalenessCollector{
+		histogramDesc: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "fetcher", "pair_staleness_seconds"),
+			"Seconds since the last successful balance fetch of the watched wallet/token pairs, by chain.",
+			[]string{"chain_id"}, nil,
+		),
+		maxDesc: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "fetcher", "pair_staleness_max_seconds"),
+			"Seconds since the last successful balance fetch of the stalest watched pair, by chain.",
+			[]string{"chain_id"}, nil,
+		),
+		lastSuccess: make(map[Pair]time.Time),
+	}
+}
+
+// Set replaces the tracked pairs; pairs no longer watched are dropped
+func (c *StalenessCollector) Set(lastSuccess map[Pair]time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.lastSuccess = lastSuccess
+}
+
+// Reset drops every pair, e.g. when this instance stops fetching
+func (c *StalenessCollector) Reset() {
+	c.Set(make(map[Pair]time.Time))
+}
+
+// Describe implements prometheus.Collector
+func (c *StalenessCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.histogramDesc
+	ch <- c.maxDesc
+}
+
+// chainStaleness accumulates the staleness of one chain's pairs
+type chainStaleness struct {
+	count   uint64
+	sum     float64
+	max     float64
+	buckets map[float64]uint64
+}
+
+// Collect implements prometheus.Collector
+func (c *StalenessCollector) Collect(ch chan<- prometheus.Metric) {
+	c.mu.Lock()
+	now := time.Now()
+	chains := make(map[int64]*chainStaleness)
+	for pair, at := range c.lastSuccess {
+		chain, ok := chains[pair.ChainID]
+		if !ok {
+			chain = &chainStaleness{buckets: make(map[float64]uint64, len(stalenessBuckets))}
+			chains[pair.ChainID] = chain
+		}
+
+		seconds := now.Sub(at).Seconds()
+		chain.count++
+		chain.sum += seconds
+		if seconds > chain.max {
+			chain.max = seconds
+		}
+		// Histogram buckets are cumulative
+		for _, bound := range stalenessBuckets {
+			if seconds <= bound {
+				chain.buckets[bound]++
+			}
+		}
+	}
+	c.mu.Unlock()
+
+	for chainID, chain := range chains {
+		label := ChainLabel(chainID)
+		ch <- prometheus.MustNewConstHistogram(c.histogramDesc, chain.count, chain.sum, chain.buckets, label)
+		ch <- prometheus.MustNewConstMetric(c.maxDesc, prometheus.GaugeValue, chain.max, label)
+	}
+}
@@ -0,0 +1,67 @@
+package models
+
+import "time"
+
+// Alert rule types
+const (
+	// AlertRuleBalanceDrop fires when a balance falls by more than Threshold percent from the previous snapshot
+	AlertRuleBalanceDrop = "balance_drop"
+	// AlertRuleBalanceBelow fires when a balance falls below Threshold tokens
+	AlertRuleBalanceBelow = "balance_below"
+	// AlertRuleBalanceAbove fires when a balance rises above Threshold tokens
+	AlertRuleBalanceAbove = "balance_above"
+	// AlertRuleOutgoingTransfer fires on each ingested outgoing transfer, or on
+	// any decrease of a balance when transactions are not ingested
+	AlertRuleOutgoingTransfer = "outgoing_transfer"
+	// AlertRuleValueCross fires when a balance's USD value crosses Threshold in either direction
+	AlertRuleValueCross = "usd_value_cross"
+)
+
+// AlertRule is a user-defined condition on a wallet's balances, checked
+// against each newly fetched balance. Rules without a token apply to every
+// tracked token on the wallet's chain.
+type AlertRule struct {
+	ID              uint       `json:"id" gorm:"primaryKey"`
+	UserID          uint       `json:"user_id" gorm:"not null;index"`
+	WalletID        uint       `json:"wallet_id" gorm:"not null;index"`
+	TokenID         *uint      `json:"token_id,omitempty" gorm:"index"`
+	Type            string     `json:"type" gorm:"not null;size:32"`
+	Threshold       string     `json:"threshold" gorm:"size:100"` // Percentage, token amount or USD value depending on Type
+	CooldownSeconds int        `json:"cooldown_seconds" gorm:"not null"`
+	Enabled         bool       `json:"enabled" gorm:"not null"`
+	LastFiredAt     *time.Time `json:"last_fired_at,omitempty"`
+	CreatedAt       time.Time  `json:"created_at"`
+	UpdatedAt       time.Time  `json:"updated_at"`
+
+	// Relationships
+	Wallet WatchlistWallet `json:"wallet,omitempty" gorm:"foreignKey:WalletID"`
+	Token  *TrackedToken   `json:"token,omitempty" gorm:"foreignKey:TokenID"`
+}
+
+// Alert is a fired alert rule with the balance change or transfer that triggered it
+type Alert struct {
+	ID               uint      `json:"id" gorm:"primaryKey"`
+	RuleID           uint      `json:"rule_id" gorm:"not null;index"`
+	UserID           uint      `json:"user_id" gorm:"not null;index"`
+	WalletID         uint      `json:"wallet_id" gorm:"not null"`
+	TokenID          uint      `json:"token_id" gorm:"not null"`
+	BalanceID        *uint     `json:"balance_id,omitempty"`             // Snapshot the rule was evaluated against
+	TxHash           *string   `json:"tx_hash,omitempty" gorm:"size:66"` // Transfer that fired an outgoing_transfer rule
+	Type             string    `json:"type" gorm:"not null;size:32"`
+	Message          string    `json:"message" gorm:"not null;size:255"`
+	PreviousAmount   *string   `json:"previous_amount,omitempty" gorm:"size:100"` // Decimal-adjusted balance of the previous snapshot
+	Amount           string    `json:"amount" gorm:"not null;size:100"`           // Decimal-adjusted balance that triggered the alert, or the amount sent
+	PreviousValueUSD *string   `json:"previous_value_usd,omitempty" gorm:"size:100"`
+	ValueUSD         *string   `json:"value_usd,omitempty" gorm:"size:100"`
+	FiredAt          time.Time `json:"fired_at" gorm:"not null;index"`
+}
+
+// TableName specifies the table name for AlertRule
+func (AlertRule) TableName() string {
+	return "alert_rules"
+}
+
+// TableName specifies the table name for Alert
+func (Alert) TableName() string {
+	return "alerts"
+}
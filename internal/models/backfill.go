@@ -0,0 +1,40 @@
+package models
+
+import "time"
+
+// Backfill job statuses
+const (
+	BackfillStatusPending   = "pending"
+	BackfillStatusRunning   = "running"
+	BackfillStatusCompleted = "completed"
+	BackfillStatusFailed    = "failed"
+)
+
+// BackfillJob tracks the historical balance backfill of one wallet/token pair.
+// One snapshot is written per day from StartDate to EndDate (00:00 UTC).
+type BackfillJob struct {
+	ID            uint       `json:"id" gorm:"primaryKey"`
+	UserID        uint       `json:"user_id" gorm:"not null;index"`
+	WalletID      uint       `json:"wallet_id" gorm:"not null;index"`
+	TokenID       uint       `json:"token_id" gorm:"not null;index"`
+	ChainID       int64      `json:"chain_id" gorm:"not null"`
+	Status        string     `json:"status" gorm:"not null;size:20;index"`
+	StartDate     time.Time  `json:"start_date" gorm:"not null"`
+	EndDate       time.Time  `json:"end_date" gorm:"not null"`
+	TotalDays     int        `json:"total_days" gorm:"not null"`
+	CompletedDays int        `json:"completed_days" gorm:"not null;default:0"`
+	LastError     string     `json:"last_error,omitempty" gorm:"size:500"`
+	StartedAt     *time.Time `json:"started_at,omitempty"`
+	CompletedAt   *time.Time `json:"completed_at,omitempty"`
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+
+	// Relationships
+	Wallet WatchlistWallet `json:"wallet,omitempty" gorm:"foreignKey:WalletID"`
+	Token  TrackedToken    `json:"token,omitempty" gorm:"foreignKey:TokenID"`
+}
+
+// TableName specifies the table name for BackfillJob
+func (BackfillJob) TableName() string {
+	return "backfill_jobs"
+}
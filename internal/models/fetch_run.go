@@ -0,0 +1,73 @@
+package models
+
+import "time"
+
+// Fetch run triggers
+const (
+	FetchRunTriggerSchedule = "schedule" // a cycle of the background fetcher
+	FetchRunTriggerRefresh  = "refresh"  // a user's queued balance refresh
+	FetchRunTriggerTransfer = "transfer" // pairs touched by Transfer events
+)
+
+// Fetch run statuses
+const (
+	FetchRunStatusCompleted   = "completed"
+	FetchRunStatusInterrupted = "interrupted" // cancelled or timed out before every pair was read
+)
+
+// FetchRun records one pass of the balance fetcher over a set of wallet/token
+// pairs, with the block read on each chain and the pairs that failed
+type FetchRun struct {
+	ID          uint      `json:"id" gorm:"primaryKey"`
+	Trigger     string    `json:"trigger" gorm:"not null;size:20;index"`
+	Status      string    `json:"status" gorm:"not null;size:20"`
+	TotalPairs  int       `json:"total_pairs" gorm:"not null"`
+	StoredPairs int       `json:"stored_pairs" gorm:"not null"`
+	FailedPairs int       `json:"failed_pairs" gorm:"not null"`
+	StartedAt   time.Time `json:"started_at" gorm:"not null;index"`
+	CompletedAt time.Time `json:"completed_at" gorm:"not null"`
+
+	// Relationships
+	Chains []FetchRunChain `json:"chains,omitempty" gorm:"foreignKey:RunID;constraint:OnDelete:CASCADE"`
+	Errors []FetchRunError `json:"errors,omitempty" gorm:"foreignKey:RunID;constraint:OnDelete:CASCADE"`
+}
+
+// TableName specifies the table name for FetchRun
+func (FetchRun) TableName() string {
+	return "fetch_runs"
+}
+
+// FetchRunChain is the part of a run on one chain and the block it was read at
+type FetchRunChain struct {
+	ID          uint    `json:"id" gorm:"primaryKey"`
+	RunID       uint    `json:"run_id" gorm:"not null;index"`
+	ChainID     int64   `json:"chain_id" gorm:"not null"`
+	BlockNumber *uint64 `json:"block_number,omitempty"`
+	BlockHash   *string `json:"block_hash,omitempty" gorm:"size:66"`
+	TotalPairs  int     `json:"total_pairs" gorm:"not null"`
+	FailedPairs int     `json:"failed_pairs" gorm:"not null"`
+	Error       string  `json:"error,omitempty" gorm:"size:500"` // set when no block could be pinned
+}
+
+// TableName specifies the table name for FetchRunChain
+func (FetchRunChain) TableName() string {
+	return "fetch_run_chains"
+}
+
+// FetchRunError is a pair whose balance could not be fetched or stored in a run
+type FetchRunError struct {
+	ID            uint   `json:"id" gorm:"primaryKey"`
+	RunID         uint   `json:"run_id" gorm:"not null;index"`
+	UserID        uint   `json:"user_id" gorm:"not null"`
+	WalletID      uint   `json:"wallet_id" gorm:"not null"`
+	WalletAddress string `json:"wallet_address" gorm:"size:42"`
+	TokenID       uint   `json:"token_id" gorm:"not null"`
+	TokenSymbol   string `json:"token_symbol" gorm:"size:32"`
+	ChainID       int64  `json:"chain_id" gorm:"not null"`
+	Error         string `json:"error" gorm:"size:500"`
+}
+
+// TableName specifies the table name for FetchRunError
+func (FetchRunError) TableName() string {
+	return "fetch_run_errors"
+}
@@ -0,0 +1,42 @@
+package models
+
+import "time"
+
+// Job kinds
+const (
+	JobKindBalanceRefresh = "balance_refresh"
+)
+
+// Job statuses
+const (
+	JobStatusPending   = "pending"
+	JobStatusRunning   = "running"
+	JobStatusCompleted = "completed"
+	JobStatusFailed    = "failed"
+)
+
+// Job is a queued unit of on-demand background work, such as refreshing all
+// balances of a user. Items are the units the job reports progress in, e.g.
+// wallet/token pairs.
+type Job struct {
+	ID     uint   `json:"id" gorm:"primaryKey"`
+	UserID uint   `json:"user_id" gorm:"not null;index"`
+	Kind   string `json:"kind" gorm:"not null;size:50"`
+	Status string `json:"status" gorm:"not null;size:20;index"`
+	// CoalesceKey is set while the job is pending; the unique index keeps a
+	// second identical job from being queued next to it
+	CoalesceKey    *string    `json:"-" gorm:"size:100;uniqueIndex"`
+	TotalItems     int        `json:"total_items" gorm:"not null;default:0"`
+	CompletedItems int        `json:"completed_items" gorm:"not null;default:0"`
+	FailedItems    int        `json:"failed_items" gorm:"not null;default:0"`
+	LastError      string     `json:"last_error,omitempty" gorm:"size:500"`
+	StartedAt      *time.Time `json:"started_at,omitempty"`
+	CompletedAt    *time.Time `json:"completed_at,omitempty"`
+	CreatedAt      time.Time  `json:"created_at" gorm:"index"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+}
+
+// TableName specifies the table name for Job
+func (Job) TableName() string {
+	return "jobs"
+}
@@ -0,0 +1,30 @@
+package models
+
+import "time"
+
+// ManualLot is a user-entered acquisition of a token in a wallet with its
+// cost. Lots linked to a transaction price that incoming transfer; other
+// lots cover holdings with no recorded transfer, e.g. balances held before
+// the wallet was added.
+type ManualLot struct {
+	ID            uint      `json:"id" gorm:"primaryKey"`
+	UserID        uint      `json:"user_id" gorm:"not null;index"`
+	WalletID      uint      `json:"wallet_id" gorm:"not null;index"`
+	TokenID       uint      `json:"token_id" gorm:"not null;index"`
+	TransactionID *uint     `json:"transaction_id,omitempty" gorm:"uniqueIndex"`
+	Amount        string    `json:"amount" gorm:"not null;size:100"`    // Raw amount in the token's smallest unit
+	PriceUSD      string    `json:"price_usd" gorm:"not null;size:100"` // USD price of one token at acquisition
+	AcquiredAt    time.Time `json:"acquired_at" gorm:"not null"`
+	Note          string    `json:"note" gorm:"size:255"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+
+	// Relationships
+	Wallet WatchlistWallet `json:"wallet,omitempty" gorm:"foreignKey:WalletID"`
+	Token  TrackedToken    `json:"token,omitempty" gorm:"foreignKey:TokenID"`
+}
+
+// TableName specifies the table name for ManualLot
+func (ManualLot) TableName() string {
+	return "manual_lots"
+}
@@ -0,0 +1,25 @@
+package models
+
+import "time"
+
+// RefreshToken is one refresh token of a login session. Every refresh marks
+// the presented token used and issues a new one in the same family, so a used
+// token presented again means it was stolen and the whole family is revoked.
+// Only the SHA-256 hash of the token is stored.
+type RefreshToken struct {
+	ID              uint       `json:"id" gorm:"primaryKey"`
+	UserID          uint       `json:"user_id" gorm:"not null;index"`
+	FamilyID        string     `json:"family_id" gorm:"not null;size:64;index"` // shared by every token of a session
+	TokenHash       string     `json:"-" gorm:"not null;size:64;uniqueIndex"`
+	AccessTokenID   string     `json:"access_token_id" gorm:"not null;size:64;index"` // jti of the access token issued with it
+	AccessExpiresAt time.Time  `json:"access_expires_at" gorm:"not null"`
+	ExpiresAt       time.Time  `json:"expires_at" gorm:"not null"`
+	UsedAt          *time.Time `json:"used_at,omitempty"`
+	RevokedAt       *time.Time `json:"revoked_at,omitempty"`
+	CreatedAt       time.Time  `json:"created_at"`
+}
+
+// TableName specifies the table name for RefreshToken
+func (RefreshToken) TableName() string {
+	return "refresh_tokens"
+}
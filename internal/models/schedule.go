@@ -0,0 +1,29 @@
+package models
+
+import "time"
+
+// FetchSchedule is the adaptive fetch schedule and fetch status of one
+// wallet/token pair. The interval grows while the balance stays unchanged and
+// drops back to the owner's minimum after a change or when the owner views
+// their balances.
+type FetchSchedule struct {
+	ID                  uint       `json:"id" gorm:"primaryKey"`
+	UserID              uint       `json:"user_id" gorm:"not null;index"`
+	WalletID            uint       `json:"wallet_id" gorm:"not null;uniqueIndex:idx_fetch_schedule_pair"`
+	TokenID             uint       `json:"token_id" gorm:"not null;uniqueIndex:idx_fetch_schedule_pair"`
+	IntervalSeconds     int        `json:"interval_seconds" gorm:"not null"`
+	NextFetchAt         time.Time  `json:"next_fetch_at" gorm:"not null;index"`
+	LastBalance         string     `json:"last_balance" gorm:"size:100"` // Balance at the last successful fetch, compared to detect changes
+	LastFetchedAt       *time.Time `json:"last_fetched_at,omitempty"`    // Last successful fetch
+	LastChangedAt       *time.Time `json:"last_changed_at,omitempty"`
+	LastErrorAt         *time.Time `json:"last_error_at,omitempty"`
+	LastError           string     `json:"last_error,omitempty" gorm:"size:500"`
+	ConsecutiveFailures int        `json:"consecutive_failures" gorm:"not null;default:0"`
+	CreatedAt           time.Time  `json:"created_at"`
+	UpdatedAt           time.Time  `json:"updated_at"`
+}
+
+// TableName specifies the table name for FetchSchedule
+func (FetchSchedule) TableName() string {
+	return "fetch_schedules"
+}
@@ -0,0 +1,53 @@
+package models
+
+import "time"
+
+// Transfer directions relative to the watched wallet
+const (
+	TransferDirectionIn   = "in"
+	TransferDirectionOut  = "out"
+	TransferDirectionSelf = "self"
+)
+
+// NativeTransferLogIndex is the LogIndex of native transfers, which have no log
+const NativeTransferLogIndex = -1
+
+// Transaction is a native or ERC-20 transfer into or out of a watched wallet.
+// A transaction hash can appear several times for the same wallet when one
+// transaction moved several tokens; each transfer is keyed by its log index.
+type Transaction struct {
+	ID           uint      `json:"id" gorm:"primaryKey"`
+	WalletID     uint      `json:"wallet_id" gorm:"not null;uniqueIndex:idx_transactions_transfer,priority:1;index:idx_transactions_wallet_block,priority:1"`
+	ChainID      int64     `json:"chain_id" gorm:"not null"`
+	TxHash       string    `json:"tx_hash" gorm:"not null;size:66;uniqueIndex:idx_transactions_transfer,priority:2"`
+	LogIndex     int       `json:"log_index" gorm:"not null;uniqueIndex:idx_transactions_transfer,priority:3"` // NativeTransferLogIndex for native transfers
+	BlockNumber  uint64    `json:"block_number" gorm:"not null;index:idx_transactions_wallet_block,priority:2"`
+	BlockHash    string    `json:"block_hash" gorm:"not null;size:66"`
+	Timestamp    time.Time `json:"timestamp" gorm:"not null"`
+	TokenAddress *string   `json:"token_address" gorm:"size:42"` // null for the chain's native token
+	FromAddress  string    `json:"from_address" gorm:"not null;size:42"`
+	ToAddress    string    `json:"to_address" gorm:"not null;size:42"`
+	Direction    string    `json:"direction" gorm:"not null;size:4"`
+	Amount       string    `json:"amount" gorm:"not null;size:100"` // Raw amount in the token's smallest unit
+	CreatedAt    time.Time `json:"created_at"`
+
+	// Relationships
+	Wallet WatchlistWallet `json:"wallet,omitempty" gorm:"foreignKey:WalletID"`
+}
+
+// TransactionCursor records how far a wallet's transfers have been ingested
+type TransactionCursor struct {
+	WalletID  uint      `json:"wallet_id" gorm:"primaryKey;autoIncrement:false"`
+	LastBlock uint64    `json:"last_block" gorm:"not null"` // Last block scanned, inclusive
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// TableName specifies the table name for Transaction
+func (Transaction) TableName() string {
+	return "transactions"
+}
+
+// TableName specifies the table name for TransactionCursor
+func (TransactionCursor) TableName() string {
+	return "transaction_cursors"
+}
@@ -6,12 +6,20 @@ import (
 	"gorm.io/gorm"
 )
 
+// User tiers
+const (
+	UserTierFree = "free"
+)
+
 type User struct {
-	ID        uint           `json:"id" gorm:"primaryKey"`
-	Email     string         `json:"email" gorm:"uniqueIndex;not null"`
-	Password  string         `json:"-" gorm:"not null"`
-	Name      string         `json:"name" gorm:"not null"`
-	CreatedAt time.Time      `json:"created_at"`
-	UpdatedAt time.Time      `json:"updated_at"`
-	DeletedAt gorm.DeletedAt `json:"-" gorm:"index"`
-}
\ No newline at end of file
+	ID               uint           `json:"id" gorm:"primaryKey"`
+	Email            *string        `json:"email,omitempty" gorm:"uniqueIndex"`                  // null for users who signed up with Sign-In with Ethereum
+	WalletAddress    *string        `json:"wallet_address,omitempty" gorm:"size:42;uniqueIndex"` // Checksummed address the user signs in with, if any
+	Password         string         `json:"-" gorm:"not null"`                                   // empty for users without a password
+	Name             string         `json:"name" gorm:"not null"`
+	Tier             string         `json:"tier" gorm:"not null;size:20;default:free"`
+	MinFetchInterval int            `json:"min_fetch_interval" gorm:"not null;default:0"` // Seconds; 0 uses the tier's default
+	CreatedAt        time.Time      `json:"created_at"`
+	UpdatedAt        time.Time      `json:"updated_at"`
+	DeletedAt        gorm.DeletedAt `json:"-" gorm:"index"`
+}
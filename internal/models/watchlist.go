@@ -11,7 +11,10 @@ type WatchlistWallet struct {
 	ID            uint           `json:"id" gorm:"primaryKey"`
 	UserID        uint           `json:"user_id" gorm:"not null;index"`
 	WalletAddress string         `json:"wallet_address" gorm:"not null;size:42;index"`
+	ChainID       int64          `json:"chain_id" gorm:"not null;default:1;index"`
 	Label         string         `json:"label" gorm:"size:100"`
+	AddedBlock    *uint64        `json:"added_block,omitempty"` // Chain head when the wallet was added; transfers are ingested from here
+	VerifiedAt    *time.Time     `json:"verified_at,omitempty"` // Set once the user proved they own the wallet; null for wallets only observed
 	CreatedAt     time.Time      `json:"created_at"`
 	UpdatedAt     time.Time      `json:"updated_at"`
 	DeletedAt     gorm.DeletedAt `json:"deleted_at,omitempty" gorm:"index"`
@@ -25,9 +28,11 @@ type WatchlistWallet struct {
 type TrackedToken struct {
 	ID           uint           `json:"id" gorm:"primaryKey"`
 	UserID       uint           `json:"user_id" gorm:"not null;index"`
-	TokenAddress *string        `json:"token_address" gorm:"size:42;index"` // null for native token (ETH)
-	TokenSymbol  string         `json:"token_symbol" gorm:"not null;size:10"`
+	TokenAddress *string        `json:"token_address" gorm:"size:42;index"` // null for the chain's native token
+	ChainID      int64          `json:"chain_id" gorm:"not null;default:1;index"`
+	TokenSymbol  string         `json:"token_symbol" gorm:"not null;size:32"`
 	TokenName    string         `json:"token_name" gorm:"not null;size:100"`
+	Decimals     *uint8         `json:"decimals"` // null for tokens added before decimals were discovered
 	CreatedAt    time.Time      `json:"created_at"`
 	UpdatedAt    time.Time      `json:"updated_at"`
 	DeletedAt    gorm.DeletedAt `json:"deleted_at,omitempty" gorm:"index"`
@@ -44,6 +49,9 @@ type WalletBalance struct {
 	TokenID      uint           `json:"token_id" gorm:"not null;index"`
 	Balance      string         `json:"balance" gorm:"not null;size:100"` // Store as string for precision
 	BalanceUSD   *string        `json:"balance_usd" gorm:"size:100"`      // Optional USD value
+	PriceUSD     *string        `json:"price_usd" gorm:"size:100"`        // USD price of one token at fetch time
+	BlockNumber  *uint64        `json:"block_number,omitempty" gorm:"index"` // Block the balance was read at; null for snapshots read at "latest"
+	BlockHash    *string        `json:"block_hash,omitempty" gorm:"size:66;index"`
 	FetchedAt    time.Time      `json:"fetched_at" gorm:"not null;index"`
 	CreatedAt    time.Time      `json:"created_at"`
 	UpdatedAt    time.Time      `json:"updated_at"`
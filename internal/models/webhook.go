@@ -0,0 +1,81 @@
+package models
+
+import "time"
+
+// Webhook event types
+const (
+	WebhookEventBalanceChanged = "balance.changed"
+	WebhookEventAlertFired     = "alert.fired"
+	WebhookEventFetchFailed    = "fetch.failed"
+)
+
+// Webhook delivery statuses
+const (
+	// WebhookDeliveryPending deliveries are attempted once NextAttemptAt has passed
+	WebhookDeliveryPending = "pending"
+	// WebhookDeliveryDelivered deliveries were acknowledged with a 2xx response
+	WebhookDeliveryDelivered = "delivered"
+	// WebhookDeliveryDead deliveries ran out of attempts and wait for a manual replay
+	WebhookDeliveryDead = "dead"
+)
+
+// WebhookEndpoint is a user's URL that events are pushed to, signed with Secret
+type WebhookEndpoint struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	UserID    uint      `json:"user_id" gorm:"not null;index"`
+	URL       string    `json:"url" gorm:"not null;size:2048"`
+	Secret    string    `json:"-" gorm:"not null;size:100"`
+	Events    string    `json:"events" gorm:"size:255"` // Comma-separated event types; empty subscribes to all events
+	Enabled   bool      `json:"enabled" gorm:"not null"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// WebhookDelivery is one event queued for one endpoint. Deliveries are
+// written to this outbox in the same flow that produces the event and sent
+// by the webhook dispatcher, so events survive restarts and endpoint outages.
+type WebhookDelivery struct {
+	ID             uint       `json:"id" gorm:"primaryKey"`
+	EndpointID     uint       `json:"endpoint_id" gorm:"not null;index"`
+	UserID         uint       `json:"user_id" gorm:"not null;index"`
+	EventID        string     `json:"event_id" gorm:"not null;size:64;index"` // Shared by the deliveries of one event to several endpoints
+	EventType      string     `json:"event_type" gorm:"not null;size:50"`
+	Payload        string     `json:"payload" gorm:"not null;type:text"`
+	Status         string     `json:"status" gorm:"not null;size:20;index"`
+	Attempts       int        `json:"attempts" gorm:"not null;default:0"`
+	NextAttemptAt  time.Time  `json:"next_attempt_at" gorm:"not null;index"`
+	LastStatusCode *int       `json:"last_status_code,omitempty"`
+	LastError      string     `json:"last_error,omitempty" gorm:"size:500"`
+	DeliveredAt    *time.Time `json:"delivered_at,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+
+	// Relationships
+	Endpoint    WebhookEndpoint  `json:"-" gorm:"foreignKey:EndpointID"`
+	AttemptLogs []WebhookAttempt `json:"attempt_logs,omitempty" gorm:"foreignKey:DeliveryID"`
+}
+
+// WebhookAttempt records one HTTP request made for a delivery
+type WebhookAttempt struct {
+	ID          uint      `json:"id" gorm:"primaryKey"`
+	DeliveryID  uint      `json:"delivery_id" gorm:"not null;index"`
+	StatusCode  *int      `json:"status_code,omitempty"` // Null when no response was received
+	Error       string    `json:"error,omitempty" gorm:"size:500"`
+	DurationMs  int64     `json:"duration_ms"`
+	AttemptedAt time.Time `json:"attempted_at" gorm:"not null"`
+}
+
+// TableName specifies the table name for WebhookEndpoint
+func (WebhookEndpoint) TableName() string {
+	return "webhook_endpoints"
+}
+
+// TableName specifies the table name for WebhookDelivery
+func (WebhookDelivery) TableName() string {
+	return "webhook_deliveries"
+}
+
+// TableName specifies the table name for WebhookAttempt
+func (WebhookAttempt) TableName() string {
+	return "webhook_attempts"
+}
@@ -0,0 +1,177 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"cryptoportfolio/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// AlertRepository defines the interface for alert rule and fired alert operations
+type AlertRepository interface {
+	CreateRule(ctx context.Context, rule *models.AlertRule) error
+	UpdateRule(ctx context.Context, rule *models.AlertRule) error
+	GetRuleByID(ctx context.Context, ruleID uint) (*models.AlertRule, error)
+	GetRulesByUserID(ctx context.Context, userID uint) ([]*models.AlertRule, error)
+	GetEnabledRules(ctx context.Context, walletID, tokenID uint) ([]*models.AlertRule, error)
+	DeleteRule(ctx context.Context, ruleID uint, userID uint) error
+
+	// Fire records a fired alert unless the rule fired for the alert's token after cooldownStart
+	Fire(ctx context.Context, alert *models.Alert, cooldownStart time.Time) (bool, error)
+	GetAlertsByUserID(ctx context.Context, userID uint, pagination Pagination) (*PaginatedResult[models.Alert], error)
+}
+
+// alertRepository implements AlertRepository
+type alertRepository struct {
+	db *gorm.DB
+}
+
+// NewAlertRepository creates a new alert repository
+func NewAlertRepository(db *gorm.DB) AlertRepository {
+	return &alertRepository{db: db}
+}
+
+// CreateRule creates a new alert rule
+func (r *alertRepository) CreateRule(ctx context.Context, rule *models.AlertRule) error {
+	return r.db.WithContext(ctx).Omit("Wallet", "Token").Create(rule).Error
+}
+
+// UpdateRule saves an alert rule's settings, leaving its firing state to Fire
+func (r *alertRepository) UpdateRule(ctx context.Context, rule *models.AlertRule) error {
+	return r.db.WithContext(ctx).
+		Model(rule).
+		Select("wallet_id", "token_id", "type", "threshold", "cooldown_seconds", "enabled").
+		Updates(rule).Error
+}
+
+// GetRuleByID retrieves an alert rule by ID
+func (r *alertRepository) GetRuleByID(ctx context.Context, ruleID uint) (*models.AlertRule, error) {
+	var rule models.AlertRule
+	err := r.db.WithContext(ctx).
+		Preload("Wallet").
+		Preload("Token").
+		Where("id = ?", ruleID).
+		First(&rule).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrRecordNotFound
+		}
+		return nil, err
+	}
+	return &rule, nil
+}
+
+// GetRulesByUserID retrieves all alert rules of a user
+func (r *alertRepository) GetRulesByUserID(ctx context.Context, userID uint) ([]*models.AlertRule, error) {
+	var rules []*models.AlertRule
+	err := r.db.WithContext(ctx).
+		Preload("Wallet").
+		Preload("Token").
+		Where("user_id = ?", userID).
+		Order("id ASC").
+		Find(&rules).Error
+	return rules, err
+}
+
+// GetEnabledRules retrieves the enabled rules that apply to a wallet/token pair
+func (r *alertRepository) GetEnabledRules(ctx context.Context, walletID, tokenID uint) ([]*models.AlertRule, error) {
+	var rules []*models.AlertRule
+	err := r.db.WithContext(ctx).
+		Where("wallet_id = ? AND (token_id = ? OR token_id IS NULL) AND enabled = ?", walletID, tokenID, true).
+		Order("id ASC").
+		Find(&rules).Error
+	return rules, err
+}
+
+// DeleteRule removes an alert rule owned by the user
+func (r *alertRepository) DeleteRule(ctx context.Context, ruleID uint, userID uint) error {
+	result := r.db.WithContext(ctx).Where("id = ? AND user_id = ?", ruleID, userID).Delete(&models.AlertRule{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrRecordNotFound
+	}
+	return nil
+}
+
+// Fire stores the alert unless the rule already fired for the same token
+// after cooldownStart, so a wallet-wide rule has a cooldown per token.
+// Concurrent evaluations of the same rule are serialized by the row lock the
+// first update takes, so at most one alert per token is stored per cooldown.
+func (r *alertRepository) Fire(ctx context.Context, alert *models.Alert, cooldownStart time.Time) (bool, error) {
+	fired := false
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.AlertRule{}).
+			Where("id = ?", alert.RuleID).
+			UpdateColumn("last_fired_at", gorm.Expr("last_fired_at"))
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			// The rule was deleted
+			return nil
+		}
+
+		var recent int64
+		err := tx.Model(&models.Alert{}).
+			Where("rule_id = ? AND token_id = ? AND fired_at > ?", alert.RuleID, alert.TokenID, cooldownStart).
+			Count(&recent).Error
+		if err != nil {
+			return err
+		}
+		if recent > 0 {
+			return nil
+		}
+
+		if err := tx.Create(alert).Error; err != nil {
+			return err
+		}
+		err = tx.Model(&models.AlertRule{}).
+			Where("id = ?", alert.RuleID).
+			UpdateColumn("last_fired_at", alert.FiredAt).Error
+		if err != nil {
+			return err
+		}
+		fired = true
+		return nil
+	})
+	if err != nil {
+		return false, err
+	}
+	return fired, nil
+}
+
+// GetAlertsByUserID retrieves a user's fired alerts, newest first
+func (r *alertRepository) GetAlertsByUserID(ctx context.Context, userID uint, pagination Pagination) (*PaginatedResult[models.Alert], error) {
+	// A new session lets the filtered query be reused for the count and the page
+	query := r.db.WithContext(ctx).Model(&models.Alert{}).Where("user_id = ?", userID).Session(&gorm.Session{})
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, err
+	}
+
+	var alerts []*models.Alert
+	err := query.
+		Order("fired_at DESC").
+		Order("id DESC").
+		Limit(pagination.Limit).
+		Offset(pagination.Offset).
+		Find(&alerts).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return &PaginatedResult[models.Alert]{
+		Data:    alerts,
+		Total:   total,
+		Limit:   pagination.Limit,
+		Offset:  pagination.Offset,
+		HasNext: int64(pagination.Offset+len(alerts)) < total,
+		HasPrev: pagination.Offset > 0,
+	}, nil
+}
@@ -0,0 +1,86 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"cryptoportfolio/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAlertRepository_EnabledRules(t *testing.T) {
+	db := setupTestDB(t)
+	require.NoError(t, db.AutoMigrate(&models.WatchlistWallet{}, &models.TrackedToken{}, &models.AlertRule{}, &models.Alert{}))
+	repo := NewAlertRepository(db)
+	ctx := context.Background()
+
+	tokenID, otherTokenID := uint(1), uint(2)
+	pairRule := &models.AlertRule{UserID: 1, WalletID: 1, TokenID: &tokenID, Type: models.AlertRuleOutgoingTransfer, Enabled: true}
+	walletRule := &models.AlertRule{UserID: 1, WalletID: 1, Type: models.AlertRuleOutgoingTransfer, Enabled: true}
+	otherToken := &models.AlertRule{UserID: 1, WalletID: 1, TokenID: &otherTokenID, Type: models.AlertRuleOutgoingTransfer, Enabled: true}
+	disabled := &models.AlertRule{UserID: 1, WalletID: 1, Type: models.AlertRuleOutgoingTransfer, Enabled: false}
+	otherWallet := &models.AlertRule{UserID: 1, WalletID: 2, Type: models.AlertRuleOutgoingTransfer, Enabled: true}
+	for _, rule := range []*models.AlertRule{pairRule, walletRule, otherToken, disabled, otherWallet} {
+		require.NoError(t, repo.CreateRule(ctx, rule))
+	}
+
+	// Rules on the pair and wallet-wide rules apply
+	rules, err := repo.GetEnabledRules(ctx, 1, tokenID)
+	require.NoError(t, err)
+	require.Len(t, rules, 2)
+	assert.Equal(t, pairRule.ID, rules[0].ID)
+	assert.Equal(t, walletRule.ID, rules[1].ID)
+
+	// Updates keep the rule's firing state
+	firedAt := time.Now()
+	_, err = repo.Fire(ctx, &models.Alert{RuleID: walletRule.ID, UserID: 1, WalletID: 1, TokenID: tokenID, Type: walletRule.Type, Message: "sent", Amount: "1", FiredAt: firedAt}, firedAt.Add(-time.Hour))
+	require.NoError(t, err)
+	walletRule.Enabled = false
+	walletRule.LastFiredAt = nil
+	require.NoError(t, repo.UpdateRule(ctx, walletRule))
+
+	stored, err := repo.GetRuleByID(ctx, walletRule.ID)
+	require.NoError(t, err)
+	assert.False(t, stored.Enabled)
+	require.NotNil(t, stored.LastFiredAt)
+
+	assert.ErrorIs(t, repo.DeleteRule(ctx, pairRule.ID, 2), ErrRecordNotFound)
+	require.NoError(t, repo.DeleteRule(ctx, pairRule.ID, 1))
+}
+
+func TestAlertRepository_FireCooldown(t *testing.T) {
+	db := setupTestDB(t)
+	require.NoError(t, db.AutoMigrate(&models.WatchlistWallet{}, &models.TrackedToken{}, &models.AlertRule{}, &models.Alert{}))
+	repo := NewAlertRepository(db)
+	ctx := context.Background()
+
+	rule := &models.AlertRule{UserID: 1, WalletID: 1, Type: models.AlertRuleOutgoingTransfer, CooldownSeconds: 3600, Enabled: true}
+	require.NoError(t, repo.CreateRule(ctx, rule))
+
+	start := time.Now()
+	fire := func(tokenID uint, at time.Time) bool {
+		alert := &models.Alert{RuleID: rule.ID, UserID: 1, WalletID: 1, TokenID: tokenID, Type: rule.Type, Message: "sent", Amount: "1", FiredAt: at}
+		fired, err := repo.Fire(ctx, alert, at.Add(-time.Hour))
+		require.NoError(t, err)
+		return fired
+	}
+
+	assert.True(t, fire(1, start))
+	assert.False(t, fire(1, start.Add(30*time.Minute)), "alerts within the cooldown are dropped")
+	assert.True(t, fire(2, start.Add(30*time.Minute)), "a wallet-wide rule cools down per token")
+	assert.True(t, fire(1, start.Add(time.Hour)))
+
+	stored, err := repo.GetRuleByID(ctx, rule.ID)
+	require.NoError(t, err)
+	require.NotNil(t, stored.LastFiredAt)
+	assert.WithinDuration(t, start.Add(time.Hour), *stored.LastFiredAt, time.Second)
+
+	page, err := repo.GetAlertsByUserID(ctx, 1, Pagination{Limit: 10})
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), page.Total)
+	require.Len(t, page.Data, 3)
+	assert.True(t, page.Data[0].FiredAt.After(page.Data[1].FiredAt), "newest first")
+}
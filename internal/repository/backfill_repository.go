@@ -0,0 +1,115 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"cryptoportfolio/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// BackfillRepository defines the interface for backfill job operations
+type BackfillRepository interface {
+	Create(ctx context.Context, job *models.BackfillJob) error
+	Update(ctx context.Context, job *models.BackfillJob) error
+	GetByID(ctx context.Context, jobID uint) (*models.BackfillJob, error)
+	GetByUserID(ctx context.Context, userID uint) ([]*models.BackfillJob, error)
+	ClaimNext(ctx context.Context) (*models.BackfillJob, error)
+	RequeueStale(ctx context.Context, before time.Time) (int64, error)
+}
+
+// backfillRepository implements BackfillRepository
+type backfillRepository struct {
+	db *gorm.DB
+}
+
+// NewBackfillRepository creates a new backfill job repository
+func NewBackfillRepository(db *gorm.DB) BackfillRepository {
+	return &backfillRepository{db: db}
+}
+
+// Create creates a new backfill job
+func (r *backfillRepository) Create(ctx context.Context, job *models.BackfillJob) error {
+	return r.db.WithContext(ctx).Create(job).Error
+}
+
+// Update saves a backfill job's progress and status
+func (r *backfillRepository) Update(ctx context.Context, job *models.BackfillJob) error {
+	return r.db.WithContext(ctx).Omit("Wallet", "Token").Save(job).Error
+}
+
+// GetByID retrieves a backfill job by ID
+func (r *backfillRepository) GetByID(ctx context.Context, jobID uint) (*models.BackfillJob, error) {
+	var job models.BackfillJob
+	err := r.db.WithContext(ctx).
+		Preload("Wallet").
+		Preload("Token").
+		Where("id = ?", jobID).
+		First(&job).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrRecordNotFound
+		}
+		return nil, err
+	}
+	return &job, nil
+}
+
+// GetByUserID retrieves all backfill jobs for a user, newest first
+func (r *backfillRepository) GetByUserID(ctx context.Context, userID uint) ([]*models.BackfillJob, error) {
+	var jobs []*models.BackfillJob
+	err := r.db.WithContext(ctx).
+		Preload("Wallet").
+		Preload("Token").
+		Where("user_id = ?", userID).
+		Order("id DESC").
+		Find(&jobs).Error
+	return jobs, err
+}
+
+// ClaimNext marks the oldest pending job as running and returns it. It
+// returns nil when no job is pending. The status check in the update keeps
+// two runners from claiming the same job.
+func (r *backfillRepository) ClaimNext(ctx context.Context) (*models.BackfillJob, error) {
+	for {
+		var job models.BackfillJob
+		err := r.db.WithContext(ctx).
+			Where("status = ?", models.BackfillStatusPending).
+			Order("id ASC").
+			First(&job).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		now := time.Now()
+		result := r.db.WithContext(ctx).Model(&models.BackfillJob{}).
+			Where("id = ? AND status = ?", job.ID, models.BackfillStatusPending).
+			Updates(map[string]interface{}{
+				"status":     models.BackfillStatusRunning,
+				"updated_at": now,
+			})
+		if result.Error != nil {
+			return nil, result.Error
+		}
+		if result.RowsAffected == 1 {
+			job.Status = models.BackfillStatusRunning
+			job.UpdatedAt = now
+			return &job, nil
+		}
+	}
+}
+
+// RequeueStale returns running jobs that have not reported progress since
+// before to the queue, e.g. because the process running them crashed. They
+// resume from their last completed day.
+func (r *backfillRepository) RequeueStale(ctx context.Context, before time.Time) (int64, error) {
+	result := r.db.WithContext(ctx).Model(&models.BackfillJob{}).
+		Where("status = ? AND updated_at < ?", models.BackfillStatusRunning, before).
+		Update("status", models.BackfillStatusPending)
+	return result.RowsAffected, result.Error
+}
@@ -0,0 +1,106 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"cryptoportfolio/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func setupBackfillTestDB(t *testing.T) *gorm.DB {
+	db := setupTestDB(t)
+	require.NoError(t, db.AutoMigrate(&models.WatchlistWallet{}, &models.TrackedToken{}, &models.BackfillJob{}))
+	return db
+}
+
+func newTestBackfillJob(userID uint) *models.BackfillJob {
+	return &models.BackfillJob{
+		UserID:    userID,
+		WalletID:  1,
+		TokenID:   1,
+		ChainID:   1,
+		Status:    models.BackfillStatusPending,
+		StartDate: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		EndDate:   time.Date(2024, 1, 30, 0, 0, 0, 0, time.UTC),
+		TotalDays: 30,
+	}
+}
+
+func TestBackfillRepository_ClaimNext(t *testing.T) {
+	repo := NewBackfillRepository(setupBackfillTestDB(t))
+	ctx := context.Background()
+
+	first := newTestBackfillJob(1)
+	second := newTestBackfillJob(1)
+	require.NoError(t, repo.Create(ctx, first))
+	require.NoError(t, repo.Create(ctx, second))
+
+	// Jobs are claimed oldest first, and only once
+	job, err := repo.ClaimNext(ctx)
+	require.NoError(t, err)
+	require.NotNil(t, job)
+	assert.Equal(t, first.ID, job.ID)
+	assert.Equal(t, models.BackfillStatusRunning, job.Status)
+
+	job, err = repo.ClaimNext(ctx)
+	require.NoError(t, err)
+	require.NotNil(t, job)
+	assert.Equal(t, second.ID, job.ID)
+
+	job, err = repo.ClaimNext(ctx)
+	require.NoError(t, err)
+	assert.Nil(t, job)
+}
+
+func TestBackfillRepository_RequeueStale(t *testing.T) {
+	repo := NewBackfillRepository(setupBackfillTestDB(t))
+	ctx := context.Background()
+
+	require.NoError(t, repo.Create(ctx, newTestBackfillJob(1)))
+	claimed, err := repo.ClaimNext(ctx)
+	require.NoError(t, err)
+	claimed.CompletedDays = 10
+	require.NoError(t, repo.Update(ctx, claimed))
+
+	// A job that reported progress recently is still being run by another instance
+	requeued, err := repo.RequeueStale(ctx, time.Now().Add(-time.Minute))
+	require.NoError(t, err)
+	assert.Zero(t, requeued)
+	job, err := repo.ClaimNext(ctx)
+	require.NoError(t, err)
+	assert.Nil(t, job)
+
+	requeued, err = repo.RequeueStale(ctx, time.Now().Add(time.Minute))
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), requeued)
+
+	job, err = repo.ClaimNext(ctx)
+	require.NoError(t, err)
+	require.NotNil(t, job)
+	assert.Equal(t, claimed.ID, job.ID)
+	assert.Equal(t, 10, job.CompletedDays)
+}
+
+func TestBackfillRepository_GetByID(t *testing.T) {
+	repo := NewBackfillRepository(setupBackfillTestDB(t))
+	ctx := context.Background()
+
+	job := newTestBackfillJob(1)
+	require.NoError(t, repo.Create(ctx, job))
+
+	found, err := repo.GetByID(ctx, job.ID)
+	require.NoError(t, err)
+	assert.Equal(t, job.ID, found.ID)
+
+	_, err = repo.GetByID(ctx, 999)
+	assert.Equal(t, ErrRecordNotFound, err)
+
+	jobs, err := repo.GetByUserID(ctx, 1)
+	require.NoError(t, err)
+	assert.Len(t, jobs, 1)
+}
@@ -0,0 +1,100 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"cryptoportfolio/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// FetchRunRepository defines the interface for fetch run history operations
+type FetchRunRepository interface {
+	Create(ctx context.Context, run *models.FetchRun) error
+	List(ctx context.Context, trigger string, pagination Pagination) (*PaginatedResult[models.FetchRun], error)
+	GetByID(ctx context.Context, runID uint) (*models.FetchRun, error)
+	DeleteOlderThan(ctx context.Context, before time.Time) error
+}
+
+// fetchRunRepository implements FetchRunRepository
+type fetchRunRepository struct {
+	db *gorm.DB
+}
+
+// NewFetchRunRepository creates a new fetch run repository
+func NewFetchRunRepository(db *gorm.DB) FetchRunRepository {
+	return &fetchRunRepository{db: db}
+}
+
+// Create stores a run together with its chains and errors
+func (r *fetchRunRepository) Create(ctx context.Context, run *models.FetchRun) error {
+	return r.db.WithContext(ctx).Create(run).Error
+}
+
+// List retrieves runs without their chains and errors, newest first. An
+// empty trigger lists runs of every trigger.
+func (r *fetchRunRepository) List(ctx context.Context, trigger string, pagination Pagination) (*PaginatedResult[models.FetchRun], error) {
+	query := r.db.WithContext(ctx).Model(&models.FetchRun{})
+	if trigger != "" {
+		query = query.Where("trigger = ?", trigger)
+	}
+	// A new session lets the filtered query be reused for the count and the page
+	query = query.Session(&gorm.Session{})
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, err
+	}
+
+	var runs []*models.FetchRun
+	err := query.
+		Order("id DESC").
+		Limit(pagination.Limit).
+		Offset(pagination.Offset).
+		Find(&runs).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return &PaginatedResult[models.FetchRun]{
+		Data:    runs,
+		Total:   total,
+		Limit:   pagination.Limit,
+		Offset:  pagination.Offset,
+		HasNext: int64(pagination.Offset+len(runs)) < total,
+		HasPrev: pagination.Offset > 0,
+	}, nil
+}
+
+// GetByID retrieves a run with its chains and errors
+func (r *fetchRunRepository) GetByID(ctx context.Context, runID uint) (*models.FetchRun, error) {
+	var run models.FetchRun
+	err := r.db.WithContext(ctx).
+		Preload("Chains", func(db *gorm.DB) *gorm.DB { return db.Order("chain_id ASC") }).
+		Preload("Errors", func(db *gorm.DB) *gorm.DB { return db.Order("id ASC") }).
+		Where("id = ?", runID).
+		First(&run).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrRecordNotFound
+		}
+		return nil, err
+	}
+	return &run, nil
+}
+
+// DeleteOlderThan deletes runs started before the given time with their chains and errors
+func (r *fetchRunRepository) DeleteOlderThan(ctx context.Context, before time.Time) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		old := tx.Model(&models.FetchRun{}).Select("id").Where("started_at < ?", before)
+		if err := tx.Where("run_id IN (?)", old).Delete(&models.FetchRunError{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("run_id IN (?)", old).Delete(&models.FetchRunChain{}).Error; err != nil {
+			return err
+		}
+		return tx.Where("started_at < ?", before).Delete(&models.FetchRun{}).Error
+	})
+}
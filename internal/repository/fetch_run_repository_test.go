@@ -0,0 +1,88 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"cryptoportfolio/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setupFetchRunTest(t *testing.T) FetchRunRepository {
+	db := setupTestDB(t)
+	require.NoError(t, db.AutoMigrate(&models.FetchRun{}, &models.FetchRunChain{}, &models.FetchRunError{}))
+	return NewFetchRunRepository(db)
+}
+
+func newFetchRun(trigger string, startedAt time.Time) *models.FetchRun {
+	blockNumber := uint64(100)
+	return &models.FetchRun{
+		Trigger:     trigger,
+		Status:      models.FetchRunStatusCompleted,
+		TotalPairs:  2,
+		StoredPairs: 1,
+		FailedPairs: 1,
+		StartedAt:   startedAt,
+		CompletedAt: startedAt.Add(time.Second),
+		Chains:      []models.FetchRunChain{{ChainID: 1, BlockNumber: &blockNumber, TotalPairs: 2, FailedPairs: 1}},
+		Errors:      []models.FetchRunError{{UserID: 1, WalletID: 1, TokenID: 2, ChainID: 1, Error: "execution reverted"}},
+	}
+}
+
+func TestFetchRunRepository_ListAndDrillDown(t *testing.T) {
+	repo := setupFetchRunTest(t)
+	ctx := context.Background()
+	now := time.Now()
+
+	require.NoError(t, repo.Create(ctx, newFetchRun(models.FetchRunTriggerSchedule, now.Add(-time.Minute))))
+	require.NoError(t, repo.Create(ctx, newFetchRun(models.FetchRunTriggerRefresh, now.Add(-30*time.Second))))
+	latest := newFetchRun(models.FetchRunTriggerSchedule, now)
+	require.NoError(t, repo.Create(ctx, latest))
+
+	page, err := repo.List(ctx, "", Pagination{Limit: 2})
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), page.Total)
+	assert.True(t, page.HasNext)
+	require.Len(t, page.Data, 2)
+	assert.Equal(t, latest.ID, page.Data[0].ID, "newest first")
+	assert.Empty(t, page.Data[0].Errors, "errors are only loaded for a single run")
+
+	page, err = repo.List(ctx, models.FetchRunTriggerSchedule, Pagination{Limit: 10})
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), page.Total)
+	assert.False(t, page.HasNext)
+	assert.Len(t, page.Data, 2)
+
+	run, err := repo.GetByID(ctx, latest.ID)
+	require.NoError(t, err)
+	require.Len(t, run.Chains, 1)
+	assert.Equal(t, uint64(100), *run.Chains[0].BlockNumber)
+	require.Len(t, run.Errors, 1)
+	assert.Equal(t, "execution reverted", run.Errors[0].Error)
+
+	_, err = repo.GetByID(ctx, 999)
+	assert.Equal(t, ErrRecordNotFound, err)
+}
+
+func TestFetchRunRepository_DeleteOlderThan(t *testing.T) {
+	repo := setupFetchRunTest(t)
+	ctx := context.Background()
+	now := time.Now()
+
+	old := newFetchRun(models.FetchRunTriggerSchedule, now.Add(-48*time.Hour))
+	require.NoError(t, repo.Create(ctx, old))
+	require.NoError(t, repo.Create(ctx, newFetchRun(models.FetchRunTriggerSchedule, now)))
+
+	require.NoError(t, repo.DeleteOlderThan(ctx, now.Add(-24*time.Hour)))
+
+	page, err := repo.List(ctx, "", Pagination{Limit: 10})
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), page.Total)
+	assert.NotEqual(t, old.ID, page.Data[0].ID)
+
+	_, err = repo.GetByID(ctx, old.ID)
+	assert.Equal(t, ErrRecordNotFound, err)
+}
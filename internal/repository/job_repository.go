@@ -0,0 +1,137 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"cryptoportfolio/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// JobRepository defines the interface for job queue operations
+type JobRepository interface {
+	Enqueue(ctx context.Context, job *models.Job) (*models.Job, bool, error)
+	Update(ctx context.Context, job *models.Job) error
+	GetByID(ctx context.Context, jobID uint) (*models.Job, error)
+	CountCreatedSince(ctx context.Context, userID uint, kind string, since time.Time) (int64, error)
+	ClaimNext(ctx context.Context) (*models.Job, error)
+	FailStale(ctx context.Context, before time.Time, reason string) (int64, error)
+}
+
+// jobRepository implements JobRepository
+type jobRepository struct {
+	db *gorm.DB
+}
+
+// NewJobRepository creates a new job repository
+func NewJobRepository(db *gorm.DB) JobRepository {
+	return &jobRepository{db: db}
+}
+
+// Enqueue adds a pending job unless a pending job with the same CoalesceKey is
+// already queued. It returns the queued job and whether it was newly created.
+func (r *jobRepository) Enqueue(ctx context.Context, job *models.Job) (*models.Job, bool, error) {
+	for {
+		result := r.db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(job)
+		if result.Error != nil {
+			return nil, false, result.Error
+		}
+		if result.RowsAffected == 1 {
+			return job, true, nil
+		}
+
+		var pending models.Job
+		err := r.db.WithContext(ctx).Where("coalesce_key = ?", job.CoalesceKey).First(&pending).Error
+		if err == nil {
+			return &pending, false, nil
+		}
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, false, err
+		}
+		// The pending job was claimed in between; queue a new one
+		job.ID = 0
+	}
+}
+
+// Update saves a job's progress and status
+func (r *jobRepository) Update(ctx context.Context, job *models.Job) error {
+	return r.db.WithContext(ctx).Save(job).Error
+}
+
+// GetByID retrieves a job by ID
+func (r *jobRepository) GetByID(ctx context.Context, jobID uint) (*models.Job, error) {
+	var job models.Job
+	err := r.db.WithContext(ctx).Where("id = ?", jobID).First(&job).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrRecordNotFound
+		}
+		return nil, err
+	}
+	return &job, nil
+}
+
+// CountCreatedSince counts the jobs of a kind a user queued since the given time
+func (r *jobRepository) CountCreatedSince(ctx context.Context, userID uint, kind string, since time.Time) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&models.Job{}).
+		Where("user_id = ? AND kind = ? AND created_at >= ?", userID, kind, since).
+		Count(&count).Error
+	return count, err
+}
+
+// ClaimNext marks the oldest pending job as running and returns it. It
+// returns nil when no job is pending. The status check in the update keeps
+// two runners from claiming the same job, and clearing the coalesce key lets
+// a new identical job queue behind the running one.
+func (r *jobRepository) ClaimNext(ctx context.Context) (*models.Job, error) {
+	for {
+		var job models.Job
+		err := r.db.WithContext(ctx).
+			Where("status = ?", models.JobStatusPending).
+			Order("id ASC").
+			First(&job).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		now := time.Now()
+		result := r.db.WithContext(ctx).Model(&models.Job{}).
+			Where("id = ? AND status = ?", job.ID, models.JobStatusPending).
+			Updates(map[string]interface{}{
+				"status":       models.JobStatusRunning,
+				"coalesce_key": nil,
+				"started_at":   now,
+				"updated_at":   now,
+			})
+		if result.Error != nil {
+			return nil, result.Error
+		}
+		if result.RowsAffected == 1 {
+			job.Status = models.JobStatusRunning
+			job.CoalesceKey = nil
+			job.StartedAt = &now
+			job.UpdatedAt = now
+			return &job, nil
+		}
+	}
+}
+
+// FailStale fails running jobs that have not reported progress since before,
+// e.g. because the process running them crashed
+func (r *jobRepository) FailStale(ctx context.Context, before time.Time, reason string) (int64, error) {
+	result := r.db.WithContext(ctx).Model(&models.Job{}).
+		Where("status = ? AND updated_at < ?", models.JobStatusRunning, before).
+		Updates(map[string]interface{}{
+			"status":       models.JobStatusFailed,
+			"last_error":   reason,
+			"completed_at": time.Now(),
+		})
+	return result.RowsAffected, result.Error
+}
@@ -0,0 +1,115 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"cryptoportfolio/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setupJobTest(t *testing.T) JobRepository {
+	db := setupTestDB(t)
+	require.NoError(t, db.AutoMigrate(&models.Job{}))
+	return NewJobRepository(db)
+}
+
+func newRefreshJob(userID uint) *models.Job {
+	key := fmt.Sprintf("balance_refresh:%d", userID)
+	return &models.Job{UserID: userID, Kind: models.JobKindBalanceRefresh, Status: models.JobStatusPending, CoalesceKey: &key}
+}
+
+func TestJobRepository_EnqueueCoalescesPendingJobs(t *testing.T) {
+	repo := setupJobTest(t)
+	ctx := context.Background()
+
+	first, created, err := repo.Enqueue(ctx, newRefreshJob(1))
+	require.NoError(t, err)
+	assert.True(t, created)
+
+	// A second request while the first is pending gets the same job
+	again, created, err := repo.Enqueue(ctx, newRefreshJob(1))
+	require.NoError(t, err)
+	assert.False(t, created)
+	assert.Equal(t, first.ID, again.ID)
+
+	// Other users queue their own jobs
+	other, created, err := repo.Enqueue(ctx, newRefreshJob(2))
+	require.NoError(t, err)
+	assert.True(t, created)
+	assert.NotEqual(t, first.ID, other.ID)
+
+	// Once claimed, a new request queues behind the running job
+	claimed, err := repo.ClaimNext(ctx)
+	require.NoError(t, err)
+	require.NotNil(t, claimed)
+	assert.Equal(t, first.ID, claimed.ID)
+	assert.Equal(t, models.JobStatusRunning, claimed.Status)
+	assert.NotNil(t, claimed.StartedAt)
+
+	next, created, err := repo.Enqueue(ctx, newRefreshJob(1))
+	require.NoError(t, err)
+	assert.True(t, created)
+	assert.NotEqual(t, first.ID, next.ID)
+
+	count, err := repo.CountCreatedSince(ctx, 1, models.JobKindBalanceRefresh, time.Now().Add(-time.Minute))
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), count)
+}
+
+func TestJobRepository_ClaimNext(t *testing.T) {
+	repo := setupJobTest(t)
+	ctx := context.Background()
+
+	job, err := repo.ClaimNext(ctx)
+	require.NoError(t, err)
+	assert.Nil(t, job)
+
+	first, _, err := repo.Enqueue(ctx, newRefreshJob(1))
+	require.NoError(t, err)
+	second, _, err := repo.Enqueue(ctx, newRefreshJob(2))
+	require.NoError(t, err)
+
+	// Jobs are claimed oldest first, each only once
+	job, err = repo.ClaimNext(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, first.ID, job.ID)
+	job, err = repo.ClaimNext(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, second.ID, job.ID)
+	job, err = repo.ClaimNext(ctx)
+	require.NoError(t, err)
+	assert.Nil(t, job)
+}
+
+func TestJobRepository_FailStale(t *testing.T) {
+	repo := setupJobTest(t)
+	ctx := context.Background()
+
+	_, _, err := repo.Enqueue(ctx, newRefreshJob(1))
+	require.NoError(t, err)
+	running, err := repo.ClaimNext(ctx)
+	require.NoError(t, err)
+
+	// A job that reported progress recently is left alone
+	failed, err := repo.FailStale(ctx, time.Now().Add(-time.Minute), "abandoned")
+	require.NoError(t, err)
+	assert.Zero(t, failed)
+
+	failed, err = repo.FailStale(ctx, time.Now().Add(time.Minute), "abandoned")
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), failed)
+
+	job, err := repo.GetByID(ctx, running.ID)
+	require.NoError(t, err)
+	assert.Equal(t, models.JobStatusFailed, job.Status)
+	assert.Equal(t, "abandoned", job.LastError)
+	assert.NotNil(t, job.CompletedAt)
+
+	_, err = repo.GetByID(ctx, running.ID+100)
+	assert.ErrorIs(t, err, ErrRecordNotFound)
+}
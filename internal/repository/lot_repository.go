@@ -0,0 +1,75 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"cryptoportfolio/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// LotRepository defines the interface for manual cost basis lot operations
+type LotRepository interface {
+	Create(ctx context.Context, lot *models.ManualLot) error
+	GetByID(ctx context.Context, lotID uint) (*models.ManualLot, error)
+	GetByUserID(ctx context.Context, userID uint) ([]*models.ManualLot, error)
+	Delete(ctx context.Context, lotID uint, userID uint) error
+}
+
+// lotRepository implements LotRepository
+type lotRepository struct {
+	db *gorm.DB
+}
+
+// NewLotRepository creates a new manual lot repository
+func NewLotRepository(db *gorm.DB) LotRepository {
+	return &lotRepository{db: db}
+}
+
+// Create creates a new manual lot
+func (r *lotRepository) Create(ctx context.Context, lot *models.ManualLot) error {
+	return r.db.WithContext(ctx).Omit("Wallet", "Token").Create(lot).Error
+}
+
+// GetByID retrieves a manual lot by ID
+func (r *lotRepository) GetByID(ctx context.Context, lotID uint) (*models.ManualLot, error) {
+	var lot models.ManualLot
+	err := r.db.WithContext(ctx).
+		Preload("Wallet").
+		Preload("Token").
+		Where("id = ?", lotID).
+		First(&lot).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrRecordNotFound
+		}
+		return nil, err
+	}
+	return &lot, nil
+}
+
+// GetByUserID retrieves all manual lots of a user, oldest acquisition first
+func (r *lotRepository) GetByUserID(ctx context.Context, userID uint) ([]*models.ManualLot, error) {
+	var lots []*models.ManualLot
+	err := r.db.WithContext(ctx).
+		Preload("Wallet").
+		Preload("Token").
+		Where("user_id = ?", userID).
+		Order("acquired_at ASC").
+		Order("id ASC").
+		Find(&lots).Error
+	return lots, err
+}
+
+// Delete removes a manual lot owned by the user
+func (r *lotRepository) Delete(ctx context.Context, lotID uint, userID uint) error {
+	result := r.db.WithContext(ctx).Where("id = ? AND user_id = ?", lotID, userID).Delete(&models.ManualLot{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrRecordNotFound
+	}
+	return nil
+}
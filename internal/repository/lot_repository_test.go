@@ -0,0 +1,48 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"cryptoportfolio/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLotRepository(t *testing.T) {
+	db := setupTestDB(t)
+	require.NoError(t, db.AutoMigrate(&models.WatchlistWallet{}, &models.TrackedToken{}, &models.ManualLot{}))
+	watchlistRepo := NewWatchlistRepository(db)
+	repo := NewLotRepository(db)
+	ctx := context.Background()
+
+	wallet := &models.WatchlistWallet{UserID: 1, WalletAddress: "0x742d35Cc6634C0532925a3b844Bc454e4438f44e", ChainID: 1}
+	require.NoError(t, watchlistRepo.CreateWallet(ctx, wallet))
+	token := &models.TrackedToken{UserID: 1, TokenSymbol: "ETH", TokenName: "Ether", ChainID: 1}
+	require.NoError(t, watchlistRepo.CreateToken(ctx, token))
+
+	acquired := time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC)
+	newer := &models.ManualLot{UserID: 1, WalletID: wallet.ID, TokenID: token.ID, Amount: "2", PriceUSD: "2500", AcquiredAt: acquired}
+	older := &models.ManualLot{UserID: 1, WalletID: wallet.ID, TokenID: token.ID, Amount: "1", PriceUSD: "2000", AcquiredAt: acquired.Add(-24 * time.Hour)}
+	require.NoError(t, repo.Create(ctx, newer))
+	require.NoError(t, repo.Create(ctx, older))
+
+	lots, err := repo.GetByUserID(ctx, 1)
+	require.NoError(t, err)
+	require.Len(t, lots, 2)
+	assert.Equal(t, older.ID, lots[0].ID)
+	assert.Equal(t, "ETH", lots[0].Token.TokenSymbol)
+	assert.Equal(t, wallet.WalletAddress, lots[0].Wallet.WalletAddress)
+
+	lot, err := repo.GetByID(ctx, newer.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "2500", lot.PriceUSD)
+
+	// Lots can only be deleted by their owner
+	assert.ErrorIs(t, repo.Delete(ctx, newer.ID, 2), ErrRecordNotFound)
+	require.NoError(t, repo.Delete(ctx, newer.ID, 1))
+	_, err = repo.GetByID(ctx, newer.ID)
+	assert.ErrorIs(t, err, ErrRecordNotFound)
+}
@@ -0,0 +1,113 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"cryptoportfolio/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// RefreshTokenRepository defines the interface for refresh token operations
+type RefreshTokenRepository interface {
+	Create(ctx context.Context, token *models.RefreshToken) error
+	GetByHash(ctx context.Context, tokenHash string) (*models.RefreshToken, error)
+	GetByAccessTokenID(ctx context.Context, accessTokenID string) (*models.RefreshToken, error)
+	MarkUsed(ctx context.Context, tokenID uint, at time.Time) (bool, error)
+	RevokeFamily(ctx context.Context, familyID string, at time.Time) ([]*models.RefreshToken, error)
+	RevokeByUserID(ctx context.Context, userID uint, at time.Time) ([]*models.RefreshToken, error)
+	DeleteExpired(ctx context.Context, userID uint, before time.Time) error
+}
+
+// refreshTokenRepository implements RefreshTokenRepository
+type refreshTokenRepository struct {
+	db *gorm.DB
+}
+
+// NewRefreshTokenRepository creates a new refresh token repository
+func NewRefreshTokenRepository(db *gorm.DB) RefreshTokenRepository {
+	return &refreshTokenRepository{db: db}
+}
+
+// Create stores a new refresh token
+func (r *refreshTokenRepository) Create(ctx context.Context, token *models.RefreshToken) error {
+	return r.db.WithContext(ctx).Create(token).Error
+}
+
+// GetByHash retrieves a refresh token by the hash of its value
+func (r *refreshTokenRepository) GetByHash(ctx context.Context, tokenHash string) (*models.RefreshToken, error) {
+	return r.first(ctx, "token_hash = ?", tokenHash)
+}
+
+// GetByAccessTokenID retrieves the refresh token issued together with an access token
+func (r *refreshTokenRepository) GetByAccessTokenID(ctx context.Context, accessTokenID string) (*models.RefreshToken, error) {
+	return r.first(ctx, "access_token_id = ?", accessTokenID)
+}
+
+func (r *refreshTokenRepository) first(ctx context.Context, query string, args ...interface{}) (*models.RefreshToken, error) {
+	var token models.RefreshToken
+	err := r.db.WithContext(ctx).Where(query, args...).First(&token).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrRecordNotFound
+		}
+		return nil, err
+	}
+	return &token, nil
+}
+
+// MarkUsed marks a token used unless it already was, and reports whether this
+// call did. Of two concurrent refreshes with the same token only one succeeds.
+func (r *refreshTokenRepository) MarkUsed(ctx context.Context, tokenID uint, at time.Time) (bool, error) {
+	result := r.db.WithContext(ctx).
+		Model(&models.RefreshToken{}).
+		Where("id = ? AND used_at IS NULL AND revoked_at IS NULL", tokenID).
+		Update("used_at", at)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+// RevokeFamily revokes every token of a session and returns the tokens it revoked
+func (r *refreshTokenRepository) RevokeFamily(ctx context.Context, familyID string, at time.Time) ([]*models.RefreshToken, error) {
+	return r.revoke(ctx, at, "family_id = ?", familyID)
+}
+
+// RevokeByUserID revokes every token of every session of a user and returns the tokens it revoked
+func (r *refreshTokenRepository) RevokeByUserID(ctx context.Context, userID uint, at time.Time) ([]*models.RefreshToken, error) {
+	return r.revoke(ctx, at, "user_id = ?", userID)
+}
+
+// revoke revokes the tokens matching the query that are not revoked yet
+func (r *refreshTokenRepository) revoke(ctx context.Context, at time.Time, query string, args ...interface{}) ([]*models.RefreshToken, error) {
+	var revoked []*models.RefreshToken
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where(query, args...).Where("revoked_at IS NULL").Find(&revoked).Error; err != nil {
+			return err
+		}
+		if len(revoked) == 0 {
+			return nil
+		}
+
+		ids := make([]uint, len(revoked))
+		for i, token := range revoked {
+			ids[i] = token.ID
+			token.RevokedAt = &at
+		}
+		return tx.Model(&models.RefreshToken{}).Where("id IN ?", ids).Update("revoked_at", at).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return revoked, nil
+}
+
+// DeleteExpired deletes a user's tokens that expired before the given time
+func (r *refreshTokenRepository) DeleteExpired(ctx context.Context, userID uint, before time.Time) error {
+	return r.db.WithContext(ctx).
+		Where("user_id = ? AND expires_at < ?", userID, before).
+		Delete(&models.RefreshToken{}).Error
+}
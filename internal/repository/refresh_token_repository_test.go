@@ -0,0 +1,107 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"cryptoportfolio/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setupRefreshTokenTest(t *testing.T) RefreshTokenRepository {
+	db := setupTestDB(t)
+	require.NoError(t, db.AutoMigrate(&models.RefreshToken{}))
+	return NewRefreshTokenRepository(db)
+}
+
+func newRefreshToken(userID uint, familyID, hash string, expiresAt time.Time) *models.RefreshToken {
+	return &models.RefreshToken{
+		UserID:          userID,
+		FamilyID:        familyID,
+		TokenHash:       hash,
+		AccessTokenID:   "jti-" + hash,
+		AccessExpiresAt: time.Now().Add(15 * time.Minute),
+		ExpiresAt:       expiresAt,
+	}
+}
+
+func TestRefreshTokenRepository_MarkUsedOnce(t *testing.T) {
+	repo := setupRefreshTokenTest(t)
+	ctx := context.Background()
+
+	token := newRefreshToken(1, "family", "hash", time.Now().Add(time.Hour))
+	require.NoError(t, repo.Create(ctx, token))
+
+	found, err := repo.GetByHash(ctx, "hash")
+	require.NoError(t, err)
+	assert.Equal(t, token.ID, found.ID)
+
+	found, err = repo.GetByAccessTokenID(ctx, "jti-hash")
+	require.NoError(t, err)
+	assert.Equal(t, token.ID, found.ID)
+
+	used, err := repo.MarkUsed(ctx, token.ID, time.Now())
+	require.NoError(t, err)
+	assert.True(t, used)
+
+	used, err = repo.MarkUsed(ctx, token.ID, time.Now())
+	require.NoError(t, err)
+	assert.False(t, used, "a token can only be used once")
+
+	_, err = repo.GetByHash(ctx, "missing")
+	assert.ErrorIs(t, err, ErrRecordNotFound)
+}
+
+func TestRefreshTokenRepository_Revoke(t *testing.T) {
+	repo := setupRefreshTokenTest(t)
+	ctx := context.Background()
+	expiresAt := time.Now().Add(time.Hour)
+
+	require.NoError(t, repo.Create(ctx, newRefreshToken(1, "a", "a1", expiresAt)))
+	require.NoError(t, repo.Create(ctx, newRefreshToken(1, "a", "a2", expiresAt)))
+	require.NoError(t, repo.Create(ctx, newRefreshToken(1, "b", "b1", expiresAt)))
+	require.NoError(t, repo.Create(ctx, newRefreshToken(2, "c", "c1", expiresAt)))
+
+	revoked, err := repo.RevokeFamily(ctx, "a", time.Now())
+	require.NoError(t, err)
+	assert.Len(t, revoked, 2)
+
+	a1, err := repo.GetByHash(ctx, "a1")
+	require.NoError(t, err)
+	assert.NotNil(t, a1.RevokedAt)
+
+	used, err := repo.MarkUsed(ctx, a1.ID, time.Now())
+	require.NoError(t, err)
+	assert.False(t, used, "revoked tokens cannot be used")
+
+	revoked, err = repo.RevokeByUserID(ctx, 1, time.Now())
+	require.NoError(t, err)
+	require.Len(t, revoked, 1, "tokens revoked before are not returned again")
+	assert.Equal(t, "b1", revoked[0].TokenHash)
+
+	c1, err := repo.GetByHash(ctx, "c1")
+	require.NoError(t, err)
+	assert.Nil(t, c1.RevokedAt, "other users' sessions are kept")
+}
+
+func TestRefreshTokenRepository_DeleteExpired(t *testing.T) {
+	repo := setupRefreshTokenTest(t)
+	ctx := context.Background()
+	now := time.Now()
+
+	require.NoError(t, repo.Create(ctx, newRefreshToken(1, "a", "expired", now.Add(-time.Hour))))
+	require.NoError(t, repo.Create(ctx, newRefreshToken(1, "b", "live", now.Add(time.Hour))))
+	require.NoError(t, repo.Create(ctx, newRefreshToken(2, "c", "other", now.Add(-time.Hour))))
+
+	require.NoError(t, repo.DeleteExpired(ctx, 1, now))
+
+	_, err := repo.GetByHash(ctx, "expired")
+	assert.ErrorIs(t, err, ErrRecordNotFound)
+	_, err = repo.GetByHash(ctx, "live")
+	assert.NoError(t, err)
+	_, err = repo.GetByHash(ctx, "other")
+	assert.NoError(t, err, "only the given user's tokens are deleted")
+}
@@ -0,0 +1,86 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"cryptoportfolio/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ScheduleRepository defines the interface for per-pair fetch schedule operations
+type ScheduleRepository interface {
+	GetAll(ctx context.Context) ([]*models.FetchSchedule, error)
+	GetByUserID(ctx context.Context, userID uint) ([]*models.FetchSchedule, error)
+	GetByWalletIDs(ctx context.Context, walletIDs []uint) ([]*models.FetchSchedule, error)
+	Upsert(ctx context.Context, schedules []*models.FetchSchedule) error
+	Shorten(ctx context.Context, userID uint, intervalSeconds int, nextFetchAt time.Time) error
+}
+
+// scheduleRepository implements ScheduleRepository
+type scheduleRepository struct {
+	db *gorm.DB
+}
+
+// NewScheduleRepository creates a new fetch schedule repository
+func NewScheduleRepository(db *gorm.DB) ScheduleRepository {
+	return &scheduleRepository{db: db}
+}
+
+// GetAll retrieves the schedules of every pair
+func (r *scheduleRepository) GetAll(ctx context.Context) ([]*models.FetchSchedule, error) {
+	var schedules []*models.FetchSchedule
+	err := r.db.WithContext(ctx).Find(&schedules).Error
+	return schedules, err
+}
+
+// GetByUserID retrieves the schedules of a user's pairs, soonest first
+func (r *scheduleRepository) GetByUserID(ctx context.Context, userID uint) ([]*models.FetchSchedule, error) {
+	var schedules []*models.FetchSchedule
+	err := r.db.WithContext(ctx).
+		Where("user_id = ?", userID).
+		Order("next_fetch_at ASC").
+		Find(&schedules).Error
+	return schedules, err
+}
+
+// GetByWalletIDs retrieves the schedules of all pairs of the given wallets
+func (r *scheduleRepository) GetByWalletIDs(ctx context.Context, walletIDs []uint) ([]*models.FetchSchedule, error) {
+	var schedules []*models.FetchSchedule
+	if len(walletIDs) == 0 {
+		return schedules, nil
+	}
+	err := r.db.WithContext(ctx).Where("wallet_id IN ?", walletIDs).Find(&schedules).Error
+	return schedules, err
+}
+
+// Upsert creates or replaces the schedules of the given pairs
+func (r *scheduleRepository) Upsert(ctx context.Context, schedules []*models.FetchSchedule) error {
+	if len(schedules) == 0 {
+		return nil
+	}
+	return r.db.WithContext(ctx).
+		Clauses(clause.OnConflict{
+			Columns: []clause.Column{{Name: "wallet_id"}, {Name: "token_id"}},
+			DoUpdates: clause.AssignmentColumns([]string{
+				"interval_seconds", "next_fetch_at", "last_balance",
+				"last_fetched_at", "last_changed_at", "last_error_at",
+				"last_error", "consecutive_failures", "updated_at",
+			}),
+		}).
+		Create(&schedules).Error
+}
+
+// Shorten caps the interval and next fetch time of all of a user's pairs. It
+// updates the columns in place, so it cannot undo a fetch recorded meanwhile.
+func (r *scheduleRepository) Shorten(ctx context.Context, userID uint, intervalSeconds int, nextFetchAt time.Time) error {
+	return r.db.WithContext(ctx).Model(&models.FetchSchedule{}).
+		Where("user_id = ? AND (interval_seconds > ? OR next_fetch_at > ?)", userID, intervalSeconds, nextFetchAt).
+		Updates(map[string]interface{}{
+			"interval_seconds": gorm.Expr("CASE WHEN interval_seconds > ? THEN ? ELSE interval_seconds END", intervalSeconds, intervalSeconds),
+			"next_fetch_at":    gorm.Expr("CASE WHEN next_fetch_at > ? THEN ? ELSE next_fetch_at END", nextFetchAt, nextFetchAt),
+			"updated_at":       time.Now(),
+		}).Error
+}
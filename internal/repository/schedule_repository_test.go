@@ -0,0 +1,85 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"cryptoportfolio/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setupScheduleTest(t *testing.T) ScheduleRepository {
+	db := setupTestDB(t)
+	require.NoError(t, db.AutoMigrate(&models.FetchSchedule{}))
+	return NewScheduleRepository(db)
+}
+
+func TestScheduleRepository_UpsertReplacesPairSchedules(t *testing.T) {
+	repo := setupScheduleTest(t)
+	ctx := context.Background()
+	now := time.Now().Truncate(time.Second)
+
+	require.NoError(t, repo.Upsert(ctx, []*models.FetchSchedule{
+		{UserID: 1, WalletID: 1, TokenID: 1, IntervalSeconds: 300, NextFetchAt: now.Add(5 * time.Minute), LastBalance: "100"},
+		{UserID: 1, WalletID: 1, TokenID: 2, IntervalSeconds: 300, NextFetchAt: now.Add(time.Minute)},
+		{UserID: 2, WalletID: 2, TokenID: 3, IntervalSeconds: 60, NextFetchAt: now},
+	}))
+
+	// The same pair is updated in place rather than duplicated
+	require.NoError(t, repo.Upsert(ctx, []*models.FetchSchedule{
+		{UserID: 1, WalletID: 1, TokenID: 1, IntervalSeconds: 600, NextFetchAt: now.Add(10 * time.Minute), LastBalance: "100", LastFetchedAt: &now},
+	}))
+
+	all, err := repo.GetAll(ctx)
+	require.NoError(t, err)
+	assert.Len(t, all, 3)
+
+	schedules, err := repo.GetByUserID(ctx, 1)
+	require.NoError(t, err)
+	require.Len(t, schedules, 2)
+	assert.Equal(t, uint(2), schedules[0].TokenID, "soonest fetch first")
+	assert.Equal(t, 600, schedules[1].IntervalSeconds)
+	assert.Equal(t, "100", schedules[1].LastBalance)
+	assert.NotNil(t, schedules[1].LastFetchedAt)
+
+	byWallet, err := repo.GetByWalletIDs(ctx, []uint{2})
+	require.NoError(t, err)
+	require.Len(t, byWallet, 1)
+	assert.Equal(t, uint(3), byWallet[0].TokenID)
+
+	none, err := repo.GetByWalletIDs(ctx, nil)
+	require.NoError(t, err)
+	assert.Empty(t, none)
+}
+
+func TestScheduleRepository_ShortenCapsIntervals(t *testing.T) {
+	repo := setupScheduleTest(t)
+	ctx := context.Background()
+	now := time.Now().Truncate(time.Second)
+
+	require.NoError(t, repo.Upsert(ctx, []*models.FetchSchedule{
+		{UserID: 1, WalletID: 1, TokenID: 1, IntervalSeconds: 3600, NextFetchAt: now.Add(time.Hour)},
+		{UserID: 1, WalletID: 1, TokenID: 2, IntervalSeconds: 30, NextFetchAt: now.Add(10 * time.Second)},
+		{UserID: 2, WalletID: 2, TokenID: 3, IntervalSeconds: 3600, NextFetchAt: now.Add(time.Hour)},
+	}))
+
+	require.NoError(t, repo.Shorten(ctx, 1, 60, now.Add(time.Minute)))
+
+	schedules, err := repo.GetByUserID(ctx, 1)
+	require.NoError(t, err)
+	require.Len(t, schedules, 2)
+
+	// Shorter schedules are left alone
+	assert.Equal(t, 30, schedules[0].IntervalSeconds)
+	assert.WithinDuration(t, now.Add(10*time.Second), schedules[0].NextFetchAt, time.Second)
+	assert.Equal(t, 60, schedules[1].IntervalSeconds)
+	assert.WithinDuration(t, now.Add(time.Minute), schedules[1].NextFetchAt, time.Second)
+
+	// Other users' pairs are untouched
+	others, err := repo.GetByUserID(ctx, 2)
+	require.NoError(t, err)
+	assert.Equal(t, 3600, others[0].IntervalSeconds)
+}
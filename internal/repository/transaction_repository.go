@@ -0,0 +1,128 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"cryptoportfolio/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// transactionInsertBatchSize keeps multi-row inserts under database parameter limits
+const transactionInsertBatchSize = 200
+
+// TransactionRepository defines the interface for ingested transfer operations
+type TransactionRepository interface {
+	StoreTransfers(ctx context.Context, transactions []*models.Transaction, cursors []*models.TransactionCursor) error
+	GetByWalletID(ctx context.Context, walletID uint, pagination Pagination) (*PaginatedResult[models.Transaction], error)
+	GetByID(ctx context.Context, transactionID uint) (*models.Transaction, error)
+	GetByWalletIDs(ctx context.Context, walletIDs []uint) ([]*models.Transaction, error)
+	GetCursors(ctx context.Context) ([]*models.TransactionCursor, error)
+}
+
+// transactionRepository implements TransactionRepository
+type transactionRepository struct {
+	db *gorm.DB
+}
+
+// NewTransactionRepository creates a new transaction repository
+func NewTransactionRepository(db *gorm.DB) TransactionRepository {
+	return &transactionRepository{db: db}
+}
+
+// StoreTransfers saves ingested transfers and advances the wallets' cursors
+// in one database transaction. Transfers that were already stored are
+// skipped, so a block range can safely be scanned again.
+func (r *transactionRepository) StoreTransfers(ctx context.Context, transactions []*models.Transaction, cursors []*models.TransactionCursor) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if len(transactions) > 0 {
+			err := tx.Omit("Wallet").
+				Clauses(clause.OnConflict{DoNothing: true}).
+				CreateInBatches(transactions, transactionInsertBatchSize).Error
+			if err != nil {
+				return err
+			}
+		}
+
+		if len(cursors) > 0 {
+			err := tx.Clauses(clause.OnConflict{
+				Columns:   []clause.Column{{Name: "wallet_id"}},
+				DoUpdates: clause.AssignmentColumns([]string{"last_block", "updated_at"}),
+			}).Create(cursors).Error
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+// GetByWalletID retrieves a page of a wallet's transfers, newest first
+func (r *transactionRepository) GetByWalletID(ctx context.Context, walletID uint, pagination Pagination) (*PaginatedResult[models.Transaction], error) {
+	// A new session lets the filtered query be reused for the count and the page
+	query := r.db.WithContext(ctx).Model(&models.Transaction{}).Where("wallet_id = ?", walletID).Session(&gorm.Session{})
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, err
+	}
+
+	var transactions []*models.Transaction
+	err := query.
+		Order("block_number DESC").
+		Order("log_index DESC").
+		Order("id DESC").
+		Limit(pagination.Limit).
+		Offset(pagination.Offset).
+		Find(&transactions).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return &PaginatedResult[models.Transaction]{
+		Data:    transactions,
+		Total:   total,
+		Limit:   pagination.Limit,
+		Offset:  pagination.Offset,
+		HasNext: int64(pagination.Offset+len(transactions)) < total,
+		HasPrev: pagination.Offset > 0,
+	}, nil
+}
+
+// GetByID retrieves a transfer by ID
+func (r *transactionRepository) GetByID(ctx context.Context, transactionID uint) (*models.Transaction, error) {
+	var transaction models.Transaction
+	err := r.db.WithContext(ctx).Where("id = ?", transactionID).First(&transaction).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrRecordNotFound
+		}
+		return nil, err
+	}
+	return &transaction, nil
+}
+
+// GetByWalletIDs retrieves every transfer of the given wallets in chain order
+func (r *transactionRepository) GetByWalletIDs(ctx context.Context, walletIDs []uint) ([]*models.Transaction, error) {
+	var transactions []*models.Transaction
+	if len(walletIDs) == 0 {
+		return transactions, nil
+	}
+	err := r.db.WithContext(ctx).
+		Where("wallet_id IN ?", walletIDs).
+		Order("block_number ASC").
+		Order("log_index ASC").
+		Order("id ASC").
+		Find(&transactions).Error
+	return transactions, err
+}
+
+// GetCursors retrieves the ingestion cursors of all wallets
+func (r *transactionRepository) GetCursors(ctx context.Context) ([]*models.TransactionCursor, error) {
+	var cursors []*models.TransactionCursor
+	err := r.db.WithContext(ctx).Find(&cursors).Error
+	return cursors, err
+}
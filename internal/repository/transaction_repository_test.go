@@ -0,0 +1,78 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"cryptoportfolio/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestTransfer(walletID uint, blockNumber uint64, logIndex int) *models.Transaction {
+	return &models.Transaction{
+		WalletID:    walletID,
+		ChainID:     1,
+		TxHash:      fmt.Sprintf("0x%064x", blockNumber),
+		LogIndex:    logIndex,
+		BlockNumber: blockNumber,
+		BlockHash:   fmt.Sprintf("0x%064x", blockNumber+1000),
+		Timestamp:   time.Unix(int64(blockNumber)*12, 0),
+		FromAddress: "0x1111111111111111111111111111111111111111",
+		ToAddress:   "0x742d35Cc6634C0532925a3b844Bc454e4438f44e",
+		Direction:   models.TransferDirectionIn,
+		Amount:      "1000",
+	}
+}
+
+func TestTransactionRepository_StoreTransfers(t *testing.T) {
+	db := setupTestDB(t)
+	require.NoError(t, db.AutoMigrate(&models.WatchlistWallet{}, &models.Transaction{}, &models.TransactionCursor{}))
+	repo := NewTransactionRepository(db)
+	ctx := context.Background()
+
+	require.NoError(t, repo.StoreTransfers(ctx,
+		[]*models.Transaction{
+			newTestTransfer(1, 100, models.NativeTransferLogIndex),
+			newTestTransfer(1, 100, 3),
+			newTestTransfer(1, 101, 0),
+			newTestTransfer(2, 100, 3),
+		},
+		[]*models.TransactionCursor{{WalletID: 1, LastBlock: 101}, {WalletID: 2, LastBlock: 101}},
+	))
+
+	// Re-scanning a range skips stored transfers and moves the cursor on
+	require.NoError(t, repo.StoreTransfers(ctx,
+		[]*models.Transaction{newTestTransfer(1, 101, 0), newTestTransfer(1, 102, 1)},
+		[]*models.TransactionCursor{{WalletID: 1, LastBlock: 102}},
+	))
+
+	cursors, err := repo.GetCursors(ctx)
+	require.NoError(t, err)
+	lastBlocks := make(map[uint]uint64)
+	for _, cursor := range cursors {
+		lastBlocks[cursor.WalletID] = cursor.LastBlock
+	}
+	assert.Equal(t, map[uint]uint64{1: 102, 2: 101}, lastBlocks)
+
+	// Newest first, by block then log index
+	page, err := repo.GetByWalletID(ctx, 1, Pagination{Limit: 3})
+	require.NoError(t, err)
+	assert.Equal(t, int64(4), page.Total)
+	assert.True(t, page.HasNext)
+	assert.False(t, page.HasPrev)
+	require.Len(t, page.Data, 3)
+	assert.Equal(t, uint64(102), page.Data[0].BlockNumber)
+	assert.Equal(t, uint64(101), page.Data[1].BlockNumber)
+	assert.Equal(t, 3, page.Data[2].LogIndex)
+
+	page, err = repo.GetByWalletID(ctx, 1, Pagination{Limit: 3, Offset: 3})
+	require.NoError(t, err)
+	assert.False(t, page.HasNext)
+	assert.True(t, page.HasPrev)
+	require.Len(t, page.Data, 1)
+	assert.Equal(t, models.NativeTransferLogIndex, page.Data[0].LogIndex)
+}
@@ -16,6 +16,7 @@ type UserRepository interface {
 	// User-specific operations
 	FindByEmail(ctx context.Context, email string) (*models.User, error)
 	ExistsByEmail(ctx context.Context, email string) (bool, error)
+	FindByWalletAddress(ctx context.Context, address string) (*models.User, error)
 	List(ctx context.Context, opts *QueryOptions) (*PaginatedResult[models.User], error)
 	Count(ctx context.Context) (int64, error)
 	FindByIDs(ctx context.Context, ids []uint) ([]*models.User, error)
@@ -69,6 +70,18 @@ func (r *userRepository) FindByEmail(ctx context.Context, email string) (*models
 	return &user, nil
 }
 
+// FindByWalletAddress finds the user who signs in with a wallet
+func (r *userRepository) FindByWalletAddress(ctx context.Context, address string) (*models.User, error) {
+	var user models.User
+	if err := r.db.WithContext(ctx).Where("wallet_address = ?", address).First(&user).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrRecordNotFound
+		}
+		return nil, ErrDatabaseError
+	}
+	return &user, nil
+}
+
 // Update updates an existing user
 func (r *userRepository) Update(ctx context.Context, user *models.User) error {
 	if err := r.db.WithContext(ctx).Save(user).Error; err != nil {
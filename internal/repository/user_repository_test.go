@@ -29,7 +29,7 @@ func TestUserRepository_Create(t *testing.T) {
 	ctx := context.Background()
 
 	user := &models.User{
-		Email:    "test@example.com",
+		Email:    stringPtr("test@example.com"),
 		Password: "hashedpassword",
 		Name:     "Test User",
 	}
@@ -46,7 +46,7 @@ func TestUserRepository_FindByID(t *testing.T) {
 
 	// Create a user first
 	user := &models.User{
-		Email:    "test@example.com",
+		Email:    stringPtr("test@example.com"),
 		Password: "hashedpassword",
 		Name:     "Test User",
 	}
@@ -67,7 +67,7 @@ func TestUserRepository_FindByEmail(t *testing.T) {
 
 	// Create a user first
 	user := &models.User{
-		Email:    "test@example.com",
+		Email:    stringPtr("test@example.com"),
 		Password: "hashedpassword",
 		Name:     "Test User",
 	}
@@ -88,7 +88,7 @@ func TestUserRepository_ExistsByEmail(t *testing.T) {
 
 	// Create a user first
 	user := &models.User{
-		Email:    "test@example.com",
+		Email:    stringPtr("test@example.com"),
 		Password: "hashedpassword",
 		Name:     "Test User",
 	}
@@ -113,9 +113,9 @@ func TestUserRepository_List(t *testing.T) {
 
 	// Create multiple users
 	users := []*models.User{
-		{Email: "user1@example.com", Password: "pass1", Name: "User 1"},
-		{Email: "user2@example.com", Password: "pass2", Name: "User 2"},
-		{Email: "user3@example.com", Password: "pass3", Name: "User 3"},
+		{Email: stringPtr("user1@example.com"), Password: "pass1", Name: "User 1"},
+		{Email: stringPtr("user2@example.com"), Password: "pass2", Name: "User 2"},
+		{Email: stringPtr("user3@example.com"), Password: "pass3", Name: "User 3"},
 	}
 
 	for _, user := range users {
@@ -148,9 +148,9 @@ func TestUserRepository_Search(t *testing.T) {
 
 	// Create users with different names
 	users := []*models.User{
-		{Email: "john@example.com", Password: "pass1", Name: "John Doe"},
-		{Email: "jane@example.com", Password: "pass2", Name: "Jane Smith"},
-		{Email: "bob@example.com", Password: "pass3", Name: "Bob Johnson"},
+		{Email: stringPtr("john@example.com"), Password: "pass1", Name: "John Doe"},
+		{Email: stringPtr("jane@example.com"), Password: "pass2", Name: "Jane Smith"},
+		{Email: stringPtr("bob@example.com"), Password: "pass3", Name: "Bob Johnson"},
 	}
 
 	for _, user := range users {
@@ -171,3 +171,26 @@ func TestUserRepository_Search(t *testing.T) {
 	assert.Equal(t, int64(2), result.Total) // John Doe and Bob Johnson
 	assert.Len(t, result.Data, 2)
 }
+
+func TestUserRepository_FindByWalletAddress(t *testing.T) {
+	db := setupTestDB(t)
+	repo := NewUserRepository(db)
+	ctx := context.Background()
+
+	address := "0x52908400098527886E0F7030069857D2E4169EE7"
+	require.NoError(t, repo.Create(ctx, &models.User{WalletAddress: &address, Name: "0x5290...9EE7"}))
+	other := "0x8617E340B3D01FA5F11F306F4090FD50E238070D"
+	require.NoError(t, repo.Create(ctx, &models.User{WalletAddress: &other, Name: "0x8617...070D"}), "users without an email do not conflict")
+
+	found, err := repo.FindByWalletAddress(ctx, address)
+	require.NoError(t, err)
+	assert.Nil(t, found.Email)
+	assert.Equal(t, address, *found.WalletAddress)
+
+	_, err = repo.FindByWalletAddress(ctx, "0x0000000000000000000000000000000000000000")
+	assert.ErrorIs(t, err, ErrRecordNotFound)
+}
+
+func stringPtr(s string) *string {
+	return &s
+}
@@ -17,6 +17,7 @@ type WatchlistRepository interface {
 	GetAllWallets(ctx context.Context) ([]*models.WatchlistWallet, error)
 	GetWalletByID(ctx context.Context, walletID uint) (*models.WatchlistWallet, error)
 	DeleteWallet(ctx context.Context, walletID uint, userID uint) error
+	MarkWalletVerified(ctx context.Context, walletID uint, at time.Time) error
 	
 	// Token operations
 	CreateToken(ctx context.Context, token *models.TrackedToken) error
@@ -27,9 +28,20 @@ type WatchlistRepository interface {
 	
 	// Balance operations
 	CreateBalance(ctx context.Context, balance *models.WalletBalance) error
+	CreateBalances(ctx context.Context, balances []*models.WalletBalance) error
+	UpdateBalance(ctx context.Context, balance *models.WalletBalance) error
 	GetLatestBalances(ctx context.Context, userID uint) ([]*models.WalletBalance, error)
 	GetBalanceHistory(ctx context.Context, walletID, tokenID uint, limit int) ([]*models.WalletBalance, error)
 	DeleteOldBalances(ctx context.Context, olderThan time.Duration) error
+	GetSnapshotBlocks(ctx context.Context, chainID int64, since time.Time) ([]SnapshotBlock, error)
+	GetBalancesAtBlock(ctx context.Context, blockHash string) ([]*models.WalletBalance, error)
+	GetPriceAt(ctx context.Context, tokenID uint, at time.Time, window time.Duration) (*string, error)
+}
+
+// SnapshotBlock identifies a block that balance snapshots were read at
+type SnapshotBlock struct {
+	BlockNumber uint64
+	BlockHash   string
 }
 
 // watchlistRepository implements WatchlistRepository
@@ -76,6 +88,11 @@ func (r *watchlistRepository) DeleteWallet(ctx context.Context, walletID uint, u
 	return r.db.WithContext(ctx).Where("id = ? AND user_id = ?", walletID, userID).Delete(&models.WatchlistWallet{}).Error
 }
 
+// MarkWalletVerified records that the wallet's owner proved they control it
+func (r *watchlistRepository) MarkWalletVerified(ctx context.Context, walletID uint, at time.Time) error {
+	return r.db.WithContext(ctx).Model(&models.WatchlistWallet{}).Where("id = ?", walletID).Update("verified_at", at).Error
+}
+
 // CreateToken creates a new tracked token
 func (r *watchlistRepository) CreateToken(ctx context.Context, token *models.TrackedToken) error {
 	return r.db.WithContext(ctx).Create(token).Error
@@ -115,6 +132,19 @@ func (r *watchlistRepository) CreateBalance(ctx context.Context, balance *models
 	return r.db.WithContext(ctx).Create(balance).Error
 }
 
+// CreateBalances creates several balance records in one insert
+func (r *watchlistRepository) CreateBalances(ctx context.Context, balances []*models.WalletBalance) error {
+	if len(balances) == 0 {
+		return nil
+	}
+	return r.db.WithContext(ctx).Create(&balances).Error
+}
+
+// UpdateBalance saves changes to an existing balance record
+func (r *watchlistRepository) UpdateBalance(ctx context.Context, balance *models.WalletBalance) error {
+	return r.db.WithContext(ctx).Omit("Wallet", "Token").Save(balance).Error
+}
+
 // GetLatestBalances retrieves the latest balance for each wallet-token combination for a user
 func (r *watchlistRepository) GetLatestBalances(ctx context.Context, userID uint) ([]*models.WalletBalance, error) {
 	var balances []*models.WalletBalance
@@ -150,4 +180,62 @@ func (r *watchlistRepository) GetBalanceHistory(ctx context.Context, walletID, t
 func (r *watchlistRepository) DeleteOldBalances(ctx context.Context, olderThan time.Duration) error {
 	cutoff := time.Now().Add(-olderThan)
 	return r.db.WithContext(ctx).Where("fetched_at < ?", cutoff).Delete(&models.WalletBalance{}).Error
-} 
\ No newline at end of file
+} 
+// GetSnapshotBlocks returns the distinct blocks that balances on a chain were
+// read at since the given time, in ascending block order
+func (r *watchlistRepository) GetSnapshotBlocks(ctx context.Context, chainID int64, since time.Time) ([]SnapshotBlock, error) {
+	var blocks []SnapshotBlock
+	err := r.db.WithContext(ctx).Model(&models.WalletBalance{}).
+		Distinct("wallet_balances.block_number", "wallet_balances.block_hash").
+		Joins("JOIN watchlist_wallets ON wallet_balances.wallet_id = watchlist_wallets.id").
+		Where("watchlist_wallets.chain_id = ? AND wallet_balances.fetched_at >= ? AND wallet_balances.block_hash IS NOT NULL", chainID, since).
+		Order("wallet_balances.block_number").
+		Scan(&blocks).Error
+	return blocks, err
+}
+
+// GetBalancesAtBlock retrieves every balance read at the block with the given hash
+func (r *watchlistRepository) GetBalancesAtBlock(ctx context.Context, blockHash string) ([]*models.WalletBalance, error) {
+	var balances []*models.WalletBalance
+	err := r.db.WithContext(ctx).
+		Where("block_hash = ?", blockHash).
+		Preload("Wallet").
+		Preload("Token").
+		Find(&balances).Error
+	return balances, err
+}
+
+// GetPriceAt returns the USD price stamped on the token's balance snapshot
+// closest to the given time, or nil when no priced snapshot is within window
+func (r *watchlistRepository) GetPriceAt(ctx context.Context, tokenID uint, at time.Time, window time.Duration) (*string, error) {
+	var before, after models.WalletBalance
+	err := r.db.WithContext(ctx).
+		Where("token_id = ? AND price_usd IS NOT NULL AND fetched_at <= ? AND fetched_at >= ?", tokenID, at, at.Add(-window)).
+		Order("fetched_at DESC").
+		Limit(1).
+		Find(&before).Error
+	if err != nil {
+		return nil, err
+	}
+	err = r.db.WithContext(ctx).
+		Where("token_id = ? AND price_usd IS NOT NULL AND fetched_at > ? AND fetched_at <= ?", tokenID, at, at.Add(window)).
+		Order("fetched_at ASC").
+		Limit(1).
+		Find(&after).Error
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case before.ID == 0 && after.ID == 0:
+		return nil, nil
+	case after.ID == 0:
+		return before.PriceUSD, nil
+	case before.ID == 0:
+		return after.PriceUSD, nil
+	case at.Sub(before.FetchedAt) <= after.FetchedAt.Sub(at):
+		return before.PriceUSD, nil
+	default:
+		return after.PriceUSD, nil
+	}
+}
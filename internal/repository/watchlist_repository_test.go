@@ -0,0 +1,90 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"cryptoportfolio/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWatchlistRepository_SnapshotBlocks(t *testing.T) {
+	db := setupTestDB(t)
+	require.NoError(t, db.AutoMigrate(&models.WatchlistWallet{}, &models.TrackedToken{}, &models.WalletBalance{}))
+	repo := NewWatchlistRepository(db)
+	ctx := context.Background()
+
+	mainnet := &models.WatchlistWallet{UserID: 1, WalletAddress: "0x742d35Cc6634C0532925a3b844Bc454e4438f44e", ChainID: 1}
+	arbitrum := &models.WatchlistWallet{UserID: 1, WalletAddress: "0x742d35Cc6634C0532925a3b844Bc454e4438f44e", ChainID: 42161}
+	require.NoError(t, repo.CreateWallet(ctx, mainnet))
+	require.NoError(t, repo.CreateWallet(ctx, arbitrum))
+
+	now := time.Now()
+	snapshot := func(wallet *models.WatchlistWallet, tokenID uint, number uint64, hash string, fetchedAt time.Time) *models.WalletBalance {
+		return &models.WalletBalance{WalletID: wallet.ID, TokenID: tokenID, Balance: "1", BlockNumber: &number, BlockHash: &hash, FetchedAt: fetchedAt}
+	}
+	require.NoError(t, repo.CreateBalances(ctx, []*models.WalletBalance{
+		snapshot(mainnet, 1, 101, "0xb", now),
+		snapshot(mainnet, 2, 101, "0xb", now),
+		snapshot(mainnet, 1, 100, "0xa", now.Add(-time.Minute)),
+		snapshot(mainnet, 1, 90, "0x9", now.Add(-2*time.Hour)),
+		snapshot(arbitrum, 1, 5000, "0xf", now),
+		{WalletID: mainnet.ID, TokenID: 1, Balance: "1", FetchedAt: now},
+	}))
+
+	// Distinct recent blocks of the chain, oldest first
+	blocks, err := repo.GetSnapshotBlocks(ctx, 1, now.Add(-time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, []SnapshotBlock{{BlockNumber: 100, BlockHash: "0xa"}, {BlockNumber: 101, BlockHash: "0xb"}}, blocks)
+
+	balances, err := repo.GetBalancesAtBlock(ctx, "0xb")
+	require.NoError(t, err)
+	require.Len(t, balances, 2)
+	assert.Equal(t, mainnet.WalletAddress, balances[0].Wallet.WalletAddress)
+
+	// Corrected snapshots are updated in place
+	canonicalHash := "0xc"
+	balances[0].Balance = "2"
+	balances[0].BlockHash = &canonicalHash
+	require.NoError(t, repo.UpdateBalance(ctx, balances[0]))
+	corrected, err := repo.GetBalancesAtBlock(ctx, "0xc")
+	require.NoError(t, err)
+	require.Len(t, corrected, 1)
+	assert.Equal(t, balances[0].ID, corrected[0].ID)
+	assert.Equal(t, "2", corrected[0].Balance)
+}
+
+func TestWatchlistRepository_GetPriceAt(t *testing.T) {
+	db := setupTestDB(t)
+	require.NoError(t, db.AutoMigrate(&models.WatchlistWallet{}, &models.TrackedToken{}, &models.WalletBalance{}))
+	repo := NewWatchlistRepository(db)
+	ctx := context.Background()
+
+	at := time.Date(2024, 1, 15, 12, 0, 0, 0, time.UTC)
+	price := func(value string) *string { return &value }
+	require.NoError(t, repo.CreateBalances(ctx, []*models.WalletBalance{
+		{WalletID: 1, TokenID: 1, Balance: "1", PriceUSD: price("100"), FetchedAt: at.Add(-20 * time.Minute)},
+		{WalletID: 1, TokenID: 1, Balance: "1", PriceUSD: price("105"), FetchedAt: at.Add(10 * time.Minute)},
+		{WalletID: 1, TokenID: 1, Balance: "1", FetchedAt: at},
+		{WalletID: 1, TokenID: 2, Balance: "1", PriceUSD: price("1"), FetchedAt: at},
+	}))
+
+	// The nearest priced snapshot on either side wins
+	stamped, err := repo.GetPriceAt(ctx, 1, at, time.Hour)
+	require.NoError(t, err)
+	require.NotNil(t, stamped)
+	assert.Equal(t, "105", *stamped)
+
+	stamped, err = repo.GetPriceAt(ctx, 1, at.Add(-30*time.Minute), time.Hour)
+	require.NoError(t, err)
+	require.NotNil(t, stamped)
+	assert.Equal(t, "100", *stamped)
+
+	// Nothing within the window
+	stamped, err = repo.GetPriceAt(ctx, 1, at.Add(3*time.Hour), time.Hour)
+	require.NoError(t, err)
+	assert.Nil(t, stamped)
+}
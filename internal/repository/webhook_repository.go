@@ -0,0 +1,206 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"cryptoportfolio/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// WebhookRepository defines the interface for webhook endpoint and delivery outbox operations
+type WebhookRepository interface {
+	// Endpoint operations
+	CreateEndpoint(ctx context.Context, endpoint *models.WebhookEndpoint) error
+	UpdateEndpoint(ctx context.Context, endpoint *models.WebhookEndpoint) error
+	GetEndpointByID(ctx context.Context, endpointID uint) (*models.WebhookEndpoint, error)
+	GetEndpointsByUserID(ctx context.Context, userID uint) ([]*models.WebhookEndpoint, error)
+	DeleteEndpoint(ctx context.Context, endpointID uint, userID uint) error
+
+	// Delivery operations
+	CreateDeliveries(ctx context.Context, deliveries []*models.WebhookDelivery) error
+	ClaimDue(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]*models.WebhookDelivery, error)
+	RecordAttempt(ctx context.Context, delivery *models.WebhookDelivery, attempt *models.WebhookAttempt) error
+	GetDeliveryByID(ctx context.Context, deliveryID uint) (*models.WebhookDelivery, error)
+	GetDeliveries(ctx context.Context, endpointID uint, status string, pagination Pagination) (*PaginatedResult[models.WebhookDelivery], error)
+	Requeue(ctx context.Context, deliveryID uint, at time.Time) error
+}
+
+// webhookRepository implements WebhookRepository
+type webhookRepository struct {
+	db *gorm.DB
+}
+
+// NewWebhookRepository creates a new webhook repository
+func NewWebhookRepository(db *gorm.DB) WebhookRepository {
+	return &webhookRepository{db: db}
+}
+
+// CreateEndpoint creates a new webhook endpoint
+func (r *webhookRepository) CreateEndpoint(ctx context.Context, endpoint *models.WebhookEndpoint) error {
+	return r.db.WithContext(ctx).Create(endpoint).Error
+}
+
+// UpdateEndpoint saves a webhook endpoint's settings
+func (r *webhookRepository) UpdateEndpoint(ctx context.Context, endpoint *models.WebhookEndpoint) error {
+	return r.db.WithContext(ctx).Save(endpoint).Error
+}
+
+// GetEndpointByID retrieves a webhook endpoint by ID
+func (r *webhookRepository) GetEndpointByID(ctx context.Context, endpointID uint) (*models.WebhookEndpoint, error) {
+	var endpoint models.WebhookEndpoint
+	err := r.db.WithContext(ctx).Where("id = ?", endpointID).First(&endpoint).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrRecordNotFound
+		}
+		return nil, err
+	}
+	return &endpoint, nil
+}
+
+// GetEndpointsByUserID retrieves all webhook endpoints of a user
+func (r *webhookRepository) GetEndpointsByUserID(ctx context.Context, userID uint) ([]*models.WebhookEndpoint, error) {
+	var endpoints []*models.WebhookEndpoint
+	err := r.db.WithContext(ctx).Where("user_id = ?", userID).Order("id ASC").Find(&endpoints).Error
+	return endpoints, err
+}
+
+// DeleteEndpoint removes a user's webhook endpoint together with its deliveries
+func (r *webhookRepository) DeleteEndpoint(ctx context.Context, endpointID uint, userID uint) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Where("id = ? AND user_id = ?", endpointID, userID).Delete(&models.WebhookEndpoint{})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrRecordNotFound
+		}
+
+		deliveries := tx.Model(&models.WebhookDelivery{}).Select("id").Where("endpoint_id = ?", endpointID)
+		if err := tx.Where("delivery_id IN (?)", deliveries).Delete(&models.WebhookAttempt{}).Error; err != nil {
+			return err
+		}
+		return tx.Where("endpoint_id = ?", endpointID).Delete(&models.WebhookDelivery{}).Error
+	})
+}
+
+// CreateDeliveries adds deliveries to the outbox
+func (r *webhookRepository) CreateDeliveries(ctx context.Context, deliveries []*models.WebhookDelivery) error {
+	if len(deliveries) == 0 {
+		return nil
+	}
+	return r.db.WithContext(ctx).Omit("Endpoint", "AttemptLogs").Create(&deliveries).Error
+}
+
+// ClaimDue leases up to limit pending deliveries whose next attempt is due.
+// A claim moves NextAttemptAt past the lease, so a delivery abandoned by a
+// crashed dispatcher becomes due again once the lease expires, and other
+// dispatchers skip it in the meantime.
+func (r *webhookRepository) ClaimDue(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]*models.WebhookDelivery, error) {
+	var due []*models.WebhookDelivery
+	err := r.db.WithContext(ctx).
+		Preload("Endpoint").
+		Where("status = ? AND next_attempt_at <= ?", models.WebhookDeliveryPending, now).
+		Order("next_attempt_at ASC").
+		Order("id ASC").
+		Limit(limit).
+		Find(&due).Error
+	if err != nil {
+		return nil, err
+	}
+
+	leasedUntil := now.Add(lease)
+	claimed := make([]*models.WebhookDelivery, 0, len(due))
+	for _, delivery := range due {
+		result := r.db.WithContext(ctx).Model(&models.WebhookDelivery{}).
+			Where("id = ? AND status = ? AND next_attempt_at = ?", delivery.ID, models.WebhookDeliveryPending, delivery.NextAttemptAt).
+			Update("next_attempt_at", leasedUntil)
+		if result.Error != nil {
+			return nil, result.Error
+		}
+		if result.RowsAffected == 1 {
+			delivery.NextAttemptAt = leasedUntil
+			claimed = append(claimed, delivery)
+		}
+	}
+	return claimed, nil
+}
+
+// RecordAttempt stores an attempt and the delivery's resulting state
+func (r *webhookRepository) RecordAttempt(ctx context.Context, delivery *models.WebhookDelivery, attempt *models.WebhookAttempt) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		attempt.DeliveryID = delivery.ID
+		if err := tx.Create(attempt).Error; err != nil {
+			return err
+		}
+		return tx.Model(delivery).
+			Select("status", "attempts", "next_attempt_at", "last_status_code", "last_error", "delivered_at").
+			Updates(delivery).Error
+	})
+}
+
+// GetDeliveryByID retrieves a delivery by ID
+func (r *webhookRepository) GetDeliveryByID(ctx context.Context, deliveryID uint) (*models.WebhookDelivery, error) {
+	var delivery models.WebhookDelivery
+	err := r.db.WithContext(ctx).Where("id = ?", deliveryID).First(&delivery).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrRecordNotFound
+		}
+		return nil, err
+	}
+	return &delivery, nil
+}
+
+// GetDeliveries retrieves an endpoint's deliveries with their attempts, newest
+// first, optionally filtered by status
+func (r *webhookRepository) GetDeliveries(ctx context.Context, endpointID uint, status string, pagination Pagination) (*PaginatedResult[models.WebhookDelivery], error) {
+	query := r.db.WithContext(ctx).Model(&models.WebhookDelivery{}).Where("endpoint_id = ?", endpointID)
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	// A new session lets the filtered query be reused for the count and the page
+	query = query.Session(&gorm.Session{})
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, err
+	}
+
+	var deliveries []*models.WebhookDelivery
+	err := query.
+		Preload("AttemptLogs", func(db *gorm.DB) *gorm.DB {
+			return db.Order("attempted_at ASC")
+		}).
+		Order("id DESC").
+		Limit(pagination.Limit).
+		Offset(pagination.Offset).
+		Find(&deliveries).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return &PaginatedResult[models.WebhookDelivery]{
+		Data:    deliveries,
+		Total:   total,
+		Limit:   pagination.Limit,
+		Offset:  pagination.Offset,
+		HasNext: int64(pagination.Offset+len(deliveries)) < total,
+		HasPrev: pagination.Offset > 0,
+	}, nil
+}
+
+// Requeue makes a delivery pending again with a fresh set of attempts
+func (r *webhookRepository) Requeue(ctx context.Context, deliveryID uint, at time.Time) error {
+	return r.db.WithContext(ctx).Model(&models.WebhookDelivery{}).
+		Where("id = ?", deliveryID).
+		Updates(map[string]interface{}{
+			"status":          models.WebhookDeliveryPending,
+			"attempts":        0,
+			"next_attempt_at": at,
+			"last_error":      "",
+		}).Error
+}
@@ -0,0 +1,103 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"cryptoportfolio/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setupWebhookTest(t *testing.T) (WebhookRepository, *models.WebhookEndpoint) {
+	db := setupTestDB(t)
+	require.NoError(t, db.AutoMigrate(&models.WebhookEndpoint{}, &models.WebhookDelivery{}, &models.WebhookAttempt{}))
+	repo := NewWebhookRepository(db)
+
+	endpoint := &models.WebhookEndpoint{UserID: 1, URL: "https://example.com/hook", Secret: "whsec_test", Enabled: true}
+	require.NoError(t, repo.CreateEndpoint(context.Background(), endpoint))
+	return repo, endpoint
+}
+
+func newTestDelivery(endpoint *models.WebhookEndpoint, at time.Time) *models.WebhookDelivery {
+	return &models.WebhookDelivery{
+		EndpointID:    endpoint.ID,
+		UserID:        endpoint.UserID,
+		EventID:       "evt_1",
+		EventType:     models.WebhookEventBalanceChanged,
+		Payload:       `{"id":"evt_1"}`,
+		Status:        models.WebhookDeliveryPending,
+		NextAttemptAt: at,
+	}
+}
+
+func TestWebhookRepository_ClaimDue(t *testing.T) {
+	repo, endpoint := setupWebhookTest(t)
+	ctx := context.Background()
+
+	now := time.Now()
+	due := newTestDelivery(endpoint, now.Add(-time.Second))
+	later := newTestDelivery(endpoint, now.Add(time.Hour))
+	require.NoError(t, repo.CreateDeliveries(ctx, []*models.WebhookDelivery{due, later}))
+
+	claimed, err := repo.ClaimDue(ctx, now, time.Minute, 10)
+	require.NoError(t, err)
+	require.Len(t, claimed, 1)
+	assert.Equal(t, due.ID, claimed[0].ID)
+	assert.Equal(t, endpoint.URL, claimed[0].Endpoint.URL)
+
+	// A claimed delivery is leased to its dispatcher
+	claimed, err = repo.ClaimDue(ctx, now, time.Minute, 10)
+	require.NoError(t, err)
+	assert.Empty(t, claimed)
+
+	// and becomes due again when the lease runs out without an attempt being recorded
+	claimed, err = repo.ClaimDue(ctx, now.Add(2*time.Minute), time.Minute, 10)
+	require.NoError(t, err)
+	require.Len(t, claimed, 1)
+	assert.Equal(t, due.ID, claimed[0].ID)
+}
+
+func TestWebhookRepository_AttemptsAndReplay(t *testing.T) {
+	repo, endpoint := setupWebhookTest(t)
+	ctx := context.Background()
+
+	delivery := newTestDelivery(endpoint, time.Now())
+	delivered := newTestDelivery(endpoint, time.Now())
+	require.NoError(t, repo.CreateDeliveries(ctx, []*models.WebhookDelivery{delivery, delivered}))
+
+	status := 500
+	delivery.Attempts = 1
+	delivery.Status = models.WebhookDeliveryDead
+	delivery.LastStatusCode = &status
+	delivery.LastError = "endpoint responded with status 500"
+	require.NoError(t, repo.RecordAttempt(ctx, delivery, &models.WebhookAttempt{StatusCode: &status, Error: delivery.LastError, AttemptedAt: time.Now()}))
+
+	dead, err := repo.GetDeliveries(ctx, endpoint.ID, models.WebhookDeliveryDead, Pagination{Limit: 10})
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), dead.Total)
+	require.Len(t, dead.Data, 1)
+	assert.Equal(t, 1, dead.Data[0].Attempts)
+	require.Len(t, dead.Data[0].AttemptLogs, 1)
+	assert.Equal(t, &status, dead.Data[0].AttemptLogs[0].StatusCode)
+
+	// Replaying resets the attempts but keeps their history
+	require.NoError(t, repo.Requeue(ctx, delivery.ID, time.Now()))
+	replayed, err := repo.GetDeliveryByID(ctx, delivery.ID)
+	require.NoError(t, err)
+	assert.Equal(t, models.WebhookDeliveryPending, replayed.Status)
+	assert.Equal(t, 0, replayed.Attempts)
+
+	all, err := repo.GetDeliveries(ctx, endpoint.ID, "", Pagination{Limit: 10})
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), all.Total)
+	assert.Len(t, all.Data[1].AttemptLogs, 1)
+
+	// Deleting the endpoint drops its outbox
+	assert.ErrorIs(t, repo.DeleteEndpoint(ctx, endpoint.ID, 2), ErrRecordNotFound)
+	require.NoError(t, repo.DeleteEndpoint(ctx, endpoint.ID, 1))
+	_, err = repo.GetDeliveryByID(ctx, delivery.ID)
+	assert.ErrorIs(t, err, ErrRecordNotFound)
+}
@@ -0,0 +1,573 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+
+	"cryptoportfolio/internal/cache"
+	"cryptoportfolio/internal/config"
+	"cryptoportfolio/internal/models"
+	"cryptoportfolio/internal/repository"
+	"cryptoportfolio/pkg/logger"
+	"cryptoportfolio/pkg/units"
+)
+
+// Alert errors
+var (
+	ErrAlertRuleNotFound = errors.New("alert rule not found")
+	ErrInvalidAlertRule  = errors.New("invalid alert rule")
+)
+
+// defaultAlertCooldown is how long a rule stays quiet for a token after firing unless the rule sets its own cooldown
+const defaultAlertCooldown = time.Hour
+
+// AlertRuleRequest creates or replaces an alert rule
+type AlertRuleRequest struct {
+	WalletID        uint   `json:"wallet_id" binding:"required"`
+	TokenID         *uint  `json:"token_id"` // Omit to apply the rule to every tracked token on the wallet's chain
+	Type            string `json:"type" binding:"required" example:"balance_drop" enums:"balance_drop,balance_below,balance_above,outgoing_transfer,usd_value_cross"`
+	Threshold       string `json:"threshold" example:"20"`          // Percentage for balance_drop, token amount for balance_below/above, USD for usd_value_cross
+	CooldownSeconds *int   `json:"cooldown_seconds" example:"3600"` // Minimum time between alerts of the rule for the same token (default: 3600)
+	Enabled         *bool  `json:"enabled"`                         // Default: true
+}
+
+// AlertRuleResponse represents an alert rule in API responses
+type AlertRuleResponse struct {
+	ID              uint       `json:"id"`
+	WalletID        uint       `json:"wallet_id"`
+	WalletAddress   string     `json:"wallet_address,omitempty"`
+	ChainID         int64      `json:"chain_id"`
+	TokenID         *uint      `json:"token_id,omitempty"`
+	TokenSymbol     string     `json:"token_symbol,omitempty"`
+	Type            string     `json:"type" example:"balance_drop"`
+	Threshold       string     `json:"threshold,omitempty" example:"20"`
+	CooldownSeconds int        `json:"cooldown_seconds" example:"3600"`
+	Enabled         bool       `json:"enabled"`
+	LastFiredAt     *time.Time `json:"last_fired_at,omitempty"`
+	CreatedAt       time.Time  `json:"created_at"`
+	UpdatedAt       time.Time  `json:"updated_at"`
+}
+
+// AlertPage is a page of fired alerts, newest first
+type AlertPage struct {
+	Alerts  []*models.Alert `json:"alerts"`
+	Total   int64           `json:"total"`
+	Limit   int             `json:"limit"`
+	Offset  int             `json:"offset"`
+	HasNext bool            `json:"has_next"`
+}
+
+// AlertService manages alert rules and evaluates them against new balances
+// and, when transactions are ingested, against new outgoing transfers
+type AlertService interface {
+	CreateRule(ctx context.Context, userID uint, req *AlertRuleRequest) (*AlertRuleResponse, error)
+	GetRules(ctx context.Context, userID uint) ([]*AlertRuleResponse, error)
+	GetRule(ctx context.Context, userID uint, ruleID uint) (*AlertRuleResponse, error)
+	UpdateRule(ctx context.Context, userID uint, ruleID uint, req *AlertRuleRequest) (*AlertRuleResponse, error)
+	DeleteRule(ctx context.Context, userID uint, ruleID uint) error
+	GetAlerts(ctx context.Context, userID uint, limit, offset int) (*AlertPage, error)
+	Evaluate(ctx context.Context, wallet *models.WatchlistWallet, token *models.TrackedToken, balance *models.WalletBalance) error
+	EvaluateTransfers(ctx context.Context, wallet *models.WatchlistWallet, transfers []*models.Transaction) error
+}
+
+// alertService implements AlertService
+type alertService struct {
+	alertRepo     repository.AlertRepository
+	watchlistRepo repository.WatchlistRepository
+	web3Registry  Web3Registry
+	events        EventPublisher
+	cacheService  cache.CacheProvider
+	logger        *logger.Logger
+	config        *config.Config
+}
+
+// NewAlertService creates a new alert service
+func NewAlertService(
+	alertRepo repository.AlertRepository,
+	watchlistRepo repository.WatchlistRepository,
+	web3Registry Web3Registry,
+	events EventPublisher,
+	cacheService cache.CacheProvider,
+	logger *logger.Logger,
+	config *config.Config,
+) AlertService {
+	return &alertService{
+		alertRepo:     alertRepo,
+		watchlistRepo: watchlistRepo,
+		web3Registry:  web3Registry,
+		events:        events,
+		cacheService:  cacheService,
+		logger:        logger,
+		config:        config,
+	}
+}
+
+// CreateRule adds an alert rule on one of the user's wallets
+func (s *alertService) CreateRule(ctx context.Context, userID uint, req *AlertRuleRequest) (*AlertRuleResponse, error) {
+	rule := &models.AlertRule{UserID: userID}
+	if err := s.applyRequest(ctx, rule, req); err != nil {
+		return nil, err
+	}
+
+	if err := s.alertRepo.CreateRule(ctx, rule); err != nil {
+		s.logger.Error("Failed to create alert rule", "error", err, "user_id", userID)
+		return nil, err
+	}
+
+	s.logger.Info("Alert rule created", "user_id", userID, "rule_id", rule.ID, "type", rule.Type)
+	return alertRuleResponse(rule), nil
+}
+
+// GetRules retrieves the user's alert rules
+func (s *alertService) GetRules(ctx context.Context, userID uint) ([]*AlertRuleResponse, error) {
+	rules, err := s.alertRepo.GetRulesByUserID(ctx, userID)
+	if err != nil {
+		s.logger.Error("Failed to get alert rules", "error", err, "user_id", userID)
+		return nil, err
+	}
+
+	responses := make([]*AlertRuleResponse, len(rules))
+	for i, rule := range rules {
+		responses[i] = alertRuleResponse(rule)
+	}
+	return responses, nil
+}
+
+// GetRule retrieves one of the user's alert rules
+func (s *alertService) GetRule(ctx context.Context, userID uint, ruleID uint) (*AlertRuleResponse, error) {
+	rule, err := s.userRule(ctx, userID, ruleID)
+	if err != nil {
+		return nil, err
+	}
+	return alertRuleResponse(rule), nil
+}
+
+// UpdateRule replaces the settings of one of the user's alert rules
+func (s *alertService) UpdateRule(ctx context.Context, userID uint, ruleID uint, req *AlertRuleRequest) (*AlertRuleResponse, error) {
+	rule, err := s.userRule(ctx, userID, ruleID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.applyRequest(ctx, rule, req); err != nil {
+		return nil, err
+	}
+
+	if err := s.alertRepo.UpdateRule(ctx, rule); err != nil {
+		s.logger.Error("Failed to update alert rule", "error", err, "user_id", userID, "rule_id", ruleID)
+		return nil, err
+	}
+
+	s.logger.Info("Alert rule updated", "user_id", userID, "rule_id", ruleID)
+	return alertRuleResponse(rule), nil
+}
+
+// DeleteRule removes one of the user's alert rules; its fired alerts are kept
+func (s *alertService) DeleteRule(ctx context.Context, userID uint, ruleID uint) error {
+	if err := s.alertRepo.DeleteRule(ctx, ruleID, userID); err != nil {
+		if errors.Is(err, repository.ErrRecordNotFound) {
+			return ErrAlertRuleNotFound
+		}
+		s.logger.Error("Failed to delete alert rule", "error", err, "user_id", userID, "rule_id", ruleID)
+		return err
+	}
+
+	s.logger.Info("Alert rule deleted", "user_id", userID, "rule_id", ruleID)
+	return nil
+}
+
+// GetAlerts retrieves a page of the user's fired alerts
+func (s *alertService) GetAlerts(ctx context.Context, userID uint, limit, offset int) (*AlertPage, error) {
+	page, err := s.alertRepo.GetAlertsByUserID(ctx, userID, repository.Pagination{Limit: limit, Offset: offset})
+	if err != nil {
+		s.logger.Error("Failed to get alerts", "error", err, "user_id", userID)
+		return nil, err
+	}
+
+	return &AlertPage{
+		Alerts:  page.Data,
+		Total:   page.Total,
+		Limit:   page.Limit,
+		Offset:  page.Offset,
+		HasNext: page.HasNext,
+	}, nil
+}
+
+// userRule loads a rule, hiding rules of other users
+func (s *alertService) userRule(ctx context.Context, userID uint, ruleID uint) (*models.AlertRule, error) {
+	rule, err := s.alertRepo.GetRuleByID(ctx, ruleID)
+	if err != nil {
+		if errors.Is(err, repository.ErrRecordNotFound) {
+			return nil, ErrAlertRuleNotFound
+		}
+		s.logger.Error("Failed to get alert rule", "error", err, "rule_id", ruleID)
+		return nil, err
+	}
+	if rule.UserID != userID {
+		return nil, ErrAlertRuleNotFound
+	}
+	return rule, nil
+}
+
+// applyRequest validates a request and copies it onto the rule
+func (s *alertService) applyRequest(ctx context.Context, rule *models.AlertRule, req *AlertRuleRequest) error {
+	wallet, err := s.watchlistRepo.GetWalletByID(ctx, req.WalletID)
+	if err != nil {
+		if errors.Is(err, repository.ErrRecordNotFound) {
+			return ErrWalletNotFound
+		}
+		return err
+	}
+	if wallet.UserID != rule.UserID {
+		return ErrWalletNotFound
+	}
+
+	var token *models.TrackedToken
+	if req.TokenID != nil {
+		token, err = s.watchlistRepo.GetTokenByID(ctx, *req.TokenID)
+		if err != nil {
+			if errors.Is(err, repository.ErrRecordNotFound) {
+				return ErrTokenNotFound
+			}
+			return err
+		}
+		if token.UserID != rule.UserID {
+			return ErrTokenNotFound
+		}
+		if token.ChainID != wallet.ChainID {
+			return ErrInvalidAlertRule
+		}
+	}
+
+	threshold, err := validAlertThreshold(req.Type, req.Threshold)
+	if err != nil {
+		return err
+	}
+
+	cooldown := int(defaultAlertCooldown / time.Second)
+	if req.CooldownSeconds != nil {
+		if *req.CooldownSeconds < 0 {
+			return ErrInvalidAlertRule
+		}
+		cooldown = *req.CooldownSeconds
+	}
+
+	rule.WalletID = wallet.ID
+	rule.Wallet = *wallet
+	rule.TokenID = req.TokenID
+	rule.Token = token
+	rule.Type = req.Type
+	rule.Threshold = threshold
+	rule.CooldownSeconds = cooldown
+	rule.Enabled = req.Enabled == nil || *req.Enabled
+	return nil
+}
+
+// validAlertThreshold checks a rule type's threshold and returns it normalized
+func validAlertThreshold(ruleType, threshold string) (string, error) {
+	if ruleType == models.AlertRuleOutgoingTransfer {
+		return "", nil
+	}
+
+	value, err := units.ParseDecimal(threshold)
+	if err != nil || value.Sign() < 0 {
+		return "", ErrInvalidAlertRule
+	}
+
+	switch ruleType {
+	case models.AlertRuleBalanceDrop:
+		if value.Sign() == 0 || value.Cmp(big.NewRat(100, 1)) > 0 {
+			return "", ErrInvalidAlertRule
+		}
+	case models.AlertRuleBalanceBelow, models.AlertRuleBalanceAbove, models.AlertRuleValueCross:
+	default:
+		return "", ErrInvalidAlertRule
+	}
+	return threshold, nil
+}
+
+// alertRuleResponse converts a rule with its preloaded wallet and token
+func alertRuleResponse(rule *models.AlertRule) *AlertRuleResponse {
+	response := &AlertRuleResponse{
+		ID:              rule.ID,
+		WalletID:        rule.WalletID,
+		WalletAddress:   rule.Wallet.WalletAddress,
+		ChainID:         rule.Wallet.ChainID,
+		TokenID:         rule.TokenID,
+		Type:            rule.Type,
+		Threshold:       rule.Threshold,
+		CooldownSeconds: rule.CooldownSeconds,
+		Enabled:         rule.Enabled,
+		LastFiredAt:     rule.LastFiredAt,
+		CreatedAt:       rule.CreatedAt,
+		UpdatedAt:       rule.UpdatedAt,
+	}
+	if rule.Token != nil {
+		response.TokenSymbol = rule.Token.TokenSymbol
+	}
+	return response
+}
+
+// balanceChange is a newly stored balance next to the previous snapshot of the pair
+type balanceChange struct {
+	symbol        string
+	decimals      uint8
+	previous      *big.Rat // nil for the pair's first snapshot
+	current       *big.Rat
+	previousValue *big.Rat // USD values; nil when unpriced
+	currentValue  *big.Rat
+}
+
+// Evaluate checks the pair's enabled rules against a newly stored balance
+// and the snapshot before it. Rules that fire within their cooldown are
+// skipped. With transaction ingestion on, outgoing_transfer rules are left
+// to EvaluateTransfers.
+func (s *alertService) Evaluate(ctx context.Context, wallet *models.WatchlistWallet, token *models.TrackedToken, balance *models.WalletBalance) error {
+	rules, err := s.alertRepo.GetEnabledRules(ctx, wallet.ID, token.ID)
+	if err != nil {
+		return fmt.Errorf("failed to get alert rules: %w", err)
+	}
+	if len(rules) == 0 {
+		return nil
+	}
+
+	decimals, err := tokenDecimals(ctx, s.web3Registry, s.cacheService, token)
+	if err != nil {
+		return fmt.Errorf("failed to get token decimals: %w", err)
+	}
+
+	// The latest two snapshots are the new balance and the one it replaced
+	history, err := s.watchlistRepo.GetBalanceHistory(ctx, wallet.ID, token.ID, 2)
+	if err != nil {
+		return fmt.Errorf("failed to get previous balance: %w", err)
+	}
+	var previous *models.WalletBalance
+	for _, snapshot := range history {
+		if snapshot.ID != balance.ID {
+			previous = snapshot
+			break
+		}
+	}
+
+	change := balanceChange{symbol: token.TokenSymbol, decimals: decimals}
+	change.current, change.currentValue = snapshotAmounts(balance, decimals)
+	if change.current == nil {
+		return fmt.Errorf("invalid balance %q", balance.Balance)
+	}
+	if previous != nil {
+		change.previous, change.previousValue = snapshotAmounts(previous, decimals)
+	}
+
+	for _, rule := range rules {
+		if rule.Type == models.AlertRuleOutgoingTransfer && s.config.Web3.IngestTransactions {
+			continue
+		}
+		message, ok := evaluateAlertRule(rule, change)
+		if !ok {
+			continue
+		}
+
+		alert := &models.Alert{
+			RuleID:    rule.ID,
+			UserID:    rule.UserID,
+			WalletID:  wallet.ID,
+			TokenID:   token.ID,
+			BalanceID: &balance.ID,
+			Type:      rule.Type,
+			Message:   message,
+			Amount:    formatRawAmount(balance.Balance, decimals),
+			ValueUSD:  balance.BalanceUSD,
+			FiredAt:   time.Now(),
+		}
+		if previous != nil {
+			amount := formatRawAmount(previous.Balance, decimals)
+			alert.PreviousAmount = &amount
+			alert.PreviousValueUSD = previous.BalanceUSD
+		}
+
+		if err := s.fire(ctx, rule, alert, wallet, token); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// EvaluateTransfers checks the outgoing_transfer rules of a wallet against
+// its newly ingested transfers. Transfers of tokens the user does not track
+// are skipped, like their balances are never fetched.
+func (s *alertService) EvaluateTransfers(ctx context.Context, wallet *models.WatchlistWallet, transfers []*models.Transaction) error {
+	var outgoing []*models.Transaction
+	for _, transfer := range transfers {
+		if transfer.WalletID == wallet.ID && transfer.Direction == models.TransferDirectionOut {
+			outgoing = append(outgoing, transfer)
+		}
+	}
+	if len(outgoing) == 0 {
+		return nil
+	}
+
+	tokens, err := s.watchlistRepo.GetTokensByUserID(ctx, wallet.UserID)
+	if err != nil {
+		return fmt.Errorf("failed to get tracked tokens: %w", err)
+	}
+	tracked := make(map[string]*models.TrackedToken)
+	for _, token := range tokens {
+		if token.ChainID == wallet.ChainID {
+			tracked[tokenKey(token.TokenAddress)] = token
+		}
+	}
+
+	for _, transfer := range outgoing {
+		token, ok := tracked[tokenKey(transfer.TokenAddress)]
+		if !ok {
+			continue
+		}
+
+		rules, err := s.alertRepo.GetEnabledRules(ctx, wallet.ID, token.ID)
+		if err != nil {
+			return fmt.Errorf("failed to get alert rules: %w", err)
+		}
+		var transferRules []*models.AlertRule
+		for _, rule := range rules {
+			if rule.Type == models.AlertRuleOutgoingTransfer {
+				transferRules = append(transferRules, rule)
+			}
+		}
+		if len(transferRules) == 0 {
+			continue
+		}
+
+		decimals, err := tokenDecimals(ctx, s.web3Registry, s.cacheService, token)
+		if err != nil {
+			return fmt.Errorf("failed to get token decimals: %w", err)
+		}
+
+		for _, rule := range transferRules {
+			amount := formatRawAmount(transfer.Amount, decimals)
+			txHash := transfer.TxHash
+			alert := &models.Alert{
+				RuleID:   rule.ID,
+				UserID:   rule.UserID,
+				WalletID: wallet.ID,
+				TokenID:  token.ID,
+				TxHash:   &txHash,
+				Type:     rule.Type,
+				Message:  fmt.Sprintf("%s %s sent from wallet to %s", amount, token.TokenSymbol, transfer.ToAddress),
+				Amount:   amount,
+				FiredAt:  time.Now(),
+			}
+			if err := s.fire(ctx, rule, alert, wallet, token); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// fire stores an alert unless the rule is cooling down for the token, and
+// publishes it
+func (s *alertService) fire(ctx context.Context, rule *models.AlertRule, alert *models.Alert, wallet *models.WatchlistWallet, token *models.TrackedToken) error {
+	cooldownStart := alert.FiredAt.Add(-time.Duration(rule.CooldownSeconds) * time.Second)
+	fired, err := s.alertRepo.Fire(ctx, alert, cooldownStart)
+	if err != nil {
+		return fmt.Errorf("failed to store alert: %w", err)
+	}
+	if fired {
+		s.logger.Info("Alert fired",
+			"user_id", rule.UserID,
+			"rule_id", rule.ID,
+			"wallet", wallet.WalletAddress,
+			"token", token.TokenSymbol,
+			"message", alert.Message)
+		s.publishAlert(ctx, alert)
+	}
+	return nil
+}
+
+// publishAlert queues an alert.fired event; a failure does not fail the evaluation
+func (s *alertService) publishAlert(ctx context.Context, alert *models.Alert) {
+	if s.events == nil {
+		return
+	}
+	if err := s.events.Publish(ctx, alert.UserID, models.WebhookEventAlertFired, alert); err != nil {
+		s.logger.Warn("Failed to publish alert", "rule_id", alert.RuleID, "error", err)
+	}
+}
+
+// snapshotAmounts returns a snapshot's decimal-adjusted balance and USD value
+func snapshotAmounts(balance *models.WalletBalance, decimals uint8) (*big.Rat, *big.Rat) {
+	raw, ok := new(big.Int).SetString(balance.Balance, 10)
+	if !ok {
+		return nil, nil
+	}
+	var value *big.Rat
+	if balance.BalanceUSD != nil {
+		value, _ = units.ParseDecimal(*balance.BalanceUSD)
+	}
+	return units.ToRat(raw, decimals), value
+}
+
+// evaluateAlertRule reports whether a rule fires for a balance change, with
+// the alert's message. outgoing_transfer rules fire on any decrease here,
+// which misses a transfer offset by an incoming one and counts drops that
+// are not transfers, e.g. of rebasing tokens; it is the fallback for when
+// transactions are not ingested. Threshold rules fire when the balance crosses the
+// threshold, not on every snapshot past it.
+func evaluateAlertRule(rule *models.AlertRule, change balanceChange) (string, bool) {
+	threshold, _ := units.ParseDecimal(rule.Threshold)
+
+	switch rule.Type {
+	case models.AlertRuleBalanceDrop:
+		if threshold == nil || change.previous == nil || change.previous.Sign() <= 0 {
+			return "", false
+		}
+		drop := new(big.Rat).Sub(change.previous, change.current)
+		drop.Mul(drop, big.NewRat(100, 1)).Quo(drop, change.previous)
+		if drop.Cmp(threshold) <= 0 {
+			return "", false
+		}
+		return fmt.Sprintf("%s balance dropped %s%% from %s to %s",
+			change.symbol, units.FormatDecimal(drop, allocationDecimals), formatTokenAmount(change.previous, change.decimals), formatTokenAmount(change.current, change.decimals)), true
+
+	case models.AlertRuleBalanceBelow:
+		if threshold == nil || change.current.Cmp(threshold) >= 0 || (change.previous != nil && change.previous.Cmp(threshold) < 0) {
+			return "", false
+		}
+		return fmt.Sprintf("%s balance %s is below %s", change.symbol, formatTokenAmount(change.current, change.decimals), rule.Threshold), true
+
+	case models.AlertRuleBalanceAbove:
+		if threshold == nil || change.current.Cmp(threshold) <= 0 || (change.previous != nil && change.previous.Cmp(threshold) > 0) {
+			return "", false
+		}
+		return fmt.Sprintf("%s balance %s is above %s", change.symbol, formatTokenAmount(change.current, change.decimals), rule.Threshold), true
+
+	case models.AlertRuleOutgoingTransfer:
+		if change.previous == nil || change.current.Cmp(change.previous) >= 0 {
+			return "", false
+		}
+		sent := new(big.Rat).Sub(change.previous, change.current)
+		return fmt.Sprintf("%s %s sent from wallet", formatTokenAmount(sent, change.decimals), change.symbol), true
+
+	case models.AlertRuleValueCross:
+		if threshold == nil || change.previousValue == nil || change.currentValue == nil {
+			return "", false
+		}
+		wasBelow := change.previousValue.Cmp(threshold) < 0
+		isBelow := change.currentValue.Cmp(threshold) < 0
+		if wasBelow == isBelow {
+			return "", false
+		}
+		direction := "above"
+		if isBelow {
+			direction = "below"
+		}
+		return fmt.Sprintf("%s value crossed %s %s USD to %s USD",
+			change.symbol, direction, rule.Threshold, units.FormatDecimal(change.currentValue, valueDecimals)), true
+	}
+
+	return "", false
+}
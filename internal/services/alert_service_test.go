@@ -0,0 +1,199 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"cryptoportfolio/internal/config"
+	"cryptoportfolio/internal/models"
+	"cryptoportfolio/internal/repository"
+	"cryptoportfolio/pkg/logger"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeAlertRepository serves rules from memory and applies cooldowns like the database
+type fakeAlertRepository struct {
+	repository.AlertRepository
+	rules  []*models.AlertRule
+	alerts []*models.Alert
+}
+
+func (r *fakeAlertRepository) GetEnabledRules(ctx context.Context, walletID, tokenID uint) ([]*models.AlertRule, error) {
+	var rules []*models.AlertRule
+	for _, rule := range r.rules {
+		if rule.Enabled && rule.WalletID == walletID && (rule.TokenID == nil || *rule.TokenID == tokenID) {
+			rules = append(rules, rule)
+		}
+	}
+	return rules, nil
+}
+
+func (r *fakeAlertRepository) Fire(ctx context.Context, alert *models.Alert, cooldownStart time.Time) (bool, error) {
+	for _, fired := range r.alerts {
+		if fired.RuleID == alert.RuleID && fired.TokenID == alert.TokenID && fired.FiredAt.After(cooldownStart) {
+			return false, nil
+		}
+	}
+	r.alerts = append(r.alerts, alert)
+	return true, nil
+}
+
+// fakeBalanceHistory serves a pair's snapshots, newest first, and the user's tracked tokens
+type fakeBalanceHistory struct {
+	repository.WatchlistRepository
+	history []*models.WalletBalance
+	tokens  []*models.TrackedToken
+}
+
+func (r *fakeBalanceHistory) GetTokensByUserID(ctx context.Context, userID uint) ([]*models.TrackedToken, error) {
+	return r.tokens, nil
+}
+
+func (r *fakeBalanceHistory) GetBalanceHistory(ctx context.Context, walletID, tokenID uint, limit int) ([]*models.WalletBalance, error) {
+	if len(r.history) > limit {
+		return r.history[:limit], nil
+	}
+	return r.history, nil
+}
+
+func TestEvaluateAlertRule(t *testing.T) {
+	change := func(previous, current, previousValue, currentValue string) balanceChange {
+		c := balanceChange{symbol: "ETH", decimals: 18, current: rat(current)}
+		if previous != "" {
+			c.previous = rat(previous)
+		}
+		if previousValue != "" {
+			c.previousValue = rat(previousValue)
+		}
+		if currentValue != "" {
+			c.currentValue = rat(currentValue)
+		}
+		return c
+	}
+
+	tests := []struct {
+		name      string
+		ruleType  string
+		threshold string
+		change    balanceChange
+		fires     bool
+		message   string
+	}{
+		{"drop above threshold", models.AlertRuleBalanceDrop, "20", change("10", "7.5", "", ""), true, "ETH balance dropped 25.00% from 10 to 7.5"},
+		{"drop at threshold", models.AlertRuleBalanceDrop, "25", change("10", "7.5", "", ""), false, ""},
+		{"drop without previous", models.AlertRuleBalanceDrop, "20", change("", "1", "", ""), false, ""},
+		{"crosses below", models.AlertRuleBalanceBelow, "5", change("6", "4", "", ""), true, "ETH balance 4 is below 5"},
+		{"stays below", models.AlertRuleBalanceBelow, "5", change("4", "3", "", ""), false, ""},
+		{"first snapshot below", models.AlertRuleBalanceBelow, "5", change("", "3", "", ""), true, "ETH balance 3 is below 5"},
+		{"crosses above", models.AlertRuleBalanceAbove, "5", change("5", "5.5", "", ""), true, "ETH balance 5.5 is above 5"},
+		{"stays above", models.AlertRuleBalanceAbove, "5", change("6", "7", "", ""), false, ""},
+		{"outgoing", models.AlertRuleOutgoingTransfer, "", change("2", "1.25", "", ""), true, "0.75 ETH sent from wallet"},
+		{"incoming", models.AlertRuleOutgoingTransfer, "", change("1", "2", "", ""), false, ""},
+		{"value crosses up", models.AlertRuleValueCross, "1000", change("1", "1", "900", "1100"), true, "ETH value crossed above 1000 USD to 1100.000000 USD"},
+		{"value crosses down", models.AlertRuleValueCross, "1000", change("1", "1", "1000", "999"), true, "ETH value crossed below 1000 USD to 999.000000 USD"},
+		{"value unpriced", models.AlertRuleValueCross, "1000", change("1", "1", "", "1100"), false, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			message, fires := evaluateAlertRule(&models.AlertRule{Type: tt.ruleType, Threshold: tt.threshold}, tt.change)
+			assert.Equal(t, tt.fires, fires)
+			assert.Equal(t, tt.message, message)
+		})
+	}
+}
+
+func TestAlertService_Evaluate(t *testing.T) {
+	decimals := uint8(6)
+	wallet := &models.WatchlistWallet{ID: 1, UserID: 1, WalletAddress: testWalletA, ChainID: 1}
+	token := &models.TrackedToken{ID: 2, UserID: 1, ChainID: 1, TokenSymbol: "USDC", Decimals: &decimals}
+	otherTokenID := uint(3)
+
+	alertRepo := &fakeAlertRepository{rules: []*models.AlertRule{
+		{ID: 1, UserID: 1, WalletID: 1, Type: models.AlertRuleOutgoingTransfer, CooldownSeconds: 3600, Enabled: true},
+		{ID: 2, UserID: 1, WalletID: 1, Type: models.AlertRuleBalanceDrop, Threshold: "50", CooldownSeconds: 3600, Enabled: true},
+		{ID: 3, UserID: 1, WalletID: 1, TokenID: &otherTokenID, Type: models.AlertRuleOutgoingTransfer, Enabled: true},
+	}}
+	valueBefore, valueAfter := "100.000000", "90.000000"
+	previous := &models.WalletBalance{ID: 10, WalletID: 1, TokenID: 2, Balance: "100000000", BalanceUSD: &valueBefore}
+	current := &models.WalletBalance{ID: 11, WalletID: 1, TokenID: 2, Balance: "90000000", BalanceUSD: &valueAfter}
+
+	service := &alertService{
+		alertRepo:     alertRepo,
+		watchlistRepo: &fakeBalanceHistory{history: []*models.WalletBalance{current, previous}},
+		logger:        logger.New(),
+		config:        &config.Config{},
+	}
+
+	require.NoError(t, service.Evaluate(context.Background(), wallet, token, current))
+	require.Len(t, alertRepo.alerts, 1)
+	alert := alertRepo.alerts[0]
+	assert.Equal(t, uint(1), alert.RuleID)
+	require.NotNil(t, alert.BalanceID)
+	assert.Equal(t, uint(11), *alert.BalanceID)
+	assert.Equal(t, "10 USDC sent from wallet", alert.Message)
+	assert.Equal(t, "90", alert.Amount)
+	require.NotNil(t, alert.PreviousAmount)
+	assert.Equal(t, "100", *alert.PreviousAmount)
+	assert.Equal(t, &valueAfter, alert.ValueUSD)
+
+	// A second decrease within the cooldown is deduplicated
+	next := &models.WalletBalance{ID: 12, WalletID: 1, TokenID: 2, Balance: "80000000"}
+	service.watchlistRepo = &fakeBalanceHistory{history: []*models.WalletBalance{next, current}}
+	require.NoError(t, service.Evaluate(context.Background(), wallet, token, next))
+	assert.Len(t, alertRepo.alerts, 1)
+}
+
+func TestAlertService_EvaluateTransfers(t *testing.T) {
+	decimals := uint8(6)
+	usdcAddress := "0xA0b86991c6218b36c1d19D4a2e9Eb0cE3606eB48"
+	daiAddress := "0x6B175474E89094C44Da98b954EedeAC495271d0F"
+	wallet := &models.WatchlistWallet{ID: 1, UserID: 1, WalletAddress: testWalletA, ChainID: 1}
+	usdc := &models.TrackedToken{ID: 2, UserID: 1, ChainID: 1, TokenAddress: &usdcAddress, TokenSymbol: "USDC", Decimals: &decimals}
+	dai := &models.TrackedToken{ID: 3, UserID: 1, ChainID: 1, TokenAddress: &daiAddress, TokenSymbol: "DAI", Decimals: &decimals}
+
+	alertRepo := &fakeAlertRepository{rules: []*models.AlertRule{
+		{ID: 1, UserID: 1, WalletID: 1, Type: models.AlertRuleOutgoingTransfer, CooldownSeconds: 3600, Enabled: true},
+		{ID: 2, UserID: 1, WalletID: 1, Type: models.AlertRuleBalanceDrop, Threshold: "50", CooldownSeconds: 3600, Enabled: true},
+	}}
+	// The balance fell, but without a transfer, e.g. of a rebasing token
+	previous := &models.WalletBalance{ID: 10, WalletID: 1, TokenID: 2, Balance: "100000000"}
+	current := &models.WalletBalance{ID: 11, WalletID: 1, TokenID: 2, Balance: "99000000"}
+	service := &alertService{
+		alertRepo:     alertRepo,
+		watchlistRepo: &fakeBalanceHistory{history: []*models.WalletBalance{current, previous}, tokens: []*models.TrackedToken{usdc, dai}},
+		logger:        logger.New(),
+		config:        &config.Config{Web3: config.Web3Config{IngestTransactions: true}},
+	}
+
+	require.NoError(t, service.Evaluate(context.Background(), wallet, usdc, current))
+	assert.Empty(t, alertRepo.alerts, "balance changes do not fire outgoing_transfer rules while transactions are ingested")
+
+	untracked := "0xdAC17F958D2ee523a2206206994597C13D831ec7"
+	transfers := []*models.Transaction{
+		{WalletID: 1, TxHash: "0x01", TokenAddress: &usdcAddress, ToAddress: testStranger, Direction: models.TransferDirectionOut, Amount: "25000000"},
+		{WalletID: 1, TxHash: "0x02", TokenAddress: &usdcAddress, FromAddress: testStranger, Direction: models.TransferDirectionIn, Amount: "75000000"},
+		{WalletID: 1, TxHash: "0x03", TokenAddress: &untracked, ToAddress: testStranger, Direction: models.TransferDirectionOut, Amount: "1"},
+		{WalletID: 1, TxHash: "0x04", TokenAddress: &usdcAddress, ToAddress: testStranger, Direction: models.TransferDirectionOut, Amount: "1000000"},
+		{WalletID: 1, TxHash: "0x05", TokenAddress: &daiAddress, ToAddress: testStranger, Direction: models.TransferDirectionOut, Amount: "2000000"},
+		{WalletID: 2, TxHash: "0x06", TokenAddress: &usdcAddress, ToAddress: testStranger, Direction: models.TransferDirectionOut, Amount: "1"},
+	}
+	require.NoError(t, service.EvaluateTransfers(context.Background(), wallet, transfers))
+
+	// An outgoing transfer fires even when an incoming one offsets it. The
+	// second USDC transfer is within the cooldown; the wallet-wide rule still
+	// fires for DAI.
+	require.Len(t, alertRepo.alerts, 2)
+	alert := alertRepo.alerts[0]
+	assert.Equal(t, uint(1), alert.RuleID)
+	assert.Equal(t, uint(2), alert.TokenID)
+	require.NotNil(t, alert.TxHash)
+	assert.Equal(t, "0x01", *alert.TxHash)
+	assert.Nil(t, alert.BalanceID)
+	assert.Equal(t, "25", alert.Amount)
+	assert.Equal(t, "25 USDC sent from wallet to "+testStranger, alert.Message)
+	assert.Equal(t, uint(3), alertRepo.alerts[1].TokenID)
+}
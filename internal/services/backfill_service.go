@@ -0,0 +1,524 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"sync"
+	"time"
+
+	"cryptoportfolio/internal/config"
+	"cryptoportfolio/internal/models"
+	"cryptoportfolio/internal/repository"
+	"cryptoportfolio/pkg/logger"
+	"cryptoportfolio/pkg/units"
+)
+
+// ErrBackfillJobNotFound is returned when a backfill job does not exist or belongs to another user
+var ErrBackfillJobNotFound = errors.New("backfill job not found")
+
+// errNoBlockBefore is returned when a target time predates the chain's genesis block
+var errNoBlockBefore = errors.New("no block at or before the target time")
+
+const (
+	// backfillPollInterval is how often the queue is checked when no job was enqueued in-process
+	backfillPollInterval = 30 * time.Second
+	// backfillChunkDays is the number of days read and stored per progress update
+	backfillChunkDays = 10
+	// backfillStaleAfter is how long a running job may go without storing a
+	// chunk before it is considered abandoned by a crashed process
+	backfillStaleAfter = 10 * time.Minute
+	// maxBackfillErrorLength matches the size of BackfillJob.LastError
+	maxBackfillErrorLength = 500
+)
+
+// BackfillJobResponse reports the progress of a historical balance backfill
+type BackfillJobResponse struct {
+	ID            uint       `json:"id"`
+	WalletID      uint       `json:"wallet_id"`
+	WalletAddress string     `json:"wallet_address,omitempty"`
+	TokenID       uint       `json:"token_id"`
+	TokenSymbol   string     `json:"token_symbol,omitempty"`
+	ChainID       int64      `json:"chain_id"`
+	Status        string     `json:"status" example:"running"`
+	StartDate     time.Time  `json:"start_date"`
+	EndDate       time.Time  `json:"end_date"`
+	TotalDays     int        `json:"total_days"`
+	CompletedDays int        `json:"completed_days"`
+	Progress      string     `json:"progress" example:"40.00"` // Percentage of days completed
+	LastError     string     `json:"last_error,omitempty"`
+	StartedAt     *time.Time `json:"started_at,omitempty"`
+	CompletedAt   *time.Time `json:"completed_at,omitempty"`
+	CreatedAt     time.Time  `json:"created_at"`
+}
+
+// BackfillService fills in daily balance history for newly added wallet/token
+// pairs by reading balances at past blocks from an archive node
+type BackfillService interface {
+	Start(ctx context.Context)
+	Stop()
+	EnqueueWallet(ctx context.Context, wallet *models.WatchlistWallet) error
+	EnqueueToken(ctx context.Context, token *models.TrackedToken) error
+	GetJobs(ctx context.Context, userID uint) ([]*BackfillJobResponse, error)
+	GetJob(ctx context.Context, userID uint, jobID uint) (*BackfillJobResponse, error)
+}
+
+// backfillService implements BackfillService
+type backfillService struct {
+	backfillRepo  repository.BackfillRepository
+	watchlistRepo repository.WatchlistRepository
+	web3Registry  Web3Registry
+	logger        *logger.Logger
+	config        *config.Config
+	wake          chan struct{}
+	stopChan      chan struct{}
+	wg            sync.WaitGroup
+}
+
+// NewBackfillService creates a new backfill service
+func NewBackfillService(
+	backfillRepo repository.BackfillRepository,
+	watchlistRepo repository.WatchlistRepository,
+	web3Registry Web3Registry,
+	logger *logger.Logger,
+	config *config.Config,
+) BackfillService {
+	return &backfillService{
+		backfillRepo:  backfillRepo,
+		watchlistRepo: watchlistRepo,
+		web3Registry:  web3Registry,
+		logger:        logger,
+		config:        config,
+		wake:          make(chan struct{}, 1),
+		stopChan:      make(chan struct{}),
+	}
+}
+
+// Start begins processing the queue. Jobs left running by a crashed process
+// are requeued once they go stale; jobs still running on other instances are
+// left alone.
+func (s *backfillService) Start(ctx context.Context) {
+	s.logger.Info("Starting balance backfill worker")
+
+	s.wg.Add(1)
+	go s.run(ctx)
+}
+
+// Stop gracefully stops the backfill worker; a job in progress is requeued
+func (s *backfillService) Stop() {
+	s.logger.Info("Stopping balance backfill worker")
+	close(s.stopChan)
+	s.wg.Wait()
+	s.logger.Info("Balance backfill worker stopped")
+}
+
+// run processes pending jobs one at a time, since archive reads are expensive
+func (s *backfillService) run(ctx context.Context) {
+	defer s.wg.Done()
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go func() {
+		select {
+		case <-s.stopChan:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	ticker := time.NewTicker(backfillPollInterval)
+	defer ticker.Stop()
+
+	for {
+		s.requeueStale(ctx)
+		s.processPending(ctx)
+
+		select {
+		case <-ticker.C:
+		case <-s.wake:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// requeueStale returns jobs whose runner stopped reporting progress to the queue
+func (s *backfillService) requeueStale(ctx context.Context) {
+	requeued, err := s.backfillRepo.RequeueStale(ctx, time.Now().Add(-backfillStaleAfter))
+	if err != nil {
+		s.logger.Error("Failed to requeue stale backfill jobs", "error", err)
+		return
+	}
+	if requeued > 0 {
+		s.logger.Warn("Requeued stale backfill jobs", "count", requeued)
+	}
+}
+
+// processPending runs queued jobs until the queue is empty
+func (s *backfillService) processPending(ctx context.Context) {
+	for ctx.Err() == nil {
+		job, err := s.backfillRepo.ClaimNext(ctx)
+		if err != nil {
+			s.logger.Error("Failed to claim backfill job", "error", err)
+			return
+		}
+		if job == nil {
+			return
+		}
+		s.runJob(ctx, job)
+	}
+}
+
+// runJob backfills a claimed job and records its outcome
+func (s *backfillService) runJob(ctx context.Context, job *models.BackfillJob) {
+	if job.StartedAt == nil {
+		now := time.Now()
+		job.StartedAt = &now
+	}
+	job.LastError = ""
+	if err := s.backfillRepo.Update(ctx, job); err != nil {
+		s.logger.Warn("Failed to update backfill job", "job_id", job.ID, "error", err)
+	}
+
+	s.logger.Info("Backfilling balance history",
+		"job_id", job.ID,
+		"chain_id", job.ChainID,
+		"wallet_id", job.WalletID,
+		"token_id", job.TokenID,
+		"completed_days", job.CompletedDays,
+		"total_days", job.TotalDays)
+
+	err := s.backfill(ctx, job)
+	switch {
+	case err == nil:
+		now := time.Now()
+		job.Status = models.BackfillStatusCompleted
+		job.CompletedAt = &now
+		s.logger.Info("Balance backfill completed", "job_id", job.ID, "days", job.TotalDays)
+	case ctx.Err() != nil:
+		// Interrupted by shutdown; the job resumes from its last completed day
+		job.Status = models.BackfillStatusPending
+	default:
+		job.Status = models.BackfillStatusFailed
+		job.LastError = truncateString(err.Error(), maxBackfillErrorLength)
+		s.logger.Error("Balance backfill failed", "job_id", job.ID, "completed_days", job.CompletedDays, "error", err)
+	}
+
+	// The job context may already be cancelled; the outcome is still recorded
+	if err := s.backfillRepo.Update(context.Background(), job); err != nil {
+		s.logger.Error("Failed to update backfill job", "job_id", job.ID, "error", err)
+	}
+}
+
+// backfill reads the job's remaining days in chunks, storing each chunk and
+// its progress before moving on so an interrupted job can resume
+func (s *backfillService) backfill(ctx context.Context, job *models.BackfillJob) error {
+	dates := backfillDates(job.StartDate, job.EndDate)
+	if job.CompletedDays >= len(dates) {
+		return nil
+	}
+
+	wallet, err := s.watchlistRepo.GetWalletByID(ctx, job.WalletID)
+	if err != nil {
+		return fmt.Errorf("failed to get wallet: %w", err)
+	}
+	token, err := s.watchlistRepo.GetTokenByID(ctx, job.TokenID)
+	if err != nil {
+		return fmt.Errorf("failed to get token: %w", err)
+	}
+
+	web3Service, err := s.web3Registry.GetArchive(job.ChainID)
+	if err != nil {
+		return err
+	}
+
+	genesis, err := web3Service.HeaderByNumber(ctx, big.NewInt(0))
+	if err != nil {
+		return fmt.Errorf("failed to get genesis block: %w", err)
+	}
+	latest, err := web3Service.HeaderByNumber(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to get latest block: %w", err)
+	}
+	finder := &blockFinder{reader: web3Service, low: genesis, high: latest}
+
+	query := BalanceQuery{WalletAddress: wallet.WalletAddress, TokenAddress: token.TokenAddress}
+
+	for start := job.CompletedDays; start < len(dates); start += backfillChunkDays {
+		end := start + backfillChunkDays
+		if end > len(dates) {
+			end = len(dates)
+		}
+
+		var headers []*BlockHeader
+		var blockNumbers []*big.Int
+		for _, date := range dates[start:end] {
+			header, err := finder.blockAt(ctx, date)
+			if errors.Is(err, errNoBlockBefore) {
+				// The chain did not exist yet on this day
+				continue
+			}
+			if err != nil {
+				return fmt.Errorf("failed to find block for %s: %w", date.Format("2006-01-02"), err)
+			}
+			headers = append(headers, header)
+			blockNumbers = append(blockNumbers, header.Number)
+		}
+
+		results, err := web3Service.GetBalancesAt(ctx, query, blockNumbers)
+		if err != nil {
+			return err
+		}
+
+		balances := make([]*models.WalletBalance, 0, len(results))
+		for i, result := range results {
+			balance := result.Balance
+			if result.Err != nil {
+				// A token that was not deployed yet, or reverts at an old block, held no balance
+				if !errors.Is(result.Err, ErrNoBalanceData) && isProviderError(result.Err) {
+					return fmt.Errorf("failed to read balance at block %s: %w", headers[i].Number, result.Err)
+				}
+				balance = big.NewInt(0)
+			}
+
+			blockNumber := headers[i].Number.Uint64()
+			blockHash := headers[i].Hash.Hex()
+			balances = append(balances, &models.WalletBalance{
+				WalletID:    job.WalletID,
+				TokenID:     job.TokenID,
+				Balance:     balance.String(),
+				BlockNumber: &blockNumber,
+				BlockHash:   &blockHash,
+				FetchedAt:   time.Unix(int64(headers[i].Time), 0).UTC(),
+			})
+		}
+
+		if err := s.watchlistRepo.CreateBalances(ctx, balances); err != nil {
+			return fmt.Errorf("failed to store balances: %w", err)
+		}
+
+		job.CompletedDays = end
+		if err := s.backfillRepo.Update(ctx, job); err != nil {
+			return fmt.Errorf("failed to update progress: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// EnqueueWallet schedules a backfill for a new wallet and each of the user's tokens on its chain
+func (s *backfillService) EnqueueWallet(ctx context.Context, wallet *models.WatchlistWallet) error {
+	if s.lookbackDays() <= 0 {
+		return nil
+	}
+
+	tokens, err := s.watchlistRepo.GetTokensByUserID(ctx, wallet.UserID)
+	if err != nil {
+		return err
+	}
+
+	for _, token := range tokens {
+		if token.ChainID != wallet.ChainID {
+			continue
+		}
+		if err := s.enqueue(ctx, wallet, token); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// EnqueueToken schedules a backfill for a new token and each of the user's wallets on its chain
+func (s *backfillService) EnqueueToken(ctx context.Context, token *models.TrackedToken) error {
+	if s.lookbackDays() <= 0 {
+		return nil
+	}
+
+	wallets, err := s.watchlistRepo.GetWalletsByUserID(ctx, token.UserID)
+	if err != nil {
+		return err
+	}
+
+	for _, wallet := range wallets {
+		if wallet.ChainID != token.ChainID {
+			continue
+		}
+		if err := s.enqueue(ctx, wallet, token); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// enqueue creates a pending job for a wallet/token pair and wakes the worker
+func (s *backfillService) enqueue(ctx context.Context, wallet *models.WatchlistWallet, token *models.TrackedToken) error {
+	days := s.lookbackDays()
+	startDate, endDate := backfillRange(time.Now(), days)
+
+	job := &models.BackfillJob{
+		UserID:    wallet.UserID,
+		WalletID:  wallet.ID,
+		TokenID:   token.ID,
+		ChainID:   wallet.ChainID,
+		Status:    models.BackfillStatusPending,
+		StartDate: startDate,
+		EndDate:   endDate,
+		TotalDays: days,
+	}
+	if err := s.backfillRepo.Create(ctx, job); err != nil {
+		return fmt.Errorf("failed to create backfill job: %w", err)
+	}
+
+	select {
+	case s.wake <- struct{}{}:
+	default:
+		// The worker is already due to check the queue
+	}
+	return nil
+}
+
+// lookbackDays returns the configured lookback, kept inside the retention
+// window so backfilled snapshots are not removed by the next cleanup
+func (s *backfillService) lookbackDays() int {
+	days := s.config.Web3.BackfillDays
+	if retention := s.config.Web3.BalanceRetentionDays; retention > 0 && days >= retention {
+		days = retention - 1
+	}
+	return days
+}
+
+// GetJobs lists a user's backfill jobs, newest first
+func (s *backfillService) GetJobs(ctx context.Context, userID uint) ([]*BackfillJobResponse, error) {
+	jobs, err := s.backfillRepo.GetByUserID(ctx, userID)
+	if err != nil {
+		s.logger.Error("Failed to get backfill jobs", "error", err, "user_id", userID)
+		return nil, err
+	}
+
+	responses := make([]*BackfillJobResponse, len(jobs))
+	for i, job := range jobs {
+		responses[i] = backfillJobResponse(job)
+	}
+	return responses, nil
+}
+
+// GetJob returns a single backfill job owned by the user
+func (s *backfillService) GetJob(ctx context.Context, userID uint, jobID uint) (*BackfillJobResponse, error) {
+	job, err := s.backfillRepo.GetByID(ctx, jobID)
+	if err != nil {
+		if errors.Is(err, repository.ErrRecordNotFound) {
+			return nil, ErrBackfillJobNotFound
+		}
+		s.logger.Error("Failed to get backfill job", "error", err, "job_id", jobID)
+		return nil, err
+	}
+	if job.UserID != userID {
+		return nil, ErrBackfillJobNotFound
+	}
+	return backfillJobResponse(job), nil
+}
+
+// backfillJobResponse converts a job to its API representation
+func backfillJobResponse(job *models.BackfillJob) *BackfillJobResponse {
+	progress := new(big.Rat)
+	if job.TotalDays > 0 {
+		progress.SetFrac64(int64(job.CompletedDays)*100, int64(job.TotalDays))
+	}
+
+	return &BackfillJobResponse{
+		ID:            job.ID,
+		WalletID:      job.WalletID,
+		WalletAddress: job.Wallet.WalletAddress,
+		TokenID:       job.TokenID,
+		TokenSymbol:   job.Token.TokenSymbol,
+		ChainID:       job.ChainID,
+		Status:        job.Status,
+		StartDate:     job.StartDate,
+		EndDate:       job.EndDate,
+		TotalDays:     job.TotalDays,
+		CompletedDays: job.CompletedDays,
+		Progress:      units.FormatDecimal(progress, allocationDecimals),
+		LastError:     job.LastError,
+		StartedAt:     job.StartedAt,
+		CompletedAt:   job.CompletedAt,
+		CreatedAt:     job.CreatedAt,
+	}
+}
+
+// backfillRange returns the first and last day of a lookback ending
+// yesterday, as midnights UTC; today is covered by the regular fetcher
+func backfillRange(now time.Time, days int) (time.Time, time.Time) {
+	now = now.UTC()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	return today.AddDate(0, 0, -days), today.AddDate(0, 0, -1)
+}
+
+// backfillDates lists every midnight UTC from start to end inclusive
+func backfillDates(start, end time.Time) []time.Time {
+	var dates []time.Time
+	for date := start.UTC(); !date.After(end); date = date.AddDate(0, 0, 1) {
+		dates = append(dates, date)
+	}
+	return dates
+}
+
+// headerReader is the part of Web3Service needed to search blocks by time
+type headerReader interface {
+	HeaderByNumber(ctx context.Context, number *big.Int) (*BlockHeader, error)
+}
+
+// blockFinder finds the last block at or before a point in time. Lookups must
+// be made in ascending time order; each result narrows the next search.
+type blockFinder struct {
+	reader headerReader
+	low    *BlockHeader // at or before every remaining target
+	high   *BlockHeader // the latest block
+}
+
+// blockAt returns the last block with a timestamp at or before target. The
+// search alternates interpolation, which converges in a few steps on chains
+// with a steady block time, with bisection, which bounds the worst case.
+func (f *blockFinder) blockAt(ctx context.Context, target time.Time) (*BlockHeader, error) {
+	if target.Unix() < 0 || f.low.Time > uint64(target.Unix()) {
+		return nil, errNoBlockBefore
+	}
+	timestamp := uint64(target.Unix())
+	if f.high.Time <= timestamp {
+		return f.high, nil
+	}
+
+	low, high := f.low, f.high
+	for step := 0; new(big.Int).Sub(high.Number, low.Number).Cmp(big.NewInt(1)) > 0; step++ {
+		span := new(big.Int).Sub(high.Number, low.Number)
+
+		var offset *big.Int
+		if step%2 == 0 {
+			offset = new(big.Int).Mul(span, new(big.Int).SetUint64(timestamp-low.Time))
+			offset.Div(offset, new(big.Int).SetUint64(high.Time-low.Time))
+		} else {
+			offset = new(big.Int).Rsh(span, 1)
+		}
+
+		// Keep the probe strictly between the bounds so every step narrows the range
+		if offset.Sign() <= 0 {
+			offset.SetInt64(1)
+		}
+		if offset.Cmp(span) >= 0 {
+			offset.Sub(span, big.NewInt(1))
+		}
+
+		header, err := f.reader.HeaderByNumber(ctx, new(big.Int).Add(low.Number, offset))
+		if err != nil {
+			return nil, err
+		}
+		if header.Time <= timestamp {
+			low = header
+		} else {
+			high = header
+		}
+	}
+
+	f.low = low
+	return low, nil
+}
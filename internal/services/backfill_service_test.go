@@ -0,0 +1,142 @@
+package services
+
+import (
+	"context"
+	"math/big"
+	"testing"
+	"time"
+
+	"cryptoportfolio/internal/config"
+	"cryptoportfolio/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testChain serves headers of a synthetic chain and counts lookups
+type testChain struct {
+	times   []uint64
+	lookups int
+}
+
+func newTestChain(genesis uint64, blockTimes ...uint64) *testChain {
+	chain := &testChain{times: []uint64{genesis}}
+	for _, blockTime := range blockTimes {
+		chain.times = append(chain.times, chain.times[len(chain.times)-1]+blockTime)
+	}
+	return chain
+}
+
+func (c *testChain) HeaderByNumber(ctx context.Context, number *big.Int) (*BlockHeader, error) {
+	c.lookups++
+	if number == nil {
+		number = big.NewInt(int64(len(c.times) - 1))
+	}
+	return &BlockHeader{Number: new(big.Int).Set(number), Time: c.times[number.Int64()]}, nil
+}
+
+func (c *testChain) finder(t *testing.T) *blockFinder {
+	genesis, err := c.HeaderByNumber(context.Background(), big.NewInt(0))
+	require.NoError(t, err)
+	latest, err := c.HeaderByNumber(context.Background(), nil)
+	require.NoError(t, err)
+	c.lookups = 0
+	return &blockFinder{reader: c, low: genesis, high: latest}
+}
+
+// lastBlockAt is the reference answer: a linear scan for the last block at or before timestamp
+func (c *testChain) lastBlockAt(timestamp uint64) int64 {
+	number := int64(-1)
+	for i, blockTime := range c.times {
+		if blockTime <= timestamp {
+			number = int64(i)
+		}
+	}
+	return number
+}
+
+func TestBlockFinder_SteadyBlockTime(t *testing.T) {
+	blockTimes := make([]uint64, 100000)
+	for i := range blockTimes {
+		blockTimes[i] = 12
+	}
+	chain := newTestChain(1_600_000_000, blockTimes...)
+	finder := chain.finder(t)
+
+	for _, offset := range []uint64{0, 5, 12, 13, 86_400, 600_000, 1_199_999} {
+		timestamp := chain.times[0] + offset
+		header, err := finder.blockAt(context.Background(), time.Unix(int64(timestamp), 0))
+		require.NoError(t, err)
+		assert.Equal(t, chain.lastBlockAt(timestamp), header.Number.Int64(), "offset %d", offset)
+	}
+
+	// Interpolation finds blocks on a steady chain in a handful of lookups
+	assert.Less(t, chain.lookups, 30)
+}
+
+func TestBlockFinder_IrregularBlockTime(t *testing.T) {
+	// Slow early blocks followed by fast ones, as after a consensus change
+	var blockTimes []uint64
+	for i := 0; i < 2000; i++ {
+		blockTimes = append(blockTimes, 15+uint64(i%7))
+	}
+	for i := 0; i < 20000; i++ {
+		blockTimes = append(blockTimes, 2)
+	}
+	chain := newTestChain(1_500_000_000, blockTimes...)
+	finder := chain.finder(t)
+
+	last := chain.times[len(chain.times)-1]
+	for timestamp := chain.times[0]; timestamp <= last; timestamp += 3_607 {
+		header, err := finder.blockAt(context.Background(), time.Unix(int64(timestamp), 0))
+		require.NoError(t, err)
+		assert.Equal(t, chain.lastBlockAt(timestamp), header.Number.Int64(), "timestamp %d", timestamp)
+	}
+}
+
+func TestBlockFinder_OutOfRange(t *testing.T) {
+	chain := newTestChain(1_600_000_000, 12, 12, 12)
+	finder := chain.finder(t)
+
+	_, err := finder.blockAt(context.Background(), time.Unix(1_599_999_999, 0))
+	assert.ErrorIs(t, err, errNoBlockBefore)
+
+	// Targets after the latest block resolve to the latest block
+	header, err := finder.blockAt(context.Background(), time.Unix(1_700_000_000, 0))
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), header.Number.Int64())
+}
+
+func TestBackfillRange(t *testing.T) {
+	now := time.Date(2024, 3, 10, 15, 30, 0, 0, time.FixedZone("UTC+5", 5*3600))
+
+	start, end := backfillRange(now, 3)
+	assert.Equal(t, time.Date(2024, 3, 7, 0, 0, 0, 0, time.UTC), start)
+	assert.Equal(t, time.Date(2024, 3, 9, 0, 0, 0, 0, time.UTC), end)
+
+	dates := backfillDates(start, end)
+	assert.Equal(t, []time.Time{
+		time.Date(2024, 3, 7, 0, 0, 0, 0, time.UTC),
+		time.Date(2024, 3, 8, 0, 0, 0, 0, time.UTC),
+		time.Date(2024, 3, 9, 0, 0, 0, 0, time.UTC),
+	}, dates)
+}
+
+func TestBackfillLookbackDays(t *testing.T) {
+	service := &backfillService{config: &config.Config{Web3: config.Web3Config{BackfillDays: 90, BalanceRetentionDays: 30}}}
+	assert.Equal(t, 29, service.lookbackDays())
+
+	service.config.Web3.BackfillDays = 7
+	assert.Equal(t, 7, service.lookbackDays())
+
+	service.config.Web3.BackfillDays = 0
+	assert.Equal(t, 0, service.lookbackDays())
+}
+
+func TestBackfillJobResponse_Progress(t *testing.T) {
+	job := &models.BackfillJob{ID: 1, Status: models.BackfillStatusRunning, TotalDays: 30, CompletedDays: 10}
+	assert.Equal(t, "33.33", backfillJobResponse(job).Progress)
+
+	job.TotalDays = 0
+	assert.Equal(t, "0.00", backfillJobResponse(job).Progress)
+}
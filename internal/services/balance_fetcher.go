@@ -9,55 +9,101 @@ import (
 
 	"cryptoportfolio/internal/cache"
 	"cryptoportfolio/internal/config"
+	"cryptoportfolio/internal/metrics"
 	"cryptoportfolio/internal/models"
 	"cryptoportfolio/internal/repository"
+	"cryptoportfolio/internal/tracing"
 	"cryptoportfolio/pkg/logger"
+	"cryptoportfolio/pkg/units"
+
+	"github.com/ethereum/go-ethereum/common"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // BalanceFetcherService handles background balance fetching
 type BalanceFetcherService interface {
 	Start(ctx context.Context)
 	Stop()
-	FetchBalancesForUser(ctx context.Context, userID uint) error
+	FetchBalancesForUser(ctx context.Context, userID uint, progress FetchProgress) error
+	FetchPairs(ctx context.Context, pairs []BalancePair) error
+}
+
+// FetchProgress is told how many pairs were stored and how many failed after
+// each batch, out of the total; firstErr is the first failure, if any
+type FetchProgress func(completed, failed, total int, firstErr error)
+
+// BalancePair is a wallet and a token on the same chain whose balance is fetched
+type BalancePair struct {
+	Wallet *models.WatchlistWallet
+	Token  *models.TrackedToken
 }
 
+const (
+	// maxRunErrors bounds the pair errors stored with a run; FailedPairs still counts every failure
+	maxRunErrors = 500
+	// maxRunErrorLength matches the size of the error columns of a run
+	maxRunErrorLength = 500
+)
+
 // balanceFetcherService implements BalanceFetcherService
 type balanceFetcherService struct {
 	watchlistRepo repository.WatchlistRepository
-	web3Service    Web3Service
-	cacheService   cache.CacheProvider
-	logger         *logger.Logger
-	config         *config.Config
-	stopChan       chan struct{}
-	wg             sync.WaitGroup
+	runRepo       repository.FetchRunRepository
+	web3Registry  Web3Registry
+	priceService  PriceService
+	alertService  AlertService
+	events        EventPublisher
+	balanceHub    BalanceHub
+	leader        LeaderElector
+	scheduler     FetchScheduler
+	cacheService  cache.CacheProvider
+	logger        *logger.Logger
+	config        *config.Config
+	stopChan      chan struct{}
+	wg            sync.WaitGroup
 }
 
 // NewBalanceFetcherService creates a new balance fetcher service
 func NewBalanceFetcherService(
 	watchlistRepo repository.WatchlistRepository,
-	web3Service Web3Service,
+	runRepo repository.FetchRunRepository,
+	web3Registry Web3Registry,
+	priceService PriceService,
+	alertService AlertService,
+	events EventPublisher,
+	balanceHub BalanceHub,
+	leader LeaderElector,
+	scheduler FetchScheduler,
 	cacheService cache.CacheProvider,
 	logger *logger.Logger,
 	config *config.Config,
 ) BalanceFetcherService {
 	return &balanceFetcherService{
 		watchlistRepo: watchlistRepo,
-		web3Service:    web3Service,
-		cacheService:   cacheService,
-		logger:         logger,
-		config:         config,
-		stopChan:       make(chan struct{}),
+		runRepo:       runRepo,
+		web3Registry:  web3Registry,
+		priceService:  priceService,
+		alertService:  alertService,
+		events:        events,
+		balanceHub:    balanceHub,
+		leader:        leader,
+		scheduler:     scheduler,
+		cacheService:  cacheService,
+		logger:        logger,
+		config:        config,
+		stopChan:      make(chan struct{}),
 	}
 }
 
 // Start begins the background balance fetching process
 func (bfs *balanceFetcherService) Start(ctx context.Context) {
 	bfs.logger.Info("Starting background balance fetcher")
-	
+
 	// Start the main balance fetching goroutine
 	bfs.wg.Add(1)
 	go bfs.runBalanceFetcher(ctx)
-	
+
 	// Start the cleanup goroutine
 	bfs.wg.Add(1)
 	go bfs.runCleanup(ctx)
@@ -71,22 +117,30 @@ func (bfs *balanceFetcherService) Stop() {
 	bfs.logger.Info("Background balance fetcher stopped")
 }
 
-// runBalanceFetcher runs the main balance fetching loop
+// runBalanceFetcher runs the main balance fetching loop. With a scheduler it
+// wakes every SCHEDULE_TICK seconds and fetches only the pairs that are due.
 func (bfs *balanceFetcherService) runBalanceFetcher(ctx context.Context) {
 	defer bfs.wg.Done()
-	
-	ticker := time.NewTicker(time.Duration(bfs.config.Web3.FetchInterval) * time.Minute)
+
+	interval := time.Duration(bfs.config.Web3.FetchInterval) * time.Minute
+	if bfs.scheduler != nil {
+		interval = time.Duration(bfs.config.Schedule.Tick) * time.Second
+		if interval <= 0 {
+			interval = 15 * time.Second
+		}
+	}
+	ticker := time.NewTicker(interval)
 	defer ticker.Stop()
-	
+
 	// Fetch immediately on startup
-	if err := bfs.fetchAllBalances(ctx); err != nil {
+	if err := bfs.fetchIfLeader(ctx); err != nil {
 		bfs.logger.Error("Failed to fetch initial balances", "error", err)
 	}
-	
+
 	for {
 		select {
 		case <-ticker.C:
-			if err := bfs.fetchAllBalances(ctx); err != nil {
+			if err := bfs.fetchIfLeader(ctx); err != nil {
 				bfs.logger.Error("Failed to fetch balances", "error", err)
 			}
 		case <-bfs.stopChan:
@@ -100,19 +154,14 @@ func (bfs *balanceFetcherService) runBalanceFetcher(ctx context.Context) {
 // runCleanup runs the cleanup process for old balance records
 func (bfs *balanceFetcherService) runCleanup(ctx context.Context) {
 	defer bfs.wg.Done()
-	
+
 	ticker := time.NewTicker(24 * time.Hour) // Cleanup daily
 	defer ticker.Stop()
-	
+
 	for {
 		select {
 		case <-ticker.C:
-			// Delete balances older than 30 days
-			if err := bfs.watchlistRepo.DeleteOldBalances(ctx, 30*24*time.Hour); err != nil {
-				bfs.logger.Error("Failed to cleanup old balances", "error", err)
-			} else {
-				bfs.logger.Info("Cleaned up old balance records")
-			}
+			bfs.cleanupIfLeader(ctx)
 		case <-bfs.stopChan:
 			return
 		case <-ctx.Done():
@@ -121,320 +170,822 @@ func (bfs *balanceFetcherService) runCleanup(ctx context.Context) {
 	}
 }
 
-// fetchAllBalances fetches balances for all users
+// fetchIfLeader runs a fetch cycle unless another instance leads. Losing the
+// lease mid-cycle cancels the cycle, so two instances never fetch at once.
+func (bfs *balanceFetcherService) fetchIfLeader(ctx context.Context) error {
+	cycleCtx, done, ok := leaderContext(ctx, bfs.leader)
+	if !ok {
+		bfs.logger.Debug("Skipping balance fetch cycle, another instance is leader")
+		// Staleness is reported by the instance that fetches
+		metrics.PairStaleness.Reset()
+		return nil
+	}
+	defer done()
+
+	return bfs.fetchAllBalances(cycleCtx)
+}
+
+// cleanupIfLeader deletes balances and fetch runs older than their retention
+// windows unless another instance leads
+func (bfs *balanceFetcherService) cleanupIfLeader(ctx context.Context) {
+	cleanupCtx, done, ok := leaderContext(ctx, bfs.leader)
+	if !ok {
+		return
+	}
+	defer done()
+
+	retention := time.Duration(bfs.config.Web3.BalanceRetentionDays) * 24 * time.Hour
+	if err := bfs.watchlistRepo.DeleteOldBalances(cleanupCtx, retention); err != nil {
+		bfs.logger.Error("Failed to cleanup old balances", "error", err)
+	} else {
+		bfs.logger.Info("Cleaned up old balance records")
+	}
+
+	if bfs.runRepo != nil && bfs.config.Web3.FetchRunRetentionDays > 0 {
+		before := time.Now().AddDate(0, 0, -bfs.config.Web3.FetchRunRetentionDays)
+		if err := bfs.runRepo.DeleteOlderThan(cleanupCtx, before); err != nil {
+			bfs.logger.Error("Failed to cleanup old fetch runs", "error", err)
+		}
+	}
+}
+
+// fetchAllBalances fetches the balances of all users' pairs that are due
 func (bfs *balanceFetcherService) fetchAllBalances(ctx context.Context) error {
 	// Create a context with timeout for the entire operation
 	fetchCtx, cancel := context.WithTimeout(ctx, 10*time.Minute)
 	defer cancel()
-	
+
+	// Each cycle is its own trace
+	fetchCtx, span := startFetchSpan(fetchCtx, models.FetchRunTriggerSchedule)
+	defer span.End()
+
 	// Get all wallets and tokens from the database
 	wallets, err := bfs.watchlistRepo.GetAllWallets(fetchCtx)
 	if err != nil {
 		return fmt.Errorf("failed to get wallets: %w", err)
 	}
-	
+
 	tokens, err := bfs.watchlistRepo.GetAllTokens(fetchCtx)
 	if err != nil {
 		return fmt.Errorf("failed to get tokens: %w", err)
 	}
-	
-	bfs.logger.Infof("Starting balance fetch cycle - wallets: %d, tokens: %d", len(wallets), len(tokens))
-	
+
 	if len(wallets) == 0 || len(tokens) == 0 {
-		bfs.logger.Info("No wallets or tokens to fetch balances for")
+		metrics.PairStaleness.Reset()
+		bfs.logger.Debug("No wallets or tokens to fetch balances for")
+		return nil
+	}
+
+	tasks := bfs.dueTasks(fetchCtx, bfs.buildTasks(wallets, tokens))
+	if len(tasks) == 0 {
+		bfs.logger.Debug("No balances due for fetching")
 		return nil
 	}
-	
-	// Use a worker pool to fetch balances concurrently
+	bfs.logger.Infof("Starting balance fetch cycle - wallets: %d, tokens: %d, due pairs: %d", len(wallets), len(tokens), len(tasks))
+	span.SetAttributes(attribute.Int("fetch.pairs", len(tasks)))
+	startedAt := time.Now()
+
+	// Group wallet/token pairs into per-chain batches that are read with one Multicall3 request each
+	batches := bfs.batchTasks(tasks)
+	queued := len(tasks)
+	metrics.FetchQueueDepth.Add(float64(queued))
+
+	// Read every chain at a single block, after correcting snapshots orphaned by a reorg
+	for chainID := range bfs.pinBatches(fetchCtx, batches) {
+		bfs.checkReorgs(fetchCtx, chainID)
+	}
+
+	// Use a worker pool to fetch batches concurrently
 	maxWorkers := bfs.config.Web3.MaxWorkers
-	taskChan := make(chan fetchTask, 100)
+	taskChan := make(chan fetchBatch, len(batches))
 	resultChan := make(chan fetchResult, 100)
-	
+
 	// Start workers
 	var wg sync.WaitGroup
 	for i := 0; i < maxWorkers; i++ {
 		wg.Add(1)
 		go bfs.balanceWorker(fetchCtx, i, taskChan, resultChan, &wg)
 	}
-	
-	// Send tasks to workers
+
+	// Send batches to workers
 	go func() {
 		defer close(taskChan)
-		
-		for _, wallet := range wallets {
-			for _, token := range tokens {
-				// Only fetch if wallet and token belong to the same user
-				if wallet.UserID == token.UserID {
-					task := fetchTask{
-						walletAddress: wallet.WalletAddress,
-						tokenAddress:  token.TokenAddress,
-					}
-					
-					select {
-					case taskChan <- task:
-					case <-fetchCtx.Done():
-						return
-					}
-				}
+
+		for _, batch := range batches {
+			select {
+			case taskChan <- batch:
+			case <-fetchCtx.Done():
+				return
 			}
 		}
 	}()
-	
+
 	// Wait for all workers to complete
 	go func() {
 		wg.Wait()
 		close(resultChan)
 	}()
-	
+
 	// Collect results and store balances
 	successCount := 0
 	errorCount := 0
-	
+	var failed []fetchResult
+	var outcomes []FetchOutcome
+
 	for result := range resultChan {
 		if result.err != nil {
 			errorCount++
-			bfs.logger.Error("Failed to fetch balance", 
-				"wallet", result.walletAddress, 
-				"token", result.tokenAddress, 
+			failed = append(failed, result)
+			bfs.logger.Error("Failed to fetch balance",
+				"chain_id", result.wallet.ChainID,
+				"wallet", result.wallet.WalletAddress,
+				"token", result.token.TokenSymbol,
 				"error", result.err)
 		} else {
 			// Store the balance in the database
 			if err := bfs.storeBalance(fetchCtx, result); err != nil {
 				errorCount++
-				bfs.logger.Error("Failed to store balance", 
-					"wallet", result.walletAddress, 
-					"token", result.tokenAddress, 
+				result.err = err
+				failed = append(failed, result)
+				bfs.logger.Error("Failed to store balance",
+					"chain_id", result.wallet.ChainID,
+					"wallet", result.wallet.WalletAddress,
+					"token", result.token.TokenSymbol,
 					"error", err)
 			} else {
 				successCount++
-				bfs.logger.Debug("Successfully fetched and stored balance", 
-					"wallet", result.walletAddress, 
-					"token", result.tokenAddress, 
+				bfs.logger.Debug("Successfully fetched and stored balance",
+					"chain_id", result.wallet.ChainID,
+					"wallet", result.wallet.WalletAddress,
+					"token", result.token.TokenSymbol,
 					"balance", result.balance)
 			}
 		}
+		outcomes = append(outcomes, result.outcome())
+		queued--
+		metrics.FetchQueueDepth.Dec()
 	}
-	
+	// Pairs left unread by a cancelled cycle leave the queue too
+	metrics.FetchQueueDepth.Sub(float64(queued))
+
 	bfs.logger.Infof("Balance fetch cycle completed - successes: %d, errors: %d", successCount, errorCount)
-	
+	bfs.publishFetchFailures(fetchCtx, failed)
+	bfs.reschedule(fetchCtx, outcomes)
+	bfs.recordRun(fetchCtx, models.FetchRunTriggerSchedule, startedAt, batches, outcomes)
+
 	return nil
 }
 
-// fetchTask represents a balance fetching task
+// fetchTask represents a balance fetching task for a wallet/token pair on the same chain
 type fetchTask struct {
-	walletAddress string
-	tokenAddress  *string // nil for ETH
+	wallet *models.WatchlistWallet
+	token  *models.TrackedToken // TokenAddress is nil for the native token
 }
 
 // fetchResult represents the result of a balance fetch
 type fetchResult struct {
-	walletAddress string
-	tokenAddress  *string
-	balance       *big.Int
-	err           error
+	wallet  *models.WatchlistWallet
+	token   *models.TrackedToken
+	balance *big.Int
+	block   *BlockHeader // block the balance was read at
+	err     error
+}
+
+// fetchBatch is a group of tasks on one chain that are read together
+type fetchBatch struct {
+	chainID int64
+	tasks   []fetchTask
+	block   *BlockHeader // block all reads of the batch are made at
+	err     error        // set when no block could be pinned for the chain
+}
+
+// outcome converts a result to the outcome the scheduler reschedules the pair by
+func (r fetchResult) outcome() FetchOutcome {
+	return FetchOutcome{
+		Pair:    BalancePair{Wallet: r.wallet, Token: r.token},
+		Balance: r.balance,
+		Err:     r.err,
+	}
+}
+
+// buildBatches pairs each wallet with the tokens of the same user and chain,
+// and splits the pairs of each chain into Multicall3-sized batches
+func (bfs *balanceFetcherService) buildBatches(wallets []*models.WatchlistWallet, tokens []*models.TrackedToken) []fetchBatch {
+	return bfs.batchTasks(bfs.buildTasks(wallets, tokens))
 }
 
-// balanceWorker processes balance fetching tasks
+// buildTasks pairs each wallet with the tokens of the same user and chain
+func (bfs *balanceFetcherService) buildTasks(wallets []*models.WatchlistWallet, tokens []*models.TrackedToken) []fetchTask {
+	var tasks []fetchTask
+	for _, wallet := range wallets {
+		for _, token := range tokens {
+			// Only fetch if wallet and token belong to the same user and chain
+			if wallet.UserID != token.UserID || wallet.ChainID != token.ChainID {
+				continue
+			}
+			tasks = append(tasks, fetchTask{
+				wallet: wallet,
+				token:  token,
+			})
+		}
+	}
+
+	return tasks
+}
+
+// dueTasks keeps the tasks whose pair is due according to the scheduler. All
+// tasks are due without a scheduler, or when the schedule cannot be read.
+func (bfs *balanceFetcherService) dueTasks(ctx context.Context, tasks []fetchTask) []fetchTask {
+	if bfs.scheduler == nil {
+		return tasks
+	}
+
+	pairs := make([]BalancePair, len(tasks))
+	for i, task := range tasks {
+		pairs[i] = BalancePair{Wallet: task.wallet, Token: task.token}
+	}
+	due, err := bfs.scheduler.Due(ctx, pairs)
+	if err != nil {
+		bfs.logger.Error("Failed to read fetch schedules, fetching all pairs", "error", err)
+		return tasks
+	}
+
+	dueTasks := make([]fetchTask, len(due))
+	for i, pair := range due {
+		dueTasks[i] = fetchTask{wallet: pair.Wallet, token: pair.Token}
+	}
+	return dueTasks
+}
+
+// reschedule records fetch outcomes with the scheduler; a failure only
+// affects when the pairs are fetched next, so it is logged
+func (bfs *balanceFetcherService) reschedule(ctx context.Context, outcomes []FetchOutcome) {
+	if bfs.scheduler == nil {
+		return
+	}
+	if err := bfs.scheduler.Record(ctx, outcomes); err != nil {
+		bfs.logger.Error("Failed to update fetch schedules", "error", err)
+	}
+}
+
+// batchTasks splits tasks into per-chain, Multicall3-sized batches
+func (bfs *balanceFetcherService) batchTasks(tasks []fetchTask) []fetchBatch {
+	batchSize := bfs.config.Web3.MulticallBatchSize
+	if batchSize <= 0 {
+		batchSize = defaultMulticallBatchSize
+	}
+
+	var chainOrder []int64
+	tasksByChain := make(map[int64][]fetchTask)
+	for _, task := range tasks {
+		chainID := task.wallet.ChainID
+		if _, seen := tasksByChain[chainID]; !seen {
+			chainOrder = append(chainOrder, chainID)
+		}
+		tasksByChain[chainID] = append(tasksByChain[chainID], task)
+	}
+
+	var batches []fetchBatch
+	for _, chainID := range chainOrder {
+		tasks := tasksByChain[chainID]
+		for start := 0; start < len(tasks); start += batchSize {
+			end := start + batchSize
+			if end > len(tasks) {
+				end = len(tasks)
+			}
+			batches = append(batches, fetchBatch{chainID: chainID, tasks: tasks[start:end]})
+		}
+	}
+
+	return batches
+}
+
+// pinBatches pins every batch to one block per chain, WEB3_CONFIRMATIONS
+// behind the head, so all reads of a cycle on a chain see the same state. It
+// returns the blocks of the chains that could be pinned.
+func (bfs *balanceFetcherService) pinBatches(ctx context.Context, batches []fetchBatch) map[int64]*BlockHeader {
+	blocks := make(map[int64]*BlockHeader)
+	errs := make(map[int64]error)
+	for i := range batches {
+		chainID := batches[i].chainID
+		if _, pinned := blocks[chainID]; !pinned && errs[chainID] == nil {
+			block, err := bfs.pinBlock(ctx, chainID)
+			if err != nil {
+				bfs.logger.Error("Failed to pin block", "chain_id", chainID, "error", err)
+				errs[chainID] = fmt.Errorf("failed to pin block: %w", err)
+			} else {
+				blocks[chainID] = block
+			}
+		}
+		batches[i].block = blocks[chainID]
+		batches[i].err = errs[chainID]
+	}
+	return blocks
+}
+
+// pinBlock returns the block a fetch cycle on the chain reads at
+func (bfs *balanceFetcherService) pinBlock(ctx context.Context, chainID int64) (*BlockHeader, error) {
+	web3Service, err := bfs.web3Registry.Get(chainID)
+	if err != nil {
+		return nil, err
+	}
+
+	head, err := web3Service.HeaderByNumber(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	confirmations := bfs.config.Web3.Confirmations
+	if confirmations <= 0 {
+		return head, nil
+	}
+
+	number := new(big.Int).Sub(head.Number, big.NewInt(int64(confirmations)))
+	if number.Sign() < 0 {
+		number.SetInt64(0)
+	}
+	return web3Service.HeaderByNumber(ctx, number)
+}
+
+// balanceWorker processes balance fetching batches
 func (bfs *balanceFetcherService) balanceWorker(
 	ctx context.Context,
 	_ int, // workerID - unused but kept for future use
-	taskChan <-chan fetchTask,
+	taskChan <-chan fetchBatch,
 	resultChan chan<- fetchResult,
 	wg *sync.WaitGroup,
 ) {
 	defer wg.Done()
-	
-	for task := range taskChan {
+
+	for batch := range taskChan {
 		select {
 		case <-ctx.Done():
 			return
 		default:
 		}
-		
-		var balance *big.Int
-		var err error
-		
-		// Fetch balance based on token type
-		if task.tokenAddress == nil {
-			// Fetch ETH balance
-			balance, err = bfs.web3Service.GetETHBalance(ctx, task.walletAddress)
-		} else {
-			// Fetch token balance
-			balance, err = bfs.web3Service.GetTokenBalance(ctx, *task.tokenAddress, task.walletAddress)
+
+		for _, result := range bfs.fetchBatch(ctx, batch) {
+			resultChan <- result
 		}
-		
-		resultChan <- fetchResult{
-			walletAddress: task.walletAddress,
-			tokenAddress:  task.tokenAddress,
-			balance:       balance,
-			err:           err,
+	}
+}
+
+// fetchBatch reads all balances of a batch from its chain in one batched
+// request, at the batch's pinned block
+func (bfs *balanceFetcherService) fetchBatch(ctx context.Context, batch fetchBatch) []fetchResult {
+	results := make([]fetchResult, len(batch.tasks))
+	queries := make([]BalanceQuery, len(batch.tasks))
+	for i, task := range batch.tasks {
+		results[i] = fetchResult{wallet: task.wallet, token: task.token, block: batch.block}
+		queries[i] = BalanceQuery{
+			WalletAddress: task.wallet.WalletAddress,
+			TokenAddress:  task.token.TokenAddress,
 		}
-		
-		// Small delay to avoid overwhelming the RPC
-		time.Sleep(100 * time.Millisecond)
 	}
+
+	err := batch.err
+	var web3Service Web3Service
+	if err == nil {
+		web3Service, err = bfs.web3Registry.Get(batch.chainID)
+	}
+	if err == nil {
+		var blockHash *common.Hash
+		if batch.block != nil {
+			blockHash = &batch.block.Hash
+		}
+		var balances []BalanceResult
+		balances, err = web3Service.GetBalancesBatch(ctx, queries, blockHash)
+		if err == nil {
+			for i, balance := range balances {
+				results[i].balance = balance.Balance
+				results[i].err = balance.Err
+			}
+			return results
+		}
+	}
+
+	for i := range results {
+		results[i].err = err
+	}
+	return results
 }
 
-// FetchBalancesForUser fetches balances for a specific user
-func (bfs *balanceFetcherService) FetchBalancesForUser(ctx context.Context, userID uint) error {
+// FetchBalancesForUser fetches balances for a specific user, reporting
+// progress after each batch when progress is not nil
+func (bfs *balanceFetcherService) FetchBalancesForUser(ctx context.Context, userID uint, progress FetchProgress) error {
 	// Get user's wallets
 	wallets, err := bfs.watchlistRepo.GetWalletsByUserID(ctx, userID)
 	if err != nil {
 		return fmt.Errorf("failed to get user wallets: %w", err)
 	}
-	
+
 	// Get user's tracked tokens
 	tokens, err := bfs.watchlistRepo.GetTokensByUserID(ctx, userID)
 	if err != nil {
 		return fmt.Errorf("failed to get user tokens: %w", err)
 	}
-	
+
 	// Create a context with timeout
 	fetchCtx, cancel := context.WithTimeout(ctx, 2*time.Minute)
 	defer cancel()
-	
-	// Fetch balances for each wallet-token combination
-	for _, wallet := range wallets {
-		for _, token := range tokens {
-			if err := bfs.fetchAndStoreBalance(fetchCtx, wallet, token); err != nil {
-				bfs.logger.Error("Failed to fetch balance", 
-					"wallet", wallet.WalletAddress, 
-					"token", token.TokenSymbol, 
-					"error", err)
-			}
-		}
-	}
-	
+
+	// Fetch balances for each wallet-token combination on the same chain
+	bfs.fetchAndStore(fetchCtx, models.FetchRunTriggerRefresh, bfs.buildBatches(wallets, tokens), progress)
+
 	// Invalidate cache for this user
 	cacheKey := fmt.Sprintf("user_balances:%d", userID)
 	bfs.cacheService.Delete(ctx, cacheKey)
-	
+
 	return nil
 }
 
-// fetchAndStoreBalance fetches and stores a single balance
-func (bfs *balanceFetcherService) fetchAndStoreBalance(
-	ctx context.Context,
-	wallet *models.WatchlistWallet,
-	token *models.TrackedToken,
-) error {
-	var balance *big.Int
-	var err error
-	
-	// Fetch balance
-	if token.TokenAddress == nil {
-		// ETH balance
-		balance, err = bfs.web3Service.GetETHBalance(ctx, wallet.WalletAddress)
-	} else {
-		// Token balance
-		balance, err = bfs.web3Service.GetTokenBalance(ctx, *token.TokenAddress, wallet.WalletAddress)
-	}
-	
-	if err != nil {
-		return err
+// FetchPairs fetches and stores the balances of specific wallet/token pairs,
+// e.g. the pairs touched by a Transfer event
+func (bfs *balanceFetcherService) FetchPairs(ctx context.Context, pairs []BalancePair) error {
+	tasks := make([]fetchTask, 0, len(pairs))
+	users := make(map[uint]bool)
+	for _, pair := range pairs {
+		if pair.Wallet.ChainID != pair.Token.ChainID {
+			continue
+		}
+		tasks = append(tasks, fetchTask{wallet: pair.Wallet, token: pair.Token})
+		users[pair.Wallet.UserID] = true
 	}
-	
-	// Create balance record
-	balanceRecord := &models.WalletBalance{
-		WalletID:  wallet.ID,
-		TokenID:   token.ID,
-		Balance:   balance.String(),
-		FetchedAt: time.Now(),
+
+	// Create a context with timeout
+	fetchCtx, cancel := context.WithTimeout(ctx, 2*time.Minute)
+	defer cancel()
+
+	bfs.fetchAndStore(fetchCtx, models.FetchRunTriggerTransfer, bfs.batchTasks(tasks), nil)
+
+	// Invalidate cache for the affected users
+	for userID := range users {
+		bfs.cacheService.Delete(ctx, fmt.Sprintf("user_balances:%d", userID))
 	}
-	
-	// Store in database
-	if err := bfs.watchlistRepo.CreateBalance(ctx, balanceRecord); err != nil {
-		return fmt.Errorf("failed to store balance: %w", err)
+
+	return nil
+}
+
+// fetchAndStore pins, reads and stores the given batches in the calling
+// goroutine, and records the run under the given trigger
+func (bfs *balanceFetcherService) fetchAndStore(ctx context.Context, trigger string, batches []fetchBatch, progress FetchProgress) {
+	startedAt := time.Now()
+	total := 0
+	for _, batch := range batches {
+		total += len(batch.tasks)
 	}
-	
-	// Cache the balance
-	cacheKey := fmt.Sprintf("balance:%d:%d", wallet.ID, token.ID)
-	cacheData := map[string]interface{}{
-		"balance":    balance.String(),
-		"fetched_at": time.Now().Unix(),
+	ctx, span := startFetchSpan(ctx, trigger)
+	defer span.End()
+	span.SetAttributes(attribute.Int("fetch.pairs", total))
+	if progress != nil {
+		progress(0, 0, total, nil)
 	}
-	
-	if err := bfs.cacheService.Set(ctx, cacheKey, cacheData, 10*time.Minute); err != nil {
-		bfs.logger.Warn("Failed to cache balance", "error", err)
+	queued := total
+	metrics.FetchQueueDepth.Add(float64(queued))
+
+	bfs.pinBatches(ctx, batches)
+	completed := 0
+	var failed []fetchResult
+	var outcomes []FetchOutcome
+	for _, batch := range batches {
+		for _, result := range bfs.fetchBatch(ctx, batch) {
+			if result.err == nil {
+				result.err = bfs.storeBalance(ctx, result)
+			}
+			outcomes = append(outcomes, result.outcome())
+			queued--
+			metrics.FetchQueueDepth.Dec()
+			if result.err != nil {
+				failed = append(failed, result)
+				bfs.logger.Error("Failed to fetch balance",
+					"chain_id", result.wallet.ChainID,
+					"wallet", result.wallet.WalletAddress,
+					"token", result.token.TokenSymbol,
+					"error", result.err)
+			} else {
+				completed++
+			}
+		}
+		if progress != nil {
+			var firstErr error
+			if len(failed) > 0 {
+				firstErr = failed[0].err
+			}
+			progress(completed, len(failed), total, firstErr)
+		}
 	}
-	
-	return nil
+	metrics.FetchQueueDepth.Sub(float64(queued))
+	bfs.publishFetchFailures(ctx, failed)
+	bfs.reschedule(ctx, outcomes)
+	bfs.recordRun(ctx, trigger, startedAt, batches, outcomes)
 }
 
-// storeBalance stores a fetched balance in the database
-func (bfs *balanceFetcherService) storeBalance(ctx context.Context, result fetchResult) error {
-	// Find the wallet and token by their addresses
-	wallets, err := bfs.watchlistRepo.GetAllWallets(ctx)
-	if err != nil {
-		return fmt.Errorf("failed to get wallets: %w", err)
+// recordRun stores the history of a fetch over the given batches: the block
+// read on each chain, the counts and the errors of the pairs that failed. A
+// run that ended before every pair was read is recorded as interrupted.
+func (bfs *balanceFetcherService) recordRun(ctx context.Context, trigger string, startedAt time.Time, batches []fetchBatch, outcomes []FetchOutcome) {
+	if len(batches) == 0 {
+		return
 	}
-	
-	tokens, err := bfs.watchlistRepo.GetAllTokens(ctx)
-	if err != nil {
-		return fmt.Errorf("failed to get tokens: %w", err)
+	completedAt := time.Now()
+	observeRun(trigger, completedAt.Sub(startedAt), outcomes)
+	if bfs.runRepo == nil {
+		return
 	}
-	
-	// Find the wallet
-	var wallet *models.WatchlistWallet
-	for _, w := range wallets {
-		if w.WalletAddress == result.walletAddress {
-			wallet = w
-			break
-		}
+
+	run := &models.FetchRun{
+		Trigger:     trigger,
+		Status:      models.FetchRunStatusCompleted,
+		StartedAt:   startedAt,
+		CompletedAt: completedAt,
 	}
-	
-	if wallet == nil {
-		return fmt.Errorf("wallet not found: %s", result.walletAddress)
-	}
-	
-	// Find the token
-	var token *models.TrackedToken
-	for _, t := range tokens {
-		if result.tokenAddress == nil {
-			// ETH balance - look for token with nil address
-			if t.TokenAddress == nil {
-				token = t
-				break
+
+	var chainOrder []int64
+	chains := make(map[int64]*models.FetchRunChain)
+	for _, batch := range batches {
+		chain, ok := chains[batch.chainID]
+		if !ok {
+			chain = &models.FetchRunChain{ChainID: batch.chainID}
+			if batch.block != nil {
+				blockNumber := batch.block.Number.Uint64()
+				blockHash := batch.block.Hash.Hex()
+				chain.BlockNumber = &blockNumber
+				chain.BlockHash = &blockHash
 			}
-		} else {
-			// Token balance - look for token with matching address
-			if t.TokenAddress != nil && *t.TokenAddress == *result.tokenAddress {
-				token = t
-				break
+			if batch.err != nil {
+				chain.Error = truncateString(batch.err.Error(), maxRunErrorLength)
 			}
+			chains[batch.chainID] = chain
+			chainOrder = append(chainOrder, batch.chainID)
+		}
+		chain.TotalPairs += len(batch.tasks)
+		run.TotalPairs += len(batch.tasks)
+	}
+
+	for _, outcome := range outcomes {
+		if outcome.Err == nil {
+			run.StoredPairs++
+			continue
+		}
+
+		wallet, token := outcome.Pair.Wallet, outcome.Pair.Token
+		run.FailedPairs++
+		if chain := chains[wallet.ChainID]; chain != nil {
+			chain.FailedPairs++
+		}
+		if len(run.Errors) < maxRunErrors {
+			run.Errors = append(run.Errors, models.FetchRunError{
+				UserID:        wallet.UserID,
+				WalletID:      wallet.ID,
+				WalletAddress: wallet.WalletAddress,
+				TokenID:       token.ID,
+				TokenSymbol:   token.TokenSymbol,
+				ChainID:       wallet.ChainID,
+				Error:         truncateString(outcome.Err.Error(), maxRunErrorLength),
+			})
 		}
 	}
-	
-	if token == nil {
-		if result.tokenAddress == nil {
-			return fmt.Errorf("ETH token not found in user's tracked tokens")
+	if run.StoredPairs+run.FailedPairs < run.TotalPairs {
+		run.Status = models.FetchRunStatusInterrupted
+	}
+	for _, chainID := range chainOrder {
+		run.Chains = append(run.Chains, *chains[chainID])
+	}
+
+	// An interrupted run is still recorded after its context is cancelled
+	if err := bfs.runRepo.Create(context.WithoutCancel(ctx), run); err != nil {
+		bfs.logger.Error("Failed to record fetch run", "trigger", trigger, "error", err)
+	}
+}
+
+// startFetchSpan starts the root span of a fetch. Refreshes and Transfer
+// fetches run in the background, apart from the request that caused them.
+func startFetchSpan(ctx context.Context, trigger string) (context.Context, trace.Span) {
+	return tracing.Tracer().Start(ctx, "BalanceFetcher.fetch "+trigger,
+		trace.WithNewRoot(),
+		trace.WithAttributes(attribute.String("fetch.trigger", trigger)),
+	)
+}
+
+// observeRun records a fetch's duration and pair results in the fetcher metrics
+func observeRun(trigger string, duration time.Duration, outcomes []FetchOutcome) {
+	metrics.FetchDuration.WithLabelValues(trigger).Observe(duration.Seconds())
+
+	stored := 0
+	for _, outcome := range outcomes {
+		if outcome.Err == nil {
+			stored++
 		}
-		return fmt.Errorf("token not found: %s", *result.tokenAddress)
 	}
-	
+	metrics.FetchedPairs.WithLabelValues(trigger, metrics.PairStored).Add(float64(stored))
+	metrics.FetchedPairs.WithLabelValues(trigger, metrics.PairFailed).Add(float64(len(outcomes) - stored))
+}
+
+// storeBalance stores a fetched balance in the database
+func (bfs *balanceFetcherService) storeBalance(ctx context.Context, result fetchResult) error {
+	// The previous snapshot is only read for users that receive balance change webhooks
+	previous, publishChange := bfs.previousBalance(ctx, result)
+
 	// Create balance record
 	balanceRecord := &models.WalletBalance{
-		WalletID:  wallet.ID,
-		TokenID:   token.ID,
+		WalletID:  result.wallet.ID,
+		TokenID:   result.token.ID,
 		Balance:   result.balance.String(),
 		FetchedAt: time.Now(),
 	}
-	
+	if result.block != nil {
+		blockNumber := result.block.Number.Uint64()
+		blockHash := result.block.Hash.Hex()
+		balanceRecord.BlockNumber = &blockNumber
+		balanceRecord.BlockHash = &blockHash
+	}
+
+	// Stamp the USD price and value at fetch time; a missing price does not block storing the balance
+	if price, value, err := bfs.priceBalance(ctx, result); err != nil {
+		bfs.logger.Warn("Failed to price balance",
+			"chain_id", result.wallet.ChainID,
+			"token", result.token.TokenSymbol,
+			"error", err)
+	} else {
+		balanceRecord.PriceUSD = &price
+		balanceRecord.BalanceUSD = &value
+	}
+
 	// Store in database
 	if err := bfs.watchlistRepo.CreateBalance(ctx, balanceRecord); err != nil {
 		return fmt.Errorf("failed to store balance: %w", err)
 	}
-	
-	// Cache the balance
-	cacheKey := fmt.Sprintf("balance:%d:%d", wallet.ID, token.ID)
+
+	if publishChange && (previous == nil || previous.Balance != balanceRecord.Balance) {
+		bfs.publishBalanceChange(ctx, result, previous, balanceRecord)
+	}
+
+	// Check alert rules against the new snapshot; a failed check does not fail the fetch
+	if bfs.alertService != nil {
+		if err := bfs.alertService.Evaluate(ctx, result.wallet, result.token, balanceRecord); err != nil {
+			bfs.logger.Warn("Failed to evaluate alert rules",
+				"wallet", result.wallet.WalletAddress,
+				"token", result.token.TokenSymbol,
+				"error", err)
+		}
+	}
+
+	// Cache the balance, streaming it to the user's dashboards when it changed
+	cacheKey := fmt.Sprintf("balance:%d:%d", result.wallet.ID, result.token.ID)
+	bfs.streamBalance(ctx, cacheKey, result, balanceRecord)
+
 	cacheData := map[string]interface{}{
-		"balance":    result.balance.String(),
-		"fetched_at": time.Now().Unix(),
+		"balance":      result.balance.String(),
+		"balance_usd":  balanceRecord.BalanceUSD,
+		"block_number": balanceRecord.BlockNumber,
+		"fetched_at":   time.Now().Unix(),
 	}
-	
+
 	if err := bfs.cacheService.Set(ctx, cacheKey, cacheData, 10*time.Minute); err != nil {
 		bfs.logger.Warn("Failed to cache balance", "error", err)
 	}
-	
+
 	return nil
-}
\ No newline at end of file
+}
+
+// cachedBalance is the part of a cached balance entry compared to detect changes
+type cachedBalance struct {
+	Balance    string  `json:"balance"`
+	BalanceUSD *string `json:"balance_usd"`
+}
+
+// streamBalance pushes a stored balance to the owner's live streams when it
+// differs from the cached one, and drops the owner's stale balance list
+func (bfs *balanceFetcherService) streamBalance(ctx context.Context, cacheKey string, result fetchResult, balance *models.WalletBalance) {
+	if bfs.balanceHub == nil {
+		return
+	}
+
+	var cached cachedBalance
+	if err := bfs.cacheService.Get(ctx, cacheKey, &cached); err == nil &&
+		cached.Balance == balance.Balance && equalOptionalStrings(cached.BalanceUSD, balance.BalanceUSD) {
+		return
+	}
+
+	formatted := ""
+	if decimals, err := tokenDecimals(ctx, bfs.web3Registry, bfs.cacheService, result.token); err == nil {
+		formatted = units.FormatUnits(result.balance, decimals)
+	}
+
+	bfs.balanceHub.Publish(ctx, result.wallet.UserID, &BalanceResponse{
+		WalletID:         result.wallet.ID,
+		WalletAddress:    result.wallet.WalletAddress,
+		ChainID:          result.wallet.ChainID,
+		TokenID:          result.token.ID,
+		TokenSymbol:      result.token.TokenSymbol,
+		Balance:          balance.Balance,
+		FormattedBalance: formatted,
+		BalanceUSD:       balance.BalanceUSD,
+		PriceUSD:         balance.PriceUSD,
+		BlockNumber:      balance.BlockNumber,
+		FetchedAt:        balance.FetchedAt,
+	})
+	bfs.cacheService.Delete(ctx, fmt.Sprintf("user_balances:%d", result.wallet.UserID))
+}
+
+// equalOptionalStrings reports whether two optional strings are both unset or equal
+func equalOptionalStrings(a, b *string) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return *a == *b
+}
+
+// previousBalance returns the pair's latest stored snapshot when the wallet's
+// owner subscribes to balance change events, and whether to publish them
+func (bfs *balanceFetcherService) previousBalance(ctx context.Context, result fetchResult) (*models.WalletBalance, bool) {
+	if bfs.events == nil || !bfs.events.Subscribed(ctx, result.wallet.UserID, models.WebhookEventBalanceChanged) {
+		return nil, false
+	}
+
+	history, err := bfs.watchlistRepo.GetBalanceHistory(ctx, result.wallet.ID, result.token.ID, 1)
+	if err != nil {
+		bfs.logger.Warn("Failed to get previous balance", "wallet", result.wallet.WalletAddress, "token", result.token.TokenSymbol, "error", err)
+		return nil, false
+	}
+	if len(history) == 0 {
+		return nil, true
+	}
+	return history[0], true
+}
+
+// publishBalanceChange queues a balance.changed event; a failure does not fail the fetch
+func (bfs *balanceFetcherService) publishBalanceChange(ctx context.Context, result fetchResult, previous, balance *models.WalletBalance) {
+	event := BalanceChangedEvent{
+		WalletID:      result.wallet.ID,
+		WalletAddress: result.wallet.WalletAddress,
+		ChainID:       result.wallet.ChainID,
+		TokenID:       result.token.ID,
+		TokenAddress:  result.token.TokenAddress,
+		TokenSymbol:   result.token.TokenSymbol,
+		Balance:       balance.Balance,
+		BalanceUSD:    balance.BalanceUSD,
+		BlockNumber:   balance.BlockNumber,
+		FetchedAt:     balance.FetchedAt,
+	}
+	if previous != nil {
+		event.PreviousBalance = &previous.Balance
+	}
+
+	if err := bfs.events.Publish(ctx, result.wallet.UserID, models.WebhookEventBalanceChanged, event); err != nil {
+		bfs.logger.Warn("Failed to publish balance change", "wallet", result.wallet.WalletAddress, "token", result.token.TokenSymbol, "error", err)
+	}
+}
+
+// publishFetchFailures queues one fetch.failed event per user with failed pairs
+func (bfs *balanceFetcherService) publishFetchFailures(ctx context.Context, failed []fetchResult) {
+	if bfs.events == nil || len(failed) == 0 {
+		return
+	}
+
+	byUser := make(map[uint][]FetchFailure)
+	var userIDs []uint
+	for _, result := range failed {
+		userID := result.wallet.UserID
+		if _, ok := byUser[userID]; !ok {
+			userIDs = append(userIDs, userID)
+		}
+		byUser[userID] = append(byUser[userID], FetchFailure{
+			WalletID:      result.wallet.ID,
+			WalletAddress: result.wallet.WalletAddress,
+			ChainID:       result.wallet.ChainID,
+			TokenID:       result.token.ID,
+			TokenSymbol:   result.token.TokenSymbol,
+			Error:         result.err.Error(),
+		})
+	}
+
+	for _, userID := range userIDs {
+		if !bfs.events.Subscribed(ctx, userID, models.WebhookEventFetchFailed) {
+			continue
+		}
+		if err := bfs.events.Publish(ctx, userID, models.WebhookEventFetchFailed, FetchFailedEvent{Failures: byUser[userID]}); err != nil {
+			bfs.logger.Warn("Failed to publish fetch failures", "user_id", userID, "error", err)
+		}
+	}
+}
+
+// priceBalance returns the token's USD price and the balance's USD value, formatted for storage
+func (bfs *balanceFetcherService) priceBalance(ctx context.Context, result fetchResult) (string, string, error) {
+	if bfs.priceService == nil {
+		return "", "", ErrPriceUnavailable
+	}
+
+	price, err := bfs.priceService.GetPriceUSD(ctx, result.token.ChainID, result.token.TokenAddress)
+	if err != nil {
+		return "", "", err
+	}
+
+	decimals, err := tokenDecimals(ctx, bfs.web3Registry, bfs.cacheService, result.token)
+	if err != nil {
+		return "", "", err
+	}
+
+	value := usdValue(result.balance, decimals, price)
+	return units.FormatDecimal(price, priceDecimals), units.FormatDecimal(value, valueDecimals), nil
+}
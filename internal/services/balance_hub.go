@@ -0,0 +1,206 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"cryptoportfolio/internal/cache"
+	"cryptoportfolio/internal/config"
+	"cryptoportfolio/pkg/logger"
+)
+
+const (
+	// balanceStreamChannel is the Redis channel balance updates are relayed through
+	balanceStreamChannel = "balance_stream"
+	// balanceHubResubscribeDelay is the wait before retrying a failed Redis subscription
+	balanceHubResubscribeDelay = 5 * time.Second
+)
+
+// BalanceHub fans newly stored balances out to the live streams of their owners
+type BalanceHub interface {
+	Start(ctx context.Context)
+	Stop()
+	// Subscribe registers a stream for the user's balance updates. The channel is
+	// closed when the subscriber falls too far behind or the hub stops; the
+	// returned function unsubscribes and must be called once the stream ends.
+	Subscribe(userID uint) (<-chan *BalanceResponse, func())
+	Publish(ctx context.Context, userID uint, balance *BalanceResponse)
+}
+
+// balanceHubMessage is a balance update relayed between server instances
+type balanceHubMessage struct {
+	UserID  uint             `json:"user_id"`
+	Balance *BalanceResponse `json:"balance"`
+}
+
+// balanceSubscriber is one open stream
+type balanceSubscriber struct {
+	updates chan *BalanceResponse
+	closed  bool
+}
+
+// balanceHub implements BalanceHub
+type balanceHub struct {
+	pubsub      cache.PubSubProvider // nil when updates stay within this instance
+	logger      *logger.Logger
+	bufferSize  int
+	mu          sync.Mutex
+	subscribers map[uint]map[*balanceSubscriber]struct{}
+	relaying    atomic.Bool // whether the Redis subscription is up
+	stopChan    chan struct{}
+	wg          sync.WaitGroup
+}
+
+// NewBalanceHub creates a new balance hub. With a pub/sub provider, published
+// balances are relayed through it so subscribers on every instance receive them.
+func NewBalanceHub(pubsub cache.PubSubProvider, logger *logger.Logger, config *config.Config) BalanceHub {
+	bufferSize := config.Stream.BufferSize
+	if bufferSize <= 0 {
+		bufferSize = 64
+	}
+	return &balanceHub{
+		pubsub:      pubsub,
+		logger:      logger,
+		bufferSize:  bufferSize,
+		subscribers: make(map[uint]map[*balanceSubscriber]struct{}),
+		stopChan:    make(chan struct{}),
+	}
+}
+
+// Start begins relaying balance updates from other instances
+func (h *balanceHub) Start(ctx context.Context) {
+	if h.pubsub == nil {
+		return
+	}
+
+	h.logger.Info("Starting balance stream relay")
+	h.wg.Add(1)
+	go h.relay(ctx)
+}
+
+// Stop stops the relay and closes every open stream
+func (h *balanceHub) Stop() {
+	h.logger.Info("Stopping balance stream hub")
+	close(h.stopChan)
+	h.wg.Wait()
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for userID, subscribers := range h.subscribers {
+		for subscriber := range subscribers {
+			h.closeSubscriber(subscriber)
+		}
+		delete(h.subscribers, userID)
+	}
+}
+
+// Subscribe registers a stream for the user's balance updates
+func (h *balanceHub) Subscribe(userID uint) (<-chan *BalanceResponse, func()) {
+	subscriber := &balanceSubscriber{updates: make(chan *BalanceResponse, h.bufferSize)}
+
+	h.mu.Lock()
+	if h.subscribers[userID] == nil {
+		h.subscribers[userID] = make(map[*balanceSubscriber]struct{})
+	}
+	h.subscribers[userID][subscriber] = struct{}{}
+	h.mu.Unlock()
+
+	var once sync.Once
+	unsubscribe := func() {
+		once.Do(func() {
+			h.mu.Lock()
+			defer h.mu.Unlock()
+			delete(h.subscribers[userID], subscriber)
+			if len(h.subscribers[userID]) == 0 {
+				delete(h.subscribers, userID)
+			}
+			h.closeSubscriber(subscriber)
+		})
+	}
+	return subscriber.updates, unsubscribe
+}
+
+// Publish sends a balance update to the user's streams. While the Redis relay
+// is up the update goes through Redis, and reaches this instance's streams the
+// same way as every other instance's; otherwise it is delivered locally.
+func (h *balanceHub) Publish(ctx context.Context, userID uint, balance *BalanceResponse) {
+	if h.pubsub != nil && h.relaying.Load() {
+		err := h.pubsub.Publish(ctx, balanceStreamChannel, balanceHubMessage{UserID: userID, Balance: balance})
+		if err == nil {
+			return
+		}
+		h.logger.Warn("Failed to relay balance update, delivering locally", "user_id", userID, "error", err)
+	}
+	h.deliver(userID, balance)
+}
+
+// deliver hands an update to the user's streams on this instance. A stream
+// whose buffer is full is closed rather than silently missing an update, so
+// the client reconnects and starts again from a fresh snapshot.
+func (h *balanceHub) deliver(userID uint, balance *BalanceResponse) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for subscriber := range h.subscribers[userID] {
+		select {
+		case subscriber.updates <- balance:
+		default:
+			h.logger.Warn("Balance stream subscriber fell behind, disconnecting", "user_id", userID)
+			delete(h.subscribers[userID], subscriber)
+			h.closeSubscriber(subscriber)
+		}
+	}
+	if len(h.subscribers[userID]) == 0 {
+		delete(h.subscribers, userID)
+	}
+}
+
+// closeSubscriber closes a subscriber's channel once; the caller holds mu
+func (h *balanceHub) closeSubscriber(subscriber *balanceSubscriber) {
+	if !subscriber.closed {
+		subscriber.closed = true
+		close(subscriber.updates)
+	}
+}
+
+// relay delivers updates received over Redis, resubscribing until stopped
+func (h *balanceHub) relay(ctx context.Context) {
+	defer h.wg.Done()
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go func() {
+		select {
+		case <-h.stopChan:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	for {
+		payloads, err := h.pubsub.Subscribe(ctx, balanceStreamChannel)
+		if err != nil {
+			h.logger.Warn("Failed to subscribe to balance updates, streaming locally only", "error", err)
+		} else {
+			h.relaying.Store(true)
+			for payload := range payloads {
+				var msg balanceHubMessage
+				if err := json.Unmarshal(payload, &msg); err != nil || msg.Balance == nil {
+					h.logger.Warn("Discarding malformed balance update", "error", err)
+					continue
+				}
+				h.deliver(msg.UserID, msg.Balance)
+			}
+			h.relaying.Store(false)
+		}
+
+		select {
+		case <-time.After(balanceHubResubscribeDelay):
+		case <-ctx.Done():
+			return
+		}
+	}
+}
@@ -0,0 +1,207 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"sync"
+	"testing"
+	"time"
+
+	"cryptoportfolio/internal/config"
+	"cryptoportfolio/internal/models"
+	"cryptoportfolio/pkg/logger"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakePubSub loops published messages back to its subscribers like a single Redis server
+type fakePubSub struct {
+	mu          sync.Mutex
+	subscribers []chan []byte
+	published   int
+	failPublish bool
+}
+
+func (p *fakePubSub) Publish(ctx context.Context, channel string, message interface{}) error {
+	if p.failPublish {
+		return errors.New("connection refused")
+	}
+	data, err := json.Marshal(message)
+	if err != nil {
+		return err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.published++
+	for _, subscriber := range p.subscribers {
+		subscriber <- data
+	}
+	return nil
+}
+
+func (p *fakePubSub) Subscribe(ctx context.Context, channel string) (<-chan []byte, error) {
+	payloads := make(chan []byte, 16)
+	p.mu.Lock()
+	p.subscribers = append(p.subscribers, payloads)
+	p.mu.Unlock()
+
+	// Like the Redis subscription, the channel is closed once ctx is done
+	go func() {
+		<-ctx.Done()
+		p.mu.Lock()
+		defer p.mu.Unlock()
+		for i, subscriber := range p.subscribers {
+			if subscriber == payloads {
+				p.subscribers = append(p.subscribers[:i], p.subscribers[i+1:]...)
+				break
+			}
+		}
+		close(payloads)
+	}()
+	return payloads, nil
+}
+
+func newTestBalanceHub(pubsub *fakePubSub, bufferSize int) *balanceHub {
+	cfg := &config.Config{Stream: config.StreamConfig{BufferSize: bufferSize}}
+	if pubsub == nil {
+		return NewBalanceHub(nil, logger.New(), cfg).(*balanceHub)
+	}
+	return NewBalanceHub(pubsub, logger.New(), cfg).(*balanceHub)
+}
+
+// receive waits briefly for the next update on a stream
+func receive(t *testing.T, updates <-chan *BalanceResponse) *BalanceResponse {
+	t.Helper()
+	select {
+	case balance := <-updates:
+		return balance
+	case <-time.After(time.Second):
+		t.Fatal("no balance update received")
+		return nil
+	}
+}
+
+func TestBalanceHub_DeliversToTheUsersStreams(t *testing.T) {
+	hub := newTestBalanceHub(nil, 4)
+	ctx := context.Background()
+
+	first, unsubscribeFirst := hub.Subscribe(1)
+	second, unsubscribeSecond := hub.Subscribe(1)
+	other, unsubscribeOther := hub.Subscribe(2)
+	defer unsubscribeSecond()
+	defer unsubscribeOther()
+
+	hub.Publish(ctx, 1, &BalanceResponse{WalletID: 10, Balance: "5"})
+
+	assert.Equal(t, "5", receive(t, first).Balance)
+	assert.Equal(t, "5", receive(t, second).Balance)
+	assert.Empty(t, other)
+
+	// An unsubscribed stream is closed and receives nothing further
+	unsubscribeFirst()
+	unsubscribeFirst()
+	hub.Publish(ctx, 1, &BalanceResponse{WalletID: 10, Balance: "6"})
+	_, open := <-first
+	assert.False(t, open)
+	assert.Equal(t, "6", receive(t, second).Balance)
+}
+
+func TestBalanceHub_DisconnectsSlowSubscribers(t *testing.T) {
+	hub := newTestBalanceHub(nil, 2)
+	ctx := context.Background()
+
+	slow, unsubscribe := hub.Subscribe(1)
+	defer unsubscribe()
+
+	for i := 0; i < 3; i++ {
+		hub.Publish(ctx, 1, &BalanceResponse{Balance: big.NewInt(int64(i)).String()})
+	}
+
+	// The buffered updates are drained, then the stream ends instead of skipping one
+	assert.Equal(t, "0", (<-slow).Balance)
+	assert.Equal(t, "1", (<-slow).Balance)
+	_, open := <-slow
+	assert.False(t, open)
+	assert.Empty(t, hub.subscribers)
+}
+
+func TestBalanceHub_RelaysThroughPubSub(t *testing.T) {
+	pubsub := &fakePubSub{}
+	publisher := newTestBalanceHub(pubsub, 4)
+	receiver := newTestBalanceHub(pubsub, 4)
+	ctx := context.Background()
+
+	publisher.Start(ctx)
+	receiver.Start(ctx)
+	defer publisher.Stop()
+	defer receiver.Stop()
+	require.Eventually(t, func() bool {
+		return publisher.relaying.Load() && receiver.relaying.Load()
+	}, time.Second, 5*time.Millisecond)
+
+	local, unsubscribeLocal := publisher.Subscribe(1)
+	remote, unsubscribeRemote := receiver.Subscribe(1)
+	defer unsubscribeLocal()
+	defer unsubscribeRemote()
+
+	// Both instances receive the update exactly once, through the relay
+	publisher.Publish(ctx, 1, &BalanceResponse{WalletID: 10, Balance: "5"})
+	assert.Equal(t, "5", receive(t, local).Balance)
+	assert.Equal(t, "5", receive(t, remote).Balance)
+	assert.Equal(t, 1, pubsub.published)
+	assert.Empty(t, local)
+
+	// When publishing fails the update still reaches the local streams
+	pubsub.failPublish = true
+	publisher.Publish(ctx, 1, &BalanceResponse{WalletID: 10, Balance: "6"})
+	assert.Equal(t, "6", receive(t, local).Balance)
+	assert.Empty(t, remote)
+}
+
+func TestStreamBalance_PublishesChangedBalances(t *testing.T) {
+	decimals := uint8(6)
+	wallet := &models.WatchlistWallet{ID: 1, UserID: 7, WalletAddress: testWalletA, ChainID: 1}
+	usdc := testUSDC
+	token := &models.TrackedToken{ID: 2, UserID: 7, ChainID: 1, TokenAddress: &usdc, TokenSymbol: "USDC", Decimals: &decimals}
+
+	hub := newTestBalanceHub(nil, 4)
+	fetcher := newTestFetcher(nil)
+	fetcher.balanceHub = hub
+	ctx := context.Background()
+
+	updates, unsubscribe := hub.Subscribe(7)
+	defer unsubscribe()
+	require.NoError(t, fetcher.cacheService.Set(ctx, "user_balances:7", []string{"stale"}, time.Minute))
+
+	store := func(amount int64, value string) {
+		balance := &models.WalletBalance{WalletID: 1, TokenID: 2, Balance: big.NewInt(amount).String(), BalanceUSD: &value, FetchedAt: time.Now()}
+		result := fetchResult{wallet: wallet, token: token, balance: big.NewInt(amount)}
+		fetcher.streamBalance(ctx, "balance:1:2", result, balance)
+		require.NoError(t, fetcher.cacheService.Set(ctx, "balance:1:2", map[string]interface{}{
+			"balance":     balance.Balance,
+			"balance_usd": balance.BalanceUSD,
+		}, time.Minute))
+	}
+
+	// The first snapshot is always new to the stream, and clears the cached balance list
+	store(1500000, "1.500000")
+	update := receive(t, updates)
+	assert.Equal(t, uint(1), update.WalletID)
+	assert.Equal(t, "USDC", update.TokenSymbol)
+	assert.Equal(t, "1500000", update.Balance)
+	assert.Equal(t, "1.5", update.FormattedBalance)
+	var cached []string
+	assert.Error(t, fetcher.cacheService.Get(ctx, "user_balances:7", &cached))
+
+	// Unchanged snapshots are not streamed; a new USD value is
+	store(1500000, "1.500000")
+	assert.Empty(t, updates)
+	store(1500000, "1.499000")
+	assert.Equal(t, "1.499000", *receive(t, updates).BalanceUSD)
+	store(2000000, "1.999000")
+	assert.Equal(t, "2000000", receive(t, updates).Balance)
+}
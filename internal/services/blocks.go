@@ -0,0 +1,164 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// blockBatchSize is the number of full blocks or receipts requested per
+// JSON-RPC batch; full blocks are large, so batches are kept small
+const blockBatchSize = 20
+
+// Block is a block header with the value transfers of its transactions
+type Block struct {
+	BlockHeader
+	Transactions []BlockTransaction
+}
+
+// BlockTransaction is the part of a transaction needed to find native transfers
+type BlockTransaction struct {
+	Hash  common.Hash
+	From  common.Address
+	To    *common.Address // nil for contract creations
+	Value *big.Int
+}
+
+// rpcBlock is the JSON form of Block returned by eth_getBlockByNumber with
+// full transactions. Only the fields common to every transaction type are
+// decoded, so blocks with chain-specific transaction types can still be read.
+type rpcBlock struct {
+	rpcBlockHeader
+	Transactions []struct {
+		Hash  common.Hash     `json:"hash"`
+		From  common.Address  `json:"from"`
+		To    *common.Address `json:"to"`
+		Value *hexutil.Big    `json:"value"`
+	} `json:"transactions"`
+}
+
+// rpcReceiptStatus is the status field of eth_getTransactionReceipt
+type rpcReceiptStatus struct {
+	Status hexutil.Uint64 `json:"status"`
+}
+
+// GetBlocks retrieves full blocks with chunked JSON-RPC batches, in the
+// order of the given numbers. A missing block fails the whole call.
+func (s *web3Service) GetBlocks(ctx context.Context, numbers []*big.Int) ([]*Block, error) {
+	blocks := make([]*Block, len(numbers))
+
+	for start := 0; start < len(numbers); start += blockBatchSize {
+		end := start + blockBatchSize
+		if end > len(numbers) {
+			end = len(numbers)
+		}
+
+		raw := make([]*rpcBlock, end-start)
+		elems := make([]rpc.BatchElem, end-start)
+		for i := range elems {
+			elems[i] = rpc.BatchElem{
+				Method: "eth_getBlockByNumber",
+				Args:   []interface{}{blockNumberArg(numbers[start+i]), true},
+				Result: &raw[i],
+			}
+		}
+
+		if err := s.sendBatch(ctx, "blocks", elems); err != nil {
+			return nil, err
+		}
+
+		for i, elem := range elems {
+			if elem.Error != nil {
+				return nil, fmt.Errorf("failed to get block %s: %w", numbers[start+i], elem.Error)
+			}
+			if raw[i] == nil || raw[i].Number == nil {
+				return nil, fmt.Errorf("block %s: %w", numbers[start+i], ethereum.NotFound)
+			}
+			blocks[start+i] = raw[i].block()
+		}
+	}
+
+	return blocks, nil
+}
+
+// GetReceiptStatuses retrieves the status of each transaction's receipt
+// (1 for success, 0 for failure), in the order of the given hashes
+func (s *web3Service) GetReceiptStatuses(ctx context.Context, txHashes []common.Hash) ([]uint64, error) {
+	statuses := make([]uint64, len(txHashes))
+
+	for start := 0; start < len(txHashes); start += blockBatchSize {
+		end := start + blockBatchSize
+		if end > len(txHashes) {
+			end = len(txHashes)
+		}
+
+		raw := make([]*rpcReceiptStatus, end-start)
+		elems := make([]rpc.BatchElem, end-start)
+		for i := range elems {
+			elems[i] = rpc.BatchElem{
+				Method: "eth_getTransactionReceipt",
+				Args:   []interface{}{txHashes[start+i]},
+				Result: &raw[i],
+			}
+		}
+
+		if err := s.sendBatch(ctx, "receipts", elems); err != nil {
+			return nil, err
+		}
+
+		for i, elem := range elems {
+			if elem.Error != nil {
+				return nil, fmt.Errorf("failed to get receipt of %s: %w", txHashes[start+i].Hex(), elem.Error)
+			}
+			if raw[i] == nil {
+				return nil, fmt.Errorf("receipt of %s: %w", txHashes[start+i].Hex(), ethereum.NotFound)
+			}
+			statuses[start+i] = uint64(raw[i].Status)
+		}
+	}
+
+	return statuses, nil
+}
+
+// sendBatch sends requests as one rate-limited JSON-RPC batch with retries
+func (s *web3Service) sendBatch(ctx context.Context, operation string, elems []rpc.BatchElem) error {
+	// Wait for rate limiter
+	if err := s.rateLimiter.Wait(ctx); err != nil {
+		return err
+	}
+
+	return s.withRetry(ctx, operation, batchMethod(elems), func() error {
+		return s.pool.BatchCall(ctx, elems)
+	}, "calls", len(elems))
+}
+
+// block converts the JSON form of a block
+func (b *rpcBlock) block() *Block {
+	block := &Block{
+		BlockHeader: BlockHeader{
+			Number:     (*big.Int)(b.Number),
+			Hash:       b.Hash,
+			ParentHash: b.ParentHash,
+			Time:       uint64(b.Time),
+		},
+		Transactions: make([]BlockTransaction, len(b.Transactions)),
+	}
+	for i, tx := range b.Transactions {
+		value := new(big.Int)
+		if tx.Value != nil {
+			value = (*big.Int)(tx.Value)
+		}
+		block.Transactions[i] = BlockTransaction{
+			Hash:  tx.Hash,
+			From:  tx.From,
+			To:    tx.To,
+			Value: value,
+		}
+	}
+	return block
+}
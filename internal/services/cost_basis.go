@@ -0,0 +1,187 @@
+package services
+
+import (
+	"math/big"
+	"sort"
+	"time"
+)
+
+// Cost basis methods used to match disposals against acquired lots
+const (
+	CostBasisFIFO    = "fifo"
+	CostBasisLIFO    = "lifo"
+	CostBasisAverage = "average"
+)
+
+// lotEventKind is what a lot event does to a wallet's lots
+type lotEventKind int
+
+const (
+	// lotAcquire adds a lot to the wallet
+	lotAcquire lotEventKind = iota
+	// lotDispose removes lots from the wallet and realizes their gain
+	lotDispose
+	// lotMove moves lots to another of the user's wallets, keeping their cost
+	lotMove
+)
+
+// lotEvent is an acquisition, disposal or move of a token amount
+type lotEvent struct {
+	kind       lotEventKind
+	walletID   uint
+	toWalletID uint // destination of a lotMove
+	amount     *big.Rat
+	price      *big.Rat // USD per token; nil when unknown
+	at         time.Time
+}
+
+// lot is an amount of a token acquired at one price
+type lot struct {
+	amount     *big.Rat
+	price      *big.Rat // USD per token; nil when the acquisition could not be priced
+	acquiredAt time.Time
+}
+
+// lotPosition is one wallet's open lots and realized gains in a token
+type lotPosition struct {
+	lots     []*lot
+	realized *big.Rat
+	// unmatched is the disposed amount left out of realized gains because its
+	// cost or proceeds are unknown, or because no lot covered it
+	unmatched *big.Rat
+}
+
+// lotMatcher replays a token's events in order and matches disposals to lots.
+// FIFO and LIFO consume the oldest or newest lot first. Average cost pools a
+// wallet's priced lots into one lot at their weighted average price; unpriced
+// acquisitions form a second pool that is consumed after it.
+type lotMatcher struct {
+	method    string
+	positions map[uint]*lotPosition
+}
+
+// newLotMatcher creates a matcher for the given cost basis method
+func newLotMatcher(method string) *lotMatcher {
+	return &lotMatcher{method: method, positions: make(map[uint]*lotPosition)}
+}
+
+// position returns a wallet's position, creating it on first use
+func (m *lotMatcher) position(walletID uint) *lotPosition {
+	position, ok := m.positions[walletID]
+	if !ok {
+		position = &lotPosition{realized: new(big.Rat), unmatched: new(big.Rat)}
+		m.positions[walletID] = position
+	}
+	return position
+}
+
+// apply replays one event
+func (m *lotMatcher) apply(event lotEvent) {
+	if event.amount.Sign() <= 0 {
+		return
+	}
+
+	switch event.kind {
+	case lotAcquire:
+		m.add(m.position(event.walletID), &lot{amount: new(big.Rat).Set(event.amount), price: event.price, acquiredAt: event.at})
+
+	case lotDispose:
+		position := m.position(event.walletID)
+		taken, uncovered := m.take(position, event.amount)
+		position.unmatched.Add(position.unmatched, uncovered)
+		for _, piece := range taken {
+			if piece.price == nil || event.price == nil {
+				position.unmatched.Add(position.unmatched, piece.amount)
+				continue
+			}
+			gain := new(big.Rat).Sub(event.price, piece.price)
+			position.realized.Add(position.realized, gain.Mul(gain, piece.amount))
+		}
+
+	case lotMove:
+		taken, uncovered := m.take(m.position(event.walletID), event.amount)
+		destination := m.position(event.toWalletID)
+		for _, piece := range taken {
+			m.add(destination, piece)
+		}
+		// Moving more than was acquired carries the excess over without a cost basis
+		if uncovered.Sign() > 0 {
+			m.add(destination, &lot{amount: uncovered, acquiredAt: event.at})
+		}
+	}
+}
+
+// add inserts a lot, keeping lots in acquisition order or pooling them for average cost
+func (m *lotMatcher) add(position *lotPosition, acquired *lot) {
+	if m.method == CostBasisAverage {
+		for _, pool := range position.lots {
+			if (pool.price == nil) != (acquired.price == nil) {
+				continue
+			}
+			if pool.price != nil {
+				cost := new(big.Rat).Mul(pool.amount, pool.price)
+				cost.Add(cost, new(big.Rat).Mul(acquired.amount, acquired.price))
+				pool.amount.Add(pool.amount, acquired.amount)
+				pool.price = cost.Quo(cost, pool.amount)
+			} else {
+				pool.amount.Add(pool.amount, acquired.amount)
+			}
+			if acquired.acquiredAt.Before(pool.acquiredAt) {
+				pool.acquiredAt = acquired.acquiredAt
+			}
+			return
+		}
+		// The priced pool is consumed first
+		if acquired.price != nil {
+			position.lots = append([]*lot{acquired}, position.lots...)
+		} else {
+			position.lots = append(position.lots, acquired)
+		}
+		return
+	}
+
+	// Lots moved in from another wallet may be older than the wallet's own
+	i := sort.Search(len(position.lots), func(i int) bool {
+		return position.lots[i].acquiredAt.After(acquired.acquiredAt)
+	})
+	position.lots = append(position.lots, nil)
+	copy(position.lots[i+1:], position.lots[i:])
+	position.lots[i] = acquired
+}
+
+// take removes amount from a position's lots in matching order, splitting
+// the last lot if needed. It returns the pieces taken and the amount no lot covered.
+func (m *lotMatcher) take(position *lotPosition, amount *big.Rat) ([]*lot, *big.Rat) {
+	remaining := new(big.Rat).Set(amount)
+	var taken []*lot
+
+	for remaining.Sign() > 0 && len(position.lots) > 0 {
+		i := 0
+		if m.method == CostBasisLIFO {
+			i = len(position.lots) - 1
+		}
+		current := position.lots[i]
+
+		if current.amount.Cmp(remaining) <= 0 {
+			taken = append(taken, current)
+			remaining.Sub(remaining, current.amount)
+			position.lots = append(position.lots[:i], position.lots[i+1:]...)
+			continue
+		}
+
+		taken = append(taken, &lot{amount: new(big.Rat).Set(remaining), price: current.price, acquiredAt: current.acquiredAt})
+		current.amount.Sub(current.amount, remaining)
+		remaining.SetInt64(0)
+	}
+
+	return taken, remaining
+}
+
+// validCostBasisMethod reports whether method is a supported cost basis method
+func validCostBasisMethod(method string) bool {
+	switch method {
+	case CostBasisFIFO, CostBasisLIFO, CostBasisAverage:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,146 @@
+package services
+
+import (
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testLotStart = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+func rat(value string) *big.Rat {
+	r, ok := new(big.Rat).SetString(value)
+	if !ok {
+		panic("invalid rat " + value)
+	}
+	return r
+}
+
+func acquire(walletID uint, day int, amount, price string) lotEvent {
+	event := lotEvent{kind: lotAcquire, walletID: walletID, amount: rat(amount), at: testLotStart.AddDate(0, 0, day)}
+	if price != "" {
+		event.price = rat(price)
+	}
+	return event
+}
+
+func dispose(walletID uint, day int, amount, price string) lotEvent {
+	event := acquire(walletID, day, amount, price)
+	event.kind = lotDispose
+	return event
+}
+
+func move(walletID, toWalletID uint, day int, amount string) lotEvent {
+	return lotEvent{kind: lotMove, walletID: walletID, toWalletID: toWalletID, amount: rat(amount), at: testLotStart.AddDate(0, 0, day)}
+}
+
+func replay(method string, events ...lotEvent) *lotMatcher {
+	matcher := newLotMatcher(method)
+	for _, event := range events {
+		matcher.apply(event)
+	}
+	return matcher
+}
+
+// openLots returns a position's open lots as amount@price strings
+func openLots(position *lotPosition) []string {
+	var lots []string
+	for _, open := range position.lots {
+		price := "?"
+		if open.price != nil {
+			price = open.price.RatString()
+		}
+		lots = append(lots, open.amount.RatString()+"@"+price)
+	}
+	return lots
+}
+
+func TestLotMatcher_Methods(t *testing.T) {
+	events := []lotEvent{
+		acquire(1, 0, "2", "100"),
+		acquire(1, 1, "2", "200"),
+		dispose(1, 2, "3", "300"),
+	}
+
+	tests := []struct {
+		method   string
+		realized string
+		open     []string
+	}{
+		// 2 @ 100 and 1 @ 200 sold at 300
+		{CostBasisFIFO, "500", []string{"1@200"}},
+		// 2 @ 200 and 1 @ 100 sold at 300
+		{CostBasisLIFO, "400", []string{"1@100"}},
+		// 3 @ 150 sold at 300
+		{CostBasisAverage, "450", []string{"1@150"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.method, func(t *testing.T) {
+			position := replay(tt.method, events...).positions[1]
+			require.NotNil(t, position)
+			assert.Equal(t, tt.realized, position.realized.RatString())
+			assert.Equal(t, "0", position.unmatched.RatString())
+			assert.Equal(t, tt.open, openLots(position))
+		})
+	}
+}
+
+func TestLotMatcher_Unpriced(t *testing.T) {
+	matcher := replay(CostBasisFIFO,
+		acquire(1, 0, "1", ""),
+		acquire(1, 1, "1", "100"),
+		dispose(1, 2, "1.5", "150"),
+		dispose(1, 3, "0.25", ""),
+	)
+	position := matcher.positions[1]
+
+	// The unpriced lot and the disposal without a price are left out of realized gains
+	assert.Equal(t, "25", position.realized.RatString())
+	assert.Equal(t, "5/4", position.unmatched.RatString())
+	assert.Equal(t, []string{"1/4@100"}, openLots(position))
+}
+
+func TestLotMatcher_Uncovered(t *testing.T) {
+	position := replay(CostBasisFIFO,
+		acquire(1, 0, "1", "100"),
+		dispose(1, 1, "3", "150"),
+	).positions[1]
+
+	assert.Equal(t, "50", position.realized.RatString())
+	assert.Equal(t, "2", position.unmatched.RatString())
+	assert.Empty(t, position.lots)
+}
+
+func TestLotMatcher_Move(t *testing.T) {
+	matcher := replay(CostBasisFIFO,
+		acquire(1, 0, "2", "100"),
+		acquire(2, 1, "1", "300"),
+		move(1, 2, 2, "3"),
+		dispose(2, 3, "2.5", "400"),
+	)
+
+	// Moved lots keep their cost and acquisition order; the excess moved carries no cost
+	assert.Equal(t, "0", matcher.positions[1].realized.RatString())
+	assert.Empty(t, matcher.positions[1].lots)
+
+	destination := matcher.positions[2]
+	assert.Equal(t, "650", destination.realized.RatString())
+	assert.Equal(t, "0", destination.unmatched.RatString())
+	assert.Equal(t, []string{"1/2@300", "1@?"}, openLots(destination))
+}
+
+func TestLotMatcher_AveragePoolsPricedLotsFirst(t *testing.T) {
+	position := replay(CostBasisAverage,
+		acquire(1, 0, "1", ""),
+		acquire(1, 1, "1", "100"),
+		acquire(1, 2, "3", "200"),
+		dispose(1, 3, "2", "300"),
+	).positions[1]
+
+	assert.Equal(t, "250", position.realized.RatString())
+	assert.Equal(t, []string{"2@175", "1@?"}, openLots(position))
+}
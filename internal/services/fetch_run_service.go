@@ -0,0 +1,74 @@
+package services
+
+import (
+	"context"
+	"errors"
+
+	"cryptoportfolio/internal/models"
+	"cryptoportfolio/internal/repository"
+	"cryptoportfolio/pkg/logger"
+)
+
+// Fetch run errors
+var (
+	ErrFetchRunNotFound = errors.New("fetch run not found")
+)
+
+// FetchRunPage is a page of fetch runs, newest first
+type FetchRunPage struct {
+	Runs    []*models.FetchRun `json:"runs"`
+	Total   int64              `json:"total"`
+	Limit   int                `json:"limit"`
+	Offset  int                `json:"offset"`
+	HasNext bool               `json:"has_next"`
+}
+
+// FetchRunService exposes the history of balance fetch runs to administrators
+type FetchRunService interface {
+	ListRuns(ctx context.Context, trigger string, limit, offset int) (*FetchRunPage, error)
+	GetRun(ctx context.Context, runID uint) (*models.FetchRun, error)
+}
+
+// fetchRunService implements FetchRunService
+type fetchRunService struct {
+	runRepo repository.FetchRunRepository
+	logger  *logger.Logger
+}
+
+// NewFetchRunService creates a new fetch run service
+func NewFetchRunService(runRepo repository.FetchRunRepository, logger *logger.Logger) FetchRunService {
+	return &fetchRunService{
+		runRepo: runRepo,
+		logger:  logger,
+	}
+}
+
+// ListRuns lists runs of every trigger, or of one trigger, without their chains and errors
+func (s *fetchRunService) ListRuns(ctx context.Context, trigger string, limit, offset int) (*FetchRunPage, error) {
+	page, err := s.runRepo.List(ctx, trigger, repository.Pagination{Limit: limit, Offset: offset})
+	if err != nil {
+		s.logger.Error("Failed to list fetch runs", "error", err)
+		return nil, err
+	}
+
+	return &FetchRunPage{
+		Runs:    page.Data,
+		Total:   page.Total,
+		Limit:   page.Limit,
+		Offset:  page.Offset,
+		HasNext: page.HasNext,
+	}, nil
+}
+
+// GetRun retrieves a run with the block read on each chain and the pairs that failed
+func (s *fetchRunService) GetRun(ctx context.Context, runID uint) (*models.FetchRun, error) {
+	run, err := s.runRepo.GetByID(ctx, runID)
+	if err != nil {
+		if errors.Is(err, repository.ErrRecordNotFound) {
+			return nil, ErrFetchRunNotFound
+		}
+		s.logger.Error("Failed to get fetch run", "error", err, "run_id", runID)
+		return nil, err
+	}
+	return run, nil
+}
@@ -0,0 +1,113 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+
+	"cryptoportfolio/internal/models"
+	"cryptoportfolio/internal/repository"
+	"cryptoportfolio/pkg/logger"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeFetchRunRepository keeps recorded runs in memory
+type fakeFetchRunRepository struct {
+	repository.FetchRunRepository
+	runs []*models.FetchRun
+}
+
+func (r *fakeFetchRunRepository) Create(ctx context.Context, run *models.FetchRun) error {
+	run.ID = uint(len(r.runs) + 1)
+	r.runs = append(r.runs, run)
+	return nil
+}
+
+func (r *fakeFetchRunRepository) GetByID(ctx context.Context, id uint) (*models.FetchRun, error) {
+	if id == 0 || int(id) > len(r.runs) {
+		return nil, repository.ErrRecordNotFound
+	}
+	return r.runs[id-1], nil
+}
+
+func TestRecordRun(t *testing.T) {
+	runRepo := &fakeFetchRunRepository{}
+	fetcher := newTestFetcher(&emptyWatchlist{})
+	fetcher.runRepo = runRepo
+
+	ethWallet := &models.WatchlistWallet{ID: 1, UserID: 7, ChainID: 1, WalletAddress: "0xabc"}
+	polygonWallet := &models.WatchlistWallet{ID: 2, UserID: 7, ChainID: 137, WalletAddress: "0xdef"}
+	usdc := &models.TrackedToken{ID: 1, UserID: 7, ChainID: 1, TokenSymbol: "USDC"}
+	eth := &models.TrackedToken{ID: 2, UserID: 7, ChainID: 1, TokenSymbol: "ETH"}
+	matic := &models.TrackedToken{ID: 3, UserID: 7, ChainID: 137, TokenSymbol: "MATIC"}
+
+	batches := []fetchBatch{
+		{
+			chainID: 1,
+			tasks:   []fetchTask{{ethWallet, usdc}, {ethWallet, eth}},
+			block:   &BlockHeader{Number: big.NewInt(100), Hash: common.HexToHash("0x64")},
+		},
+		{
+			chainID: 137,
+			tasks:   []fetchTask{{polygonWallet, matic}},
+			err:     errors.New("no healthy providers"),
+		},
+	}
+	outcomes := []FetchOutcome{
+		{Pair: BalancePair{Wallet: ethWallet, Token: usdc}, Balance: big.NewInt(5)},
+		{Pair: BalancePair{Wallet: ethWallet, Token: eth}, Err: errors.New("execution reverted")},
+		{Pair: BalancePair{Wallet: polygonWallet, Token: matic}, Err: errors.New("no healthy providers")},
+	}
+
+	startedAt := time.Now().Add(-time.Second)
+	fetcher.recordRun(context.Background(), models.FetchRunTriggerSchedule, startedAt, batches, outcomes)
+
+	require.Len(t, runRepo.runs, 1)
+	run := runRepo.runs[0]
+	assert.Equal(t, models.FetchRunTriggerSchedule, run.Trigger)
+	assert.Equal(t, models.FetchRunStatusCompleted, run.Status)
+	assert.Equal(t, startedAt, run.StartedAt)
+	assert.Equal(t, 3, run.TotalPairs)
+	assert.Equal(t, 1, run.StoredPairs)
+	assert.Equal(t, 2, run.FailedPairs)
+
+	require.Len(t, run.Chains, 2)
+	assert.Equal(t, int64(1), run.Chains[0].ChainID)
+	require.NotNil(t, run.Chains[0].BlockNumber)
+	assert.Equal(t, uint64(100), *run.Chains[0].BlockNumber)
+	assert.Equal(t, 2, run.Chains[0].TotalPairs)
+	assert.Equal(t, 1, run.Chains[0].FailedPairs)
+	assert.Nil(t, run.Chains[1].BlockNumber)
+	assert.Equal(t, "no healthy providers", run.Chains[1].Error)
+
+	require.Len(t, run.Errors, 2)
+	assert.Equal(t, "ETH", run.Errors[0].TokenSymbol)
+	assert.Equal(t, "0xabc", run.Errors[0].WalletAddress)
+	assert.Equal(t, uint(7), run.Errors[0].UserID)
+	assert.Equal(t, "execution reverted", run.Errors[0].Error)
+
+	// A run cut short before every pair was read is interrupted
+	fetcher.recordRun(context.Background(), models.FetchRunTriggerRefresh, startedAt, batches, outcomes[:1])
+	require.Len(t, runRepo.runs, 2)
+	assert.Equal(t, models.FetchRunStatusInterrupted, runRepo.runs[1].Status)
+}
+
+func TestFetchRunService_GetRun(t *testing.T) {
+	runRepo := &fakeFetchRunRepository{}
+	service := NewFetchRunService(runRepo, logger.New())
+	ctx := context.Background()
+
+	require.NoError(t, runRepo.Create(ctx, &models.FetchRun{Trigger: models.FetchRunTriggerTransfer}))
+
+	run, err := service.GetRun(ctx, 1)
+	require.NoError(t, err)
+	assert.Equal(t, models.FetchRunTriggerTransfer, run.Trigger)
+
+	_, err = service.GetRun(ctx, 2)
+	assert.Equal(t, ErrFetchRunNotFound, err)
+}
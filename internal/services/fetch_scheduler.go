@@ -0,0 +1,432 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+	"time"
+
+	"cryptoportfolio/internal/cache"
+	"cryptoportfolio/internal/config"
+	"cryptoportfolio/internal/metrics"
+	"cryptoportfolio/internal/models"
+	"cryptoportfolio/internal/repository"
+	"cryptoportfolio/pkg/logger"
+)
+
+// Fetch schedule errors
+var (
+	ErrMinIntervalNotAllowed = errors.New("setting a minimum fetch interval is not available on your tier")
+	ErrInvalidMinInterval    = errors.New("minimum fetch interval is outside the range allowed for your tier")
+)
+
+// UpdateScheduleRequest sets the shortest interval the user's balances are fetched at
+type UpdateScheduleRequest struct {
+	MinIntervalSeconds int `json:"min_interval_seconds" example:"30"` // 0 restores the default
+}
+
+// PairScheduleResponse is the fetch schedule of one wallet/token pair
+type PairScheduleResponse struct {
+	WalletID        uint       `json:"wallet_id"`
+	WalletAddress   string     `json:"wallet_address"`
+	ChainID         int64      `json:"chain_id"`
+	TokenID         uint       `json:"token_id"`
+	TokenSymbol     string     `json:"token_symbol"`
+	IntervalSeconds int        `json:"interval_seconds"`
+	NextFetchAt     time.Time  `json:"next_fetch_at"`
+	LastChangedAt   *time.Time `json:"last_changed_at,omitempty"`
+	FetchStatus
+}
+
+// FetchStatus reports how recent a pair's stored balance is: when it was last
+// fetched, and whether the fetches since have been failing
+type FetchStatus struct {
+	LastSuccessAt       *time.Time `json:"last_success_at,omitempty"`
+	LastErrorAt         *time.Time `json:"last_error_at,omitempty"`
+	LastError           string     `json:"last_error,omitempty"`
+	ConsecutiveFailures int        `json:"consecutive_failures"`
+}
+
+// FetchScheduleResponse lists when each of the user's pairs is fetched next
+type FetchScheduleResponse struct {
+	Tier               string                  `json:"tier" example:"free"`
+	MinIntervalSeconds int                     `json:"min_interval_seconds"` // Interval a pair drops to after a change or a view
+	MaxIntervalSeconds int                     `json:"max_interval_seconds"` // Interval a dormant pair backs off to
+	Pairs              []*PairScheduleResponse `json:"pairs"`
+}
+
+// BalancePairID identifies a wallet/token pair
+type BalancePairID struct {
+	WalletID uint
+	TokenID  uint
+}
+
+// FetchOutcome is the result of fetching one pair; Err is set when the
+// balance could not be fetched or stored
+type FetchOutcome struct {
+	Pair    BalancePair
+	Balance *big.Int
+	Err     error
+}
+
+// FetchScheduler keeps an adaptive fetch schedule per wallet/token pair: pairs
+// whose balance does not change are fetched less and less often, and a change
+// or the owner viewing their balances brings them back to the owner's minimum
+type FetchScheduler interface {
+	// Due returns the pairs whose next fetch is due; pairs never fetched are
+	// always due. Given every watched pair, it also refreshes the staleness gauge.
+	Due(ctx context.Context, pairs []BalancePair) ([]BalancePair, error)
+	// Record reschedules fetched pairs by whether their balance changed
+	Record(ctx context.Context, outcomes []FetchOutcome) error
+	// Viewed brings a user's pairs down to their minimum interval
+	Viewed(ctx context.Context, userID uint) error
+	// FetchStatuses returns the fetch status of each of a user's pairs that was fetched before
+	FetchStatuses(ctx context.Context, userID uint) (map[BalancePairID]*FetchStatus, error)
+	GetSchedule(ctx context.Context, userID uint) (*FetchScheduleResponse, error)
+	UpdateSchedule(ctx context.Context, userID uint, req *UpdateScheduleRequest) (*FetchScheduleResponse, error)
+}
+
+// fetchScheduler implements FetchScheduler
+type fetchScheduler struct {
+	scheduleRepo  repository.ScheduleRepository
+	watchlistRepo repository.WatchlistRepository
+	userRepo      repository.UserRepository
+	cacheService  cache.CacheProvider
+	logger        *logger.Logger
+	config        *config.Config
+	base          int // interval in seconds of a pair without history
+	minInterval   int
+	maxInterval   int
+	factor        int
+}
+
+// NewFetchScheduler creates a new fetch scheduler
+func NewFetchScheduler(
+	scheduleRepo repository.ScheduleRepository,
+	watchlistRepo repository.WatchlistRepository,
+	userRepo repository.UserRepository,
+	cacheService cache.CacheProvider,
+	logger *logger.Logger,
+	config *config.Config,
+) FetchScheduler {
+	s := &fetchScheduler{
+		scheduleRepo:  scheduleRepo,
+		watchlistRepo: watchlistRepo,
+		userRepo:      userRepo,
+		cacheService:  cacheService,
+		logger:        logger,
+		config:        config,
+		base:          config.Web3.FetchInterval * 60,
+		minInterval:   config.Schedule.MinInterval,
+		maxInterval:   config.Schedule.MaxInterval,
+		factor:        config.Schedule.BackoffFactor,
+	}
+	if s.minInterval <= 0 {
+		s.minInterval = 60
+	}
+	if s.maxInterval < s.minInterval {
+		s.maxInterval = s.minInterval
+	}
+	if s.factor < 1 {
+		s.factor = 1
+	}
+	s.base = s.clamp(s.base, s.minInterval)
+	return s
+}
+
+// Due returns the pairs whose next fetch is due
+func (s *fetchScheduler) Due(ctx context.Context, pairs []BalancePair) ([]BalancePair, error) {
+	schedules, err := s.scheduleRepo.GetAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+	byPair := make(map[BalancePairID]*models.FetchSchedule, len(schedules))
+	for _, schedule := range schedules {
+		byPair[BalancePairID{schedule.WalletID, schedule.TokenID}] = schedule
+	}
+
+	now := time.Now()
+	var due []BalancePair
+	lastSuccess := make(map[metrics.Pair]time.Time)
+	for _, pair := range pairs {
+		schedule, scheduled := byPair[BalancePairID{pair.Wallet.ID, pair.Token.ID}]
+		if !scheduled || !schedule.NextFetchAt.After(now) {
+			due = append(due, pair)
+		}
+		if scheduled && schedule.LastFetchedAt != nil {
+			lastSuccess[metrics.Pair{ChainID: pair.Wallet.ChainID, WalletID: pair.Wallet.ID, TokenID: pair.Token.ID}] = *schedule.LastFetchedAt
+		}
+	}
+	metrics.PairStaleness.Set(lastSuccess)
+	return due, nil
+}
+
+// Record reschedules fetched pairs. An unchanged balance multiplies the
+// interval by the backoff factor, a changed one drops it to the owner's
+// minimum, and a failed fetch keeps it so an outage is not retried every tick.
+func (s *fetchScheduler) Record(ctx context.Context, outcomes []FetchOutcome) error {
+	if len(outcomes) == 0 {
+		return nil
+	}
+
+	var walletIDs, userIDs []uint
+	seenWallets := make(map[uint]bool)
+	seenUsers := make(map[uint]bool)
+	for _, outcome := range outcomes {
+		if wallet := outcome.Pair.Wallet; !seenWallets[wallet.ID] {
+			seenWallets[wallet.ID] = true
+			walletIDs = append(walletIDs, wallet.ID)
+		}
+		if userID := outcome.Pair.Wallet.UserID; !seenUsers[userID] {
+			seenUsers[userID] = true
+			userIDs = append(userIDs, userID)
+		}
+	}
+
+	existing, err := s.scheduleRepo.GetByWalletIDs(ctx, walletIDs)
+	if err != nil {
+		return err
+	}
+	previous := make(map[BalancePairID]*models.FetchSchedule, len(existing))
+	for _, schedule := range existing {
+		previous[BalancePairID{schedule.WalletID, schedule.TokenID}] = schedule
+	}
+
+	users, err := s.userRepo.FindByIDs(ctx, userIDs)
+	if err != nil {
+		return err
+	}
+	floors := make(map[uint]int, len(users))
+	for _, user := range users {
+		floors[user.ID] = s.userMinInterval(user)
+	}
+
+	now := time.Now()
+	schedules := make([]*models.FetchSchedule, 0, len(outcomes))
+	for _, outcome := range outcomes {
+		wallet, token := outcome.Pair.Wallet, outcome.Pair.Token
+		floor, ok := floors[wallet.UserID]
+		if !ok {
+			floor = s.minInterval
+		}
+
+		schedule := &models.FetchSchedule{UserID: wallet.UserID, WalletID: wallet.ID, TokenID: token.ID, IntervalSeconds: s.base}
+		prev := previous[BalancePairID{wallet.ID, token.ID}]
+		if prev != nil {
+			copied := *prev
+			schedule = &copied
+		}
+
+		if outcome.Err != nil {
+			schedule.LastErrorAt = &now
+			schedule.LastError = truncateString(outcome.Err.Error(), maxRunErrorLength)
+			schedule.ConsecutiveFailures++
+		} else {
+			balance := outcome.Balance.String()
+			switch {
+			case prev == nil || prev.LastBalance == "":
+				schedule.IntervalSeconds = s.base
+			case prev.LastBalance != balance:
+				schedule.IntervalSeconds = floor
+				schedule.LastChangedAt = &now
+			default:
+				schedule.IntervalSeconds = prev.IntervalSeconds * s.factor
+			}
+			schedule.LastBalance = balance
+			schedule.LastFetchedAt = &now
+			schedule.ConsecutiveFailures = 0
+		}
+
+		schedule.IntervalSeconds = s.clamp(schedule.IntervalSeconds, floor)
+		schedule.NextFetchAt = now.Add(time.Duration(schedule.IntervalSeconds) * time.Second)
+		schedules = append(schedules, schedule)
+	}
+
+	return s.scheduleRepo.Upsert(ctx, schedules)
+}
+
+// Viewed brings a user's pairs down to their minimum interval, fetching
+// pairs due later than that within one minimum interval. Views are checked
+// on every balance request, so the pairs are rescheduled at most once per
+// minimum interval; repeated views within it would not move them earlier.
+func (s *fetchScheduler) Viewed(ctx context.Context, userID uint) error {
+	key := viewedKey(userID)
+	var viewed bool
+	if err := s.cacheService.Get(ctx, key, &viewed); err == nil {
+		return nil
+	}
+
+	user, err := s.userRepo.FindByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	floor, err := s.shorten(ctx, user)
+	if err != nil {
+		return err
+	}
+
+	if err := s.cacheService.Set(ctx, key, true, time.Duration(floor)*time.Second); err != nil {
+		s.logger.Warn("Failed to record balance view", "error", err, "user_id", userID)
+	}
+	return nil
+}
+
+// shorten brings a user's pairs down to their minimum interval and returns it
+func (s *fetchScheduler) shorten(ctx context.Context, user *models.User) (int, error) {
+	floor := s.userMinInterval(user)
+	return floor, s.scheduleRepo.Shorten(ctx, user.ID, floor, time.Now().Add(time.Duration(floor)*time.Second))
+}
+
+func viewedKey(userID uint) string {
+	return fmt.Sprintf("schedule_viewed:%d", userID)
+}
+
+// GetSchedule lists when each of the user's pairs is fetched next, soonest first
+func (s *fetchScheduler) GetSchedule(ctx context.Context, userID uint) (*FetchScheduleResponse, error) {
+	user, err := s.userRepo.FindByID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, repository.ErrRecordNotFound) {
+			return nil, ErrUserNotFound
+		}
+		s.logger.Error("Failed to get user", "error", err, "user_id", userID)
+		return nil, err
+	}
+
+	wallets, err := s.watchlistRepo.GetWalletsByUserID(ctx, userID)
+	if err != nil {
+		s.logger.Error("Failed to get wallets", "error", err, "user_id", userID)
+		return nil, err
+	}
+	tokens, err := s.watchlistRepo.GetTokensByUserID(ctx, userID)
+	if err != nil {
+		s.logger.Error("Failed to get tokens", "error", err, "user_id", userID)
+		return nil, err
+	}
+	schedules, err := s.scheduleRepo.GetByUserID(ctx, userID)
+	if err != nil {
+		s.logger.Error("Failed to get fetch schedules", "error", err, "user_id", userID)
+		return nil, err
+	}
+	byPair := make(map[BalancePairID]*models.FetchSchedule, len(schedules))
+	for _, schedule := range schedules {
+		byPair[BalancePairID{schedule.WalletID, schedule.TokenID}] = schedule
+	}
+
+	floor := s.userMinInterval(user)
+	now := time.Now()
+	pairs := make([]*PairScheduleResponse, 0)
+	for _, wallet := range wallets {
+		for _, token := range tokens {
+			if wallet.ChainID != token.ChainID {
+				continue
+			}
+			pair := &PairScheduleResponse{
+				WalletID:        wallet.ID,
+				WalletAddress:   wallet.WalletAddress,
+				ChainID:         wallet.ChainID,
+				TokenID:         token.ID,
+				TokenSymbol:     token.TokenSymbol,
+				IntervalSeconds: s.clamp(s.base, floor),
+				NextFetchAt:     now, // never fetched, so due on the next tick
+			}
+			if schedule, ok := byPair[BalancePairID{wallet.ID, token.ID}]; ok {
+				pair.IntervalSeconds = schedule.IntervalSeconds
+				pair.NextFetchAt = schedule.NextFetchAt
+				pair.LastChangedAt = schedule.LastChangedAt
+				pair.FetchStatus = fetchStatus(schedule)
+			}
+			pairs = append(pairs, pair)
+		}
+	}
+	sort.SliceStable(pairs, func(i, j int) bool {
+		return pairs[i].NextFetchAt.Before(pairs[j].NextFetchAt)
+	})
+
+	return &FetchScheduleResponse{
+		Tier:               user.Tier,
+		MinIntervalSeconds: floor,
+		MaxIntervalSeconds: s.maxInterval,
+		Pairs:              pairs,
+	}, nil
+}
+
+// FetchStatuses returns the fetch status of each of a user's pairs that was fetched before
+func (s *fetchScheduler) FetchStatuses(ctx context.Context, userID uint) (map[BalancePairID]*FetchStatus, error) {
+	schedules, err := s.scheduleRepo.GetByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	statuses := make(map[BalancePairID]*FetchStatus, len(schedules))
+	for _, schedule := range schedules {
+		status := fetchStatus(schedule)
+		statuses[BalancePairID{schedule.WalletID, schedule.TokenID}] = &status
+	}
+	return statuses, nil
+}
+
+// fetchStatus extracts the fetch status of a pair from its schedule
+func fetchStatus(schedule *models.FetchSchedule) FetchStatus {
+	return FetchStatus{
+		LastSuccessAt:       schedule.LastFetchedAt,
+		LastErrorAt:         schedule.LastErrorAt,
+		LastError:           schedule.LastError,
+		ConsecutiveFailures: schedule.ConsecutiveFailures,
+	}
+}
+
+// UpdateSchedule sets the user's minimum fetch interval. Only tiers with a
+// configured minimum may set one, and not below the tier's minimum.
+func (s *fetchScheduler) UpdateSchedule(ctx context.Context, userID uint, req *UpdateScheduleRequest) (*FetchScheduleResponse, error) {
+	user, err := s.userRepo.FindByID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, repository.ErrRecordNotFound) {
+			return nil, ErrUserNotFound
+		}
+		s.logger.Error("Failed to get user", "error", err, "user_id", userID)
+		return nil, err
+	}
+
+	if req.MinIntervalSeconds != 0 {
+		tierMin, ok := s.config.Schedule.TierMinIntervals[user.Tier]
+		if !ok {
+			return nil, ErrMinIntervalNotAllowed
+		}
+		if req.MinIntervalSeconds < tierMin || req.MinIntervalSeconds > s.maxInterval {
+			return nil, ErrInvalidMinInterval
+		}
+	}
+
+	user.MinFetchInterval = req.MinIntervalSeconds
+	if err := s.userRepo.Update(ctx, user); err != nil {
+		s.logger.Error("Failed to update minimum fetch interval", "error", err, "user_id", userID)
+		return nil, err
+	}
+	s.logger.Info("Minimum fetch interval updated", "user_id", userID, "min_interval", req.MinIntervalSeconds)
+
+	// A lower minimum applies right away rather than after the next fetch or view
+	if _, err := s.shorten(ctx, user); err != nil {
+		s.logger.Warn("Failed to reschedule pairs", "error", err, "user_id", userID)
+	}
+	if err := s.cacheService.Delete(ctx, viewedKey(userID)); err != nil {
+		s.logger.Warn("Failed to reset balance view", "error", err, "user_id", userID)
+	}
+
+	return s.GetSchedule(ctx, userID)
+}
+
+// userMinInterval returns the interval a user's pairs drop to after a change
+// or a view. A minimum set on a tier that no longer allows it is ignored.
+func (s *fetchScheduler) userMinInterval(user *models.User) int {
+	floor := s.minInterval
+	if tierMin, ok := s.config.Schedule.TierMinIntervals[user.Tier]; ok && user.MinFetchInterval > 0 {
+		floor = max(user.MinFetchInterval, tierMin)
+	}
+	return min(floor, s.maxInterval)
+}
+
+// clamp bounds an interval by the given minimum and the maximum interval
+func (s *fetchScheduler) clamp(interval, floor int) int {
+	return min(max(interval, floor), s.maxInterval)
+}
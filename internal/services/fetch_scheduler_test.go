@@ -0,0 +1,307 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+
+	"cryptoportfolio/internal/config"
+	"cryptoportfolio/internal/metrics"
+	"cryptoportfolio/internal/models"
+	"cryptoportfolio/internal/repository"
+	"cryptoportfolio/pkg/logger"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeScheduleRepository keeps schedules in memory, keyed by pair
+type fakeScheduleRepository struct {
+	repository.ScheduleRepository
+	schedules map[BalancePairID]*models.FetchSchedule
+	shortened map[uint]int
+}
+
+func (r *fakeScheduleRepository) GetAll(ctx context.Context) ([]*models.FetchSchedule, error) {
+	var schedules []*models.FetchSchedule
+	for _, schedule := range r.schedules {
+		schedules = append(schedules, schedule)
+	}
+	return schedules, nil
+}
+
+func (r *fakeScheduleRepository) GetByUserID(ctx context.Context, userID uint) ([]*models.FetchSchedule, error) {
+	var schedules []*models.FetchSchedule
+	for _, schedule := range r.schedules {
+		if schedule.UserID == userID {
+			schedules = append(schedules, schedule)
+		}
+	}
+	return schedules, nil
+}
+
+func (r *fakeScheduleRepository) GetByWalletIDs(ctx context.Context, walletIDs []uint) ([]*models.FetchSchedule, error) {
+	return r.GetAll(ctx)
+}
+
+func (r *fakeScheduleRepository) Upsert(ctx context.Context, schedules []*models.FetchSchedule) error {
+	for _, schedule := range schedules {
+		r.schedules[BalancePairID{schedule.WalletID, schedule.TokenID}] = schedule
+	}
+	return nil
+}
+
+func (r *fakeScheduleRepository) Shorten(ctx context.Context, userID uint, intervalSeconds int, nextFetchAt time.Time) error {
+	r.shortened[userID] = intervalSeconds
+	return nil
+}
+
+// fakeUserRepository serves users from memory
+type fakeUserRepository struct {
+	repository.UserRepository
+	users map[uint]*models.User
+}
+
+func (r *fakeUserRepository) FindByID(ctx context.Context, id uint) (*models.User, error) {
+	user, ok := r.users[id]
+	if !ok {
+		return nil, repository.ErrRecordNotFound
+	}
+	return user, nil
+}
+
+func (r *fakeUserRepository) FindByIDs(ctx context.Context, ids []uint) ([]*models.User, error) {
+	var users []*models.User
+	for _, id := range ids {
+		if user, ok := r.users[id]; ok {
+			users = append(users, user)
+		}
+	}
+	return users, nil
+}
+
+func (r *fakeUserRepository) Update(ctx context.Context, user *models.User) error {
+	r.users[user.ID] = user
+	return nil
+}
+
+func (r *fakeUserRepository) Create(ctx context.Context, user *models.User) error {
+	user.ID = uint(len(r.users) + 1)
+	r.users[user.ID] = user
+	return nil
+}
+
+func (r *fakeUserRepository) FindByWalletAddress(ctx context.Context, address string) (*models.User, error) {
+	for _, user := range r.users {
+		if user.WalletAddress != nil && *user.WalletAddress == address {
+			return user, nil
+		}
+	}
+	return nil, repository.ErrRecordNotFound
+}
+
+// emptyWatchlist is a watchlist without wallets or tokens
+type emptyWatchlist struct {
+	repository.WatchlistRepository
+}
+
+func (r *emptyWatchlist) GetWalletsByUserID(ctx context.Context, userID uint) ([]*models.WatchlistWallet, error) {
+	return nil, nil
+}
+
+func (r *emptyWatchlist) GetTokensByUserID(ctx context.Context, userID uint) ([]*models.TrackedToken, error) {
+	return nil, nil
+}
+
+func newTestFetchScheduler(users ...*models.User) (*fetchScheduler, *fakeScheduleRepository, *fakeUserRepository) {
+	scheduleRepo := &fakeScheduleRepository{schedules: make(map[BalancePairID]*models.FetchSchedule), shortened: make(map[uint]int)}
+	userRepo := &fakeUserRepository{users: make(map[uint]*models.User)}
+	for _, user := range users {
+		userRepo.users[user.ID] = user
+	}
+	scheduler := NewFetchScheduler(scheduleRepo, &emptyWatchlist{}, userRepo, NewMockCacheProvider(), logger.New(), &config.Config{
+		Web3: config.Web3Config{FetchInterval: 5},
+		Schedule: config.ScheduleConfig{
+			MinInterval:      60,
+			MaxInterval:      3600,
+			BackoffFactor:    2,
+			TierMinIntervals: map[string]int{"pro": 15},
+		},
+	}).(*fetchScheduler)
+	return scheduler, scheduleRepo, userRepo
+}
+
+func testPair(userID, walletID, tokenID uint) BalancePair {
+	return BalancePair{
+		Wallet: &models.WatchlistWallet{ID: walletID, UserID: userID},
+		Token:  &models.TrackedToken{ID: tokenID, UserID: userID},
+	}
+}
+
+func TestFetchScheduler_BacksOffUntilTheBalanceChanges(t *testing.T) {
+	scheduler, repo, _ := newTestFetchScheduler(&models.User{ID: 1, Tier: models.UserTierFree})
+	ctx := context.Background()
+	pair := testPair(1, 1, 1)
+	key := BalancePairID{1, 1}
+
+	record := func(balance int64, err error) *models.FetchSchedule {
+		require.NoError(t, scheduler.Record(ctx, []FetchOutcome{{Pair: pair, Balance: big.NewInt(balance), Err: err}}))
+		return repo.schedules[key]
+	}
+
+	// A new pair starts at WEB3_FETCH_INTERVAL
+	schedule := record(100, nil)
+	assert.Equal(t, 300, schedule.IntervalSeconds)
+	assert.Equal(t, "100", schedule.LastBalance)
+	assert.Nil(t, schedule.LastChangedAt)
+	assert.WithinDuration(t, time.Now().Add(5*time.Minute), schedule.NextFetchAt, time.Second)
+
+	// Unchanged balances double the interval up to the maximum
+	for _, want := range []int{600, 1200, 2400, 3600, 3600} {
+		assert.Equal(t, want, record(100, nil).IntervalSeconds)
+	}
+
+	// A change drops it to the minimum
+	schedule = record(250, nil)
+	assert.Equal(t, 60, schedule.IntervalSeconds)
+	assert.NotNil(t, schedule.LastChangedAt)
+
+	// A failed fetch keeps the interval and the last known balance
+	schedule = record(0, errors.New("execution reverted"))
+	assert.Equal(t, 60, schedule.IntervalSeconds)
+	assert.Equal(t, "250", schedule.LastBalance)
+}
+
+func TestFetchScheduler_UsesTheTierMinimum(t *testing.T) {
+	scheduler, repo, _ := newTestFetchScheduler(
+		&models.User{ID: 1, Tier: "pro", MinFetchInterval: 20},
+		&models.User{ID: 2, Tier: models.UserTierFree, MinFetchInterval: 20}, // left over from a downgrade
+	)
+	ctx := context.Background()
+	pro, free := testPair(1, 1, 1), testPair(2, 2, 2)
+
+	require.NoError(t, scheduler.Record(ctx, []FetchOutcome{
+		{Pair: pro, Balance: big.NewInt(1)},
+		{Pair: free, Balance: big.NewInt(1)},
+	}))
+	require.NoError(t, scheduler.Record(ctx, []FetchOutcome{
+		{Pair: pro, Balance: big.NewInt(2)},
+		{Pair: free, Balance: big.NewInt(2)},
+	}))
+	assert.Equal(t, 20, repo.schedules[BalancePairID{1, 1}].IntervalSeconds)
+	assert.Equal(t, 60, repo.schedules[BalancePairID{2, 2}].IntervalSeconds)
+
+	// Viewing balances shortens the user's schedules to their minimum
+	require.NoError(t, scheduler.Viewed(ctx, 1))
+	assert.Equal(t, 20, repo.shortened[1])
+}
+
+func TestFetchScheduler_ViewedThrottled(t *testing.T) {
+	scheduler, repo, _ := newTestFetchScheduler(&models.User{ID: 1, Tier: "pro"})
+	ctx := context.Background()
+
+	require.NoError(t, scheduler.Viewed(ctx, 1))
+	assert.Equal(t, 60, repo.shortened[1])
+
+	// Views within the minimum interval do not reschedule again
+	delete(repo.shortened, 1)
+	require.NoError(t, scheduler.Viewed(ctx, 1))
+	assert.NotContains(t, repo.shortened, uint(1))
+
+	// A new minimum applies right away and is not held back by an earlier view
+	_, err := scheduler.UpdateSchedule(ctx, 1, &UpdateScheduleRequest{MinIntervalSeconds: 20})
+	require.NoError(t, err)
+	assert.Equal(t, 20, repo.shortened[1])
+
+	delete(repo.shortened, 1)
+	require.NoError(t, scheduler.Viewed(ctx, 1))
+	assert.Equal(t, 20, repo.shortened[1])
+}
+
+func TestFetchScheduler_Due(t *testing.T) {
+	scheduler, repo, _ := newTestFetchScheduler()
+	now := time.Now()
+	fetchedAt := now.Add(-time.Hour)
+	repo.schedules[BalancePairID{1, 1}] = &models.FetchSchedule{WalletID: 1, TokenID: 1, NextFetchAt: now.Add(time.Hour), LastFetchedAt: &fetchedAt}
+	repo.schedules[BalancePairID{1, 2}] = &models.FetchSchedule{WalletID: 1, TokenID: 2, NextFetchAt: now.Add(-time.Second)}
+
+	due, err := scheduler.Due(context.Background(), []BalancePair{testPair(1, 1, 1), testPair(1, 1, 2), testPair(1, 1, 3)})
+	require.NoError(t, err)
+	require.Len(t, due, 2)
+	assert.Equal(t, uint(2), due[0].Token.ID)
+	assert.Equal(t, uint(3), due[1].Token.ID, "pairs never fetched are due")
+
+	// Only pairs fetched successfully before have a staleness, aggregated per chain
+	registry := prometheus.NewPedanticRegistry()
+	require.NoError(t, registry.Register(metrics.PairStaleness))
+	families, err := registry.Gather()
+	require.NoError(t, err)
+	require.Len(t, families, 2)
+	for _, family := range families {
+		require.Len(t, family.GetMetric(), 1)
+		metric := family.GetMetric()[0]
+		require.Len(t, metric.GetLabel(), 1, "pairs are not labelled individually")
+		assert.Equal(t, "chain_id", metric.GetLabel()[0].GetName())
+		switch family.GetName() {
+		case "cryptoportfolio_fetcher_pair_staleness_seconds":
+			assert.Equal(t, uint64(1), metric.GetHistogram().GetSampleCount())
+		case "cryptoportfolio_fetcher_pair_staleness_max_seconds":
+			assert.InDelta(t, time.Hour.Seconds(), metric.GetGauge().GetValue(), 5)
+		}
+	}
+}
+
+func TestFetchScheduler_UpdateSchedule(t *testing.T) {
+	scheduler, repo, users := newTestFetchScheduler(
+		&models.User{ID: 1, Tier: "pro"},
+		&models.User{ID: 2, Tier: models.UserTierFree},
+	)
+	ctx := context.Background()
+
+	_, err := scheduler.UpdateSchedule(ctx, 2, &UpdateScheduleRequest{MinIntervalSeconds: 30})
+	assert.Equal(t, ErrMinIntervalNotAllowed, err)
+
+	for _, interval := range []int{10, -1, 7200} {
+		_, err = scheduler.UpdateSchedule(ctx, 1, &UpdateScheduleRequest{MinIntervalSeconds: interval})
+		assert.Equal(t, ErrInvalidMinInterval, err, interval)
+	}
+
+	// A new minimum is saved and applied to the pairs right away
+	schedule, err := scheduler.UpdateSchedule(ctx, 1, &UpdateScheduleRequest{MinIntervalSeconds: 30})
+	require.NoError(t, err)
+	assert.Equal(t, 30, users.users[1].MinFetchInterval)
+	assert.Equal(t, 30, schedule.MinIntervalSeconds)
+	assert.Equal(t, 30, repo.shortened[1])
+
+	_, err = scheduler.UpdateSchedule(ctx, 3, &UpdateScheduleRequest{})
+	assert.Equal(t, ErrUserNotFound, err)
+}
+
+func TestFetchScheduler_TracksFailures(t *testing.T) {
+	scheduler, repo, _ := newTestFetchScheduler(&models.User{ID: 1, Tier: models.UserTierFree})
+	ctx := context.Background()
+	pair := testPair(1, 1, 1)
+	key := BalancePairID{1, 1}
+
+	require.NoError(t, scheduler.Record(ctx, []FetchOutcome{{Pair: pair, Balance: big.NewInt(100)}}))
+	for i := 0; i < 2; i++ {
+		require.NoError(t, scheduler.Record(ctx, []FetchOutcome{{Pair: pair, Err: errors.New("execution reverted")}}))
+	}
+
+	statuses, err := scheduler.FetchStatuses(ctx, 1)
+	require.NoError(t, err)
+	status := statuses[key]
+	require.NotNil(t, status)
+	assert.NotNil(t, status.LastSuccessAt)
+	assert.NotNil(t, status.LastErrorAt)
+	assert.Equal(t, "execution reverted", status.LastError)
+	assert.Equal(t, 2, status.ConsecutiveFailures)
+
+	// A success resets the failure count but keeps the last error
+	require.NoError(t, scheduler.Record(ctx, []FetchOutcome{{Pair: pair, Balance: big.NewInt(100)}}))
+	assert.Equal(t, 0, repo.schedules[key].ConsecutiveFailures)
+	assert.Equal(t, "execution reverted", repo.schedules[key].LastError)
+}
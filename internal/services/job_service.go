@@ -0,0 +1,286 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"sync"
+	"time"
+
+	"cryptoportfolio/internal/config"
+	"cryptoportfolio/internal/models"
+	"cryptoportfolio/internal/repository"
+	"cryptoportfolio/pkg/logger"
+	"cryptoportfolio/pkg/units"
+)
+
+// Job errors
+var (
+	ErrJobNotFound        = errors.New("job not found")
+	ErrRefreshRateLimited = errors.New("too many balance refreshes")
+)
+
+const (
+	// jobStaleAfter is how long a running job may go without reporting
+	// progress before it is considered abandoned by a crashed process
+	jobStaleAfter = 10 * time.Minute
+	// maxJobErrorLength matches the size of Job.LastError
+	maxJobErrorLength = 500
+)
+
+// JobResponse reports the status and progress of a queued job
+type JobResponse struct {
+	ID             uint       `json:"id"`
+	Kind           string     `json:"kind" example:"balance_refresh"`
+	Status         string     `json:"status" example:"running"`
+	TotalItems     int        `json:"total_items"`
+	CompletedItems int        `json:"completed_items"`
+	FailedItems    int        `json:"failed_items"`
+	Progress       string     `json:"progress" example:"40.00"` // Percentage of items processed
+	LastError      string     `json:"last_error,omitempty"`
+	StartedAt      *time.Time `json:"started_at,omitempty"`
+	CompletedAt    *time.Time `json:"completed_at,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+}
+
+// JobService queues on-demand work such as balance refreshes and runs it in
+// the background, so requests return without waiting on RPC calls
+type JobService interface {
+	Start(ctx context.Context)
+	Stop()
+	EnqueueRefresh(ctx context.Context, userID uint) (*JobResponse, error)
+	GetJob(ctx context.Context, userID uint, jobID uint) (*JobResponse, error)
+}
+
+// jobService implements JobService
+type jobService struct {
+	jobRepo        repository.JobRepository
+	balanceFetcher BalanceFetcherService
+	logger         *logger.Logger
+	config         *config.Config
+	wake           chan struct{}
+	stopChan       chan struct{}
+	wg             sync.WaitGroup
+}
+
+// NewJobService creates a new job service
+func NewJobService(
+	jobRepo repository.JobRepository,
+	balanceFetcher BalanceFetcherService,
+	logger *logger.Logger,
+	config *config.Config,
+) JobService {
+	return &jobService{
+		jobRepo:        jobRepo,
+		balanceFetcher: balanceFetcher,
+		logger:         logger,
+		config:         config,
+		wake:           make(chan struct{}, 1),
+		stopChan:       make(chan struct{}),
+	}
+}
+
+// Start begins processing the queue with the configured number of runners
+func (s *jobService) Start(ctx context.Context) {
+	concurrency := s.config.Jobs.Concurrency
+	if concurrency <= 0 {
+		concurrency = 1
+	}
+	s.logger.Info("Starting job runners", "concurrency", concurrency)
+
+	ctx, cancel := context.WithCancel(ctx)
+	go func() {
+		select {
+		case <-s.stopChan:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	for i := 0; i < concurrency; i++ {
+		s.wg.Add(1)
+		go s.run(ctx)
+	}
+}
+
+// Stop gracefully stops the job runners; a job in progress is failed
+func (s *jobService) Stop() {
+	s.logger.Info("Stopping job runners")
+	close(s.stopChan)
+	s.wg.Wait()
+	s.logger.Info("Job runners stopped")
+}
+
+// EnqueueRefresh queues a refresh of all of the user's balances. While a
+// refresh is still waiting in the queue, further requests return that job
+// instead of queueing another; only newly queued jobs count against the rate limit.
+func (s *jobService) EnqueueRefresh(ctx context.Context, userID uint) (*JobResponse, error) {
+	window := time.Duration(s.config.Jobs.RefreshWindow) * time.Second
+	if limit := s.config.Jobs.RefreshLimit; limit > 0 && window > 0 {
+		count, err := s.jobRepo.CountCreatedSince(ctx, userID, models.JobKindBalanceRefresh, time.Now().Add(-window))
+		if err != nil {
+			s.logger.Error("Failed to count refresh jobs", "error", err, "user_id", userID)
+			return nil, err
+		}
+		if count >= int64(limit) {
+			return nil, ErrRefreshRateLimited
+		}
+	}
+
+	coalesceKey := fmt.Sprintf("%s:%d", models.JobKindBalanceRefresh, userID)
+	job, created, err := s.jobRepo.Enqueue(ctx, &models.Job{
+		UserID:      userID,
+		Kind:        models.JobKindBalanceRefresh,
+		Status:      models.JobStatusPending,
+		CoalesceKey: &coalesceKey,
+	})
+	if err != nil {
+		s.logger.Error("Failed to enqueue refresh job", "error", err, "user_id", userID)
+		return nil, err
+	}
+
+	if created {
+		s.logger.Info("Balance refresh queued", "user_id", userID, "job_id", job.ID)
+		select {
+		case s.wake <- struct{}{}:
+		default:
+		}
+	}
+	return jobResponse(job), nil
+}
+
+// GetJob retrieves one of the user's jobs
+func (s *jobService) GetJob(ctx context.Context, userID uint, jobID uint) (*JobResponse, error) {
+	job, err := s.jobRepo.GetByID(ctx, jobID)
+	if err != nil {
+		if errors.Is(err, repository.ErrRecordNotFound) {
+			return nil, ErrJobNotFound
+		}
+		s.logger.Error("Failed to get job", "error", err, "job_id", jobID)
+		return nil, err
+	}
+	if job.UserID != userID {
+		return nil, ErrJobNotFound
+	}
+	return jobResponse(job), nil
+}
+
+// run processes the queue whenever it is polled or woken
+func (s *jobService) run(ctx context.Context) {
+	defer s.wg.Done()
+
+	pollInterval := time.Duration(s.config.Jobs.PollInterval) * time.Second
+	if pollInterval <= 0 {
+		pollInterval = 2 * time.Second
+	}
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+
+	for {
+		s.failStale(ctx)
+		s.processPending(ctx)
+
+		select {
+		case <-ticker.C:
+		case <-s.wake:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// failStale fails jobs whose runner stopped reporting progress
+func (s *jobService) failStale(ctx context.Context) {
+	failed, err := s.jobRepo.FailStale(ctx, time.Now().Add(-jobStaleAfter), "job was abandoned by its runner")
+	if err != nil {
+		s.logger.Error("Failed to fail stale jobs", "error", err)
+		return
+	}
+	if failed > 0 {
+		s.logger.Warn("Failed stale jobs", "count", failed)
+	}
+}
+
+// processPending runs queued jobs until the queue is empty
+func (s *jobService) processPending(ctx context.Context) {
+	for ctx.Err() == nil {
+		job, err := s.jobRepo.ClaimNext(ctx)
+		if err != nil {
+			s.logger.Error("Failed to claim job", "error", err)
+			return
+		}
+		if job == nil {
+			return
+		}
+		s.runJob(ctx, job)
+	}
+}
+
+// runJob runs a claimed job and records its outcome. A refresh job completes
+// even when some pairs failed; they are counted in FailedItems.
+func (s *jobService) runJob(ctx context.Context, job *models.Job) {
+	s.logger.Info("Running job", "job_id", job.ID, "kind", job.Kind, "user_id", job.UserID)
+
+	var err error
+	switch job.Kind {
+	case models.JobKindBalanceRefresh:
+		err = s.balanceFetcher.FetchBalancesForUser(ctx, job.UserID, func(completed, failed, total int, firstErr error) {
+			job.CompletedItems = completed
+			job.FailedItems = failed
+			job.TotalItems = total
+			if firstErr != nil {
+				job.LastError = truncateString(firstErr.Error(), maxJobErrorLength)
+			}
+			if err := s.jobRepo.Update(ctx, job); err != nil {
+				s.logger.Warn("Failed to update job progress", "job_id", job.ID, "error", err)
+			}
+		})
+	default:
+		err = fmt.Errorf("unknown job kind %q", job.Kind)
+	}
+
+	now := time.Now()
+	job.CompletedAt = &now
+	switch {
+	case ctx.Err() != nil:
+		job.Status = models.JobStatusFailed
+		job.LastError = "job was interrupted by a shutdown"
+	case err != nil:
+		job.Status = models.JobStatusFailed
+		job.LastError = truncateString(err.Error(), maxJobErrorLength)
+		s.logger.Error("Job failed", "job_id", job.ID, "error", err)
+	default:
+		job.Status = models.JobStatusCompleted
+		s.logger.Info("Job completed", "job_id", job.ID, "completed_items", job.CompletedItems, "failed_items", job.FailedItems)
+	}
+
+	// The job context may already be cancelled; the outcome is still recorded
+	if err := s.jobRepo.Update(context.Background(), job); err != nil {
+		s.logger.Error("Failed to update job", "job_id", job.ID, "error", err)
+	}
+}
+
+// jobResponse converts a job to its API representation
+func jobResponse(job *models.Job) *JobResponse {
+	progress := new(big.Rat)
+	if job.TotalItems > 0 {
+		progress.SetFrac64(int64(job.CompletedItems+job.FailedItems)*100, int64(job.TotalItems))
+	} else if job.Status == models.JobStatusCompleted {
+		progress.SetInt64(100)
+	}
+
+	return &JobResponse{
+		ID:             job.ID,
+		Kind:           job.Kind,
+		Status:         job.Status,
+		TotalItems:     job.TotalItems,
+		CompletedItems: job.CompletedItems,
+		FailedItems:    job.FailedItems,
+		Progress:       units.FormatDecimal(progress, allocationDecimals),
+		LastError:      job.LastError,
+		StartedAt:      job.StartedAt,
+		CompletedAt:    job.CompletedAt,
+		CreatedAt:      job.CreatedAt,
+	}
+}
@@ -0,0 +1,152 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"cryptoportfolio/internal/config"
+	"cryptoportfolio/internal/models"
+	"cryptoportfolio/internal/repository"
+	"cryptoportfolio/pkg/logger"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeJobRepository keeps jobs in memory and records every saved state
+type fakeJobRepository struct {
+	repository.JobRepository
+	jobs    map[uint]*models.Job
+	created int64
+	updates []models.Job
+}
+
+func (r *fakeJobRepository) Enqueue(ctx context.Context, job *models.Job) (*models.Job, bool, error) {
+	for _, queued := range r.jobs {
+		if queued.CoalesceKey != nil && *queued.CoalesceKey == *job.CoalesceKey {
+			return queued, false, nil
+		}
+	}
+	job.ID = uint(len(r.jobs) + 1)
+	r.jobs[job.ID] = job
+	r.created++
+	return job, true, nil
+}
+
+func (r *fakeJobRepository) CountCreatedSince(ctx context.Context, userID uint, kind string, since time.Time) (int64, error) {
+	return r.created, nil
+}
+
+func (r *fakeJobRepository) GetByID(ctx context.Context, jobID uint) (*models.Job, error) {
+	job, ok := r.jobs[jobID]
+	if !ok {
+		return nil, repository.ErrRecordNotFound
+	}
+	return job, nil
+}
+
+func (r *fakeJobRepository) Update(ctx context.Context, job *models.Job) error {
+	r.updates = append(r.updates, *job)
+	return nil
+}
+
+// fakeRefreshFetcher reports a fixed sequence of progress updates
+type fakeRefreshFetcher struct {
+	BalanceFetcherService
+	userID uint
+	err    error
+}
+
+func (f *fakeRefreshFetcher) FetchBalancesForUser(ctx context.Context, userID uint, progress FetchProgress) error {
+	f.userID = userID
+	progress(0, 0, 3, nil)
+	progress(1, 1, 3, errors.New("execution reverted"))
+	progress(2, 1, 3, errors.New("execution reverted"))
+	return f.err
+}
+
+func newTestJobService(repo *fakeJobRepository, fetcher BalanceFetcherService) *jobService {
+	return NewJobService(repo, fetcher, logger.New(), &config.Config{
+		Jobs: config.JobsConfig{RefreshLimit: 2, RefreshWindow: 60},
+	}).(*jobService)
+}
+
+func TestJobService_EnqueueRefresh(t *testing.T) {
+	repo := &fakeJobRepository{jobs: make(map[uint]*models.Job)}
+	service := newTestJobService(repo, nil)
+	ctx := context.Background()
+
+	job, err := service.EnqueueRefresh(ctx, 1)
+	require.NoError(t, err)
+	assert.Equal(t, models.JobKindBalanceRefresh, job.Kind)
+	assert.Equal(t, models.JobStatusPending, job.Status)
+	assert.Equal(t, "0.00", job.Progress)
+	assert.Len(t, service.wake, 1)
+
+	// A refresh requested while one is queued returns the queued job
+	again, err := service.EnqueueRefresh(ctx, 1)
+	require.NoError(t, err)
+	assert.Equal(t, job.ID, again.ID)
+	assert.Equal(t, int64(1), repo.created)
+
+	// Queued jobs count against the limit once the pending one started
+	repo.jobs[job.ID].CoalesceKey = nil
+	_, err = service.EnqueueRefresh(ctx, 1)
+	require.NoError(t, err)
+	_, err = service.EnqueueRefresh(ctx, 1)
+	assert.Equal(t, ErrRefreshRateLimited, err)
+}
+
+func TestJobService_RunJob(t *testing.T) {
+	repo := &fakeJobRepository{jobs: make(map[uint]*models.Job)}
+	fetcher := &fakeRefreshFetcher{}
+	service := newTestJobService(repo, fetcher)
+	ctx := context.Background()
+
+	job := &models.Job{ID: 1, UserID: 7, Kind: models.JobKindBalanceRefresh, Status: models.JobStatusRunning}
+	repo.jobs[job.ID] = job
+	service.runJob(ctx, job)
+
+	assert.Equal(t, uint(7), fetcher.userID)
+
+	// Progress is saved after every batch
+	require.Len(t, repo.updates, 4)
+	assert.Equal(t, 3, repo.updates[0].TotalItems)
+	assert.Equal(t, 1, repo.updates[1].CompletedItems)
+	assert.Equal(t, 1, repo.updates[1].FailedItems)
+
+	// Failed pairs do not fail the refresh
+	assert.Equal(t, models.JobStatusCompleted, job.Status)
+	assert.NotNil(t, job.CompletedAt)
+	assert.Equal(t, "execution reverted", job.LastError)
+
+	response, err := service.GetJob(ctx, 7, job.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "100.00", response.Progress)
+	assert.Equal(t, 2, response.CompletedItems)
+
+	_, err = service.GetJob(ctx, 8, job.ID)
+	assert.Equal(t, ErrJobNotFound, err)
+	_, err = service.GetJob(ctx, 7, 99)
+	assert.Equal(t, ErrJobNotFound, err)
+}
+
+func TestJobService_RunJobFailures(t *testing.T) {
+	repo := &fakeJobRepository{jobs: make(map[uint]*models.Job)}
+	service := newTestJobService(repo, &fakeRefreshFetcher{err: errors.New("failed to get user wallets")})
+
+	job := &models.Job{ID: 1, UserID: 7, Kind: models.JobKindBalanceRefresh, Status: models.JobStatusRunning}
+	service.runJob(context.Background(), job)
+	assert.Equal(t, models.JobStatusFailed, job.Status)
+	assert.Equal(t, "failed to get user wallets", job.LastError)
+
+	// A job cut off by a shutdown is failed rather than left running
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	job = &models.Job{ID: 2, UserID: 7, Kind: models.JobKindBalanceRefresh, Status: models.JobStatusRunning}
+	service.runJob(ctx, job)
+	assert.Equal(t, models.JobStatusFailed, job.Status)
+	assert.Equal(t, "job was interrupted by a shutdown", job.LastError)
+}
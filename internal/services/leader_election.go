@@ -0,0 +1,171 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"os"
+	"sync"
+	"time"
+
+	"cryptoportfolio/internal/cache"
+	"cryptoportfolio/internal/config"
+	"cryptoportfolio/pkg/logger"
+)
+
+// LeaderElector elects one of several instances as leader through a lease
+// that the leader keeps renewing
+type LeaderElector interface {
+	Start(ctx context.Context)
+	Stop()
+	// Leadership returns a context that is cancelled as soon as this instance
+	// loses the lease, and whether it currently leads
+	Leadership() (context.Context, bool)
+}
+
+// leaderElector implements LeaderElector
+type leaderElector struct {
+	locks    cache.LockProvider
+	key      string
+	id       string // identifies this instance as the lease owner
+	ttl      time.Duration
+	logger   *logger.Logger
+	mu       sync.Mutex
+	term     context.Context // nil while another instance leads
+	endTerm  context.CancelFunc
+	stopChan chan struct{}
+	wg       sync.WaitGroup
+}
+
+// NewLeaderElector creates a new leader elector
+func NewLeaderElector(locks cache.LockProvider, logger *logger.Logger, config *config.Config) LeaderElector {
+	ttl := time.Duration(config.Leader.LeaseTTL) * time.Second
+	if ttl <= 0 {
+		ttl = 30 * time.Second
+	}
+	return &leaderElector{
+		locks:    locks,
+		key:      config.Leader.Key,
+		id:       instanceID(),
+		ttl:      ttl,
+		logger:   logger,
+		stopChan: make(chan struct{}),
+	}
+}
+
+// Start campaigns once right away, so a sole instance leads before its first
+// fetch cycle, then keeps campaigning in the background
+func (e *leaderElector) Start(ctx context.Context) {
+	e.logger.Info("Starting leader election", "key", e.key, "instance", e.id)
+	e.campaign(ctx)
+
+	e.wg.Add(1)
+	go e.run(ctx)
+}
+
+// Stop stops campaigning and releases the lease so another instance can take
+// over without waiting for it to expire
+func (e *leaderElector) Stop() {
+	e.logger.Info("Stopping leader election")
+	close(e.stopChan)
+	e.wg.Wait()
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.term == nil {
+		return
+	}
+	e.endTerm()
+	e.term = nil
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := e.locks.ReleaseLock(ctx, e.key, e.id); err != nil {
+		e.logger.Warn("Failed to release leader lease", "error", err)
+	}
+}
+
+// Leadership returns the current term's context and whether this instance leads
+func (e *leaderElector) Leadership() (context.Context, bool) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.term, e.term != nil
+}
+
+// run renews or tries to take the lease every third of its TTL
+func (e *leaderElector) run(ctx context.Context) {
+	defer e.wg.Done()
+
+	ticker := time.NewTicker(e.ttl / 3)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			e.campaign(ctx)
+		case <-e.stopChan:
+			return
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// campaign takes or renews the lease. Any failure to renew ends the term at
+// once: an attempt is bounded by a third of the TTL, so the leader steps down
+// before its lease can have expired and been taken by another instance.
+func (e *leaderElector) campaign(ctx context.Context) {
+	attemptCtx, cancel := context.WithTimeout(ctx, e.ttl/3)
+	defer cancel()
+
+	held, err := e.locks.AcquireLock(attemptCtx, e.key, e.id, e.ttl)
+	if err != nil {
+		e.logger.Warn("Failed to renew leader lease", "key", e.key, "error", err)
+		held = false
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	switch {
+	case held && e.term == nil:
+		e.term, e.endTerm = context.WithCancel(context.Background())
+		e.logger.Info("Became leader", "key", e.key, "instance", e.id)
+	case !held && e.term != nil:
+		e.endTerm()
+		e.term = nil
+		e.logger.Warn("Lost leadership", "key", e.key, "instance", e.id)
+	}
+}
+
+// leaderContext returns the context for background work that only the leader
+// does, cancelled when the leader loses its lease, and false when another
+// instance leads. Without an elector every instance does the work.
+func leaderContext(ctx context.Context, leader LeaderElector) (context.Context, context.CancelFunc, bool) {
+	if leader == nil {
+		return ctx, func() {}, true
+	}
+
+	term, ok := leader.Leadership()
+	if !ok {
+		return nil, nil, false
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	stop := context.AfterFunc(term, cancel)
+	return ctx, func() {
+		stop()
+		cancel()
+	}, true
+}
+
+// instanceID names this process as a lease owner: the host name plus a random
+// suffix, so replicas sharing a host name are told apart
+func instanceID() string {
+	host, err := os.Hostname()
+	if err != nil || host == "" {
+		host = "instance"
+	}
+	suffix := make([]byte, 4)
+	rand.Read(suffix)
+	return host + "-" + hex.EncodeToString(suffix)
+}
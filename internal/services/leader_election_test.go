@@ -0,0 +1,151 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"cryptoportfolio/internal/config"
+	"cryptoportfolio/pkg/logger"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeLockProvider keeps leases in memory; expire and fail simulate a lease
+// running out and Redis being unreachable
+type fakeLockProvider struct {
+	mu     sync.Mutex
+	owners map[string]string
+	fail   bool
+}
+
+func (p *fakeLockProvider) AcquireLock(ctx context.Context, key, owner string, ttl time.Duration) (bool, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.fail {
+		return false, errors.New("connection refused")
+	}
+	if current, ok := p.owners[key]; ok && current != owner {
+		return false, nil
+	}
+	p.owners[key] = owner
+	return true, nil
+}
+
+func (p *fakeLockProvider) ReleaseLock(ctx context.Context, key, owner string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.owners[key] == owner {
+		delete(p.owners, key)
+	}
+	return nil
+}
+
+func (p *fakeLockProvider) expire(key string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	delete(p.owners, key)
+}
+
+func newTestLeaderElector(locks *fakeLockProvider) *leaderElector {
+	return NewLeaderElector(locks, logger.New(), &config.Config{
+		Leader: config.LeaderConfig{Key: "balance_fetcher_leader", LeaseTTL: 30},
+	}).(*leaderElector)
+}
+
+func TestLeaderElector_OneLeaderAtATime(t *testing.T) {
+	locks := &fakeLockProvider{owners: make(map[string]string)}
+	first := newTestLeaderElector(locks)
+	second := newTestLeaderElector(locks)
+	ctx := context.Background()
+
+	first.Start(ctx)
+	second.Start(ctx)
+	defer second.Stop()
+
+	firstTerm, firstLeads := first.Leadership()
+	_, secondLeads := second.Leadership()
+	assert.True(t, firstLeads)
+	assert.False(t, secondLeads)
+
+	// Renewing keeps the same term
+	first.campaign(ctx)
+	renewedTerm, _ := first.Leadership()
+	assert.Equal(t, firstTerm, renewedTerm)
+
+	// Stopping ends the term and frees the lease for the next campaign
+	first.Stop()
+	assert.Error(t, firstTerm.Err())
+	second.campaign(ctx)
+	_, secondLeads = second.Leadership()
+	assert.True(t, secondLeads)
+}
+
+func TestLeaderElector_StepsDownWhenTheLeaseIsLost(t *testing.T) {
+	locks := &fakeLockProvider{owners: make(map[string]string)}
+	first := newTestLeaderElector(locks)
+	second := newTestLeaderElector(locks)
+	ctx := context.Background()
+
+	first.campaign(ctx)
+	term, leads := first.Leadership()
+	require.True(t, leads)
+
+	// Another instance took the expired lease
+	locks.expire("balance_fetcher_leader")
+	second.campaign(ctx)
+	first.campaign(ctx)
+	_, leads = first.Leadership()
+	assert.False(t, leads)
+	assert.Error(t, term.Err())
+
+	// A leader that cannot reach Redis steps down rather than risk a second leader
+	locks.fail = true
+	term, leads = second.Leadership()
+	require.True(t, leads)
+	second.campaign(ctx)
+	_, leads = second.Leadership()
+	assert.False(t, leads)
+	assert.Error(t, term.Err())
+}
+
+func TestFetchIfLeader_SkipsWhenAnotherInstanceLeads(t *testing.T) {
+	locks := &fakeLockProvider{owners: map[string]string{"balance_fetcher_leader": "other-instance"}}
+	leader := newTestLeaderElector(locks)
+	leader.campaign(context.Background())
+
+	// The fetcher has no repository, so a cycle that ran would panic
+	fetcher := newTestFetcher(nil)
+	fetcher.leader = leader
+	assert.NoError(t, fetcher.fetchIfLeader(context.Background()))
+}
+
+func TestLeaderContext_CancelledWhenTheTermEnds(t *testing.T) {
+	locks := &fakeLockProvider{owners: make(map[string]string)}
+	leader := newTestLeaderElector(locks)
+	leader.campaign(context.Background())
+
+	ctx, done, ok := leaderContext(context.Background(), leader)
+	require.True(t, ok)
+	defer done()
+	assert.NoError(t, ctx.Err())
+
+	locks.expire("balance_fetcher_leader")
+	locks.owners["balance_fetcher_leader"] = "other-instance"
+	leader.campaign(context.Background())
+
+	select {
+	case <-ctx.Done():
+	case <-time.After(time.Second):
+		t.Fatal("work context outlived the leadership term")
+	}
+
+	// Without an elector every instance does the work
+	ctx, done, ok = leaderContext(context.Background(), nil)
+	require.True(t, ok)
+	done()
+	assert.NoError(t, ctx.Err())
+}
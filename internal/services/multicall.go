@@ -0,0 +1,422 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// BalanceQuery identifies a single balance read for GetBalancesBatch
+type BalanceQuery struct {
+	WalletAddress string
+	TokenAddress  *string // nil for the native token
+}
+
+// BalanceResult holds the outcome of a single BalanceQuery
+type BalanceResult struct {
+	Balance *big.Int
+	Err     error
+}
+
+// ErrNoBalanceData is returned when a balanceOf call returns no data, e.g.
+// when the token contract did not exist yet at the queried block
+var ErrNoBalanceData = errors.New("balance call returned no data")
+
+// defaultMulticallBatchSize is used when WEB3_MULTICALL_BATCH_SIZE is not set
+const defaultMulticallBatchSize = 100
+
+// multicall3ABI covers the Multicall3 functions used for balance reads
+const multicall3ABI = `[
+	{"inputs":[{"components":[{"name":"target","type":"address"},{"name":"allowFailure","type":"bool"},{"name":"callData","type":"bytes"}],"name":"calls","type":"tuple[]"}],"name":"aggregate3","outputs":[{"components":[{"name":"success","type":"bool"},{"name":"returnData","type":"bytes"}],"name":"returnData","type":"tuple[]"}],"stateMutability":"payable","type":"function"},
+	{"inputs":[{"name":"addr","type":"address"}],"name":"getEthBalance","outputs":[{"name":"balance","type":"uint256"}],"stateMutability":"view","type":"function"}
+]`
+
+// erc20BalanceOfABI covers the ERC-20 balanceOf function
+const erc20BalanceOfABI = `[
+	{"constant":true,"inputs":[{"name":"owner","type":"address"}],"name":"balanceOf","outputs":[{"name":"balance","type":"uint256"}],"stateMutability":"view","type":"function"}
+]`
+
+var (
+	multicall3Contract = mustParseABI(multicall3ABI)
+	erc20BalanceOf     = mustParseABI(erc20BalanceOfABI)
+)
+
+// multicall3Call mirrors the Multicall3.Call3 struct
+type multicall3Call struct {
+	Target       common.Address
+	AllowFailure bool
+	CallData     []byte
+}
+
+// multicall3Result mirrors the Multicall3.Result struct
+type multicall3Result struct {
+	Success    bool
+	ReturnData []byte
+}
+
+// mustParseABI parses a static ABI definition and panics if it is malformed
+func mustParseABI(definition string) abi.ABI {
+	parsed, err := abi.JSON(strings.NewReader(definition))
+	if err != nil {
+		panic(fmt.Sprintf("invalid ABI definition: %v", err))
+	}
+	return parsed
+}
+
+// GetBalancesBatch reads many balances at once. Calls are aggregated into
+// chunked Multicall3 aggregate3 requests where Multicall3 is deployed, and
+// sent as JSON-RPC batches of eth_getBalance/eth_call otherwise. A chunk whose
+// aggregate3 call fails as a whole, e.g. by running out of gas or exceeding a
+// response size limit, is read again as a JSON-RPC batch. A failing query
+// only sets the Err of its own result; the returned error is reserved for
+// cancellation. All reads are made at the block with the given hash (EIP-1898)
+// so they see the same state, or at the latest block when it is nil.
+func (s *web3Service) GetBalancesBatch(ctx context.Context, queries []BalanceQuery, blockHash *common.Hash) ([]BalanceResult, error) {
+	if len(queries) == 0 {
+		return make([]BalanceResult, 0), nil
+	}
+
+	var fallback balanceChunkReader
+	readChunk := s.rpcBatchBalances
+	if s.multicallAvailable(ctx) {
+		readChunk = s.aggregateBalances
+		fallback = s.rpcBatchBalances
+	}
+
+	batchSize := s.config.Web3.MulticallBatchSize
+	if batchSize <= 0 {
+		batchSize = defaultMulticallBatchSize
+	}
+
+	return s.readBalanceChunks(ctx, queries, blockHash, batchSize, readChunk, fallback)
+}
+
+// balanceChunkReader reads one chunk of balances into results, which has the
+// length of queries. The returned error fails every query of the chunk.
+type balanceChunkReader func(ctx context.Context, queries []BalanceQuery, results []BalanceResult, blockHash *common.Hash) error
+
+// readBalanceChunks reads queries in chunks of batchSize with readChunk. A
+// chunk readChunk fails is read again with fallback when one is given.
+func (s *web3Service) readBalanceChunks(ctx context.Context, queries []BalanceQuery, blockHash *common.Hash, batchSize int, readChunk, fallback balanceChunkReader) ([]BalanceResult, error) {
+	results := make([]BalanceResult, len(queries))
+
+	for start := 0; start < len(queries); start += batchSize {
+		end := start + batchSize
+		if end > len(queries) {
+			end = len(queries)
+		}
+
+		err := readChunk(ctx, queries[start:end], results[start:end], blockHash)
+		if err != nil && fallback != nil && ctx.Err() == nil {
+			s.logger.Warn("Batched balance read failed, retrying chunk with single calls",
+				"chain_id", s.chain.ChainID, "calls", end-start, "error", err)
+			clear(results[start:end])
+			err = fallback(ctx, queries[start:end], results[start:end], blockHash)
+		}
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			for i := start; i < end; i++ {
+				if results[i].Err == nil {
+					results[i].Err = err
+				}
+			}
+		}
+	}
+
+	return results, nil
+}
+
+// GetBalancesAt reads a single balance at each of the given blocks with
+// chunked JSON-RPC batches. Reading state more than a few minutes old
+// requires an archive node. Errors are reported per result as in
+// GetBalancesBatch.
+func (s *web3Service) GetBalancesAt(ctx context.Context, query BalanceQuery, blockNumbers []*big.Int) ([]BalanceResult, error) {
+	results := make([]BalanceResult, len(blockNumbers))
+
+	batchSize := s.config.Web3.MulticallBatchSize
+	if batchSize <= 0 {
+		batchSize = defaultMulticallBatchSize
+	}
+
+	for start := 0; start < len(blockNumbers); start += batchSize {
+		end := start + batchSize
+		if end > len(blockNumbers) {
+			end = len(blockNumbers)
+		}
+
+		elems := make([]rpc.BatchElem, 0, end-start)
+		indexes := make([]int, 0, end-start)
+		for i := start; i < end; i++ {
+			elem, err := balanceBatchElem(query, blockNumberArg(blockNumbers[i]))
+			if err != nil {
+				results[i].Err = err
+				continue
+			}
+			elems = append(elems, elem)
+			indexes = append(indexes, i)
+		}
+
+		if err := s.sendBalanceBatch(ctx, elems, indexes, results); err != nil {
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			for i := start; i < end; i++ {
+				if results[i].Err == nil {
+					results[i].Err = err
+				}
+			}
+		}
+	}
+
+	return results, nil
+}
+
+// rpcBatchBalances reads one chunk of balances with a single JSON-RPC batch
+// request, for chains without Multicall3
+func (s *web3Service) rpcBatchBalances(ctx context.Context, queries []BalanceQuery, results []BalanceResult, blockHash *common.Hash) error {
+	elems := make([]rpc.BatchElem, 0, len(queries))
+	indexes := make([]int, 0, len(queries))
+	for i, query := range queries {
+		elem, err := balanceBatchElem(query, blockHashArg(blockHash))
+		if err != nil {
+			results[i].Err = err
+			continue
+		}
+		elems = append(elems, elem)
+		indexes = append(indexes, i)
+	}
+
+	return s.sendBalanceBatch(ctx, elems, indexes, results)
+}
+
+// sendBalanceBatch sends balance requests as one JSON-RPC batch and decodes
+// each response into results[indexes[i]]
+func (s *web3Service) sendBalanceBatch(ctx context.Context, elems []rpc.BatchElem, indexes []int, results []BalanceResult) error {
+	if len(elems) == 0 {
+		return nil
+	}
+
+	// Wait for rate limiter
+	if err := s.rateLimiter.Wait(ctx); err != nil {
+		return err
+	}
+
+	err := s.withRetry(ctx, "batched balances", batchMethod(elems), func() error {
+		return s.pool.BatchCall(ctx, elems)
+	}, "calls", len(elems))
+	if err != nil {
+		return err
+	}
+
+	for j, elem := range elems {
+		result := &results[indexes[j]]
+		if elem.Error != nil {
+			result.Err = elem.Error
+			continue
+		}
+
+		switch value := elem.Result.(type) {
+		case *hexutil.Big:
+			result.Balance = (*big.Int)(value)
+		case *hexutil.Bytes:
+			if len(*value) < 32 {
+				result.Err = ErrNoBalanceData
+				continue
+			}
+			result.Balance = new(big.Int).SetBytes((*value)[:32])
+		}
+	}
+
+	return nil
+}
+
+// blockNumberArg formats a block number for a JSON-RPC request; nil is the latest block
+func blockNumberArg(number *big.Int) interface{} {
+	if number == nil {
+		return "latest"
+	}
+	return hexutil.EncodeBig(number)
+}
+
+// blockHashArg formats a block hash as an EIP-1898 block parameter; nil is the latest block
+func blockHashArg(hash *common.Hash) interface{} {
+	if hash == nil {
+		return "latest"
+	}
+	return map[string]interface{}{"blockHash": *hash}
+}
+
+// balanceBatchElem builds the JSON-RPC request for a single balance query at the given block
+func balanceBatchElem(query BalanceQuery, block interface{}) (rpc.BatchElem, error) {
+	if !validateAddress(query.WalletAddress) {
+		return rpc.BatchElem{}, errors.New("invalid Ethereum address")
+	}
+	wallet := common.HexToAddress(query.WalletAddress)
+
+	if query.TokenAddress == nil {
+		return rpc.BatchElem{
+			Method: "eth_getBalance",
+			Args:   []interface{}{wallet, block},
+			Result: new(hexutil.Big),
+		}, nil
+	}
+
+	if !validateAddress(*query.TokenAddress) {
+		return rpc.BatchElem{}, errors.New("invalid address")
+	}
+	data, err := erc20BalanceOf.Pack("balanceOf", wallet)
+	if err != nil {
+		return rpc.BatchElem{}, err
+	}
+	return rpc.BatchElem{
+		Method: "eth_call",
+		Args: []interface{}{
+			map[string]interface{}{
+				"to":   common.HexToAddress(*query.TokenAddress),
+				"data": hexutil.Bytes(data),
+			},
+			block,
+		},
+		Result: new(hexutil.Bytes),
+	}, nil
+}
+
+// aggregateBalances reads one chunk of balances with a single aggregate3 call
+func (s *web3Service) aggregateBalances(ctx context.Context, queries []BalanceQuery, results []BalanceResult, blockHash *common.Hash) error {
+	multicallAddr := common.HexToAddress(s.chain.Multicall3Address)
+
+	calls := make([]multicall3Call, 0, len(queries))
+	indexes := make([]int, 0, len(queries))
+	for i, query := range queries {
+		call, err := balanceCall(multicallAddr, query)
+		if err != nil {
+			results[i].Err = err
+			continue
+		}
+		calls = append(calls, call)
+		indexes = append(indexes, i)
+	}
+
+	if len(calls) == 0 {
+		return nil
+	}
+
+	data, err := multicall3Contract.Pack("aggregate3", calls)
+	if err != nil {
+		return fmt.Errorf("failed to pack aggregate3 call: %w", err)
+	}
+
+	// Wait for rate limiter
+	if err := s.rateLimiter.Wait(ctx); err != nil {
+		return err
+	}
+
+	var output []byte
+	err = s.withRetry(ctx, "multicall balances", methodCall, func() error {
+		return s.pool.Do(ctx, methodCall, func(client *ethclient.Client) error {
+			var err error
+			output, err = callContractAt(ctx, client, ethereum.CallMsg{
+				To:   &multicallAddr,
+				Data: data,
+			}, blockHash)
+			return err
+		})
+	}, "calls", len(calls))
+	if err != nil {
+		return err
+	}
+
+	var callResults []multicall3Result
+	if err := multicall3Contract.UnpackIntoInterface(&callResults, "aggregate3", output); err != nil {
+		return fmt.Errorf("failed to unpack aggregate3 result: %w", err)
+	}
+	if len(callResults) != len(calls) {
+		return fmt.Errorf("aggregate3 returned %d results for %d calls", len(callResults), len(calls))
+	}
+
+	for i, callResult := range callResults {
+		result := &results[indexes[i]]
+		if !callResult.Success {
+			result.Err = errors.New("balance call reverted")
+			continue
+		}
+		if len(callResult.ReturnData) < 32 {
+			result.Err = ErrNoBalanceData
+			continue
+		}
+		result.Balance = new(big.Int).SetBytes(callResult.ReturnData[:32])
+	}
+
+	return nil
+}
+
+// balanceCall builds the Multicall3 sub-call for a single balance query
+func balanceCall(multicallAddr common.Address, query BalanceQuery) (multicall3Call, error) {
+	if !validateAddress(query.WalletAddress) {
+		return multicall3Call{}, errors.New("invalid Ethereum address")
+	}
+	wallet := common.HexToAddress(query.WalletAddress)
+
+	if query.TokenAddress == nil {
+		data, err := multicall3Contract.Pack("getEthBalance", wallet)
+		if err != nil {
+			return multicall3Call{}, err
+		}
+		return multicall3Call{Target: multicallAddr, AllowFailure: true, CallData: data}, nil
+	}
+
+	if !validateAddress(*query.TokenAddress) {
+		return multicall3Call{}, errors.New("invalid address")
+	}
+	data, err := erc20BalanceOf.Pack("balanceOf", wallet)
+	if err != nil {
+		return multicall3Call{}, err
+	}
+	return multicall3Call{Target: common.HexToAddress(*query.TokenAddress), AllowFailure: true, CallData: data}, nil
+}
+
+// multicallAvailable checks once per service whether Multicall3 is deployed
+// on the chain. Lookup errors are not cached so the check is retried later.
+func (s *web3Service) multicallAvailable(ctx context.Context) bool {
+	s.multicallMu.Lock()
+	defer s.multicallMu.Unlock()
+
+	if s.multicallChecked {
+		return s.multicallEnabled
+	}
+
+	if s.chain.Multicall3Address == "" {
+		s.multicallChecked = true
+		return false
+	}
+
+	var code []byte
+	err := s.pool.Do(ctx, methodGetCode, func(client *ethclient.Client) error {
+		var err error
+		code, err = client.CodeAt(ctx, common.HexToAddress(s.chain.Multicall3Address), nil)
+		return err
+	})
+	if err != nil {
+		s.logger.Warn("Failed to check for Multicall3 deployment", "chain_id", s.chain.ChainID, "error", err)
+		return false
+	}
+
+	s.multicallChecked = true
+	s.multicallEnabled = len(code) > 0
+	if !s.multicallEnabled {
+		s.logger.Info("Multicall3 not deployed, falling back to single balance calls", "chain_id", s.chain.ChainID)
+	}
+
+	return s.multicallEnabled
+}
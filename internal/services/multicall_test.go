@@ -0,0 +1,155 @@
+package services
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"math/big"
+	"testing"
+
+	"cryptoportfolio/internal/config"
+	"cryptoportfolio/internal/models"
+	"cryptoportfolio/pkg/logger"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBalanceCall_NativeToken(t *testing.T) {
+	multicallAddr := common.HexToAddress(config.DefaultMulticall3Address)
+
+	call, err := balanceCall(multicallAddr, BalanceQuery{
+		WalletAddress: "0x742d35Cc6634C0532925a3b844Bc454e4438f44e",
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, multicallAddr, call.Target)
+	assert.True(t, call.AllowFailure)
+	assert.Equal(t, "4d2301cc", hex.EncodeToString(call.CallData[:4])) // getEthBalance(address)
+}
+
+func TestBalanceCall_ERC20Token(t *testing.T) {
+	token := "0xA0b86991c6218b36c1d19D4a2e9Eb0cE3606eB48"
+
+	call, err := balanceCall(common.HexToAddress(config.DefaultMulticall3Address), BalanceQuery{
+		WalletAddress: "0x742d35Cc6634C0532925a3b844Bc454e4438f44e",
+		TokenAddress:  &token,
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, common.HexToAddress(token), call.Target)
+	assert.Equal(t, "70a08231", hex.EncodeToString(call.CallData[:4])) // balanceOf(address)
+	assert.Len(t, call.CallData, 4+32)
+}
+
+func TestBalanceCall_InvalidAddress(t *testing.T) {
+	_, err := balanceCall(common.HexToAddress(config.DefaultMulticall3Address), BalanceQuery{
+		WalletAddress: "not-an-address",
+	})
+
+	assert.Error(t, err)
+}
+
+func TestAggregate3_ResultDecoding(t *testing.T) {
+	method := multicall3Contract.Methods["aggregate3"]
+	encoded, err := method.Outputs.Pack([]multicall3Result{
+		{Success: true, ReturnData: common.LeftPadBytes(big.NewInt(1500).Bytes(), 32)},
+		{Success: false, ReturnData: nil},
+	})
+	require.NoError(t, err)
+
+	var results []multicall3Result
+	require.NoError(t, multicall3Contract.UnpackIntoInterface(&results, "aggregate3", encoded))
+
+	require.Len(t, results, 2)
+	assert.True(t, results[0].Success)
+	assert.Equal(t, big.NewInt(1500), new(big.Int).SetBytes(results[0].ReturnData))
+	assert.False(t, results[1].Success)
+}
+
+func TestBuildBatches_GroupsByChainAndUser(t *testing.T) {
+	bfs := &balanceFetcherService{
+		config: &config.Config{Web3: config.Web3Config{MulticallBatchSize: 2}},
+	}
+
+	wallets := []*models.WatchlistWallet{
+		{ID: 1, UserID: 1, ChainID: 1},
+		{ID: 2, UserID: 1, ChainID: 42161},
+		{ID: 3, UserID: 2, ChainID: 1},
+	}
+	tokens := []*models.TrackedToken{
+		{ID: 1, UserID: 1, ChainID: 1},
+		{ID: 2, UserID: 1, ChainID: 1},
+		{ID: 3, UserID: 1, ChainID: 1},
+		{ID: 4, UserID: 1, ChainID: 42161},
+		{ID: 5, UserID: 2, ChainID: 1},
+	}
+
+	batches := bfs.buildBatches(wallets, tokens)
+
+	// Chain 1: user 1 has 3 pairs, user 2 has 1 pair -> 4 pairs in 2 batches
+	// Chain 42161: 1 pair in 1 batch
+	require.Len(t, batches, 3)
+	assert.Equal(t, int64(1), batches[0].chainID)
+	assert.Len(t, batches[0].tasks, 2)
+	assert.Equal(t, int64(1), batches[1].chainID)
+	assert.Len(t, batches[1].tasks, 2)
+	assert.Equal(t, int64(42161), batches[2].chainID)
+	assert.Len(t, batches[2].tasks, 1)
+
+	for _, batch := range batches {
+		for _, task := range batch.tasks {
+			assert.Equal(t, task.wallet.UserID, task.token.UserID)
+			assert.Equal(t, batch.chainID, task.token.ChainID)
+		}
+	}
+}
+
+func TestReadBalanceChunks_FallsBackOnFailedChunk(t *testing.T) {
+	service := &web3Service{chain: config.ChainConfig{ChainID: 1}, logger: logger.New()}
+	queries := make([]BalanceQuery, 5)
+	for i := range queries {
+		queries[i] = BalanceQuery{WalletAddress: common.BigToAddress(big.NewInt(int64(i))).Hex()}
+	}
+
+	outOfGas := errors.New("out of gas")
+	var aggregated, batched int
+	aggregate := func(ctx context.Context, chunk []BalanceQuery, results []BalanceResult, blockHash *common.Hash) error {
+		aggregated++
+		if aggregated == 2 {
+			results[0].Err = ErrNoBalanceData
+			return outOfGas
+		}
+		for i := range results {
+			results[i].Balance = big.NewInt(1)
+		}
+		return nil
+	}
+	batch := func(ctx context.Context, chunk []BalanceQuery, results []BalanceResult, blockHash *common.Hash) error {
+		batched++
+		for i := range results {
+			results[i].Balance = big.NewInt(2)
+		}
+		return nil
+	}
+
+	results, err := service.readBalanceChunks(context.Background(), queries, nil, 2, aggregate, batch)
+	require.NoError(t, err)
+	require.Len(t, results, 5)
+	assert.Equal(t, 3, aggregated)
+	assert.Equal(t, 1, batched, "only the failed chunk is read again")
+	for i, want := range []int64{1, 1, 2, 2, 1} {
+		assert.NoError(t, results[i].Err)
+		assert.Equal(t, want, results[i].Balance.Int64())
+	}
+
+	failing := func(ctx context.Context, chunk []BalanceQuery, results []BalanceResult, blockHash *common.Hash) error {
+		return outOfGas
+	}
+	results, err = service.readBalanceChunks(context.Background(), queries, nil, 2, failing, failing)
+	require.NoError(t, err)
+	for _, result := range results {
+		assert.ErrorIs(t, result.Err, outOfGas, "a chunk fails only when its fallback fails too")
+	}
+}
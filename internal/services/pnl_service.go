@@ -0,0 +1,650 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"cryptoportfolio/internal/cache"
+	"cryptoportfolio/internal/models"
+	"cryptoportfolio/internal/repository"
+	"cryptoportfolio/pkg/logger"
+	"cryptoportfolio/pkg/units"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// PnL errors
+var (
+	ErrUnsupportedCostBasis = errors.New("unsupported cost basis method")
+	ErrLotNotFound          = errors.New("lot not found")
+	ErrInvalidLot           = errors.New("invalid lot")
+	ErrTransactionNotFound  = errors.New("transaction not found")
+	ErrLotAlreadyExists     = errors.New("transaction already has a lot")
+)
+
+// pnlPriceWindow is how far from a transfer a stamped price may be to value it
+const pnlPriceWindow = time.Hour
+
+// PnLReport is the profit and loss of a user's holdings under one cost basis method
+type PnLReport struct {
+	Method        string       `json:"method" example:"fifo"`
+	RealizedPnL   string       `json:"realized_pnl" example:"1250.000000"`
+	UnrealizedPnL string       `json:"unrealized_pnl" example:"-310.500000"`
+	CostBasis     string       `json:"cost_basis" example:"8200.000000"`
+	Tokens        []*TokenPnL  `json:"tokens"`
+	Wallets       []*WalletPnL `json:"wallets"`
+}
+
+// TokenPnL is the profit and loss of one token, across all wallets or in one wallet
+type TokenPnL struct {
+	TokenID       uint    `json:"token_id"`
+	TokenAddress  *string `json:"token_address"`
+	ChainID       int64   `json:"chain_id"`
+	TokenSymbol   string  `json:"token_symbol"`
+	PriceUSD      string  `json:"price_usd,omitempty"` // Current price; empty when the token cannot be priced
+	Amount        string  `json:"amount"`              // Decimal-adjusted amount held in open lots
+	CostBasis     string  `json:"cost_basis"`          // USD cost of the priced open lots
+	RealizedPnL   string  `json:"realized_pnl"`
+	UnrealizedPnL string  `json:"unrealized_pnl"`
+	// UnpricedAmount is held in open lots without a cost basis and is excluded from unrealized PnL
+	UnpricedAmount string `json:"unpriced_amount"`
+	// UnmatchedAmount was disposed of without a known cost or proceeds and is excluded from realized PnL
+	UnmatchedAmount string `json:"unmatched_amount"`
+}
+
+// WalletPnL is the profit and loss of one wallet across its tokens
+type WalletPnL struct {
+	WalletID      uint        `json:"wallet_id"`
+	WalletAddress string      `json:"wallet_address"`
+	ChainID       int64       `json:"chain_id"`
+	Label         string      `json:"label"`
+	RealizedPnL   string      `json:"realized_pnl"`
+	UnrealizedPnL string      `json:"unrealized_pnl"`
+	CostBasis     string      `json:"cost_basis"`
+	Tokens        []*TokenPnL `json:"tokens"`
+}
+
+// AddLotRequest enters the cost of an acquisition the system cannot price
+type AddLotRequest struct {
+	WalletID      uint       `json:"wallet_id" binding:"required"`
+	TokenID       uint       `json:"token_id" binding:"required"`
+	TransactionID *uint      `json:"transaction_id"`                                 // Incoming transfer this lot prices; amount and acquired_at default to the transfer's
+	Amount        string     `json:"amount" example:"1.5"`                           // Decimal-adjusted amount; required without transaction_id
+	PriceUSD      string     `json:"price_usd" binding:"required" example:"2500.00"` // USD price of one token at acquisition
+	AcquiredAt    *time.Time `json:"acquired_at" example:"2024-01-15T00:00:00Z"`     // Required without transaction_id
+	Note          string     `json:"note" example:"Bought on an exchange before tracking"`
+}
+
+// LotResponse is a manually entered lot
+type LotResponse struct {
+	ID              uint      `json:"id"`
+	WalletID        uint      `json:"wallet_id"`
+	WalletAddress   string    `json:"wallet_address,omitempty"`
+	TokenID         uint      `json:"token_id"`
+	TokenSymbol     string    `json:"token_symbol,omitempty"`
+	ChainID         int64     `json:"chain_id"`
+	TransactionID   *uint     `json:"transaction_id,omitempty"`
+	Amount          string    `json:"amount"` // Raw amount in the token's smallest unit
+	FormattedAmount string    `json:"formatted_amount,omitempty"`
+	PriceUSD        string    `json:"price_usd"`
+	AcquiredAt      time.Time `json:"acquired_at"`
+	Note            string    `json:"note,omitempty"`
+	CreatedAt       time.Time `json:"created_at"`
+}
+
+// PnLService computes cost basis and realized/unrealized profit and loss from
+// wallet transfer history, and manages manually entered lots
+type PnLService interface {
+	GetPnL(ctx context.Context, userID uint, method string) (*PnLReport, error)
+	AddLot(ctx context.Context, userID uint, req *AddLotRequest) (*LotResponse, error)
+	GetLots(ctx context.Context, userID uint) ([]*LotResponse, error)
+	DeleteLot(ctx context.Context, userID uint, lotID uint) error
+}
+
+// pnlService implements PnLService
+type pnlService struct {
+	watchlistRepo   repository.WatchlistRepository
+	transactionRepo repository.TransactionRepository
+	lotRepo         repository.LotRepository
+	web3Registry    Web3Registry
+	priceService    PriceService
+	cacheService    cache.CacheProvider
+	logger          *logger.Logger
+}
+
+// NewPnLService creates a new PnL service
+func NewPnLService(
+	watchlistRepo repository.WatchlistRepository,
+	transactionRepo repository.TransactionRepository,
+	lotRepo repository.LotRepository,
+	web3Registry Web3Registry,
+	priceService PriceService,
+	cacheService cache.CacheProvider,
+	logger *logger.Logger,
+) PnLService {
+	return &pnlService{
+		watchlistRepo:   watchlistRepo,
+		transactionRepo: transactionRepo,
+		lotRepo:         lotRepo,
+		web3Registry:    web3Registry,
+		priceService:    priceService,
+		cacheService:    cacheService,
+		logger:          logger,
+	}
+}
+
+// GetPnL replays each tracked token's transfers and manual lots through the
+// cost basis method. Acquisitions and disposals are valued with the price
+// stamped on the nearest balance snapshot; open lots are valued at the
+// current price.
+func (s *pnlService) GetPnL(ctx context.Context, userID uint, method string) (*PnLReport, error) {
+	if method == "" {
+		method = CostBasisFIFO
+	}
+	method = strings.ToLower(method)
+	if !validCostBasisMethod(method) {
+		return nil, ErrUnsupportedCostBasis
+	}
+
+	wallets, err := s.watchlistRepo.GetWalletsByUserID(ctx, userID)
+	if err != nil {
+		s.logger.Error("Failed to get user wallets", "error", err, "user_id", userID)
+		return nil, err
+	}
+	tokens, err := s.watchlistRepo.GetTokensByUserID(ctx, userID)
+	if err != nil {
+		s.logger.Error("Failed to get user tokens", "error", err, "user_id", userID)
+		return nil, err
+	}
+	walletIDs := make([]uint, len(wallets))
+	for i, wallet := range wallets {
+		walletIDs[i] = wallet.ID
+	}
+	transfers, err := s.transactionRepo.GetByWalletIDs(ctx, walletIDs)
+	if err != nil {
+		s.logger.Error("Failed to get transactions", "error", err, "user_id", userID)
+		return nil, err
+	}
+	lots, err := s.lotRepo.GetByUserID(ctx, userID)
+	if err != nil {
+		s.logger.Error("Failed to get lots", "error", err, "user_id", userID)
+		return nil, err
+	}
+
+	var positions []tokenPositions
+	for _, token := range tokens {
+		decimals, err := tokenDecimals(ctx, s.web3Registry, s.cacheService, token)
+		if err != nil {
+			s.logger.Warn("Failed to get token decimals", "error", err, "token_id", token.ID)
+			continue
+		}
+
+		events, err := s.tokenEvents(ctx, token, decimals, wallets, transfers, lots)
+		if err != nil {
+			return nil, err
+		}
+		if len(events) == 0 {
+			continue
+		}
+
+		matcher := newLotMatcher(method)
+		for _, event := range events {
+			matcher.apply(event.lotEvent)
+		}
+
+		price, err := s.priceService.GetPriceUSD(ctx, token.ChainID, token.TokenAddress)
+		if err != nil {
+			price = nil
+		}
+		positions = append(positions, tokenPositions{token: token, decimals: decimals, price: price, positions: matcher.positions})
+	}
+
+	return buildPnLReport(method, wallets, positions), nil
+}
+
+// orderedLotEvent is a lot event with its position in chain order
+type orderedLotEvent struct {
+	lotEvent
+	blockNumber uint64
+	logIndex    int
+}
+
+// tokenEvents builds a token's lot events from the user's transfers and
+// manual lots, in chronological order. A transfer between two of the user's
+// wallets moves lots instead of disposing of them; its incoming side is skipped.
+func (s *pnlService) tokenEvents(
+	ctx context.Context,
+	token *models.TrackedToken,
+	decimals uint8,
+	wallets []*models.WatchlistWallet,
+	transfers []*models.Transaction,
+	lots []*models.ManualLot,
+) ([]orderedLotEvent, error) {
+	ownWallets := make(map[common.Address]uint)
+	for _, wallet := range wallets {
+		if wallet.ChainID == token.ChainID {
+			ownWallets[common.HexToAddress(wallet.WalletAddress)] = wallet.ID
+		}
+	}
+
+	manualPrices := make(map[uint]*models.ManualLot)
+	var events []orderedLotEvent
+	for _, manual := range lots {
+		if manual.TokenID != token.ID {
+			continue
+		}
+		if manual.TransactionID != nil {
+			manualPrices[*manual.TransactionID] = manual
+			continue
+		}
+		event, ok := manualLotEvent(manual, decimals)
+		if ok {
+			events = append(events, orderedLotEvent{lotEvent: event})
+		}
+	}
+
+	// Outgoing sides of transfers between the user's wallets, keyed by transfer
+	moved := make(map[string]bool)
+	for _, tx := range transfers {
+		if tx.ChainID != token.ChainID || tokenKey(tx.TokenAddress) != tokenKey(token.TokenAddress) {
+			continue
+		}
+		if _, own := ownWallets[common.HexToAddress(tx.ToAddress)]; own && tx.Direction == models.TransferDirectionOut {
+			moved[transferKey(tx)] = true
+		}
+	}
+
+	for _, tx := range transfers {
+		if tx.ChainID != token.ChainID || tokenKey(tx.TokenAddress) != tokenKey(token.TokenAddress) {
+			continue
+		}
+		raw, ok := new(big.Int).SetString(tx.Amount, 10)
+		if !ok {
+			continue
+		}
+
+		event := lotEvent{walletID: tx.WalletID, amount: units.ToRat(raw, decimals), at: tx.Timestamp}
+		switch tx.Direction {
+		case models.TransferDirectionIn:
+			if moved[transferKey(tx)] {
+				continue
+			}
+			event.kind = lotAcquire
+			if manual, ok := manualPrices[tx.ID]; ok {
+				event.price, _ = units.ParseDecimal(manual.PriceUSD)
+				if amount, ok := new(big.Int).SetString(manual.Amount, 10); ok {
+					event.amount = units.ToRat(amount, decimals)
+				}
+			}
+		case models.TransferDirectionOut:
+			if toWalletID, own := ownWallets[common.HexToAddress(tx.ToAddress)]; own {
+				event.kind = lotMove
+				event.toWalletID = toWalletID
+			} else {
+				event.kind = lotDispose
+			}
+		default:
+			continue
+		}
+
+		if event.kind != lotMove && event.price == nil {
+			price, err := s.priceAt(ctx, token, tx.Timestamp)
+			if err != nil {
+				return nil, err
+			}
+			event.price = price
+		}
+
+		events = append(events, orderedLotEvent{lotEvent: event, blockNumber: tx.BlockNumber, logIndex: tx.LogIndex})
+	}
+
+	sort.SliceStable(events, func(i, j int) bool {
+		a, b := events[i], events[j]
+		if !a.at.Equal(b.at) {
+			return a.at.Before(b.at)
+		}
+		if a.blockNumber != b.blockNumber {
+			return a.blockNumber < b.blockNumber
+		}
+		return a.logIndex < b.logIndex
+	})
+
+	return events, nil
+}
+
+// priceAt returns the token's stamped USD price near the given time, or nil when there is none
+func (s *pnlService) priceAt(ctx context.Context, token *models.TrackedToken, at time.Time) (*big.Rat, error) {
+	stamped, err := s.watchlistRepo.GetPriceAt(ctx, token.ID, at, pnlPriceWindow)
+	if err != nil {
+		s.logger.Error("Failed to get historical price", "error", err, "token_id", token.ID)
+		return nil, err
+	}
+	if stamped == nil {
+		return nil, nil
+	}
+	price, err := units.ParseDecimal(*stamped)
+	if err != nil {
+		return nil, nil
+	}
+	return price, nil
+}
+
+// manualLotEvent converts a standalone manual lot into an acquisition
+func manualLotEvent(manual *models.ManualLot, decimals uint8) (lotEvent, bool) {
+	raw, ok := new(big.Int).SetString(manual.Amount, 10)
+	if !ok {
+		return lotEvent{}, false
+	}
+	price, err := units.ParseDecimal(manual.PriceUSD)
+	if err != nil {
+		return lotEvent{}, false
+	}
+	return lotEvent{
+		kind:     lotAcquire,
+		walletID: manual.WalletID,
+		amount:   units.ToRat(raw, decimals),
+		price:    price,
+		at:       manual.AcquiredAt,
+	}, true
+}
+
+// transferKey identifies a transfer independently of the wallet it was recorded for
+func transferKey(tx *models.Transaction) string {
+	return tx.TxHash + ":" + strconv.Itoa(tx.LogIndex)
+}
+
+// tokenPositions is the outcome of matching one token's lots
+type tokenPositions struct {
+	token     *models.TrackedToken
+	decimals  uint8
+	price     *big.Rat // current USD price; nil when unknown
+	positions map[uint]*lotPosition
+}
+
+// pnlTotals accumulates USD amounts and token amounts of positions
+type pnlTotals struct {
+	amount, costBasis, realized, unrealized, unpriced, unmatched *big.Rat
+}
+
+func newPnLTotals() *pnlTotals {
+	return &pnlTotals{new(big.Rat), new(big.Rat), new(big.Rat), new(big.Rat), new(big.Rat), new(big.Rat)}
+}
+
+// addPosition adds a wallet position valued at the current price
+func (t *pnlTotals) addPosition(position *lotPosition, price *big.Rat) {
+	t.realized.Add(t.realized, position.realized)
+	t.unmatched.Add(t.unmatched, position.unmatched)
+	for _, open := range position.lots {
+		t.amount.Add(t.amount, open.amount)
+		if open.price == nil {
+			t.unpriced.Add(t.unpriced, open.amount)
+			continue
+		}
+		cost := new(big.Rat).Mul(open.amount, open.price)
+		t.costBasis.Add(t.costBasis, cost)
+		if price != nil {
+			value := new(big.Rat).Mul(open.amount, price)
+			t.unrealized.Add(t.unrealized, value.Sub(value, cost))
+		}
+	}
+}
+
+// add adds the USD amounts of other
+func (t *pnlTotals) add(other *pnlTotals) {
+	t.costBasis.Add(t.costBasis, other.costBasis)
+	t.realized.Add(t.realized, other.realized)
+	t.unrealized.Add(t.unrealized, other.unrealized)
+}
+
+// tokenPnL formats totals for a token
+func (t *pnlTotals) tokenPnL(positions tokenPositions) *TokenPnL {
+	result := &TokenPnL{
+		TokenID:         positions.token.ID,
+		TokenAddress:    positions.token.TokenAddress,
+		ChainID:         positions.token.ChainID,
+		TokenSymbol:     positions.token.TokenSymbol,
+		Amount:          formatTokenAmount(t.amount, positions.decimals),
+		CostBasis:       units.FormatDecimal(t.costBasis, valueDecimals),
+		RealizedPnL:     units.FormatDecimal(t.realized, valueDecimals),
+		UnrealizedPnL:   units.FormatDecimal(t.unrealized, valueDecimals),
+		UnpricedAmount:  formatTokenAmount(t.unpriced, positions.decimals),
+		UnmatchedAmount: formatTokenAmount(t.unmatched, positions.decimals),
+	}
+	if positions.price != nil {
+		result.PriceUSD = units.FormatDecimal(positions.price, priceDecimals)
+	}
+	return result
+}
+
+// buildPnLReport totals matched positions per token and per wallet
+func buildPnLReport(method string, wallets []*models.WatchlistWallet, tokens []tokenPositions) *PnLReport {
+	report := &PnLReport{
+		Method:  method,
+		Tokens:  []*TokenPnL{},
+		Wallets: []*WalletPnL{},
+	}
+
+	total := newPnLTotals()
+	walletTotals := make(map[uint]*pnlTotals)
+	walletTokens := make(map[uint][]*TokenPnL)
+
+	for _, positions := range tokens {
+		tokenTotal := newPnLTotals()
+
+		walletIDs := make([]uint, 0, len(positions.positions))
+		for walletID := range positions.positions {
+			walletIDs = append(walletIDs, walletID)
+		}
+		sort.Slice(walletIDs, func(i, j int) bool { return walletIDs[i] < walletIDs[j] })
+
+		for _, walletID := range walletIDs {
+			walletTotal := newPnLTotals()
+			walletTotal.addPosition(positions.positions[walletID], positions.price)
+			tokenTotal.addPosition(positions.positions[walletID], positions.price)
+
+			if _, ok := walletTotals[walletID]; !ok {
+				walletTotals[walletID] = newPnLTotals()
+			}
+			walletTotals[walletID].add(walletTotal)
+			walletTokens[walletID] = append(walletTokens[walletID], walletTotal.tokenPnL(positions))
+		}
+
+		total.add(tokenTotal)
+		report.Tokens = append(report.Tokens, tokenTotal.tokenPnL(positions))
+	}
+
+	for _, wallet := range wallets {
+		walletTotal, ok := walletTotals[wallet.ID]
+		if !ok {
+			continue
+		}
+		report.Wallets = append(report.Wallets, &WalletPnL{
+			WalletID:      wallet.ID,
+			WalletAddress: wallet.WalletAddress,
+			ChainID:       wallet.ChainID,
+			Label:         wallet.Label,
+			RealizedPnL:   units.FormatDecimal(walletTotal.realized, valueDecimals),
+			UnrealizedPnL: units.FormatDecimal(walletTotal.unrealized, valueDecimals),
+			CostBasis:     units.FormatDecimal(walletTotal.costBasis, valueDecimals),
+			Tokens:        walletTokens[wallet.ID],
+		})
+	}
+
+	report.RealizedPnL = units.FormatDecimal(total.realized, valueDecimals)
+	report.UnrealizedPnL = units.FormatDecimal(total.unrealized, valueDecimals)
+	report.CostBasis = units.FormatDecimal(total.costBasis, valueDecimals)
+
+	return report
+}
+
+// formatTokenAmount formats a decimal-adjusted amount with the token's decimals
+func formatTokenAmount(amount *big.Rat, decimals uint8) string {
+	raw := new(big.Int).Mul(amount.Num(), units.Pow10(decimals))
+	raw.Quo(raw, amount.Denom())
+	return units.FormatUnits(raw, decimals)
+}
+
+// AddLot records a manually priced acquisition. A lot linked to a
+// transaction prices that incoming transfer and replaces its stamped price.
+func (s *pnlService) AddLot(ctx context.Context, userID uint, req *AddLotRequest) (*LotResponse, error) {
+	wallet, err := s.watchlistRepo.GetWalletByID(ctx, req.WalletID)
+	if err != nil {
+		if errors.Is(err, repository.ErrRecordNotFound) {
+			return nil, ErrWalletNotFound
+		}
+		return nil, err
+	}
+	if wallet.UserID != userID {
+		return nil, ErrWalletNotFound
+	}
+
+	token, err := s.watchlistRepo.GetTokenByID(ctx, req.TokenID)
+	if err != nil {
+		if errors.Is(err, repository.ErrRecordNotFound) {
+			return nil, ErrTokenNotFound
+		}
+		return nil, err
+	}
+	if token.UserID != userID {
+		return nil, ErrTokenNotFound
+	}
+	if token.ChainID != wallet.ChainID {
+		return nil, ErrInvalidLot
+	}
+
+	price, err := units.ParseDecimal(req.PriceUSD)
+	if err != nil || price.Sign() < 0 {
+		return nil, ErrInvalidLot
+	}
+
+	decimals, err := tokenDecimals(ctx, s.web3Registry, s.cacheService, token)
+	if err != nil {
+		s.logger.Error("Failed to get token decimals", "error", err, "token_id", token.ID)
+		return nil, err
+	}
+
+	lot := &models.ManualLot{
+		UserID:        userID,
+		WalletID:      wallet.ID,
+		TokenID:       token.ID,
+		TransactionID: req.TransactionID,
+		PriceUSD:      units.FormatDecimal(price, priceDecimals),
+		Note:          req.Note,
+	}
+
+	if req.TransactionID != nil {
+		tx, err := s.transactionRepo.GetByID(ctx, *req.TransactionID)
+		if err != nil {
+			if errors.Is(err, repository.ErrRecordNotFound) {
+				return nil, ErrTransactionNotFound
+			}
+			return nil, err
+		}
+		// Only incoming transfers of this token into this wallet create lots
+		if tx.WalletID != wallet.ID || tx.Direction != models.TransferDirectionIn ||
+			tx.ChainID != token.ChainID || tokenKey(tx.TokenAddress) != tokenKey(token.TokenAddress) {
+			return nil, ErrTransactionNotFound
+		}
+
+		lots, err := s.lotRepo.GetByUserID(ctx, userID)
+		if err != nil {
+			s.logger.Error("Failed to get lots", "error", err, "user_id", userID)
+			return nil, err
+		}
+		for _, existing := range lots {
+			if existing.TransactionID != nil && *existing.TransactionID == tx.ID {
+				return nil, ErrLotAlreadyExists
+			}
+		}
+
+		lot.Amount = tx.Amount
+		lot.AcquiredAt = tx.Timestamp
+	}
+
+	if req.Amount != "" {
+		amount, err := units.ParseUnits(req.Amount, decimals)
+		if err != nil {
+			return nil, ErrInvalidLot
+		}
+		lot.Amount = amount.String()
+	}
+	if req.AcquiredAt != nil {
+		lot.AcquiredAt = *req.AcquiredAt
+	}
+	if lot.AcquiredAt.IsZero() {
+		return nil, ErrInvalidLot
+	}
+	if amount, ok := new(big.Int).SetString(lot.Amount, 10); !ok || amount.Sign() <= 0 {
+		return nil, ErrInvalidLot
+	}
+
+	if err := s.lotRepo.Create(ctx, lot); err != nil {
+		s.logger.Error("Failed to create lot", "error", err, "user_id", userID)
+		return nil, err
+	}
+
+	s.logger.Info("Manual lot added", "user_id", userID, "lot_id", lot.ID, "wallet_id", wallet.ID, "token_id", token.ID)
+
+	lot.Wallet = *wallet
+	lot.Token = *token
+	response := lotResponse(lot)
+	response.FormattedAmount = formatRawAmount(lot.Amount, decimals)
+	return response, nil
+}
+
+// GetLots retrieves the user's manual lots
+func (s *pnlService) GetLots(ctx context.Context, userID uint) ([]*LotResponse, error) {
+	lots, err := s.lotRepo.GetByUserID(ctx, userID)
+	if err != nil {
+		s.logger.Error("Failed to get lots", "error", err, "user_id", userID)
+		return nil, err
+	}
+
+	responses := make([]*LotResponse, 0, len(lots))
+	for _, lot := range lots {
+		response := lotResponse(lot)
+		if decimals, err := tokenDecimals(ctx, s.web3Registry, s.cacheService, &lot.Token); err == nil {
+			response.FormattedAmount = formatRawAmount(lot.Amount, decimals)
+		}
+		responses = append(responses, response)
+	}
+
+	return responses, nil
+}
+
+// DeleteLot removes one of the user's manual lots
+func (s *pnlService) DeleteLot(ctx context.Context, userID uint, lotID uint) error {
+	if err := s.lotRepo.Delete(ctx, lotID, userID); err != nil {
+		if errors.Is(err, repository.ErrRecordNotFound) {
+			return ErrLotNotFound
+		}
+		s.logger.Error("Failed to delete lot", "error", err, "user_id", userID, "lot_id", lotID)
+		return err
+	}
+
+	s.logger.Info("Manual lot removed", "user_id", userID, "lot_id", lotID)
+	return nil
+}
+
+// lotResponse converts a manual lot with its preloaded wallet and token
+func lotResponse(lot *models.ManualLot) *LotResponse {
+	return &LotResponse{
+		ID:            lot.ID,
+		WalletID:      lot.WalletID,
+		WalletAddress: lot.Wallet.WalletAddress,
+		TokenID:       lot.TokenID,
+		TokenSymbol:   lot.Token.TokenSymbol,
+		ChainID:       lot.Token.ChainID,
+		TransactionID: lot.TransactionID,
+		Amount:        lot.Amount,
+		PriceUSD:      lot.PriceUSD,
+		AcquiredAt:    lot.AcquiredAt,
+		Note:          lot.Note,
+		CreatedAt:     lot.CreatedAt,
+	}
+}
@@ -0,0 +1,132 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"cryptoportfolio/internal/models"
+	"cryptoportfolio/internal/repository"
+	"cryptoportfolio/pkg/logger"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakePriceHistory serves stamped prices by exact snapshot time
+type fakePriceHistory struct {
+	repository.WatchlistRepository
+	prices map[time.Time]string
+}
+
+func (r *fakePriceHistory) GetPriceAt(ctx context.Context, tokenID uint, at time.Time, window time.Duration) (*string, error) {
+	if price, ok := r.prices[at]; ok {
+		return &price, nil
+	}
+	return nil, nil
+}
+
+func testTransfer(id, walletID uint, day int, logIndex int, direction, from, to, amount string) *models.Transaction {
+	return &models.Transaction{
+		ID:          id,
+		WalletID:    walletID,
+		ChainID:     1,
+		TxHash:      "0x" + string(rune('a'+day)),
+		LogIndex:    logIndex,
+		BlockNumber: uint64(100 + day),
+		Timestamp:   testLotStart.AddDate(0, 0, day),
+		FromAddress: from,
+		ToAddress:   to,
+		Direction:   direction,
+		Amount:      amount,
+	}
+}
+
+func TestPnL_TokenEvents(t *testing.T) {
+	walletA := &models.WatchlistWallet{ID: 1, UserID: 1, WalletAddress: testWalletA, ChainID: 1, Label: "cold"}
+	walletB := &models.WatchlistWallet{ID: 2, UserID: 1, WalletAddress: testWalletB, ChainID: 1, Label: "hot"}
+	eth := &models.TrackedToken{ID: 10, UserID: 1, ChainID: 1, TokenSymbol: "ETH"}
+	usdc := testUSDC
+
+	const ether = "000000000000000000"
+	transfers := []*models.Transaction{
+		// Bought 2 ETH, priced later by a manual lot
+		testTransfer(1, 1, 1, models.NativeTransferLogIndex, models.TransferDirectionIn, testStranger, testWalletA, "2"+ether),
+		// Moved 1 ETH from A to B, recorded for both wallets
+		testTransfer(2, 1, 2, models.NativeTransferLogIndex, models.TransferDirectionOut, testWalletA, testWalletB, "1"+ether),
+		testTransfer(3, 2, 2, models.NativeTransferLogIndex, models.TransferDirectionIn, testWalletA, testWalletB, "1"+ether),
+		// Sold 1.5 ETH from B at the stamped price
+		testTransfer(4, 2, 3, models.NativeTransferLogIndex, models.TransferDirectionOut, testWalletB, testStranger, "15"+ether[1:]),
+		// USDC transfers belong to another token
+		{ID: 5, WalletID: 1, ChainID: 1, TokenAddress: &usdc, Direction: models.TransferDirectionIn, Amount: "1000000", Timestamp: testLotStart},
+	}
+	lots := []*models.ManualLot{
+		{ID: 1, WalletID: 1, TokenID: 10, TransactionID: uintPtr(1), Amount: "2" + ether, PriceUSD: "1000"},
+		{ID: 2, WalletID: 2, TokenID: 10, Amount: "1" + ether, PriceUSD: "500", AcquiredAt: testLotStart},
+		{ID: 3, WalletID: 1, TokenID: 11, Amount: "1000000", PriceUSD: "1", AcquiredAt: testLotStart},
+	}
+
+	service := &pnlService{
+		watchlistRepo: &fakePriceHistory{prices: map[time.Time]string{
+			testLotStart.AddDate(0, 0, 1): "900",
+			testLotStart.AddDate(0, 0, 3): "1200",
+		}},
+		logger: logger.New(),
+	}
+
+	events, err := service.tokenEvents(context.Background(), eth, 18, []*models.WatchlistWallet{walletA, walletB}, transfers, lots)
+	require.NoError(t, err)
+
+	type event struct {
+		kind       lotEventKind
+		walletID   uint
+		toWalletID uint
+		amount     string
+		price      string
+	}
+	var got []event
+	for _, e := range events {
+		price := ""
+		if e.price != nil {
+			price = e.price.RatString()
+		}
+		got = append(got, event{e.kind, e.walletID, e.toWalletID, e.amount.RatString(), price})
+	}
+	assert.Equal(t, []event{
+		{lotAcquire, 2, 0, "1", "500"},
+		{lotAcquire, 1, 0, "2", "1000"},
+		{lotMove, 1, 2, "1", ""},
+		{lotDispose, 2, 0, "3/2", "1200"},
+	}, got)
+
+	matcher := newLotMatcher(CostBasisFIFO)
+	for _, e := range events {
+		matcher.apply(e.lotEvent)
+	}
+	report := buildPnLReport(CostBasisFIFO, []*models.WatchlistWallet{walletA, walletB}, []tokenPositions{
+		{token: eth, decimals: 18, price: rat("1500"), positions: matcher.positions},
+	})
+
+	// B sells its own 1 @ 500 and half of the lot moved from A @ 1000
+	assert.Equal(t, "fifo", report.Method)
+	assert.Equal(t, "800.000000", report.RealizedPnL)
+	assert.Equal(t, "750.000000", report.UnrealizedPnL)
+	assert.Equal(t, "1500.000000", report.CostBasis)
+
+	require.Len(t, report.Tokens, 1)
+	assert.Equal(t, "1.5", report.Tokens[0].Amount)
+	assert.Equal(t, "1500.00000000", report.Tokens[0].PriceUSD)
+	assert.Equal(t, "0", report.Tokens[0].UnmatchedAmount)
+
+	require.Len(t, report.Wallets, 2)
+	assert.Equal(t, "cold", report.Wallets[0].Label)
+	assert.Equal(t, "0.000000", report.Wallets[0].RealizedPnL)
+	assert.Equal(t, "500.000000", report.Wallets[0].UnrealizedPnL)
+	assert.Equal(t, "800.000000", report.Wallets[1].RealizedPnL)
+	assert.Equal(t, "250.000000", report.Wallets[1].UnrealizedPnL)
+	assert.Equal(t, "0.5", report.Wallets[1].Tokens[0].Amount)
+}
+
+func uintPtr(v uint) *uint {
+	return &v
+}
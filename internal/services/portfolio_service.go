@@ -0,0 +1,297 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"sort"
+	"strings"
+	"time"
+
+	"cryptoportfolio/internal/cache"
+	"cryptoportfolio/internal/models"
+	"cryptoportfolio/internal/repository"
+	"cryptoportfolio/pkg/logger"
+	"cryptoportfolio/pkg/units"
+)
+
+// ErrUnsupportedQuote is returned when a summary is requested in a currency that cannot be priced
+var ErrUnsupportedQuote = errors.New("unsupported quote currency")
+
+// DefaultQuote is the currency portfolio values are reported in by default
+const DefaultQuote = "USD"
+
+// Prices, values and allocations are reported with this many decimal places
+const (
+	priceDecimals      = 8
+	valueDecimals      = 6
+	allocationDecimals = 2
+)
+
+// PortfolioSummary is a valuation of all of a user's latest balances
+type PortfolioSummary struct {
+	Quote            string           `json:"quote" example:"USD"`
+	TotalValue       string           `json:"total_value" example:"12345.678900"`
+	Wallets          []*WalletSummary `json:"wallets"`
+	Tokens           []*TokenSummary  `json:"tokens"`
+	UnpricedBalances int              `json:"unpriced_balances"`    // Balances without a price, excluded from totals
+	UpdatedAt        *time.Time       `json:"updated_at,omitempty"` // Most recent fetch among the balances
+}
+
+// WalletSummary is the value of one wallet across all its tokens
+type WalletSummary struct {
+	WalletID      uint   `json:"wallet_id"`
+	WalletAddress string `json:"wallet_address"`
+	ChainID       int64  `json:"chain_id"`
+	Label         string `json:"label"`
+	Value         string `json:"value"`
+	Allocation    string `json:"allocation" example:"42.50"` // Percentage of the total value
+}
+
+// TokenSummary is the holding of one token across all wallets
+type TokenSummary struct {
+	TokenID      uint    `json:"token_id"`
+	TokenAddress *string `json:"token_address"`
+	ChainID      int64   `json:"chain_id"`
+	TokenSymbol  string  `json:"token_symbol"`
+	TokenName    string  `json:"token_name"`
+	Balance      string  `json:"balance"` // Decimal-adjusted balance summed over wallets
+	Value        string  `json:"value"`
+	Allocation   string  `json:"allocation" example:"42.50"` // Percentage of the total value
+}
+
+// PortfolioService values a user's portfolio
+type PortfolioService interface {
+	GetSummary(ctx context.Context, userID uint, quote string) (*PortfolioSummary, error)
+}
+
+// portfolioService implements PortfolioService
+type portfolioService struct {
+	watchlistRepo repository.WatchlistRepository
+	web3Registry  Web3Registry
+	priceService  PriceService
+	cacheService  cache.CacheProvider
+	logger        *logger.Logger
+}
+
+// NewPortfolioService creates a new portfolio service
+func NewPortfolioService(
+	watchlistRepo repository.WatchlistRepository,
+	web3Registry Web3Registry,
+	priceService PriceService,
+	cacheService cache.CacheProvider,
+	logger *logger.Logger,
+) PortfolioService {
+	return &portfolioService{
+		watchlistRepo: watchlistRepo,
+		web3Registry:  web3Registry,
+		priceService:  priceService,
+		cacheService:  cacheService,
+		logger:        logger,
+	}
+}
+
+// portfolioHolding is a latest balance with its decimal-adjusted amount and USD value
+type portfolioHolding struct {
+	balance  *models.WalletBalance
+	amount   *big.Int
+	decimals uint8
+	valueUSD *big.Rat // nil when the balance could not be priced
+}
+
+// GetSummary values the user's latest balances in the quote currency. The
+// quote is USD or the symbol of one of the user's tracked tokens.
+func (s *portfolioService) GetSummary(ctx context.Context, userID uint, quote string) (*PortfolioSummary, error) {
+	quote, quotePrice, err := s.resolveQuote(ctx, userID, quote)
+	if err != nil {
+		return nil, err
+	}
+
+	balances, err := s.watchlistRepo.GetLatestBalances(ctx, userID)
+	if err != nil {
+		s.logger.Error("Failed to get balances", "error", err, "user_id", userID)
+		return nil, err
+	}
+
+	holdings := make([]portfolioHolding, 0, len(balances))
+	for _, balance := range balances {
+		// Balances of removed wallets and tokens are kept for history but are not part of the portfolio
+		if balance.Wallet.ID == 0 || balance.Token.ID == 0 {
+			continue
+		}
+
+		holding, err := s.valueBalance(ctx, balance)
+		if err != nil {
+			s.logger.Warn("Failed to value balance",
+				"user_id", userID,
+				"wallet_id", balance.WalletID,
+				"token_id", balance.TokenID,
+				"error", err)
+			continue
+		}
+		holdings = append(holdings, holding)
+	}
+
+	return buildPortfolioSummary(holdings, quote, quotePrice), nil
+}
+
+// resolveQuote normalizes the quote currency and returns its USD price
+func (s *portfolioService) resolveQuote(ctx context.Context, userID uint, quote string) (string, *big.Rat, error) {
+	if quote == "" || strings.EqualFold(quote, DefaultQuote) {
+		return DefaultQuote, big.NewRat(1, 1), nil
+	}
+
+	tokens, err := s.watchlistRepo.GetTokensByUserID(ctx, userID)
+	if err != nil {
+		return "", nil, err
+	}
+
+	// Prefer the token on the default chain when a symbol is tracked on several chains
+	var match *models.TrackedToken
+	for _, token := range tokens {
+		if !strings.EqualFold(token.TokenSymbol, quote) {
+			continue
+		}
+		if match == nil || token.ChainID == s.web3Registry.DefaultChainID() {
+			match = token
+		}
+	}
+	if match == nil {
+		return "", nil, ErrUnsupportedQuote
+	}
+
+	price, err := s.priceService.GetPriceUSD(ctx, match.ChainID, match.TokenAddress)
+	if err != nil || price.Sign() == 0 {
+		s.logger.Warn("Failed to price quote currency", "quote", quote, "error", err)
+		return "", nil, ErrUnsupportedQuote
+	}
+
+	return match.TokenSymbol, price, nil
+}
+
+// valueBalance decimal-adjusts a balance and values it with the price stamped
+// at fetch time, falling back to the current price for unstamped balances
+func (s *portfolioService) valueBalance(ctx context.Context, balance *models.WalletBalance) (portfolioHolding, error) {
+	raw, ok := new(big.Int).SetString(balance.Balance, 10)
+	if !ok {
+		return portfolioHolding{}, errors.New("invalid stored balance")
+	}
+
+	decimals, err := tokenDecimals(ctx, s.web3Registry, s.cacheService, &balance.Token)
+	if err != nil {
+		return portfolioHolding{}, err
+	}
+
+	holding := portfolioHolding{
+		balance:  balance,
+		amount:   raw,
+		decimals: decimals,
+	}
+
+	if balance.BalanceUSD != nil {
+		if value, err := units.ParseDecimal(*balance.BalanceUSD); err == nil {
+			holding.valueUSD = value
+			return holding, nil
+		}
+	}
+
+	if price, err := s.priceService.GetPriceUSD(ctx, balance.Token.ChainID, balance.Token.TokenAddress); err == nil {
+		holding.valueUSD = usdValue(raw, decimals, price)
+	}
+
+	return holding, nil
+}
+
+// buildPortfolioSummary totals holdings per wallet and per token, converts
+// values into the quote currency and computes each entry's allocation
+func buildPortfolioSummary(holdings []portfolioHolding, quote string, quotePrice *big.Rat) *PortfolioSummary {
+	summary := &PortfolioSummary{
+		Quote:   quote,
+		Wallets: []*WalletSummary{},
+		Tokens:  []*TokenSummary{},
+	}
+
+	total := new(big.Rat)
+	walletValues := make(map[uint]*big.Rat)
+	tokenValues := make(map[uint]*big.Rat)
+	tokenAmounts := make(map[uint]*big.Int)
+	decimalsByToken := make(map[uint]uint8)
+	wallets := make(map[uint]*WalletSummary)
+	tokens := make(map[uint]*TokenSummary)
+
+	for _, holding := range holdings {
+		balance := holding.balance
+
+		if _, ok := wallets[balance.WalletID]; !ok {
+			wallets[balance.WalletID] = &WalletSummary{
+				WalletID:      balance.WalletID,
+				WalletAddress: balance.Wallet.WalletAddress,
+				ChainID:       balance.Wallet.ChainID,
+				Label:         balance.Wallet.Label,
+			}
+			walletValues[balance.WalletID] = new(big.Rat)
+			summary.Wallets = append(summary.Wallets, wallets[balance.WalletID])
+		}
+		if _, ok := tokens[balance.TokenID]; !ok {
+			tokens[balance.TokenID] = &TokenSummary{
+				TokenID:      balance.TokenID,
+				TokenAddress: balance.Token.TokenAddress,
+				ChainID:      balance.Token.ChainID,
+				TokenSymbol:  balance.Token.TokenSymbol,
+				TokenName:    balance.Token.TokenName,
+			}
+			tokenValues[balance.TokenID] = new(big.Rat)
+			tokenAmounts[balance.TokenID] = new(big.Int)
+			decimalsByToken[balance.TokenID] = holding.decimals
+			summary.Tokens = append(summary.Tokens, tokens[balance.TokenID])
+		}
+
+		tokenAmounts[balance.TokenID].Add(tokenAmounts[balance.TokenID], holding.amount)
+
+		if summary.UpdatedAt == nil || balance.FetchedAt.After(*summary.UpdatedAt) {
+			fetchedAt := balance.FetchedAt
+			summary.UpdatedAt = &fetchedAt
+		}
+
+		if holding.valueUSD == nil {
+			summary.UnpricedBalances++
+			continue
+		}
+
+		value := new(big.Rat).Quo(holding.valueUSD, quotePrice)
+		total.Add(total, value)
+		walletValues[balance.WalletID].Add(walletValues[balance.WalletID], value)
+		tokenValues[balance.TokenID].Add(tokenValues[balance.TokenID], value)
+	}
+
+	summary.TotalValue = units.FormatDecimal(total, valueDecimals)
+	for _, wallet := range summary.Wallets {
+		wallet.Value = units.FormatDecimal(walletValues[wallet.WalletID], valueDecimals)
+		wallet.Allocation = allocation(walletValues[wallet.WalletID], total)
+	}
+	for _, token := range summary.Tokens {
+		token.Balance = units.FormatUnits(tokenAmounts[token.TokenID], decimalsByToken[token.TokenID])
+		token.Value = units.FormatDecimal(tokenValues[token.TokenID], valueDecimals)
+		token.Allocation = allocation(tokenValues[token.TokenID], total)
+	}
+
+	// Largest positions first
+	sort.SliceStable(summary.Wallets, func(i, j int) bool {
+		return walletValues[summary.Wallets[i].WalletID].Cmp(walletValues[summary.Wallets[j].WalletID]) > 0
+	})
+	sort.SliceStable(summary.Tokens, func(i, j int) bool {
+		return tokenValues[summary.Tokens[i].TokenID].Cmp(tokenValues[summary.Tokens[j].TokenID]) > 0
+	})
+
+	return summary
+}
+
+// allocation returns value as a percentage of total
+func allocation(value, total *big.Rat) string {
+	if total.Sign() == 0 {
+		return units.FormatDecimal(new(big.Rat), allocationDecimals)
+	}
+	percent := new(big.Rat).Quo(value, total)
+	percent.Mul(percent, big.NewRat(100, 1))
+	return units.FormatDecimal(percent, allocationDecimals)
+}
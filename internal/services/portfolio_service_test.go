@@ -0,0 +1,99 @@
+package services
+
+import (
+	"math/big"
+	"testing"
+	"time"
+
+	"cryptoportfolio/internal/models"
+	"cryptoportfolio/pkg/units"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testHolding(wallet *models.WatchlistWallet, token *models.TrackedToken, amount string, decimals uint8, valueUSD string) portfolioHolding {
+	holding := portfolioHolding{
+		balance: &models.WalletBalance{
+			WalletID:  wallet.ID,
+			TokenID:   token.ID,
+			Wallet:    *wallet,
+			Token:     *token,
+			FetchedAt: time.Date(2024, 1, 1, 0, 0, int(wallet.ID), 0, time.UTC),
+		},
+		decimals: decimals,
+	}
+	holding.amount, _ = units.ParseUnits(amount, decimals)
+	if valueUSD != "" {
+		holding.valueUSD, _ = new(big.Rat).SetString(valueUSD)
+	}
+	return holding
+}
+
+func TestBuildPortfolioSummary(t *testing.T) {
+	usdcAddress := "0xA0b86991c6218b36c1d19D4a2e9Eb0cE3606eB48"
+	walletA := &models.WatchlistWallet{ID: 1, WalletAddress: "0xaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa", ChainID: 1, Label: "cold"}
+	walletB := &models.WatchlistWallet{ID: 2, WalletAddress: "0xbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbb", ChainID: 1, Label: "hot"}
+	eth := &models.TrackedToken{ID: 10, ChainID: 1, TokenSymbol: "ETH", TokenName: "Ether"}
+	usdc := &models.TrackedToken{ID: 11, ChainID: 1, TokenAddress: &usdcAddress, TokenSymbol: "USDC", TokenName: "USD Coin"}
+	unpriced := &models.TrackedToken{ID: 12, ChainID: 1, TokenSymbol: "XYZ", TokenName: "Unpriced"}
+
+	holdings := []portfolioHolding{
+		testHolding(walletA, eth, "1.5", 18, "3000"),
+		testHolding(walletA, usdc, "500", 6, "500"),
+		testHolding(walletB, eth, "0.5", 18, "1000"),
+		testHolding(walletB, usdc, "500.25", 6, "500.25"),
+		testHolding(walletB, unpriced, "7", 18, ""),
+	}
+
+	summary := buildPortfolioSummary(holdings, DefaultQuote, big.NewRat(1, 1))
+
+	assert.Equal(t, "USD", summary.Quote)
+	assert.Equal(t, "5000.250000", summary.TotalValue)
+	assert.Equal(t, 1, summary.UnpricedBalances)
+	require.NotNil(t, summary.UpdatedAt)
+	assert.Equal(t, time.Date(2024, 1, 1, 0, 0, 2, 0, time.UTC), *summary.UpdatedAt)
+
+	// Wallets are ordered by value
+	require.Len(t, summary.Wallets, 2)
+	assert.Equal(t, uint(1), summary.Wallets[0].WalletID)
+	assert.Equal(t, "3500.000000", summary.Wallets[0].Value)
+	assert.Equal(t, "70.00", summary.Wallets[0].Allocation)
+	assert.Equal(t, "cold", summary.Wallets[0].Label)
+	assert.Equal(t, "1500.250000", summary.Wallets[1].Value)
+	assert.Equal(t, "30.00", summary.Wallets[1].Allocation)
+
+	// Tokens are summed across wallets and ordered by value
+	require.Len(t, summary.Tokens, 3)
+	assert.Equal(t, "ETH", summary.Tokens[0].TokenSymbol)
+	assert.Equal(t, "2", summary.Tokens[0].Balance)
+	assert.Equal(t, "4000.000000", summary.Tokens[0].Value)
+	assert.Equal(t, "80.00", summary.Tokens[0].Allocation)
+	assert.Equal(t, "USDC", summary.Tokens[1].TokenSymbol)
+	assert.Equal(t, "1000.25", summary.Tokens[1].Balance)
+	assert.Equal(t, "20.00", summary.Tokens[1].Allocation)
+	assert.Equal(t, "XYZ", summary.Tokens[2].TokenSymbol)
+	assert.Equal(t, "7", summary.Tokens[2].Balance)
+	assert.Equal(t, "0.000000", summary.Tokens[2].Value)
+	assert.Equal(t, "0.00", summary.Tokens[2].Allocation)
+}
+
+func TestBuildPortfolioSummary_Quote(t *testing.T) {
+	wallet := &models.WatchlistWallet{ID: 1, ChainID: 1}
+	eth := &models.TrackedToken{ID: 10, ChainID: 1, TokenSymbol: "ETH"}
+
+	summary := buildPortfolioSummary([]portfolioHolding{testHolding(wallet, eth, "2", 18, "5000")}, "ETH", big.NewRat(2500, 1))
+
+	assert.Equal(t, "ETH", summary.Quote)
+	assert.Equal(t, "2.000000", summary.TotalValue)
+	assert.Equal(t, "100.00", summary.Wallets[0].Allocation)
+}
+
+func TestBuildPortfolioSummary_Empty(t *testing.T) {
+	summary := buildPortfolioSummary(nil, DefaultQuote, big.NewRat(1, 1))
+
+	assert.Equal(t, "0.000000", summary.TotalValue)
+	assert.Empty(t, summary.Wallets)
+	assert.Empty(t, summary.Tokens)
+	assert.Nil(t, summary.UpdatedAt)
+}
@@ -0,0 +1,206 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"strings"
+	"time"
+
+	"cryptoportfolio/internal/cache"
+	"cryptoportfolio/internal/config"
+	"cryptoportfolio/pkg/logger"
+	"cryptoportfolio/pkg/units"
+)
+
+// ErrPriceUnavailable is returned when no price source can price a token
+var ErrPriceUnavailable = errors.New("price unavailable")
+
+// PriceService resolves USD prices for tokens
+type PriceService interface {
+	GetPriceUSD(ctx context.Context, chainID int64, tokenAddress *string) (*big.Rat, error)
+}
+
+// PriceSource is a single way of pricing tokens. Sources return
+// ErrPriceUnavailable for tokens they have no feed for.
+type PriceSource interface {
+	Name() string
+	GetPriceUSD(ctx context.Context, chainID int64, tokenAddress *string) (*big.Rat, error)
+}
+
+// PriceFeed describes how a token is priced. A token may be listed several
+// times; each source only uses the fields it understands.
+type PriceFeed struct {
+	ChainID int64  `json:"chain_id"`
+	Token   string `json:"token"` // Token address, or "native" for the chain's native token
+
+	// Static USD price, used by the static source
+	USD string `json:"usd,omitempty"`
+
+	// USD-denominated Chainlink aggregator, used by the chainlink source
+	Chainlink string `json:"chainlink,omitempty"`
+
+	// Uniswap V3 pool pairing the token with QuoteToken, used by the uniswap
+	// source. The quote token's USD price is resolved through the other sources.
+	UniswapV3Pool string `json:"uniswap_v3_pool,omitempty"`
+	QuoteToken    string `json:"quote_token,omitempty"`
+	TWAPWindow    int    `json:"twap_window,omitempty"` // Seconds; PRICE_TWAP_WINDOW when zero
+}
+
+// defaultPriceFeeds are used when PRICE_FEEDS_FILE is not set
+var defaultPriceFeeds = []PriceFeed{
+	{ChainID: 1, Token: "native", Chainlink: "0x5f4eC3Df9cbd43714FE2740f5E3616155c5b8419"},                                     // ETH / USD
+	{ChainID: 1, Token: "0xA0b86991c6218b36c1d19D4a2e9Eb0cE3606eB48", Chainlink: "0x8fFfFfd4AfB6115b954Bd326cbe7B4BA576818f6"}, // USDC / USD
+	{ChainID: 1, Token: "0xdAC17F958D2ee523a2206206994597C13D831ec7", Chainlink: "0x3E7d1eAB13ad0104d2750B8863b489D65364e32D"}, // USDT / USD
+	{ChainID: 1, Token: "0x6B175474E89094C44Da98b954EedeAC495271d0F", Chainlink: "0xAed0c38402a5d19df6E4c03F4E2DceD6e29c1ee9"}, // DAI / USD
+}
+
+// LoadPriceFeeds reads feed definitions from a JSON file, falling back to
+// the built-in feeds when no file is configured
+func LoadPriceFeeds(path string) ([]PriceFeed, error) {
+	if path == "" {
+		return defaultPriceFeeds, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read price feeds: %w", err)
+	}
+
+	var feeds []PriceFeed
+	if err := json.Unmarshal(data, &feeds); err != nil {
+		return nil, fmt.Errorf("failed to parse price feeds: %w", err)
+	}
+
+	return feeds, nil
+}
+
+// NewPriceSources builds the sources listed in PRICE_SOURCES, in order
+func NewPriceSources(cfg *config.Config, web3Registry Web3Registry) ([]PriceSource, error) {
+	feeds, err := LoadPriceFeeds(cfg.Price.FeedsFile)
+	if err != nil {
+		return nil, err
+	}
+
+	maxAge := time.Duration(cfg.Price.MaxAge) * time.Second
+	twapWindow := time.Duration(cfg.Price.TWAPWindow) * time.Second
+
+	var sources []PriceSource
+	var quoteSources priceSourceChain
+	for _, name := range cfg.Price.Sources {
+		switch strings.ToLower(name) {
+		case "static":
+			source, err := NewStaticPriceSource(feeds)
+			if err != nil {
+				return nil, err
+			}
+			sources = append(sources, source)
+			quoteSources = append(quoteSources, source)
+		case "chainlink":
+			source := NewChainlinkPriceSource(web3Registry, feeds, maxAge)
+			sources = append(sources, source)
+			quoteSources = append(quoteSources, source)
+		case "uniswap":
+			// Quote tokens are priced with the non-TWAP sources, which are
+			// filled in as the remaining names are parsed
+			sources = append(sources, NewUniswapV3PriceSource(web3Registry, feeds, &quoteSources, twapWindow))
+		default:
+			return nil, fmt.Errorf("unknown price source %q", name)
+		}
+	}
+
+	return sources, nil
+}
+
+// priceSourceChain tries each source in turn
+type priceSourceChain []PriceSource
+
+// Name returns the names of the chained sources
+func (c *priceSourceChain) Name() string {
+	names := make([]string, len(*c))
+	for i, source := range *c {
+		names[i] = source.Name()
+	}
+	return strings.Join(names, ",")
+}
+
+// GetPriceUSD returns the first price any source can provide. When no source
+// has a feed for the token ErrPriceUnavailable is returned; otherwise the last
+// source error is.
+func (c *priceSourceChain) GetPriceUSD(ctx context.Context, chainID int64, tokenAddress *string) (*big.Rat, error) {
+	lastErr := ErrPriceUnavailable
+	for _, source := range *c {
+		price, err := source.GetPriceUSD(ctx, chainID, tokenAddress)
+		if err == nil {
+			return price, nil
+		}
+		if !errors.Is(err, ErrPriceUnavailable) {
+			lastErr = fmt.Errorf("%s: %w", source.Name(), err)
+		}
+	}
+	return nil, lastErr
+}
+
+// priceService implements PriceService
+type priceService struct {
+	sources      priceSourceChain
+	cacheService cache.CacheProvider
+	cacheTTL     time.Duration
+	logger       *logger.Logger
+}
+
+// NewPriceService creates a price service that queries sources in order and
+// caches resolved prices
+func NewPriceService(sources []PriceSource, cacheService cache.CacheProvider, cacheTTL time.Duration, logger *logger.Logger) PriceService {
+	return &priceService{
+		sources:      sources,
+		cacheService: cacheService,
+		cacheTTL:     cacheTTL,
+		logger:       logger,
+	}
+}
+
+// GetPriceUSD returns the USD price of one whole token
+func (s *priceService) GetPriceUSD(ctx context.Context, chainID int64, tokenAddress *string) (*big.Rat, error) {
+	cacheKey := fmt.Sprintf("price:%d:%s", chainID, priceFeedKey(tokenAddress))
+
+	var cached string
+	if err := s.cacheService.Get(ctx, cacheKey, &cached); err == nil {
+		if price, ok := new(big.Rat).SetString(cached); ok {
+			return price, nil
+		}
+	}
+
+	price, err := s.sources.GetPriceUSD(ctx, chainID, tokenAddress)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.cacheService.Set(ctx, cacheKey, price.RatString(), s.cacheTTL); err != nil {
+		s.logger.Warn("Failed to cache price", "chain_id", chainID, "token", priceFeedKey(tokenAddress), "error", err)
+	}
+
+	return price, nil
+}
+
+// priceFeedKey normalizes a token address for feed lookups
+func priceFeedKey(tokenAddress *string) string {
+	if tokenAddress == nil || strings.EqualFold(*tokenAddress, "native") {
+		return "native"
+	}
+	return strings.ToLower(*tokenAddress)
+}
+
+// priceFeedIndexKey identifies a token on a chain
+func priceFeedIndexKey(chainID int64, token string) string {
+	return fmt.Sprintf("%d:%s", chainID, priceFeedKey(&token))
+}
+
+// usdValue converts a raw token amount to its USD value
+func usdValue(amount *big.Int, decimals uint8, price *big.Rat) *big.Rat {
+	value := units.ToRat(amount, decimals)
+	return value.Mul(value, price)
+}
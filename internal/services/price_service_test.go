@@ -0,0 +1,208 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"sync"
+	"testing"
+	"time"
+
+	"cryptoportfolio/internal/cache"
+	"cryptoportfolio/pkg/logger"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// MockCacheProvider implements cache.CacheProvider in memory for testing
+type MockCacheProvider struct {
+	mu     sync.Mutex
+	values map[string][]byte
+}
+
+func NewMockCacheProvider() *MockCacheProvider {
+	return &MockCacheProvider{values: make(map[string][]byte)}
+}
+
+func (m *MockCacheProvider) Set(ctx context.Context, key string, value interface{}, expiration time.Duration) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.values[key] = data
+	return nil
+}
+
+func (m *MockCacheProvider) Get(ctx context.Context, key string, dest interface{}) error {
+	m.mu.Lock()
+	data, ok := m.values[key]
+	m.mu.Unlock()
+	if !ok {
+		return cache.ErrCacheMiss
+	}
+	return json.Unmarshal(data, dest)
+}
+
+func (m *MockCacheProvider) GetDel(ctx context.Context, key string, dest interface{}) error {
+	m.mu.Lock()
+	data, ok := m.values[key]
+	delete(m.values, key)
+	m.mu.Unlock()
+	if !ok {
+		return cache.ErrCacheMiss
+	}
+	return json.Unmarshal(data, dest)
+}
+
+func (m *MockCacheProvider) Delete(ctx context.Context, key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.values, key)
+	return nil
+}
+
+func (m *MockCacheProvider) DeletePattern(ctx context.Context, pattern string) error {
+	return nil
+}
+
+// countingPriceSource returns a fixed price and counts lookups
+type countingPriceSource struct {
+	price *big.Rat
+	err   error
+	calls int
+}
+
+func (s *countingPriceSource) Name() string {
+	return "counting"
+}
+
+func (s *countingPriceSource) GetPriceUSD(ctx context.Context, chainID int64, tokenAddress *string) (*big.Rat, error) {
+	s.calls++
+	return s.price, s.err
+}
+
+func TestStaticPriceSource(t *testing.T) {
+	usdc := "0xA0b86991c6218b36c1d19D4a2e9Eb0cE3606eB48"
+	source, err := NewStaticPriceSource([]PriceFeed{
+		{ChainID: 1, Token: "native", USD: "3000.5"},
+		{ChainID: 1, Token: usdc, USD: "1"},
+		{ChainID: 1, Token: "0x6B175474E89094C44Da98b954EedeAC495271d0F", Chainlink: "0xAed0c38402a5d19df6E4c03F4E2DceD6e29c1ee9"},
+	})
+	require.NoError(t, err)
+
+	price, err := source.GetPriceUSD(context.Background(), 1, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "6001/2", price.RatString())
+
+	// Addresses match regardless of case
+	lower := "0xa0b86991c6218b36c1d19d4a2e9eb0ce3606eb48"
+	price, err = source.GetPriceUSD(context.Background(), 1, &lower)
+	require.NoError(t, err)
+	assert.Equal(t, "1", price.RatString())
+
+	_, err = source.GetPriceUSD(context.Background(), 10, nil)
+	assert.ErrorIs(t, err, ErrPriceUnavailable)
+
+	_, err = NewStaticPriceSource([]PriceFeed{{ChainID: 1, Token: "native", USD: "abc"}})
+	assert.Error(t, err)
+}
+
+func TestPriceService_FallsThroughAndCaches(t *testing.T) {
+	missing := &countingPriceSource{err: ErrPriceUnavailable}
+	found := &countingPriceSource{price: big.NewRat(2500, 1)}
+	cacheProvider := NewMockCacheProvider()
+
+	service := NewPriceService([]PriceSource{missing, found}, cacheProvider, time.Minute, logger.New())
+
+	price, err := service.GetPriceUSD(context.Background(), 1, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "2500", price.RatString())
+	assert.Equal(t, 1, missing.calls)
+	assert.Equal(t, 1, found.calls)
+
+	// Second lookup is served from the cache
+	price, err = service.GetPriceUSD(context.Background(), 1, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "2500", price.RatString())
+	assert.Equal(t, 1, found.calls)
+}
+
+func TestPriceService_ReportsSourceErrors(t *testing.T) {
+	failing := &countingPriceSource{err: errors.New("rpc down")}
+	service := NewPriceService([]PriceSource{failing}, NewMockCacheProvider(), time.Minute, logger.New())
+
+	_, err := service.GetPriceUSD(context.Background(), 1, nil)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "rpc down")
+
+	service = NewPriceService(nil, NewMockCacheProvider(), time.Minute, logger.New())
+	_, err = service.GetPriceUSD(context.Background(), 1, nil)
+	assert.ErrorIs(t, err, ErrPriceUnavailable)
+}
+
+func TestChainlinkPriceSource(t *testing.T) {
+	aggregator := "0x5f4eC3Df9cbd43714FE2740f5E3616155c5b8419"
+	updatedAt := big.NewInt(time.Now().Unix())
+
+	web3Service := &MockWeb3Service{
+		chainID: 1,
+		callContract: func(contractAddress string, data []byte) ([]byte, error) {
+			method, err := chainlinkAggregator.MethodById(data)
+			require.NoError(t, err)
+			switch method.Name {
+			case "decimals":
+				return method.Outputs.Pack(uint8(8))
+			default:
+				return method.Outputs.Pack(big.NewInt(1), big.NewInt(345012345678), updatedAt, updatedAt, big.NewInt(1))
+			}
+		},
+	}
+	registry := &web3Registry{services: make(map[int64]Web3Service), defaultChainID: 1}
+	registry.Register(web3Service)
+
+	source := NewChainlinkPriceSource(registry, []PriceFeed{{ChainID: 1, Token: "native", Chainlink: aggregator}}, time.Hour)
+
+	price, err := source.GetPriceUSD(context.Background(), 1, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "3450.12345678", price.FloatString(8))
+
+	// Stale answers are rejected
+	updatedAt.SetInt64(time.Now().Add(-2 * time.Hour).Unix())
+	_, err = source.GetPriceUSD(context.Background(), 1, nil)
+	assert.Error(t, err)
+
+	token := "0x6B175474E89094C44Da98b954EedeAC495271d0F"
+	_, err = source.GetPriceUSD(context.Background(), 1, &token)
+	assert.ErrorIs(t, err, ErrPriceUnavailable)
+}
+
+func TestMeanTick(t *testing.T) {
+	assert.Equal(t, int64(10), meanTick(big.NewInt(0), big.NewInt(100), 10))
+	assert.Equal(t, int64(-10), meanTick(big.NewInt(100), big.NewInt(0), 10))
+	// Negative ticks round towards negative infinity
+	assert.Equal(t, int64(-11), meanTick(big.NewInt(0), big.NewInt(-105), 10))
+	assert.Equal(t, int64(10), meanTick(big.NewInt(0), big.NewInt(105), 10))
+}
+
+func TestTickPrice(t *testing.T) {
+	// Tick 0 means one raw unit of token0 buys one raw unit of token1
+	assert.Equal(t, "1000000000000.00", tickPrice(0, 18, 6).FloatString(2))
+	assert.Equal(t, "0.000001", tickPrice(0, 6, 12).FloatString(6))
+
+	// 1.0001^6932 is roughly 2
+	price, _ := tickPrice(6932, 18, 18).Float64()
+	assert.InDelta(t, 2.0, price, 0.001)
+}
+
+func TestUSDValue(t *testing.T) {
+	// 1.5 tokens with 18 decimals at $2000
+	amount, _ := new(big.Int).SetString("1500000000000000000", 10)
+	assert.Equal(t, "3000.000000", usdValue(amount, 18, big.NewRat(2000, 1)).FloatString(6))
+
+	// 2.5 USDC at $0.9999
+	assert.Equal(t, "2.499750", usdValue(big.NewInt(2500000), 6, big.NewRat(9999, 10000)).FloatString(6))
+}
@@ -0,0 +1,342 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"strings"
+	"sync"
+	"time"
+
+	"cryptoportfolio/pkg/units"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// staticPriceSource prices tokens from fixed values in the feed definitions
+type staticPriceSource struct {
+	prices map[string]*big.Rat
+}
+
+// NewStaticPriceSource creates a source from the feeds that have a USD value
+func NewStaticPriceSource(feeds []PriceFeed) (PriceSource, error) {
+	prices := make(map[string]*big.Rat)
+	for _, feed := range feeds {
+		if feed.USD == "" {
+			continue
+		}
+		price, err := units.ParseDecimal(feed.USD)
+		if err != nil || price.Sign() < 0 {
+			return nil, fmt.Errorf("invalid static price %q for %s", feed.USD, feed.Token)
+		}
+		prices[priceFeedIndexKey(feed.ChainID, feed.Token)] = price
+	}
+	return &staticPriceSource{prices: prices}, nil
+}
+
+// Name returns the source name
+func (s *staticPriceSource) Name() string {
+	return "static"
+}
+
+// GetPriceUSD returns the configured price
+func (s *staticPriceSource) GetPriceUSD(ctx context.Context, chainID int64, tokenAddress *string) (*big.Rat, error) {
+	price, ok := s.prices[priceFeedIndexKey(chainID, priceFeedKey(tokenAddress))]
+	if !ok {
+		return nil, ErrPriceUnavailable
+	}
+	return new(big.Rat).Set(price), nil
+}
+
+// chainlinkAggregatorABI covers the AggregatorV3Interface functions used for pricing
+const chainlinkAggregatorABI = `[
+	{"inputs":[],"name":"decimals","outputs":[{"name":"","type":"uint8"}],"stateMutability":"view","type":"function"},
+	{"inputs":[],"name":"latestRoundData","outputs":[{"name":"roundId","type":"uint80"},{"name":"answer","type":"int256"},{"name":"startedAt","type":"uint256"},{"name":"updatedAt","type":"uint256"},{"name":"answeredInRound","type":"uint80"}],"stateMutability":"view","type":"function"}
+]`
+
+var chainlinkAggregator = mustParseABI(chainlinkAggregatorABI)
+
+// chainlinkPriceSource reads USD prices from Chainlink aggregators
+type chainlinkPriceSource struct {
+	web3Registry Web3Registry
+	aggregators  map[string]string
+	maxAge       time.Duration
+
+	// Aggregator decimals never change, so they are read once
+	decimals sync.Map
+}
+
+// NewChainlinkPriceSource creates a source from the feeds that have a Chainlink aggregator
+func NewChainlinkPriceSource(web3Registry Web3Registry, feeds []PriceFeed, maxAge time.Duration) PriceSource {
+	aggregators := make(map[string]string)
+	for _, feed := range feeds {
+		if feed.Chainlink != "" {
+			aggregators[priceFeedIndexKey(feed.ChainID, feed.Token)] = feed.Chainlink
+		}
+	}
+	return &chainlinkPriceSource{
+		web3Registry: web3Registry,
+		aggregators:  aggregators,
+		maxAge:       maxAge,
+	}
+}
+
+// Name returns the source name
+func (s *chainlinkPriceSource) Name() string {
+	return "chainlink"
+}
+
+// GetPriceUSD reads the aggregator's latest answer with latestRoundData
+func (s *chainlinkPriceSource) GetPriceUSD(ctx context.Context, chainID int64, tokenAddress *string) (*big.Rat, error) {
+	aggregator, ok := s.aggregators[priceFeedIndexKey(chainID, priceFeedKey(tokenAddress))]
+	if !ok {
+		return nil, ErrPriceUnavailable
+	}
+
+	web3Service, err := s.web3Registry.Get(chainID)
+	if err != nil {
+		return nil, err
+	}
+
+	decimals, err := s.aggregatorDecimals(ctx, web3Service, aggregator)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := chainlinkAggregator.Pack("latestRoundData")
+	if err != nil {
+		return nil, err
+	}
+	output, err := web3Service.CallContract(ctx, aggregator, data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to call latestRoundData: %w", err)
+	}
+	values, err := chainlinkAggregator.Unpack("latestRoundData", output)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unpack latestRoundData: %w", err)
+	}
+
+	answer := values[1].(*big.Int)
+	updatedAt := values[3].(*big.Int)
+	if answer.Sign() <= 0 {
+		return nil, fmt.Errorf("aggregator %s returned non-positive answer %s", aggregator, answer)
+	}
+	if s.maxAge > 0 && time.Since(time.Unix(updatedAt.Int64(), 0)) > s.maxAge {
+		return nil, fmt.Errorf("aggregator %s answer is stale (updated %s)", aggregator, time.Unix(updatedAt.Int64(), 0).UTC())
+	}
+
+	return units.ToRat(answer, decimals), nil
+}
+
+// aggregatorDecimals returns the number of decimals of an aggregator's answers
+func (s *chainlinkPriceSource) aggregatorDecimals(ctx context.Context, web3Service Web3Service, aggregator string) (uint8, error) {
+	key := fmt.Sprintf("%d:%s", web3Service.ChainID(), strings.ToLower(aggregator))
+	if decimals, ok := s.decimals.Load(key); ok {
+		return decimals.(uint8), nil
+	}
+
+	data, err := chainlinkAggregator.Pack("decimals")
+	if err != nil {
+		return 0, err
+	}
+	output, err := web3Service.CallContract(ctx, aggregator, data)
+	if err != nil {
+		return 0, fmt.Errorf("failed to call aggregator decimals: %w", err)
+	}
+	values, err := chainlinkAggregator.Unpack("decimals", output)
+	if err != nil {
+		return 0, fmt.Errorf("failed to unpack aggregator decimals: %w", err)
+	}
+
+	decimals := values[0].(uint8)
+	s.decimals.Store(key, decimals)
+	return decimals, nil
+}
+
+// uniswapV3PoolABI covers the pool functions used for TWAP pricing
+const uniswapV3PoolABI = `[
+	{"inputs":[],"name":"token0","outputs":[{"name":"","type":"address"}],"stateMutability":"view","type":"function"},
+	{"inputs":[],"name":"token1","outputs":[{"name":"","type":"address"}],"stateMutability":"view","type":"function"},
+	{"inputs":[{"name":"secondsAgos","type":"uint32[]"}],"name":"observe","outputs":[{"name":"tickCumulatives","type":"int56[]"},{"name":"secondsPerLiquidityCumulativeX128s","type":"uint160[]"}],"stateMutability":"view","type":"function"}
+]`
+
+var uniswapV3Pool = mustParseABI(uniswapV3PoolABI)
+
+// uniswapV3PriceSource prices tokens from the time-weighted average tick of a
+// Uniswap V3 pool against a quote token whose USD price is known
+type uniswapV3PriceSource struct {
+	web3Registry  Web3Registry
+	feeds         map[string]PriceFeed
+	quotes        PriceSource
+	defaultWindow time.Duration
+
+	// Token decimals never change, so they are read once
+	decimals sync.Map
+}
+
+// NewUniswapV3PriceSource creates a source from the feeds that have a Uniswap V3
+// pool. Quote tokens are priced with quotes.
+func NewUniswapV3PriceSource(web3Registry Web3Registry, feeds []PriceFeed, quotes PriceSource, defaultWindow time.Duration) PriceSource {
+	pools := make(map[string]PriceFeed)
+	for _, feed := range feeds {
+		if feed.UniswapV3Pool != "" && feed.QuoteToken != "" {
+			pools[priceFeedIndexKey(feed.ChainID, feed.Token)] = feed
+		}
+	}
+	return &uniswapV3PriceSource{
+		web3Registry:  web3Registry,
+		feeds:         pools,
+		quotes:        quotes,
+		defaultWindow: defaultWindow,
+	}
+}
+
+// Name returns the source name
+func (s *uniswapV3PriceSource) Name() string {
+	return "uniswap"
+}
+
+// GetPriceUSD converts the pool's TWAP into USD through the quote token price
+func (s *uniswapV3PriceSource) GetPriceUSD(ctx context.Context, chainID int64, tokenAddress *string) (*big.Rat, error) {
+	feed, ok := s.feeds[priceFeedIndexKey(chainID, priceFeedKey(tokenAddress))]
+	if !ok {
+		return nil, ErrPriceUnavailable
+	}
+
+	web3Service, err := s.web3Registry.Get(chainID)
+	if err != nil {
+		return nil, err
+	}
+
+	window := s.defaultWindow
+	if feed.TWAPWindow > 0 {
+		window = time.Duration(feed.TWAPWindow) * time.Second
+	}
+	if window < time.Second {
+		return nil, errors.New("TWAP window must be at least one second")
+	}
+
+	token0, err := s.poolToken(ctx, web3Service, feed.UniswapV3Pool, "token0")
+	if err != nil {
+		return nil, err
+	}
+	token1, err := s.poolToken(ctx, web3Service, feed.UniswapV3Pool, "token1")
+	if err != nil {
+		return nil, err
+	}
+
+	tick, err := s.averageTick(ctx, web3Service, feed.UniswapV3Pool, uint32(window/time.Second))
+	if err != nil {
+		return nil, err
+	}
+
+	decimals0, err := s.tokenDecimals(ctx, web3Service, token0)
+	if err != nil {
+		return nil, err
+	}
+	decimals1, err := s.tokenDecimals(ctx, web3Service, token1)
+	if err != nil {
+		return nil, err
+	}
+
+	// Price of token0 denominated in token1
+	price := tickPrice(tick, decimals0, decimals1)
+
+	quote := common.HexToAddress(feed.QuoteToken)
+	switch quote {
+	case token1:
+	case token0:
+		price.Inv(price)
+	default:
+		return nil, fmt.Errorf("quote token %s is not in pool %s", feed.QuoteToken, feed.UniswapV3Pool)
+	}
+
+	quoteAddress := quote.Hex()
+	quotePrice, err := s.quotes.GetPriceUSD(ctx, chainID, &quoteAddress)
+	if err != nil {
+		return nil, fmt.Errorf("failed to price quote token %s: %w", quoteAddress, err)
+	}
+
+	return price.Mul(price, quotePrice), nil
+}
+
+// poolToken reads token0 or token1 of a pool
+func (s *uniswapV3PriceSource) poolToken(ctx context.Context, web3Service Web3Service, pool, method string) (common.Address, error) {
+	data, err := uniswapV3Pool.Pack(method)
+	if err != nil {
+		return common.Address{}, err
+	}
+	output, err := web3Service.CallContract(ctx, pool, data)
+	if err != nil {
+		return common.Address{}, fmt.Errorf("failed to call %s: %w", method, err)
+	}
+	values, err := uniswapV3Pool.Unpack(method, output)
+	if err != nil {
+		return common.Address{}, fmt.Errorf("failed to unpack %s: %w", method, err)
+	}
+	return values[0].(common.Address), nil
+}
+
+// averageTick reads the pool's arithmetic mean tick over the window
+func (s *uniswapV3PriceSource) averageTick(ctx context.Context, web3Service Web3Service, pool string, window uint32) (int64, error) {
+	data, err := uniswapV3Pool.Pack("observe", []uint32{window, 0})
+	if err != nil {
+		return 0, err
+	}
+	output, err := web3Service.CallContract(ctx, pool, data)
+	if err != nil {
+		return 0, fmt.Errorf("failed to call observe: %w", err)
+	}
+	values, err := uniswapV3Pool.Unpack("observe", output)
+	if err != nil {
+		return 0, fmt.Errorf("failed to unpack observe: %w", err)
+	}
+
+	tickCumulatives := values[0].([]*big.Int)
+	if len(tickCumulatives) != 2 {
+		return 0, fmt.Errorf("observe returned %d observations", len(tickCumulatives))
+	}
+
+	return meanTick(tickCumulatives[0], tickCumulatives[1], window), nil
+}
+
+// tokenDecimals returns a pool token's decimals
+func (s *uniswapV3PriceSource) tokenDecimals(ctx context.Context, web3Service Web3Service, token common.Address) (uint8, error) {
+	key := fmt.Sprintf("%d:%s", web3Service.ChainID(), token.Hex())
+	if decimals, ok := s.decimals.Load(key); ok {
+		return decimals.(uint8), nil
+	}
+
+	decimals, err := fetchERC20Decimals(ctx, web3Service, token.Hex())
+	if err != nil {
+		return 0, err
+	}
+	s.decimals.Store(key, decimals)
+	return decimals, nil
+}
+
+// meanTick computes the arithmetic mean tick between two tick cumulatives,
+// rounding towards negative infinity like Uniswap's OracleLibrary
+func meanTick(olderCumulative, newerCumulative *big.Int, window uint32) int64 {
+	delta := new(big.Int).Sub(newerCumulative, olderCumulative)
+	seconds := big.NewInt(int64(window))
+
+	tick, remainder := new(big.Int).QuoRem(delta, seconds, new(big.Int))
+	if delta.Sign() < 0 && remainder.Sign() != 0 {
+		tick.Sub(tick, big.NewInt(1))
+	}
+	return tick.Int64()
+}
+
+// tickPrice converts a tick into the price of one whole token0 in token1
+func tickPrice(tick int64, decimals0, decimals1 uint8) *big.Rat {
+	price := new(big.Rat)
+	price.SetFloat64(math.Pow(1.0001, float64(tick)))
+
+	if decimals0 >= decimals1 {
+		return price.Mul(price, new(big.Rat).SetInt(units.Pow10(decimals0-decimals1)))
+	}
+	return price.Quo(price, new(big.Rat).SetInt(units.Pow10(decimals1-decimals0)))
+}
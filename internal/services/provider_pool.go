@@ -0,0 +1,358 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"strings"
+	"sync"
+	"time"
+
+	"cryptoportfolio/internal/config"
+	"cryptoportfolio/internal/metrics"
+	"cryptoportfolio/internal/tracing"
+	"cryptoportfolio/pkg/logger"
+
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/rpc"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// ErrNoProviders is returned when a pool has no endpoints to send a call to
+var ErrNoProviders = errors.New("no RPC providers available")
+
+// latencySmoothing is the weight of the newest sample in the latency moving average
+const latencySmoothing = 0.2
+
+// ProviderPool spreads RPC calls over several weighted endpoints, tracks
+// their health and fails over to the next endpoint when one errors.
+// Endpoints that rate-limit or fail repeatedly are ejected for a cooldown.
+type ProviderPool struct {
+	chainID     int64 // labels the pool's RPC metrics
+	providers   []*provider
+	cooldown    time.Duration
+	maxFailures int
+	logger      *logger.Logger
+
+	mu   sync.Mutex
+	rand *rand.Rand
+}
+
+// provider is a single endpoint in the pool together with its health stats
+type provider struct {
+	url       string
+	weight    int
+	rpcClient *rpc.Client
+	client    *ethclient.Client
+
+	requests            uint64
+	errors              uint64
+	rateLimited         uint64
+	consecutiveFailures int
+	avgLatency          time.Duration
+	ejectedUntil        time.Time
+}
+
+// ProviderHealth is a snapshot of an endpoint's health
+type ProviderHealth struct {
+	URL          string        `json:"url"`
+	Weight       int           `json:"weight"`
+	Requests     uint64        `json:"requests"`
+	Errors       uint64        `json:"errors"`
+	RateLimited  uint64        `json:"rate_limited"`
+	ErrorRate    float64       `json:"error_rate"`
+	AvgLatency   time.Duration `json:"avg_latency"`
+	Healthy      bool          `json:"healthy"`
+	EjectedUntil *time.Time    `json:"ejected_until,omitempty"`
+}
+
+// NewProviderPool dials every endpoint. Endpoints that cannot be dialed are
+// skipped; an error is only returned when none could be dialed.
+func NewProviderPool(ctx context.Context, chainID int64, endpoints []config.RPCEndpointConfig, cooldown time.Duration, maxFailures int, logger *logger.Logger) (*ProviderPool, error) {
+	if maxFailures <= 0 {
+		maxFailures = 1
+	}
+
+	pool := &ProviderPool{
+		chainID:     chainID,
+		cooldown:    cooldown,
+		maxFailures: maxFailures,
+		logger:      logger,
+		rand:        rand.New(rand.NewSource(time.Now().UnixNano())),
+	}
+
+	for _, endpoint := range endpoints {
+		rpcClient, err := rpc.DialContext(ctx, endpoint.URL)
+		if err != nil {
+			logger.Error("Failed to dial RPC endpoint", "url", redactURL(endpoint.URL), "error", err)
+			continue
+		}
+
+		weight := endpoint.Weight
+		if weight <= 0 {
+			weight = 1
+		}
+
+		pool.providers = append(pool.providers, &provider{
+			url:       endpoint.URL,
+			weight:    weight,
+			rpcClient: rpcClient,
+			client:    ethclient.NewClient(rpcClient),
+		})
+	}
+
+	if len(pool.providers) == 0 {
+		return nil, ErrNoProviders
+	}
+
+	return pool, nil
+}
+
+// Do runs fn against the pool's endpoints in weighted random order, failing
+// over to the next endpoint until one succeeds. Errors that are caused by the
+// call itself (e.g. a reverted eth_call) are returned without failing over.
+// method is the JSON-RPC method fn calls, which labels the RPC metrics.
+func (p *ProviderPool) Do(ctx context.Context, method string, fn func(client *ethclient.Client) error) error {
+	return p.do(ctx, method, func(pr *provider) error {
+		return fn(pr.client)
+	})
+}
+
+// BatchCall sends several JSON-RPC requests in a single HTTP round trip,
+// failing over between endpoints like Do. Per-request errors are reported
+// through each element's Error field.
+func (p *ProviderPool) BatchCall(ctx context.Context, batch []rpc.BatchElem) error {
+	if len(batch) == 0 {
+		return nil
+	}
+	return p.do(ctx, batchMethod(batch), func(pr *provider) error {
+		return pr.rpcClient.BatchCallContext(ctx, batch)
+	})
+}
+
+// do tries each endpoint once and returns the last error if all failed. The
+// call is traced as one span; endpoints that failed are recorded as events.
+func (p *ProviderPool) do(ctx context.Context, method string, fn func(pr *provider) error) (err error) {
+	_, span := tracing.Tracer().Start(ctx, "rpc."+method,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("rpc.system", "jsonrpc"),
+			attribute.String("rpc.method", method),
+			attribute.Int64("chain.id", p.chainID),
+		),
+	)
+	defer func() { tracing.End(span, err) }()
+
+	var lastErr error
+	for _, pr := range p.order() {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		start := time.Now()
+		err := fn(pr)
+		latency := time.Since(start)
+		p.observe(method, latency, err)
+		if err == nil || !isProviderError(err) {
+			p.recordSuccess(pr, latency)
+			span.SetAttributes(attribute.String("rpc.endpoint", redactURL(pr.url)))
+			return err
+		}
+
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		p.recordFailure(pr, err)
+		span.AddEvent("endpoint failed", trace.WithAttributes(
+			attribute.String("rpc.endpoint", redactURL(pr.url)),
+			attribute.String("error", err.Error()),
+		))
+		lastErr = err
+	}
+
+	if lastErr == nil {
+		return ErrNoProviders
+	}
+	return lastErr
+}
+
+// order returns healthy endpoints in weighted random order, followed by
+// ejected endpoints (soonest to recover first) as a last resort
+func (p *ProviderPool) order() []*provider {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	now := time.Now()
+	var healthy, ejected []*provider
+	totalWeight := 0
+	for _, pr := range p.providers {
+		if now.Before(pr.ejectedUntil) {
+			ejected = append(ejected, pr)
+			continue
+		}
+		healthy = append(healthy, pr)
+		totalWeight += pr.weight
+	}
+
+	ordered := make([]*provider, 0, len(p.providers))
+	for len(healthy) > 0 {
+		pick := p.rand.Intn(totalWeight)
+		for i, pr := range healthy {
+			if pick < pr.weight {
+				ordered = append(ordered, pr)
+				totalWeight -= pr.weight
+				healthy = append(healthy[:i], healthy[i+1:]...)
+				break
+			}
+			pick -= pr.weight
+		}
+	}
+
+	for i := 1; i < len(ejected); i++ {
+		for j := i; j > 0 && ejected[j].ejectedUntil.Before(ejected[j-1].ejectedUntil); j-- {
+			ejected[j], ejected[j-1] = ejected[j-1], ejected[j]
+		}
+	}
+
+	return append(ordered, ejected...)
+}
+
+// recordSuccess updates an endpoint's stats after a successful call
+func (p *ProviderPool) recordSuccess(pr *provider, latency time.Duration) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	pr.requests++
+	pr.consecutiveFailures = 0
+	pr.ejectedUntil = time.Time{}
+	if pr.avgLatency == 0 {
+		pr.avgLatency = latency
+	} else {
+		pr.avgLatency = time.Duration(latencySmoothing*float64(latency) + (1-latencySmoothing)*float64(pr.avgLatency))
+	}
+}
+
+// recordFailure updates an endpoint's stats after a failed call and ejects
+// it when it is rate-limiting or has failed too many times in a row
+func (p *ProviderPool) recordFailure(pr *provider, err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	pr.requests++
+	pr.errors++
+	pr.consecutiveFailures++
+
+	isRateLimit := isRateLimitError(err)
+	if isRateLimit {
+		pr.rateLimited++
+	}
+
+	if isRateLimit || pr.consecutiveFailures >= p.maxFailures {
+		pr.ejectedUntil = time.Now().Add(p.cooldown)
+		p.logger.Warn("Ejecting RPC endpoint",
+			"url", redactURL(pr.url),
+			"cooldown", p.cooldown,
+			"consecutive_failures", pr.consecutiveFailures,
+			"is_rate_limit", isRateLimit,
+			"error", err)
+	}
+}
+
+// observe records the latency and result of a call to one endpoint
+func (p *ProviderPool) observe(method string, latency time.Duration, err error) {
+	chain := metrics.ChainLabel(p.chainID)
+	metrics.RPCRequestDuration.WithLabelValues(chain, method).Observe(latency.Seconds())
+
+	result := metrics.RPCSuccess
+	if err != nil {
+		result = metrics.RPCError
+		if isRateLimitError(err) {
+			metrics.RPCRateLimited.WithLabelValues(chain, method).Inc()
+		}
+	}
+	metrics.RPCRequests.WithLabelValues(chain, method, result).Inc()
+}
+
+// Health returns a snapshot of every endpoint's health
+func (p *ProviderPool) Health() []ProviderHealth {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	now := time.Now()
+	health := make([]ProviderHealth, len(p.providers))
+	for i, pr := range p.providers {
+		health[i] = ProviderHealth{
+			URL:         redactURL(pr.url),
+			Weight:      pr.weight,
+			Requests:    pr.requests,
+			Errors:      pr.errors,
+			RateLimited: pr.rateLimited,
+			AvgLatency:  pr.avgLatency,
+			Healthy:     !now.Before(pr.ejectedUntil),
+		}
+		if pr.requests > 0 {
+			health[i].ErrorRate = float64(pr.errors) / float64(pr.requests)
+		}
+		if !health[i].Healthy {
+			ejectedUntil := pr.ejectedUntil
+			health[i].EjectedUntil = &ejectedUntil
+		}
+	}
+	return health
+}
+
+// Close closes every endpoint's client
+func (p *ProviderPool) Close() {
+	for _, pr := range p.providers {
+		pr.rpcClient.Close()
+	}
+}
+
+// batchMethod is the method of every request in a batch, or methodBatch when they differ
+func batchMethod(batch []rpc.BatchElem) string {
+	if len(batch) == 0 {
+		return methodBatch
+	}
+	for _, elem := range batch[1:] {
+		if elem.Method != batch[0].Method {
+			return methodBatch
+		}
+	}
+	return batch[0].Method
+}
+
+// isProviderError reports whether an error was caused by the endpoint rather
+// than by the call itself, i.e. whether another endpoint might succeed
+func isProviderError(err error) bool {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+
+	// Reverts are returned with code 3, or with a generic code and an "execution reverted" message
+	if strings.Contains(err.Error(), "execution reverted") {
+		return false
+	}
+	var rpcErr rpc.Error
+	if errors.As(err, &rpcErr) && rpcErr.ErrorCode() == 3 {
+		return false
+	}
+
+	return true
+}
+
+// redactURL strips the path and query from an endpoint URL, which commonly
+// embed API keys, so it can be logged
+func redactURL(url string) string {
+	schemeEnd := strings.Index(url, "://")
+	if schemeEnd == -1 {
+		return url
+	}
+	hostStart := schemeEnd + 3
+	if pathStart := strings.IndexAny(url[hostStart:], "/?"); pathStart != -1 {
+		return fmt.Sprintf("%s/...", url[:hostStart+pathStart])
+	}
+	return url
+}
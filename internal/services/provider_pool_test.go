@@ -0,0 +1,156 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"testing"
+	"time"
+
+	"cryptoportfolio/internal/metrics"
+	"cryptoportfolio/pkg/logger"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestPool(urls ...string) *ProviderPool {
+	pool := &ProviderPool{
+		cooldown:    time.Minute,
+		maxFailures: 2,
+		logger:      logger.New(),
+		rand:        rand.New(rand.NewSource(1)),
+	}
+	for _, url := range urls {
+		pool.providers = append(pool.providers, &provider{url: url, weight: 1})
+	}
+	return pool
+}
+
+func TestProviderPool_FailsOverToNextEndpoint(t *testing.T) {
+	pool := newTestPool("https://a.example", "https://b.example")
+
+	var tried []string
+	err := pool.do(context.Background(), methodCall, func(pr *provider) error {
+		tried = append(tried, pr.url)
+		if len(tried) == 1 {
+			return errors.New("connection refused")
+		}
+		return nil
+	})
+
+	require.NoError(t, err)
+	assert.Len(t, tried, 2)
+
+	health := pool.Health()
+	var totalErrors, totalRequests uint64
+	for _, h := range health {
+		totalErrors += h.Errors
+		totalRequests += h.Requests
+	}
+	assert.Equal(t, uint64(1), totalErrors)
+	assert.Equal(t, uint64(2), totalRequests)
+}
+
+func TestProviderPool_EjectsRateLimitedEndpoint(t *testing.T) {
+	pool := newTestPool("https://a.example", "https://b.example")
+
+	// Whichever endpoint is tried first rate-limits the call
+	var limited string
+	err := pool.do(context.Background(), methodCall, func(pr *provider) error {
+		if limited == "" {
+			limited = pr.url
+			return errors.New("429 Too Many Requests")
+		}
+		return nil
+	})
+	require.NoError(t, err)
+
+	// The rate-limited endpoint is only tried after healthy ones until its cooldown ends
+	for i := 0; i < 10; i++ {
+		order := pool.order()
+		assert.NotEqual(t, limited, order[0].url)
+		assert.Equal(t, limited, order[1].url)
+	}
+
+	for _, h := range pool.Health() {
+		if h.URL == limited {
+			assert.False(t, h.Healthy)
+			assert.Equal(t, uint64(1), h.RateLimited)
+			assert.NotNil(t, h.EjectedUntil)
+		}
+	}
+}
+
+func TestProviderPool_EjectsAfterConsecutiveFailures(t *testing.T) {
+	pool := newTestPool("https://a.example")
+
+	failing := func(pr *provider) error { return errors.New("connection reset") }
+
+	assert.Error(t, pool.do(context.Background(), methodCall, failing))
+	assert.True(t, pool.Health()[0].Healthy)
+
+	assert.Error(t, pool.do(context.Background(), methodCall, failing))
+	assert.False(t, pool.Health()[0].Healthy)
+
+	// Ejected endpoints are still used as a last resort and recover on success
+	require.NoError(t, pool.do(context.Background(), methodCall, func(pr *provider) error { return nil }))
+	assert.True(t, pool.Health()[0].Healthy)
+}
+
+func TestProviderPool_DoesNotFailOverOnCancellation(t *testing.T) {
+	pool := newTestPool("https://a.example", "https://b.example")
+
+	calls := 0
+	err := pool.do(context.Background(), methodCall, func(pr *provider) error {
+		calls++
+		return context.Canceled
+	})
+
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Equal(t, 1, calls)
+}
+
+func TestProviderPool_WeightedOrder(t *testing.T) {
+	pool := newTestPool("https://a.example", "https://b.example")
+	pool.providers[0].weight = 9
+
+	first := map[string]int{}
+	for i := 0; i < 1000; i++ {
+		first[pool.order()[0].url]++
+	}
+
+	assert.Greater(t, first["https://a.example"], first["https://b.example"]*4)
+}
+
+func TestRedactURL(t *testing.T) {
+	assert.Equal(t, "https://mainnet.infura.io/...", redactURL("https://mainnet.infura.io/v3/secret-key"))
+	assert.Equal(t, "https://rpc.example/...", redactURL("https://rpc.example?apikey=secret"))
+	assert.Equal(t, "https://rpc.example", redactURL("https://rpc.example"))
+}
+
+func TestProviderPool_RecordsMetrics(t *testing.T) {
+	pool := newTestPool("https://a.example", "https://b.example")
+	pool.chainID = 8453
+
+	requests := func(result string) float64 {
+		return testutil.ToFloat64(metrics.RPCRequests.WithLabelValues("8453", methodGetLogs, result))
+	}
+	rateLimited := testutil.ToFloat64(metrics.RPCRateLimited.WithLabelValues("8453", methodGetLogs))
+	successes, failures := requests(metrics.RPCSuccess), requests(metrics.RPCError)
+
+	// Each failover attempt is counted
+	first := true
+	require.NoError(t, pool.do(context.Background(), methodGetLogs, func(pr *provider) error {
+		if first {
+			first = false
+			return errors.New("429 Too Many Requests")
+		}
+		return nil
+	}))
+
+	assert.Equal(t, successes+1, requests(metrics.RPCSuccess))
+	assert.Equal(t, failures+1, requests(metrics.RPCError))
+	assert.Equal(t, rateLimited+1, testutil.ToFloat64(metrics.RPCRateLimited.WithLabelValues("8453", methodGetLogs)))
+}
@@ -0,0 +1,125 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	"cryptoportfolio/internal/models"
+	"cryptoportfolio/pkg/units"
+)
+
+// reorgCheckWindow is how far back stored snapshots are compared against the
+// canonical chain; reorgs older than this are not corrected
+const reorgCheckWindow = time.Hour
+
+// checkReorgs compares the block hashes of a chain's recent snapshots with the
+// canonical chain and re-reads the balances of snapshots whose block was
+// reorged out
+func (bfs *balanceFetcherService) checkReorgs(ctx context.Context, chainID int64) {
+	web3Service, err := bfs.web3Registry.Get(chainID)
+	if err != nil {
+		return
+	}
+
+	blocks, err := bfs.watchlistRepo.GetSnapshotBlocks(ctx, chainID, time.Now().Add(-reorgCheckWindow))
+	if err != nil {
+		bfs.logger.Error("Failed to get snapshot blocks", "chain_id", chainID, "error", err)
+		return
+	}
+
+	for _, block := range blocks {
+		canonical, err := web3Service.HeaderByNumber(ctx, new(big.Int).SetUint64(block.BlockNumber))
+		if err != nil {
+			bfs.logger.Warn("Failed to verify snapshot block", "chain_id", chainID, "block_number", block.BlockNumber, "error", err)
+			return
+		}
+		if strings.EqualFold(canonical.Hash.Hex(), block.BlockHash) {
+			continue
+		}
+
+		bfs.logger.Warn("Reorg detected, re-reading orphaned balance snapshots",
+			"chain_id", chainID,
+			"block_number", block.BlockNumber,
+			"orphaned_hash", block.BlockHash,
+			"canonical_hash", canonical.Hash.Hex())
+
+		if err := bfs.correctSnapshots(ctx, web3Service, block.BlockHash, canonical); err != nil {
+			bfs.logger.Error("Failed to correct orphaned balance snapshots", "chain_id", chainID, "block_number", block.BlockNumber, "error", err)
+		}
+	}
+}
+
+// correctSnapshots re-reads every balance stored at an orphaned block at the
+// canonical block of the same height and updates the records in place
+func (bfs *balanceFetcherService) correctSnapshots(ctx context.Context, web3Service Web3Service, orphanedHash string, canonical *BlockHeader) error {
+	balances, err := bfs.watchlistRepo.GetBalancesAtBlock(ctx, orphanedHash)
+	if err != nil {
+		return fmt.Errorf("failed to get orphaned balances: %w", err)
+	}
+
+	queries := make([]BalanceQuery, len(balances))
+	for i, balance := range balances {
+		queries[i] = BalanceQuery{
+			WalletAddress: balance.Wallet.WalletAddress,
+			TokenAddress:  balance.Token.TokenAddress,
+		}
+	}
+
+	results, err := web3Service.GetBalancesBatch(ctx, queries, &canonical.Hash)
+	if err != nil {
+		return err
+	}
+
+	canonicalHash := canonical.Hash.Hex()
+	users := make(map[uint]bool)
+	for i, balance := range balances {
+		if results[i].Err != nil {
+			bfs.logger.Warn("Failed to re-read orphaned balance", "balance_id", balance.ID, "error", results[i].Err)
+			continue
+		}
+
+		balance.Balance = results[i].Balance.String()
+		balance.BlockHash = &canonicalHash
+
+		// Keep the price stamped at fetch time and revalue the corrected balance with it
+		if balance.PriceUSD != nil {
+			if value, err := bfs.revalueBalance(ctx, balance, results[i].Balance); err != nil {
+				bfs.logger.Warn("Failed to revalue corrected balance", "balance_id", balance.ID, "error", err)
+				balance.BalanceUSD = nil
+			} else {
+				balance.BalanceUSD = &value
+			}
+		}
+
+		if err := bfs.watchlistRepo.UpdateBalance(ctx, balance); err != nil {
+			return fmt.Errorf("failed to update balance %d: %w", balance.ID, err)
+		}
+
+		users[balance.Wallet.UserID] = true
+		bfs.cacheService.Delete(ctx, fmt.Sprintf("balance:%d:%d", balance.WalletID, balance.TokenID))
+	}
+
+	for userID := range users {
+		bfs.cacheService.Delete(ctx, fmt.Sprintf("user_balances:%d", userID))
+	}
+
+	return nil
+}
+
+// revalueBalance computes a balance's USD value from its stamped price
+func (bfs *balanceFetcherService) revalueBalance(ctx context.Context, balance *models.WalletBalance, amount *big.Int) (string, error) {
+	price, err := units.ParseDecimal(*balance.PriceUSD)
+	if err != nil {
+		return "", err
+	}
+
+	decimals, err := tokenDecimals(ctx, bfs.web3Registry, bfs.cacheService, &balance.Token)
+	if err != nil {
+		return "", err
+	}
+
+	return units.FormatDecimal(usdValue(amount, decimals, price), valueDecimals), nil
+}
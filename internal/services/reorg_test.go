@@ -0,0 +1,165 @@
+package services
+
+import (
+	"context"
+	"math/big"
+	"testing"
+	"time"
+
+	"cryptoportfolio/internal/config"
+	"cryptoportfolio/internal/models"
+	"cryptoportfolio/internal/repository"
+	"cryptoportfolio/pkg/logger"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeWatchlistRepository serves snapshots from memory; methods a test does not use panic
+type fakeWatchlistRepository struct {
+	repository.WatchlistRepository
+	blocks   []repository.SnapshotBlock
+	balances []*models.WalletBalance
+	updated  []*models.WalletBalance
+}
+
+func (r *fakeWatchlistRepository) GetSnapshotBlocks(ctx context.Context, chainID int64, since time.Time) ([]repository.SnapshotBlock, error) {
+	return r.blocks, nil
+}
+
+func (r *fakeWatchlistRepository) GetBalancesAtBlock(ctx context.Context, blockHash string) ([]*models.WalletBalance, error) {
+	var balances []*models.WalletBalance
+	for _, balance := range r.balances {
+		if balance.BlockHash != nil && *balance.BlockHash == blockHash {
+			balances = append(balances, balance)
+		}
+	}
+	return balances, nil
+}
+
+func (r *fakeWatchlistRepository) UpdateBalance(ctx context.Context, balance *models.WalletBalance) error {
+	r.updated = append(r.updated, balance)
+	return nil
+}
+
+func newTestFetcher(repo repository.WatchlistRepository, web3Services ...Web3Service) *balanceFetcherService {
+	registry := &web3Registry{services: make(map[int64]Web3Service)}
+	for _, service := range web3Services {
+		registry.Register(service)
+	}
+	return &balanceFetcherService{
+		watchlistRepo: repo,
+		web3Registry:  registry,
+		cacheService:  NewMockCacheProvider(),
+		logger:        logger.New(),
+		config:        &config.Config{},
+	}
+}
+
+// testChainHeaders returns canonical headers whose hash is derived from the block number
+func testChainHeaders(head int64) func(number *big.Int) (*BlockHeader, error) {
+	return func(number *big.Int) (*BlockHeader, error) {
+		if number == nil {
+			number = big.NewInt(head)
+		}
+		return &BlockHeader{Number: number, Hash: common.BigToHash(new(big.Int).Add(number, big.NewInt(0xc0ffee)))}, nil
+	}
+}
+
+func TestCheckReorgs_CorrectsOrphanedSnapshots(t *testing.T) {
+	canonical, _ := testChainHeaders(105)(big.NewInt(101))
+	canonicalHash := canonical.Hash.Hex()
+	kept, _ := testChainHeaders(105)(big.NewInt(100))
+	keptHash := kept.Hash.Hex()
+	orphanedHash := common.HexToHash("0xdead").Hex()
+
+	usdc := "0xA0b86991c6218b36c1d19D4a2e9Eb0cE3606eB48"
+	decimals := uint8(6)
+	price := "2.00000000"
+	wallet := models.WatchlistWallet{ID: 1, UserID: 7, WalletAddress: "0x742d35Cc6634C0532925a3b844Bc454e4438f44e", ChainID: 1}
+	token := models.TrackedToken{ID: 2, UserID: 7, ChainID: 1, TokenAddress: &usdc, Decimals: &decimals}
+
+	orphaned := &models.WalletBalance{ID: 11, WalletID: 1, TokenID: 2, Balance: "1000000", PriceUSD: &price, BlockHash: &orphanedHash, Wallet: wallet, Token: token}
+	repo := &fakeWatchlistRepository{
+		blocks: []repository.SnapshotBlock{
+			{BlockNumber: 100, BlockHash: keptHash},
+			{BlockNumber: 101, BlockHash: orphanedHash},
+		},
+		balances: []*models.WalletBalance{
+			{ID: 10, WalletID: 1, TokenID: 2, Balance: "1000000", BlockHash: &keptHash, Wallet: wallet, Token: token},
+			orphaned,
+		},
+	}
+
+	var readAt *common.Hash
+	web3Service := &MockWeb3Service{
+		chainID:        1,
+		headerByNumber: testChainHeaders(105),
+		getBalancesBatch: func(queries []BalanceQuery, blockHash *common.Hash) ([]BalanceResult, error) {
+			readAt = blockHash
+			require.Len(t, queries, 1)
+			assert.Equal(t, wallet.WalletAddress, queries[0].WalletAddress)
+			return []BalanceResult{{Balance: big.NewInt(5000000)}}, nil
+		},
+	}
+
+	fetcher := newTestFetcher(repo, web3Service)
+	require.NoError(t, fetcher.cacheService.Set(context.Background(), "user_balances:7", []string{"stale"}, time.Minute))
+
+	fetcher.checkReorgs(context.Background(), 1)
+
+	// Only the snapshot on the orphaned block is re-read, at the canonical block
+	require.NotNil(t, readAt)
+	assert.Equal(t, canonical.Hash, *readAt)
+	require.Len(t, repo.updated, 1)
+	assert.Equal(t, uint(11), repo.updated[0].ID)
+	assert.Equal(t, "5000000", orphaned.Balance)
+	assert.Equal(t, canonicalHash, *orphaned.BlockHash)
+	require.NotNil(t, orphaned.BalanceUSD)
+	assert.Equal(t, "10.000000", *orphaned.BalanceUSD)
+
+	var cached []string
+	assert.Error(t, fetcher.cacheService.Get(context.Background(), "user_balances:7", &cached))
+}
+
+func TestPinBlock_Confirmations(t *testing.T) {
+	web3Service := &MockWeb3Service{chainID: 1, headerByNumber: testChainHeaders(1000)}
+	fetcher := newTestFetcher(&fakeWatchlistRepository{}, web3Service)
+
+	block, err := fetcher.pinBlock(context.Background(), 1)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1000), block.Number.Int64())
+
+	fetcher.config.Web3.Confirmations = 12
+	block, err = fetcher.pinBlock(context.Background(), 1)
+	require.NoError(t, err)
+	assert.Equal(t, int64(988), block.Number.Int64())
+
+	// Young chains are pinned at genesis rather than a negative block
+	fetcher.config.Web3.Confirmations = 5000
+	block, err = fetcher.pinBlock(context.Background(), 1)
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), block.Number.Int64())
+}
+
+func TestPinBatches_OneBlockPerChain(t *testing.T) {
+	lookups := 0
+	headers := testChainHeaders(500)
+	web3Service := &MockWeb3Service{chainID: 1, headerByNumber: func(number *big.Int) (*BlockHeader, error) {
+		lookups++
+		return headers(number)
+	}}
+	fetcher := newTestFetcher(&fakeWatchlistRepository{}, web3Service)
+
+	batches := []fetchBatch{{chainID: 1}, {chainID: 1}, {chainID: 10}}
+	pinned := fetcher.pinBatches(context.Background(), batches)
+
+	assert.Equal(t, 1, lookups)
+	assert.Len(t, pinned, 1)
+	assert.Same(t, batches[0].block, batches[1].block)
+	assert.NoError(t, batches[0].err)
+	// Chains without a service cannot be pinned and fail their reads
+	assert.Nil(t, batches[2].block)
+	assert.ErrorIs(t, batches[2].err, ErrUnsupportedChain)
+}
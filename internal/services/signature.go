@@ -0,0 +1,60 @@
+package services
+
+import (
+	"bytes"
+	"errors"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// ErrInvalidSignature is returned when a signature is malformed or was not made by the expected address
+var ErrInvalidSignature = errors.New("invalid signature")
+
+// erc1271ABI covers the EIP-1271 signature check of smart contract wallets
+const erc1271ABI = `[
+	{"inputs":[{"name":"hash","type":"bytes32"},{"name":"signature","type":"bytes"}],"name":"isValidSignature","outputs":[{"name":"magicValue","type":"bytes4"}],"stateMutability":"view","type":"function"}
+]`
+
+var erc1271 = mustParseABI(erc1271ABI)
+
+// erc1271MagicValue is returned by isValidSignature for a valid signature
+var erc1271MagicValue = []byte{0x16, 0x26, 0xba, 0x7e}
+
+// isERC1271MagicValue reports whether an isValidSignature result accepts the signature
+func isERC1271MagicValue(result []byte) bool {
+	// The bytes4 return value is left-aligned in its 32-byte word
+	return len(result) >= 32 && bytes.Equal(result[:4], erc1271MagicValue)
+}
+
+// recoverPersonalSigner returns the address that signed message with
+// personal_sign (EIP-191 version 0x45), given the 65-byte hex signature
+func recoverPersonalSigner(message []byte, signature string) (common.Address, error) {
+	sig, err := hexutil.Decode(signature)
+	if err != nil {
+		return common.Address{}, ErrInvalidSignature
+	}
+	return recoverSigner(accounts.TextHash(message), sig)
+}
+
+// recoverSigner returns the address whose key made the 65-byte ECDSA
+// signature of hash
+func recoverSigner(hash []byte, signature []byte) (common.Address, error) {
+	if len(signature) != crypto.SignatureLength {
+		return common.Address{}, ErrInvalidSignature
+	}
+
+	// Wallets return the recovery ID as 27/28, SigToPub expects 0/1
+	sig := common.CopyBytes(signature)
+	if sig[crypto.RecoveryIDOffset] >= 27 {
+		sig[crypto.RecoveryIDOffset] -= 27
+	}
+
+	pub, err := crypto.SigToPub(hash, sig)
+	if err != nil {
+		return common.Address{}, ErrInvalidSignature
+	}
+	return crypto.PubkeyToAddress(*pub), nil
+}
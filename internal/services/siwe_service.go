@@ -0,0 +1,279 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"cryptoportfolio/internal/cache"
+	"cryptoportfolio/internal/config"
+	"cryptoportfolio/pkg/logger"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// Sign-In with Ethereum errors
+var (
+	ErrInvalidSIWEMessage = errors.New("invalid SIWE message")
+	ErrInvalidNonce       = errors.New("invalid or expired nonce")
+)
+
+// siweHeaderSuffix ends the first line of an EIP-4361 message, after the domain
+const siweHeaderSuffix = " wants you to sign in with your Ethereum account:"
+
+type SIWEVerifyRequest struct {
+	Message   string `json:"message"`   // EIP-4361 message as signed
+	Signature string `json:"signature"` // personal_sign signature of the message
+}
+
+type SIWENonceResponse struct {
+	Nonce     string    `json:"nonce"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// SIWEService interface defines the contract for Sign-In with Ethereum
+type SIWEService interface {
+	Nonce(ctx context.Context) (*SIWENonceResponse, error)
+	Verify(ctx context.Context, currentUserID uint, req *SIWEVerifyRequest) (*AuthResponse, error)
+}
+
+// siweService implements SIWEService
+type siweService struct {
+	userService      UserService
+	watchlistService WatchlistService
+	web3Registry     Web3Registry
+	cacheService     cache.CacheProvider
+	logger           *logger.Logger
+	config           *config.Config
+}
+
+// NewSIWEService creates a new Sign-In with Ethereum service
+func NewSIWEService(userService UserService, watchlistService WatchlistService, web3Registry Web3Registry, cacheService cache.CacheProvider, logger *logger.Logger, config *config.Config) SIWEService {
+	return &siweService{
+		userService:      userService,
+		watchlistService: watchlistService,
+		web3Registry:     web3Registry,
+		cacheService:     cacheService,
+		logger:           logger,
+		config:           config,
+	}
+}
+
+// Nonce issues a single-use nonce for the client to put in its SIWE message
+func (s *siweService) Nonce(ctx context.Context) (*SIWENonceResponse, error) {
+	nonce := newTokenID()
+	ttl := time.Duration(s.config.SIWE.NonceTTL) * time.Second
+	if err := s.cacheService.Set(ctx, siweNonceKey(nonce), true, ttl); err != nil {
+		s.logger.Error("Failed to store SIWE nonce", "error", err)
+		return nil, err
+	}
+
+	return &SIWENonceResponse{
+		Nonce:     nonce,
+		ExpiresAt: time.Now().Add(ttl),
+	}, nil
+}
+
+// Verify checks a signed SIWE message and signs in the user linked to its
+// address, linking it to the signed-in user or a new user if none is. The
+// address is added to the user's watchlist as a verified wallet.
+func (s *siweService) Verify(ctx context.Context, currentUserID uint, req *SIWEVerifyRequest) (*AuthResponse, error) {
+	msg, err := parseSIWEMessage(req.Message)
+	if err != nil {
+		return nil, err
+	}
+
+	if msg.Domain != s.config.SIWE.Domain {
+		return nil, fmt.Errorf("%w: domain %q is not %q", ErrInvalidSIWEMessage, msg.Domain, s.config.SIWE.Domain)
+	}
+	now := time.Now()
+	if msg.ExpirationTime != nil && !now.Before(*msg.ExpirationTime) {
+		return nil, fmt.Errorf("%w: message expired", ErrInvalidSIWEMessage)
+	}
+	if msg.NotBefore != nil && now.Before(*msg.NotBefore) {
+		return nil, fmt.Errorf("%w: message not valid yet", ErrInvalidSIWEMessage)
+	}
+	if !s.web3Registry.IsSupported(msg.ChainID) {
+		return nil, ErrUnsupportedChain
+	}
+
+	signer, err := recoverPersonalSigner([]byte(req.Message), req.Signature)
+	if err != nil {
+		return nil, err
+	}
+	if signer != msg.Address {
+		return nil, ErrInvalidSignature
+	}
+
+	// The nonce is only consumed by a valid signature. Nonces expire after
+	// SIWE_NONCE_TTL, which also bounds how old an accepted message can be.
+	if err := s.consumeNonce(ctx, msg.Nonce); err != nil {
+		return nil, err
+	}
+
+	address := msg.Address.Hex()
+	response, err := s.userService.SignInWithWallet(ctx, currentUserID, address)
+	if err != nil {
+		return nil, err
+	}
+
+	// Signing in does not fail when the wallet cannot be watched
+	if _, err := s.watchlistService.AddVerifiedWallet(ctx, response.User.ID, address, msg.ChainID); err != nil {
+		s.logger.Error("Failed to add signed-in wallet to watchlist", "error", err, "user_id", response.User.ID, "address", address)
+	}
+
+	return response, nil
+}
+
+// consumeNonce deletes a nonce issued by Nonce, failing if it was not issued
+// or already used. The nonce is read and deleted in one step, so of several
+// concurrent requests with the same message only one consumes it.
+func (s *siweService) consumeNonce(ctx context.Context, nonce string) error {
+	var issued bool
+	if err := s.cacheService.GetDel(ctx, siweNonceKey(nonce), &issued); err != nil {
+		if errors.Is(err, cache.ErrCacheMiss) {
+			return ErrInvalidNonce
+		}
+		s.logger.Error("Failed to consume SIWE nonce", "error", err)
+		return err
+	}
+	return nil
+}
+
+func siweNonceKey(nonce string) string {
+	return fmt.Sprintf("siwe_nonce:%s", nonce)
+}
+
+// siweMessage is a parsed EIP-4361 message
+type siweMessage struct {
+	Domain         string
+	Address        common.Address
+	Statement      string
+	URI            string
+	Version        string
+	ChainID        int64
+	Nonce          string
+	IssuedAt       time.Time
+	ExpirationTime *time.Time
+	NotBefore      *time.Time
+	RequestID      string
+	Resources      []string
+}
+
+// parseSIWEMessage parses an EIP-4361 message:
+//
+//	${domain} wants you to sign in with your Ethereum account:
+//	${address}
+//
+//	${statement}
+//
+//	URI: ${uri}
+//	Version: 1
+//	Chain ID: ${chain-id}
+//	Nonce: ${nonce}
+//	Issued At: ${issued-at}
+//	Expiration Time: ${expiration-time}
+//	Not Before: ${not-before}
+//	Request ID: ${request-id}
+//	Resources:
+//	- ${resources[0]}
+//
+// The statement and the fields after Issued At are optional.
+func parseSIWEMessage(message string) (*siweMessage, error) {
+	invalid := func(reason string) error {
+		return fmt.Errorf("%w: %s", ErrInvalidSIWEMessage, reason)
+	}
+
+	lines := strings.Split(message, "\n")
+	if len(lines) < 4 || !strings.HasSuffix(lines[0], siweHeaderSuffix) {
+		return nil, invalid("missing header")
+	}
+
+	msg := &siweMessage{Domain: strings.TrimSuffix(lines[0], siweHeaderSuffix)}
+	if scheme := strings.Index(msg.Domain, "://"); scheme != -1 {
+		msg.Domain = msg.Domain[scheme+3:]
+	}
+
+	// Addresses must be EIP-55 checksummed
+	if !common.IsHexAddress(lines[1]) || common.HexToAddress(lines[1]).Hex() != lines[1] {
+		return nil, invalid("address must be a checksummed Ethereum address")
+	}
+	msg.Address = common.HexToAddress(lines[1])
+
+	// A blank line, the optional statement and another blank line precede the fields
+	if lines[2] != "" {
+		return nil, invalid("expected a blank line after the address")
+	}
+	i := 3
+	if lines[i] != "" {
+		msg.Statement = lines[i]
+		i++
+	}
+	if i >= len(lines) || lines[i] != "" {
+		return nil, invalid("expected a blank line before the fields")
+	}
+	i++
+
+	for ; i < len(lines); i++ {
+		line := lines[i]
+		if line == "Resources:" {
+			for _, resource := range lines[i+1:] {
+				if !strings.HasPrefix(resource, "- ") {
+					return nil, invalid("malformed resource")
+				}
+				msg.Resources = append(msg.Resources, strings.TrimPrefix(resource, "- "))
+			}
+			break
+		}
+
+		key, value, ok := strings.Cut(line, ": ")
+		if !ok {
+			return nil, invalid(fmt.Sprintf("malformed field %q", line))
+		}
+		var err error
+		switch key {
+		case "URI":
+			msg.URI = value
+		case "Version":
+			msg.Version = value
+		case "Chain ID":
+			msg.ChainID, err = strconv.ParseInt(value, 10, 64)
+		case "Nonce":
+			msg.Nonce = value
+		case "Issued At":
+			msg.IssuedAt, err = time.Parse(time.RFC3339, value)
+		case "Expiration Time":
+			var t time.Time
+			t, err = time.Parse(time.RFC3339, value)
+			msg.ExpirationTime = &t
+		case "Not Before":
+			var t time.Time
+			t, err = time.Parse(time.RFC3339, value)
+			msg.NotBefore = &t
+		case "Request ID":
+			msg.RequestID = value
+		default:
+			return nil, invalid(fmt.Sprintf("unknown field %q", key))
+		}
+		if err != nil {
+			return nil, invalid(fmt.Sprintf("malformed %s", key))
+		}
+	}
+
+	switch {
+	case msg.URI == "":
+		return nil, invalid("missing URI")
+	case msg.Version != "1":
+		return nil, invalid("version must be 1")
+	case msg.ChainID <= 0:
+		return nil, invalid("missing chain ID")
+	case len(msg.Nonce) < 8:
+		return nil, invalid("nonce must be at least 8 characters")
+	case msg.IssuedAt.IsZero():
+		return nil, invalid("missing issued at")
+	}
+	return msg, nil
+}
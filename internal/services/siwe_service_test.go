@@ -0,0 +1,185 @@
+package services
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"cryptoportfolio/internal/models"
+	"cryptoportfolio/pkg/logger"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeVerifiedWatchlist records the wallets added as verified
+type fakeVerifiedWatchlist struct {
+	WatchlistService
+	added []string
+}
+
+func (w *fakeVerifiedWatchlist) AddVerifiedWallet(ctx context.Context, userID uint, address string, chainID int64) (*WalletResponse, error) {
+	w.added = append(w.added, fmt.Sprintf("%d:%s:%d", userID, address, chainID))
+	return &WalletResponse{WalletAddress: address, ChainID: chainID, Verified: true}, nil
+}
+
+func newTestSIWEService() (*siweService, *fakeUserRepository, *fakeVerifiedWatchlist) {
+	users, _, _ := newTestSessionService()
+	userRepo := &fakeUserRepository{users: make(map[uint]*models.User)}
+	users.userRepo = userRepo
+	users.config.SIWE.Domain = "app.example.com"
+	users.config.SIWE.NonceTTL = 300
+
+	registry := &web3Registry{services: make(map[int64]Web3Service), defaultChainID: 1}
+	registry.Register(&MockWeb3Service{chainID: 1})
+
+	watchlist := &fakeVerifiedWatchlist{}
+	service := NewSIWEService(users, watchlist, registry, NewMockCacheProvider(), logger.New(), users.config).(*siweService)
+	return service, userRepo, watchlist
+}
+
+// signSIWE builds a SIWE message for key and signs it with personal_sign
+func signSIWE(t *testing.T, key *ecdsa.PrivateKey, domain, nonce string) (string, string) {
+	address := crypto.PubkeyToAddress(key.PublicKey).Hex()
+	message := fmt.Sprintf("%s wants you to sign in with your Ethereum account:\n%s\n\nSign in to Crypto Portfolio\n\nURI: https://%s\nVersion: 1\nChain ID: 1\nNonce: %s\nIssued At: %s",
+		domain, address, domain, nonce, time.Now().UTC().Format(time.RFC3339))
+
+	sig, err := crypto.Sign(accounts.TextHash([]byte(message)), key)
+	require.NoError(t, err)
+	sig[crypto.RecoveryIDOffset] += 27
+	return message, hexutil.Encode(sig)
+}
+
+func TestParseSIWEMessage(t *testing.T) {
+	expires := "2030-01-01T00:00:00Z"
+	message := "https://app.example.com wants you to sign in with your Ethereum account:\n" +
+		"0x52908400098527886E0F7030069857D2E4169EE7\n\n\n" +
+		"URI: https://app.example.com/login\nVersion: 1\nChain ID: 42161\nNonce: abcdef123456\n" +
+		"Issued At: 2024-01-01T00:00:00Z\nExpiration Time: " + expires + "\n" +
+		"Resources:\n- ipfs://bafy\n- https://example.com/terms"
+
+	msg, err := parseSIWEMessage(message)
+	require.NoError(t, err)
+	assert.Equal(t, "app.example.com", msg.Domain, "the scheme is not part of the domain")
+	assert.Equal(t, "0x52908400098527886E0F7030069857D2E4169EE7", msg.Address.Hex())
+	assert.Empty(t, msg.Statement)
+	assert.Equal(t, int64(42161), msg.ChainID)
+	assert.Equal(t, "abcdef123456", msg.Nonce)
+	require.NotNil(t, msg.ExpirationTime)
+	assert.Equal(t, expires, msg.ExpirationTime.Format(time.RFC3339))
+	assert.Equal(t, []string{"ipfs://bafy", "https://example.com/terms"}, msg.Resources)
+
+	invalid := []string{
+		"not a SIWE message",
+		"app.example.com wants you to sign in with your Ethereum account:\n0x52908400098527886e0f7030069857d2e4169ee7\n\n\nURI: x\nVersion: 1\nChain ID: 1\nNonce: abcdef123456\nIssued At: 2024-01-01T00:00:00Z",
+		"app.example.com wants you to sign in with your Ethereum account:\n0x52908400098527886E0F7030069857D2E4169EE7\n\n\nURI: x\nVersion: 2\nChain ID: 1\nNonce: abcdef123456\nIssued At: 2024-01-01T00:00:00Z",
+		"app.example.com wants you to sign in with your Ethereum account:\n0x52908400098527886E0F7030069857D2E4169EE7\n\n\nURI: x\nVersion: 1\nChain ID: 1\nIssued At: 2024-01-01T00:00:00Z",
+	}
+	for _, message := range invalid {
+		_, err := parseSIWEMessage(message)
+		assert.ErrorIs(t, err, ErrInvalidSIWEMessage, message)
+	}
+}
+
+func TestSIWEService_Verify(t *testing.T) {
+	service, userRepo, watchlist := newTestSIWEService()
+	ctx := context.Background()
+	key, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	address := crypto.PubkeyToAddress(key.PublicKey).Hex()
+
+	nonce, err := service.Nonce(ctx)
+	require.NoError(t, err)
+	message, signature := signSIWE(t, key, "app.example.com", nonce.Nonce)
+
+	response, err := service.Verify(ctx, 0, &SIWEVerifyRequest{Message: message, Signature: signature})
+	require.NoError(t, err)
+	assert.NotEmpty(t, response.Token)
+	assert.NotEmpty(t, response.RefreshToken)
+	require.NotNil(t, response.User.WalletAddress)
+	assert.Equal(t, address, *response.User.WalletAddress)
+	assert.Len(t, userRepo.users, 1, "a user is created for a new wallet")
+	assert.Equal(t, []string{fmt.Sprintf("%d:%s:1", response.User.ID, address)}, watchlist.added)
+
+	_, err = service.Verify(ctx, 0, &SIWEVerifyRequest{Message: message, Signature: signature})
+	assert.ErrorIs(t, err, ErrInvalidNonce, "nonces are single-use")
+
+	nonce, err = service.Nonce(ctx)
+	require.NoError(t, err)
+	message, signature = signSIWE(t, key, "app.example.com", nonce.Nonce)
+	again, err := service.Verify(ctx, 0, &SIWEVerifyRequest{Message: message, Signature: signature})
+	require.NoError(t, err)
+	assert.Equal(t, response.User.ID, again.User.ID, "the wallet signs in to the same user")
+	assert.Len(t, userRepo.users, 1)
+}
+
+func TestSIWEService_VerifyRejects(t *testing.T) {
+	service, _, _ := newTestSIWEService()
+	ctx := context.Background()
+	key, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	other, err := crypto.GenerateKey()
+	require.NoError(t, err)
+
+	nonce, err := service.Nonce(ctx)
+	require.NoError(t, err)
+
+	message, _ := signSIWE(t, key, "app.example.com", nonce.Nonce)
+	_, otherSignature := signSIWE(t, other, "app.example.com", nonce.Nonce)
+	_, err = service.Verify(ctx, 0, &SIWEVerifyRequest{Message: message, Signature: otherSignature})
+	assert.ErrorIs(t, err, ErrInvalidSignature)
+
+	message, signature := signSIWE(t, key, "evil.example.com", nonce.Nonce)
+	_, err = service.Verify(ctx, 0, &SIWEVerifyRequest{Message: message, Signature: signature})
+	assert.ErrorIs(t, err, ErrInvalidSIWEMessage, "messages for another domain are rejected")
+
+	message, signature = signSIWE(t, key, "app.example.com", "unissued123")
+	_, err = service.Verify(ctx, 0, &SIWEVerifyRequest{Message: message, Signature: signature})
+	assert.ErrorIs(t, err, ErrInvalidNonce)
+
+	message, signature = signSIWE(t, key, "app.example.com", nonce.Nonce)
+	_, err = service.Verify(ctx, 0, &SIWEVerifyRequest{Message: message, Signature: signature})
+	assert.NoError(t, err, "rejected attempts do not use up the nonce")
+}
+
+func TestSIWEService_VerifyConcurrentReplay(t *testing.T) {
+	service, userRepo, _ := newTestSIWEService()
+	ctx := context.Background()
+	key, err := crypto.GenerateKey()
+	require.NoError(t, err)
+
+	nonce, err := service.Nonce(ctx)
+	require.NoError(t, err)
+	message, signature := signSIWE(t, key, "app.example.com", nonce.Nonce)
+
+	const attempts = 8
+	errs := make(chan error, attempts)
+	var wg sync.WaitGroup
+	for i := 0; i < attempts; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := service.Verify(ctx, 0, &SIWEVerifyRequest{Message: message, Signature: signature})
+			errs <- err
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	succeeded := 0
+	for err := range errs {
+		if err == nil {
+			succeeded++
+			continue
+		}
+		assert.ErrorIs(t, err, ErrInvalidNonce)
+	}
+	assert.Equal(t, 1, succeeded, "a signed message signs in only once")
+	assert.Len(t, userRepo.users, 1)
+}
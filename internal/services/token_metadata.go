@@ -0,0 +1,197 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"cryptoportfolio/internal/cache"
+	"cryptoportfolio/internal/models"
+)
+
+// ErrNotERC20 is returned when an address does not behave like an ERC-20 token contract
+var ErrNotERC20 = errors.New("address is not an ERC-20 token contract")
+
+// nativeTokenDecimals is the number of decimals of every EVM chain's native token
+const nativeTokenDecimals = 18
+
+// Discovered metadata is truncated to the size of the TrackedToken columns
+const (
+	maxTokenSymbolLength = 32
+	maxTokenNameLength   = 100
+)
+
+// maxTokenDecimals is the largest sensible decimals value: 10^77 is the largest power of ten that fits in a uint256
+const maxTokenDecimals = 77
+
+// erc20MetadataABI covers the ERC-20 metadata functions
+const erc20MetadataABI = `[
+	{"constant":true,"inputs":[],"name":"name","outputs":[{"name":"","type":"string"}],"stateMutability":"view","type":"function"},
+	{"constant":true,"inputs":[],"name":"symbol","outputs":[{"name":"","type":"string"}],"stateMutability":"view","type":"function"},
+	{"constant":true,"inputs":[],"name":"decimals","outputs":[{"name":"","type":"uint8"}],"stateMutability":"view","type":"function"},
+	{"constant":true,"inputs":[],"name":"totalSupply","outputs":[{"name":"","type":"uint256"}],"stateMutability":"view","type":"function"}
+]`
+
+var erc20Metadata = mustParseABI(erc20MetadataABI)
+
+// TokenMetadata is the metadata an ERC-20 contract reports about itself
+type TokenMetadata struct {
+	Name     string
+	Symbol   string
+	Decimals uint8
+}
+
+// FetchTokenMetadata reads name(), symbol() and decimals() from an ERC-20
+// contract. Legacy tokens such as MKR that return bytes32 instead of string
+// are supported. name() and symbol() are optional in ERC-20 and are left
+// empty when missing; ErrNotERC20 is returned when the address has no
+// totalSupply() or decimals().
+func FetchTokenMetadata(ctx context.Context, web3Service Web3Service, tokenAddress string) (*TokenMetadata, error) {
+	// Every ERC-20 contract has totalSupply(); calls to accounts without code return no data
+	supply, err := callERC20(ctx, web3Service, tokenAddress, "totalSupply")
+	if err != nil {
+		return nil, err
+	}
+	if len(supply) < 32 {
+		return nil, ErrNotERC20
+	}
+
+	decimals, err := fetchERC20Decimals(ctx, web3Service, tokenAddress)
+	if err != nil {
+		if errors.Is(err, ErrNotERC20) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to read decimals: %w", err)
+	}
+
+	metadata := &TokenMetadata{Decimals: decimals}
+
+	for _, field := range []struct {
+		method    string
+		maxLength int
+		dest      *string
+	}{
+		{"name", maxTokenNameLength, &metadata.Name},
+		{"symbol", maxTokenSymbolLength, &metadata.Symbol},
+	} {
+		output, err := callERC20(ctx, web3Service, tokenAddress, field.method)
+		if err != nil && !errors.Is(err, ErrNotERC20) {
+			return nil, fmt.Errorf("failed to read %s: %w", field.method, err)
+		}
+		*field.dest = truncateString(decodeERC20String(field.method, output), field.maxLength)
+	}
+
+	return metadata, nil
+}
+
+// callERC20 calls an argument-less ERC-20 function. Reverts are reported as ErrNotERC20.
+func callERC20(ctx context.Context, web3Service Web3Service, tokenAddress, method string) ([]byte, error) {
+	data, err := erc20Metadata.Pack(method)
+	if err != nil {
+		return nil, err
+	}
+
+	output, err := web3Service.CallContract(ctx, tokenAddress, data)
+	if err != nil {
+		if !isProviderError(err) && ctx.Err() == nil {
+			return nil, ErrNotERC20
+		}
+		return nil, err
+	}
+
+	return output, nil
+}
+
+// decodeERC20String decodes the result of name() or symbol(), which is an
+// ABI-encoded string on current tokens and a NUL-padded bytes32 on legacy ones
+func decodeERC20String(method string, output []byte) string {
+	if values, err := erc20Metadata.Unpack(method, output); err == nil {
+		return sanitizeTokenString(values[0].(string))
+	}
+
+	if len(output) == 32 {
+		return sanitizeTokenString(string(bytes.TrimRight(output, "\x00")))
+	}
+
+	return ""
+}
+
+// sanitizeTokenString makes contract-supplied text safe to store and display
+func sanitizeTokenString(value string) string {
+	value = strings.ToValidUTF8(value, "")
+	value = strings.Map(func(r rune) rune {
+		if r < 0x20 || r == 0x7f {
+			return -1
+		}
+		return r
+	}, value)
+	return strings.TrimSpace(value)
+}
+
+// truncateString shortens value to at most maxLength bytes without splitting a character
+func truncateString(value string, maxLength int) string {
+	if len(value) <= maxLength {
+		return value
+	}
+	value = value[:maxLength]
+	for !utf8.ValidString(value) {
+		value = value[:len(value)-1]
+	}
+	return value
+}
+
+// fetchERC20Decimals reads a token's decimals() from the chain
+func fetchERC20Decimals(ctx context.Context, web3Service Web3Service, tokenAddress string) (uint8, error) {
+	output, err := callERC20(ctx, web3Service, tokenAddress, "decimals")
+	if err != nil {
+		return 0, err
+	}
+	if len(output) < 32 {
+		return 0, ErrNotERC20
+	}
+
+	// Some tokens declare decimals as uint256, so the word is range-checked rather than unpacked as uint8
+	decimals := new(big.Int).SetBytes(output[:32])
+	if !decimals.IsUint64() || decimals.Uint64() > maxTokenDecimals {
+		return 0, ErrNotERC20
+	}
+
+	return uint8(decimals.Uint64()), nil
+}
+
+// tokenDecimals returns a tracked token's decimals. Tokens added before
+// decimals were persisted have them read from the chain once per day.
+func tokenDecimals(ctx context.Context, web3Registry Web3Registry, cacheService cache.CacheProvider, token *models.TrackedToken) (uint8, error) {
+	if token.Decimals != nil {
+		return *token.Decimals, nil
+	}
+	if token.TokenAddress == nil {
+		return nativeTokenDecimals, nil
+	}
+
+	cacheKey := fmt.Sprintf("token_decimals:%d:%s", token.ChainID, strings.ToLower(*token.TokenAddress))
+	var decimals uint8
+	if err := cacheService.Get(ctx, cacheKey, &decimals); err == nil {
+		return decimals, nil
+	}
+
+	web3Service, err := web3Registry.Get(token.ChainID)
+	if err != nil {
+		return 0, err
+	}
+
+	decimals, err = fetchERC20Decimals(ctx, web3Service, *token.TokenAddress)
+	if err != nil {
+		return 0, err
+	}
+
+	// Cache errors are logged by the cache provider and don't affect the result
+	_ = cacheService.Set(ctx, cacheKey, decimals, 24*time.Hour)
+
+	return decimals, nil
+}
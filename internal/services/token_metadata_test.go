@@ -0,0 +1,148 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testTokenContract answers ERC-20 metadata calls like a deployed token would
+type testTokenContract struct {
+	name, symbol []byte // raw return data; nil makes the call revert
+	decimals     []byte
+	totalSupply  []byte
+}
+
+func (c *testTokenContract) call(contractAddress string, data []byte) ([]byte, error) {
+	method, err := erc20Metadata.MethodById(data)
+	if err != nil {
+		return nil, err
+	}
+
+	var output []byte
+	switch method.Name {
+	case "name":
+		output = c.name
+	case "symbol":
+		output = c.symbol
+	case "decimals":
+		output = c.decimals
+	case "totalSupply":
+		output = c.totalSupply
+	}
+	if output == nil {
+		return nil, errors.New("execution reverted")
+	}
+	return output, nil
+}
+
+func packString(t *testing.T, value string) []byte {
+	stringType, err := abi.NewType("string", "", nil)
+	require.NoError(t, err)
+	output, err := abi.Arguments{{Type: stringType}}.Pack(value)
+	require.NoError(t, err)
+	return output
+}
+
+func packBytes32(value string) []byte {
+	output := make([]byte, 32)
+	copy(output, value)
+	return output
+}
+
+func uintWord(value int64) []byte {
+	word := make([]byte, 32)
+	big.NewInt(value).FillBytes(word)
+	return word
+}
+
+func fetchTestMetadata(contract *testTokenContract) (*TokenMetadata, error) {
+	web3Service := &MockWeb3Service{chainID: 1, callContract: contract.call}
+	return FetchTokenMetadata(context.Background(), web3Service, "0x9f8F72aA9304c8B593d555F12eF6589cC3A579A2")
+}
+
+func TestFetchTokenMetadata_StringToken(t *testing.T) {
+	metadata, err := fetchTestMetadata(&testTokenContract{
+		name:        packString(t, "USD Coin"),
+		symbol:      packString(t, "USDC"),
+		decimals:    uintWord(6),
+		totalSupply: uintWord(1000),
+	})
+	require.NoError(t, err)
+	assert.Equal(t, &TokenMetadata{Name: "USD Coin", Symbol: "USDC", Decimals: 6}, metadata)
+}
+
+func TestFetchTokenMetadata_Bytes32Token(t *testing.T) {
+	// MKR returns name and symbol as NUL-padded bytes32
+	metadata, err := fetchTestMetadata(&testTokenContract{
+		name:        packBytes32("Maker"),
+		symbol:      packBytes32("MKR"),
+		decimals:    uintWord(18),
+		totalSupply: uintWord(1000),
+	})
+	require.NoError(t, err)
+	assert.Equal(t, &TokenMetadata{Name: "Maker", Symbol: "MKR", Decimals: 18}, metadata)
+}
+
+func TestFetchTokenMetadata_MissingOptionalFields(t *testing.T) {
+	metadata, err := fetchTestMetadata(&testTokenContract{
+		decimals:    uintWord(8),
+		totalSupply: uintWord(1000),
+	})
+	require.NoError(t, err)
+	assert.Equal(t, &TokenMetadata{Decimals: 8}, metadata)
+}
+
+func TestFetchTokenMetadata_RejectsNonERC20(t *testing.T) {
+	// An externally owned account returns no data for any call
+	_, err := fetchTestMetadata(&testTokenContract{
+		totalSupply: []byte{},
+		decimals:    []byte{},
+	})
+	assert.ErrorIs(t, err, ErrNotERC20)
+
+	// A contract without totalSupply reverts
+	_, err = fetchTestMetadata(&testTokenContract{decimals: uintWord(18)})
+	assert.ErrorIs(t, err, ErrNotERC20)
+
+	// A contract without decimals reverts
+	_, err = fetchTestMetadata(&testTokenContract{totalSupply: uintWord(1000)})
+	assert.ErrorIs(t, err, ErrNotERC20)
+
+	// Out of range decimals
+	_, err = fetchTestMetadata(&testTokenContract{totalSupply: uintWord(1000), decimals: uintWord(1000)})
+	assert.ErrorIs(t, err, ErrNotERC20)
+}
+
+func TestFetchTokenMetadata_ProviderErrorsAreNotRejections(t *testing.T) {
+	web3Service := &MockWeb3Service{
+		chainID: 1,
+		callContract: func(contractAddress string, data []byte) ([]byte, error) {
+			return nil, errors.New("connection refused")
+		},
+	}
+	_, err := FetchTokenMetadata(context.Background(), web3Service, "0x9f8F72aA9304c8B593d555F12eF6589cC3A579A2")
+	require.Error(t, err)
+	assert.False(t, errors.Is(err, ErrNotERC20))
+}
+
+func TestDecodeERC20String(t *testing.T) {
+	assert.Equal(t, "Dai Stablecoin", decodeERC20String("name", packString(t, "Dai Stablecoin")))
+	assert.Equal(t, "MKR", decodeERC20String("symbol", packBytes32("MKR")))
+	assert.Equal(t, "", decodeERC20String("symbol", nil))
+	// Control characters and invalid UTF-8 are stripped
+	assert.Equal(t, "BAD", decodeERC20String("symbol", packString(t, "B\x01A\xffD\n")))
+}
+
+func TestTruncateString(t *testing.T) {
+	assert.Equal(t, "abc", truncateString("abc", 32))
+	assert.Equal(t, strings.Repeat("a", 32), truncateString(strings.Repeat("a", 40), 32))
+	// Multi-byte characters are not split
+	assert.Equal(t, "a", truncateString("aé", 2))
+}
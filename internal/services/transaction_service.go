@@ -0,0 +1,565 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"cryptoportfolio/internal/cache"
+	"cryptoportfolio/internal/config"
+	"cryptoportfolio/internal/models"
+	"cryptoportfolio/internal/repository"
+	"cryptoportfolio/pkg/logger"
+	"cryptoportfolio/pkg/units"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// errBlockChanged is returned when a log's block is not the block that was read
+// for the same height, i.e. the chain reorganized during a scan
+var errBlockChanged = errors.New("block changed while scanning")
+
+const (
+	// defaultTransactionScanInterval is used when WEB3_TX_SCAN_INTERVAL is not positive
+	defaultTransactionScanInterval = 30 * time.Second
+	// defaultTransactionScanBlocks is used when WEB3_TX_SCAN_BLOCKS is not positive
+	defaultTransactionScanBlocks = 100
+)
+
+// TransactionResponse is a transfer into or out of a watched wallet
+type TransactionResponse struct {
+	ID              uint      `json:"id"`
+	WalletID        uint      `json:"wallet_id"`
+	ChainID         int64     `json:"chain_id"`
+	TxHash          string    `json:"tx_hash"`
+	LogIndex        int       `json:"log_index"` // -1 for native transfers
+	BlockNumber     uint64    `json:"block_number"`
+	BlockHash       string    `json:"block_hash"`
+	Timestamp       time.Time `json:"timestamp"`
+	Direction       string    `json:"direction" example:"in"`
+	Counterparty    string    `json:"counterparty"`       // empty for native transfers that created a contract
+	TokenAddress    *string   `json:"token_address"`      // null for the chain's native token
+	TokenID         *uint     `json:"token_id,omitempty"` // set when the user tracks the token
+	TokenSymbol     string    `json:"token_symbol,omitempty"`
+	Amount          string    `json:"amount"` // Raw amount in the token's smallest unit
+	FormattedAmount string    `json:"formatted_amount,omitempty"`
+}
+
+// TransactionPage is one page of a wallet's transfers, newest first
+type TransactionPage struct {
+	Transactions []*TransactionResponse `json:"transactions"`
+	Total        int64                  `json:"total"`
+	Limit        int                    `json:"limit"`
+	Offset       int                    `json:"offset"`
+	HasNext      bool                   `json:"has_next"`
+}
+
+// TransactionService records the native and ERC-20 transfers of watched
+// wallets and lists them
+type TransactionService interface {
+	Start(ctx context.Context)
+	Stop()
+	GetTransactions(ctx context.Context, userID uint, walletID uint, limit, offset int) (*TransactionPage, error)
+}
+
+// transactionService implements TransactionService
+type transactionService struct {
+	transactionRepo repository.TransactionRepository
+	watchlistRepo   repository.WatchlistRepository
+	web3Registry    Web3Registry
+	alertService    AlertService
+	cacheService    cache.CacheProvider
+	logger          *logger.Logger
+	config          *config.Config
+	stopChan        chan struct{}
+	wg              sync.WaitGroup
+}
+
+// NewTransactionService creates a new transaction service
+func NewTransactionService(
+	transactionRepo repository.TransactionRepository,
+	watchlistRepo repository.WatchlistRepository,
+	web3Registry Web3Registry,
+	alertService AlertService,
+	cacheService cache.CacheProvider,
+	logger *logger.Logger,
+	config *config.Config,
+) TransactionService {
+	return &transactionService{
+		transactionRepo: transactionRepo,
+		watchlistRepo:   watchlistRepo,
+		web3Registry:    web3Registry,
+		alertService:    alertService,
+		cacheService:    cacheService,
+		logger:          logger,
+		config:          config,
+		stopChan:        make(chan struct{}),
+	}
+}
+
+// Start begins ingesting transfers in the background
+func (s *transactionService) Start(ctx context.Context) {
+	s.logger.Info("Starting transaction ingestion")
+
+	s.wg.Add(1)
+	go s.run(ctx)
+}
+
+// Stop gracefully stops the ingestion; a cycle in progress resumes from the stored cursors
+func (s *transactionService) Stop() {
+	s.logger.Info("Stopping transaction ingestion")
+	close(s.stopChan)
+	s.wg.Wait()
+	s.logger.Info("Transaction ingestion stopped")
+}
+
+// run ingests new blocks on every tick
+func (s *transactionService) run(ctx context.Context) {
+	defer s.wg.Done()
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go func() {
+		select {
+		case <-s.stopChan:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	interval := time.Duration(s.config.Web3.TransactionScanInterval) * time.Second
+	if interval <= 0 {
+		interval = defaultTransactionScanInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		s.ingest(ctx)
+
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// ingest scans every chain for new transfers of its watched wallets
+func (s *transactionService) ingest(ctx context.Context) {
+	wallets, err := s.watchlistRepo.GetAllWallets(ctx)
+	if err != nil {
+		s.logger.Error("Failed to get wallets", "error", err)
+		return
+	}
+
+	cursors, err := s.transactionRepo.GetCursors(ctx)
+	if err != nil {
+		s.logger.Error("Failed to get transaction cursors", "error", err)
+		return
+	}
+	lastBlocks := make(map[uint]uint64, len(cursors))
+	for _, cursor := range cursors {
+		lastBlocks[cursor.WalletID] = cursor.LastBlock
+	}
+
+	for _, chain := range s.config.Web3.Chains {
+		chainWallets, _ := onChain(chain.ChainID, wallets, nil)
+		if len(chainWallets) == 0 {
+			continue
+		}
+
+		web3Service, err := s.web3Registry.Get(chain.ChainID)
+		if err != nil {
+			continue
+		}
+
+		if err := s.ingestChain(ctx, web3Service, chainWallets, lastBlocks); err != nil && ctx.Err() == nil {
+			s.logger.Warn("Failed to ingest transactions", "chain_id", chain.ChainID, "error", err)
+		}
+	}
+}
+
+// ingestChain scans the blocks after each wallet's cursor up to the
+// confirmed head, at most WEB3_TX_SCAN_BLOCKS blocks per cycle. Wallets with
+// the same cursor are scanned together. New wallets start at the block they
+// were added at; wallets added before ingestion was enabled start at the
+// current head.
+func (s *transactionService) ingestChain(ctx context.Context, web3Service Web3Service, wallets []*models.WatchlistWallet, lastBlocks map[uint]uint64) error {
+	chainID := web3Service.ChainID()
+
+	header, err := web3Service.HeaderByNumber(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to get latest block: %w", err)
+	}
+	confirmed := confirmedBlock(header.Number.Uint64(), s.config.Web3.Confirmations)
+
+	maxBlocks := uint64(defaultTransactionScanBlocks)
+	if s.config.Web3.TransactionScanBlocks > 0 {
+		maxBlocks = uint64(s.config.Web3.TransactionScanBlocks)
+	}
+
+	var started []*models.TransactionCursor
+	groups := make(map[uint64][]*models.WatchlistWallet)
+	for _, wallet := range wallets {
+		from, ok := nextScanBlock(wallet, lastBlocks)
+		if !ok {
+			started = append(started, &models.TransactionCursor{WalletID: wallet.ID, LastBlock: confirmed})
+			continue
+		}
+		if from > confirmed {
+			continue
+		}
+		groups[from] = append(groups[from], wallet)
+	}
+
+	if len(started) > 0 {
+		if err := s.transactionRepo.StoreTransfers(ctx, nil, started); err != nil {
+			return fmt.Errorf("failed to store transaction cursors: %w", err)
+		}
+		for _, cursor := range started {
+			lastBlocks[cursor.WalletID] = cursor.LastBlock
+		}
+	}
+
+	starts := make([]uint64, 0, len(groups))
+	for from := range groups {
+		starts = append(starts, from)
+	}
+	sort.Slice(starts, func(i, j int) bool { return starts[i] < starts[j] })
+
+	for _, from := range starts {
+		group := groups[from]
+		to := from + maxBlocks - 1
+		if to > confirmed {
+			to = confirmed
+		}
+
+		transfers, err := s.scanTransfers(ctx, web3Service, group, from, to)
+		if err != nil {
+			return fmt.Errorf("failed to scan blocks %d-%d: %w", from, to, err)
+		}
+
+		cursors := make([]*models.TransactionCursor, len(group))
+		for i, wallet := range group {
+			cursors[i] = &models.TransactionCursor{WalletID: wallet.ID, LastBlock: to}
+		}
+		if err := s.transactionRepo.StoreTransfers(ctx, transfers, cursors); err != nil {
+			return fmt.Errorf("failed to store transfers: %w", err)
+		}
+		for _, cursor := range cursors {
+			lastBlocks[cursor.WalletID] = cursor.LastBlock
+		}
+
+		if len(transfers) > 0 {
+			s.logger.Info("Ingested transfers", "chain_id", chainID, "from_block", from, "to_block", to, "transfers", len(transfers))
+			s.evaluateAlerts(ctx, group, transfers)
+		}
+	}
+
+	return nil
+}
+
+// evaluateAlerts checks the outgoing transfer alerts of the scanned wallets;
+// a failure does not fail the ingestion
+func (s *transactionService) evaluateAlerts(ctx context.Context, wallets []*models.WatchlistWallet, transfers []*models.Transaction) {
+	if s.alertService == nil {
+		return
+	}
+	for _, wallet := range wallets {
+		if err := s.alertService.EvaluateTransfers(ctx, wallet, transfers); err != nil {
+			s.logger.Warn("Failed to evaluate transfer alerts", "wallet_id", wallet.ID, "error", err)
+		}
+	}
+}
+
+// nextScanBlock returns the first block to scan for a wallet, or false when
+// the wallet has neither a cursor nor a recorded added block
+func nextScanBlock(wallet *models.WatchlistWallet, lastBlocks map[uint]uint64) (uint64, bool) {
+	if lastBlock, ok := lastBlocks[wallet.ID]; ok {
+		return lastBlock + 1, true
+	}
+	if wallet.AddedBlock != nil {
+		return *wallet.AddedBlock, true
+	}
+	return 0, false
+}
+
+// confirmedBlock returns the block the given number of confirmations behind the head
+func confirmedBlock(head uint64, confirmations int) uint64 {
+	if confirmations <= 0 {
+		return head
+	}
+	if head < uint64(confirmations) {
+		return 0
+	}
+	return head - uint64(confirmations)
+}
+
+// scanTransfers finds the transfers of the given wallets in a block range.
+// Native transfers are read from the blocks' transactions and kept only when
+// the transaction succeeded; value moved by contract calls (internal
+// transactions) is not visible this way. ERC-20 transfers are read from
+// Transfer logs of any contract.
+func (s *transactionService) scanTransfers(ctx context.Context, web3Service Web3Service, wallets []*models.WatchlistWallet, from, to uint64) ([]*models.Transaction, error) {
+	chainID := web3Service.ChainID()
+
+	walletsByAddress := make(map[common.Address][]*models.WatchlistWallet)
+	for _, wallet := range wallets {
+		address := common.HexToAddress(wallet.WalletAddress)
+		walletsByAddress[address] = append(walletsByAddress[address], wallet)
+	}
+
+	numbers := make([]*big.Int, 0, to-from+1)
+	for number := from; number <= to; number++ {
+		numbers = append(numbers, new(big.Int).SetUint64(number))
+	}
+	blocks, err := web3Service.GetBlocks(ctx, numbers)
+	if err != nil {
+		return nil, err
+	}
+	blocksByNumber := make(map[uint64]*Block, len(blocks))
+	for _, block := range blocks {
+		blocksByNumber[block.Number.Uint64()] = block
+	}
+
+	var transfers []*models.Transaction
+
+	// Native transfers
+	type nativeTransfer struct {
+		block *Block
+		tx    BlockTransaction
+	}
+	var candidates []nativeTransfer
+	var hashes []common.Hash
+	for _, block := range blocks {
+		for _, tx := range block.Transactions {
+			if tx.Value == nil || tx.Value.Sign() == 0 {
+				continue
+			}
+			if len(walletsByAddress[tx.From]) == 0 && (tx.To == nil || len(walletsByAddress[*tx.To]) == 0) {
+				continue
+			}
+			candidates = append(candidates, nativeTransfer{block: block, tx: tx})
+			hashes = append(hashes, tx.Hash)
+		}
+	}
+	if len(candidates) > 0 {
+		statuses, err := web3Service.GetReceiptStatuses(ctx, hashes)
+		if err != nil {
+			return nil, err
+		}
+		for i, candidate := range candidates {
+			// Failed transactions move no value
+			if statuses[i] != 1 {
+				continue
+			}
+			transfers = append(transfers, newTransfers(walletsByAddress, chainID, candidate.block, candidate.tx.Hash,
+				models.NativeTransferLogIndex, nil, candidate.tx.From, candidate.tx.To, candidate.tx.Value)...)
+		}
+	}
+
+	// ERC-20 transfers; a transfer between two watched wallets matches both queries
+	seen := make(map[string]bool)
+	for _, query := range transferQueries(nil, walletTopics(wallets), from, to) {
+		logs, err := web3Service.FilterLogs(ctx, query)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, log := range logs {
+			// ERC-721 transfers share the event signature but index the token ID as a fourth topic
+			if log.Removed || len(log.Topics) != 3 || log.Topics[0] != transferTopic || len(log.Data) != 32 {
+				continue
+			}
+			key := fmt.Sprintf("%s:%d", log.TxHash.Hex(), log.Index)
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+
+			block := blocksByNumber[log.BlockNumber]
+			if block == nil || block.Hash != log.BlockHash {
+				return nil, fmt.Errorf("block %d: %w", log.BlockNumber, errBlockChanged)
+			}
+
+			tokenAddress := log.Address.Hex()
+			sender := common.BytesToAddress(log.Topics[1].Bytes())
+			recipient := common.BytesToAddress(log.Topics[2].Bytes())
+			transfers = append(transfers, newTransfers(walletsByAddress, chainID, block, log.TxHash,
+				int(log.Index), &tokenAddress, sender, &recipient, new(big.Int).SetBytes(log.Data))...)
+		}
+	}
+
+	return transfers, nil
+}
+
+// newTransfers records a transfer once for each watched wallet it touches,
+// with the direction seen from that wallet
+func newTransfers(
+	walletsByAddress map[common.Address][]*models.WatchlistWallet,
+	chainID int64,
+	block *Block,
+	txHash common.Hash,
+	logIndex int,
+	tokenAddress *string,
+	from common.Address,
+	to *common.Address,
+	amount *big.Int,
+) []*models.Transaction {
+	touched := walletsByAddress[from]
+	toAddress := ""
+	if to != nil {
+		toAddress = to.Hex()
+		if *to != from {
+			touched = append(touched[:len(touched):len(touched)], walletsByAddress[*to]...)
+		}
+	}
+
+	transfers := make([]*models.Transaction, 0, len(touched))
+	for _, wallet := range touched {
+		address := common.HexToAddress(wallet.WalletAddress)
+		direction := models.TransferDirectionIn
+		switch {
+		case address == from && to != nil && address == *to:
+			direction = models.TransferDirectionSelf
+		case address == from:
+			direction = models.TransferDirectionOut
+		}
+
+		transfers = append(transfers, &models.Transaction{
+			WalletID:     wallet.ID,
+			ChainID:      chainID,
+			TxHash:       txHash.Hex(),
+			LogIndex:     logIndex,
+			BlockNumber:  block.Number.Uint64(),
+			BlockHash:    block.Hash.Hex(),
+			Timestamp:    time.Unix(int64(block.Time), 0).UTC(),
+			TokenAddress: tokenAddress,
+			FromAddress:  from.Hex(),
+			ToAddress:    toAddress,
+			Direction:    direction,
+			Amount:       amount.String(),
+		})
+	}
+	return transfers
+}
+
+// GetTransactions retrieves a page of a watched wallet's transfers, newest first
+func (s *transactionService) GetTransactions(ctx context.Context, userID uint, walletID uint, limit, offset int) (*TransactionPage, error) {
+	wallet, err := s.watchlistRepo.GetWalletByID(ctx, walletID)
+	if err != nil {
+		if errors.Is(err, repository.ErrRecordNotFound) {
+			return nil, ErrWalletNotFound
+		}
+		s.logger.Error("Failed to get wallet", "error", err, "wallet_id", walletID)
+		return nil, err
+	}
+	if wallet.UserID != userID {
+		return nil, ErrWalletNotFound
+	}
+
+	page, err := s.transactionRepo.GetByWalletID(ctx, walletID, repository.Pagination{Limit: limit, Offset: offset})
+	if err != nil {
+		s.logger.Error("Failed to get transactions", "error", err, "wallet_id", walletID)
+		return nil, err
+	}
+
+	// Transfers of tracked tokens are shown with the token's symbol and decimals
+	tokens, err := s.watchlistRepo.GetTokensByUserID(ctx, userID)
+	if err != nil {
+		s.logger.Error("Failed to get user tokens", "error", err, "user_id", userID)
+		return nil, err
+	}
+	tracked := make(map[string]*models.TrackedToken)
+	for _, token := range tokens {
+		if token.ChainID == wallet.ChainID {
+			tracked[tokenKey(token.TokenAddress)] = token
+		}
+	}
+
+	nativeSymbol := ""
+	for _, chain := range s.config.Web3.Chains {
+		if chain.ChainID == wallet.ChainID {
+			nativeSymbol = chain.NativeSymbol
+		}
+	}
+
+	response := &TransactionPage{
+		Transactions: make([]*TransactionResponse, 0, len(page.Data)),
+		Total:        page.Total,
+		Limit:        page.Limit,
+		Offset:       page.Offset,
+		HasNext:      page.HasNext,
+	}
+	for _, tx := range page.Data {
+		item := &TransactionResponse{
+			ID:           tx.ID,
+			WalletID:     tx.WalletID,
+			ChainID:      tx.ChainID,
+			TxHash:       tx.TxHash,
+			LogIndex:     tx.LogIndex,
+			BlockNumber:  tx.BlockNumber,
+			BlockHash:    tx.BlockHash,
+			Timestamp:    tx.Timestamp,
+			Direction:    tx.Direction,
+			Counterparty: counterparty(tx),
+			TokenAddress: tx.TokenAddress,
+			Amount:       tx.Amount,
+		}
+
+		if token, ok := tracked[tokenKey(tx.TokenAddress)]; ok {
+			item.TokenID = &token.ID
+			item.TokenSymbol = token.TokenSymbol
+			item.FormattedAmount = s.formatAmount(ctx, token, tx.Amount)
+		} else if tx.TokenAddress == nil {
+			item.TokenSymbol = nativeSymbol
+			item.FormattedAmount = formatRawAmount(tx.Amount, nativeTokenDecimals)
+		}
+
+		response.Transactions = append(response.Transactions, item)
+	}
+
+	return response, nil
+}
+
+// formatAmount formats a raw amount with a tracked token's decimals. An
+// empty string is returned when the decimals cannot be determined.
+func (s *transactionService) formatAmount(ctx context.Context, token *models.TrackedToken, amount string) string {
+	decimals, err := tokenDecimals(ctx, s.web3Registry, s.cacheService, token)
+	if err != nil {
+		s.logger.Warn("Failed to get token decimals", "error", err, "token_id", token.ID)
+		return ""
+	}
+	return formatRawAmount(amount, decimals)
+}
+
+// formatRawAmount formats a base-10 raw amount; invalid amounts format as an empty string
+func formatRawAmount(amount string, decimals uint8) string {
+	value, ok := new(big.Int).SetString(amount, 10)
+	if !ok {
+		return ""
+	}
+	return units.FormatUnits(value, decimals)
+}
+
+// tokenKey identifies a token by its lower-cased address; the native token is the empty key
+func tokenKey(tokenAddress *string) string {
+	if tokenAddress == nil {
+		return ""
+	}
+	return strings.ToLower(*tokenAddress)
+}
+
+// counterparty returns the other side of a transfer as seen from the watched wallet
+func counterparty(tx *models.Transaction) string {
+	if tx.Direction == models.TransferDirectionIn {
+		return tx.FromAddress
+	}
+	return tx.ToAddress
+}
@@ -0,0 +1,241 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+
+	"cryptoportfolio/internal/config"
+	"cryptoportfolio/internal/models"
+	"cryptoportfolio/internal/repository"
+	"cryptoportfolio/pkg/logger"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeTransactionRepository records stored transfers and cursors
+type fakeTransactionRepository struct {
+	repository.TransactionRepository
+	transfers []*models.Transaction
+	cursors   []*models.TransactionCursor
+}
+
+func (r *fakeTransactionRepository) StoreTransfers(ctx context.Context, transactions []*models.Transaction, cursors []*models.TransactionCursor) error {
+	r.transfers = append(r.transfers, transactions...)
+	r.cursors = append(r.cursors, cursors...)
+	return nil
+}
+
+// testBlockHash derives a block's hash from its number
+func testBlockHash(number uint64) common.Hash {
+	return common.BigToHash(new(big.Int).SetUint64(number + 0xb10c))
+}
+
+// testBlocks serves empty blocks, adding the given transactions to their block
+func testBlocks(transactions map[uint64][]BlockTransaction) func(numbers []*big.Int) ([]*Block, error) {
+	return func(numbers []*big.Int) ([]*Block, error) {
+		blocks := make([]*Block, len(numbers))
+		for i, number := range numbers {
+			blocks[i] = &Block{
+				BlockHeader:  BlockHeader{Number: number, Hash: testBlockHash(number.Uint64()), Time: 1700000000 + number.Uint64()*12},
+				Transactions: transactions[number.Uint64()],
+			}
+		}
+		return blocks, nil
+	}
+}
+
+func testTransferLog(block uint64, index uint, token, from, to string) types.Log {
+	log := transferLog(token, from, to)
+	log.BlockNumber = block
+	log.BlockHash = testBlockHash(block)
+	log.TxHash = common.BigToHash(big.NewInt(int64(block*100) + int64(index)))
+	log.Index = index
+	log.Data = common.LeftPadBytes(big.NewInt(2500000).Bytes(), 32)
+	return log
+}
+
+func TestScanTransfers(t *testing.T) {
+	walletA := &models.WatchlistWallet{ID: 1, UserID: 1, WalletAddress: testWalletA, ChainID: 1}
+	walletAUser2 := &models.WatchlistWallet{ID: 2, UserID: 2, WalletAddress: testWalletA, ChainID: 1}
+	walletB := &models.WatchlistWallet{ID: 3, UserID: 1, WalletAddress: testWalletB, ChainID: 1}
+
+	a, b, stranger := common.HexToAddress(testWalletA), common.HexToAddress(testWalletB), common.HexToAddress(testStranger)
+	sent := BlockTransaction{Hash: common.HexToHash("0x01"), From: a, To: &stranger, Value: big.NewInt(1)}
+	failed := BlockTransaction{Hash: common.HexToHash("0x02"), From: stranger, To: &b, Value: big.NewInt(2)}
+	noValue := BlockTransaction{Hash: common.HexToHash("0x03"), From: a, To: &b, Value: big.NewInt(0)}
+	self := BlockTransaction{Hash: common.HexToHash("0x04"), From: b, To: &b, Value: big.NewInt(3)}
+	unrelated := BlockTransaction{Hash: common.HexToHash("0x05"), From: stranger, To: &stranger, Value: big.NewInt(4)}
+
+	received := testTransferLog(100, 0, testUSDC, testStranger, testWalletA)
+	between := testTransferLog(101, 2, testUSDC, testWalletA, testWalletB)
+	nft := testTransferLog(101, 3, testDAI, testStranger, testWalletB)
+	nft.Topics = append(nft.Topics, common.HexToHash("0x2a"))
+	nft.Data = nil
+
+	var receiptRequests []common.Hash
+	web3Service := &MockWeb3Service{
+		chainID: 1,
+		getBlocks: testBlocks(map[uint64][]BlockTransaction{
+			100: {sent, failed, unrelated},
+			101: {noValue, self},
+		}),
+		receiptStatuses: func(txHashes []common.Hash) ([]uint64, error) {
+			receiptRequests = txHashes
+			statuses := make([]uint64, len(txHashes))
+			for i, hash := range txHashes {
+				if hash != failed.Hash {
+					statuses[i] = 1
+				}
+			}
+			return statuses, nil
+		},
+		filterLogs: func(query ethereum.FilterQuery) ([]types.Log, error) {
+			assert.Empty(t, query.Addresses, "ERC-20 transfers of any token are ingested")
+			// Senders are filtered on the second topic, recipients on the third
+			if len(query.Topics[1]) > 0 {
+				return []types.Log{between}, nil
+			}
+			return []types.Log{received, between, nft}, nil
+		},
+	}
+
+	service := &transactionService{logger: logger.New(), config: &config.Config{}}
+	transfers, err := service.scanTransfers(context.Background(), web3Service, []*models.WatchlistWallet{walletA, walletAUser2, walletB}, 100, 101)
+	require.NoError(t, err)
+
+	// Only native transactions touching a watched wallet with value are checked
+	assert.Equal(t, []common.Hash{sent.Hash, failed.Hash, self.Hash}, receiptRequests)
+
+	type transfer struct {
+		walletID  uint
+		txHash    string
+		direction string
+		token     string
+		amount    string
+	}
+	var got []transfer
+	for _, tx := range transfers {
+		token := "native"
+		if tx.TokenAddress != nil {
+			token = *tx.TokenAddress
+		}
+		got = append(got, transfer{tx.WalletID, tx.TxHash, tx.Direction, token, tx.Amount})
+	}
+	usdc := common.HexToAddress(testUSDC).Hex()
+	assert.ElementsMatch(t, []transfer{
+		{1, sent.Hash.Hex(), models.TransferDirectionOut, "native", "1"},
+		{2, sent.Hash.Hex(), models.TransferDirectionOut, "native", "1"},
+		{3, self.Hash.Hex(), models.TransferDirectionSelf, "native", "3"},
+		{1, received.TxHash.Hex(), models.TransferDirectionIn, usdc, "2500000"},
+		{2, received.TxHash.Hex(), models.TransferDirectionIn, usdc, "2500000"},
+		{1, between.TxHash.Hex(), models.TransferDirectionOut, usdc, "2500000"},
+		{2, between.TxHash.Hex(), models.TransferDirectionOut, usdc, "2500000"},
+		{3, between.TxHash.Hex(), models.TransferDirectionIn, usdc, "2500000"},
+	}, got)
+
+	for _, tx := range transfers {
+		if tx.TxHash == between.TxHash.Hex() {
+			assert.Equal(t, 2, tx.LogIndex)
+			assert.Equal(t, uint64(101), tx.BlockNumber)
+			assert.Equal(t, testBlockHash(101).Hex(), tx.BlockHash)
+			assert.Equal(t, int64(1700000000+101*12), tx.Timestamp.Unix())
+			assert.Equal(t, a.Hex(), tx.FromAddress)
+			assert.Equal(t, b.Hex(), tx.ToAddress)
+		}
+		if tx.TxHash == sent.Hash.Hex() {
+			assert.Equal(t, models.NativeTransferLogIndex, tx.LogIndex)
+		}
+	}
+}
+
+func TestScanTransfers_BlockChanged(t *testing.T) {
+	reorged := testTransferLog(100, 0, testUSDC, testStranger, testWalletA)
+	reorged.BlockHash = common.HexToHash("0xdead")
+
+	web3Service := &MockWeb3Service{
+		chainID:   1,
+		getBlocks: testBlocks(nil),
+		filterLogs: func(query ethereum.FilterQuery) ([]types.Log, error) {
+			return []types.Log{reorged}, nil
+		},
+	}
+
+	service := &transactionService{logger: logger.New(), config: &config.Config{}}
+	wallets := []*models.WatchlistWallet{{ID: 1, UserID: 1, WalletAddress: testWalletA, ChainID: 1}}
+	_, err := service.scanTransfers(context.Background(), web3Service, wallets, 100, 100)
+	assert.ErrorIs(t, err, errBlockChanged)
+}
+
+func TestIngestChain_Cursors(t *testing.T) {
+	addedBlock := uint64(95)
+	added := &models.WatchlistWallet{ID: 1, UserID: 1, WalletAddress: testWalletA, ChainID: 1, AddedBlock: &addedBlock}
+	legacy := &models.WatchlistWallet{ID: 2, UserID: 1, WalletAddress: testWalletB, ChainID: 1}
+	caughtUp := &models.WatchlistWallet{ID: 3, UserID: 2, WalletAddress: testStranger, ChainID: 1}
+	current := &models.WatchlistWallet{ID: 4, UserID: 2, WalletAddress: testWalletA, ChainID: 1}
+
+	var scanned [][2]uint64
+	web3Service := &MockWeb3Service{
+		chainID:        1,
+		headerByNumber: testChainHeaders(110),
+		getBlocks: func(numbers []*big.Int) ([]*Block, error) {
+			scanned = append(scanned, [2]uint64{numbers[0].Uint64(), numbers[len(numbers)-1].Uint64()})
+			return testBlocks(nil)(numbers)
+		},
+	}
+
+	repo := &fakeTransactionRepository{}
+	service := &transactionService{
+		transactionRepo: repo,
+		logger:          logger.New(),
+		config:          &config.Config{Web3: config.Web3Config{Confirmations: 2, TransactionScanBlocks: 5}},
+	}
+	lastBlocks := map[uint]uint64{3: 97, 4: 108}
+
+	err := service.ingestChain(context.Background(), web3Service, []*models.WatchlistWallet{added, legacy, caughtUp, current}, lastBlocks)
+	require.NoError(t, err)
+
+	// Scans start at the added block or after the cursor, capped per cycle and at the confirmed head
+	assert.Equal(t, [][2]uint64{{95, 99}, {98, 102}}, scanned)
+	assert.Equal(t, map[uint]uint64{1: 99, 2: 108, 3: 102, 4: 108}, lastBlocks)
+
+	stored := make(map[uint]uint64)
+	for _, cursor := range repo.cursors {
+		stored[cursor.WalletID] = cursor.LastBlock
+	}
+	assert.Equal(t, map[uint]uint64{1: 99, 2: 108, 3: 102}, stored)
+}
+
+func TestIngestChain_ScanFailureKeepsCursor(t *testing.T) {
+	addedBlock := uint64(100)
+	wallet := &models.WatchlistWallet{ID: 1, UserID: 1, WalletAddress: testWalletA, ChainID: 1, AddedBlock: &addedBlock}
+
+	web3Service := &MockWeb3Service{
+		chainID:        1,
+		headerByNumber: testChainHeaders(110),
+		getBlocks: func(numbers []*big.Int) ([]*Block, error) {
+			return nil, errors.New("rpc unavailable")
+		},
+	}
+
+	repo := &fakeTransactionRepository{}
+	service := &transactionService{transactionRepo: repo, logger: logger.New(), config: &config.Config{}}
+	lastBlocks := map[uint]uint64{}
+
+	err := service.ingestChain(context.Background(), web3Service, []*models.WatchlistWallet{wallet}, lastBlocks)
+	assert.Error(t, err)
+	assert.Empty(t, repo.cursors)
+	assert.Empty(t, lastBlocks)
+}
+
+func TestConfirmedBlock(t *testing.T) {
+	assert.Equal(t, uint64(100), confirmedBlock(100, 0))
+	assert.Equal(t, uint64(88), confirmedBlock(100, 12))
+	assert.Equal(t, uint64(0), confirmedBlock(5, 12))
+	assert.Equal(t, uint64(100), confirmedBlock(100, -1))
+}
@@ -0,0 +1,378 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"sync"
+	"time"
+
+	"cryptoportfolio/internal/config"
+	"cryptoportfolio/internal/models"
+	"cryptoportfolio/internal/repository"
+	"cryptoportfolio/pkg/logger"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethclient"
+)
+
+// transferTopic is the topic of Transfer(address,address,uint256)
+var transferTopic = crypto.Keccak256Hash([]byte("Transfer(address,address,uint256)"))
+
+const (
+	// maxLogRange is the largest block range requested with eth_getLogs; older
+	// blocks are skipped and left to the regular fetch cycle
+	maxLogRange = 1000
+	// subscribeRetryInterval is how long polling is used after a WebSocket subscription fails
+	subscribeRetryInterval = time.Minute
+)
+
+// TransferWatcher re-fetches balances as soon as an ERC-20 Transfer event
+// touches a watched wallet, instead of waiting for the next fetch cycle
+type TransferWatcher interface {
+	Start(ctx context.Context)
+	Stop()
+}
+
+// transferWatcher implements TransferWatcher
+type transferWatcher struct {
+	watchlistRepo  repository.WatchlistRepository
+	web3Registry   Web3Registry
+	balanceFetcher BalanceFetcherService
+	leader         LeaderElector
+	logger         *logger.Logger
+	config         *config.Config
+	stopChan       chan struct{}
+	wg             sync.WaitGroup
+}
+
+// NewTransferWatcher creates a new Transfer event watcher
+func NewTransferWatcher(
+	watchlistRepo repository.WatchlistRepository,
+	web3Registry Web3Registry,
+	balanceFetcher BalanceFetcherService,
+	leader LeaderElector,
+	logger *logger.Logger,
+	config *config.Config,
+) TransferWatcher {
+	return &transferWatcher{
+		watchlistRepo:  watchlistRepo,
+		web3Registry:   web3Registry,
+		balanceFetcher: balanceFetcher,
+		leader:         leader,
+		logger:         logger,
+		config:         config,
+		stopChan:       make(chan struct{}),
+	}
+}
+
+// Start watches every connected chain in its own goroutine
+func (w *transferWatcher) Start(ctx context.Context) {
+	w.logger.Info("Starting Transfer event watcher")
+
+	for _, chain := range w.config.Web3.Chains {
+		web3Service, err := w.web3Registry.Get(chain.ChainID)
+		if err != nil {
+			continue
+		}
+		w.wg.Add(1)
+		go w.watchChain(ctx, chain, web3Service)
+	}
+}
+
+// Stop gracefully stops the watcher
+func (w *transferWatcher) Stop() {
+	w.logger.Info("Stopping Transfer event watcher")
+	close(w.stopChan)
+	w.wg.Wait()
+	w.logger.Info("Transfer event watcher stopped")
+}
+
+// watchChain scans new blocks of a chain for Transfer events. New heads from
+// a WebSocket subscription trigger a scan immediately; eth_getLogs is polled
+// every WEB3_LOG_POLL_INTERVAL seconds regardless, which covers chains
+// without a WebSocket endpoint and subscriptions that dropped.
+func (w *transferWatcher) watchChain(ctx context.Context, chain config.ChainConfig, web3Service Web3Service) {
+	defer w.wg.Done()
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go func() {
+		select {
+		case <-w.stopChan:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	pollInterval := time.Duration(w.config.Web3.LogPollInterval) * time.Second
+	if pollInterval <= 0 {
+		pollInterval = 15 * time.Second
+	}
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+
+	var (
+		wsClient  *ethclient.Client
+		sub       ethereum.Subscription
+		heads     chan *types.Header
+		subErr    <-chan error
+		subscribe <-chan time.Time
+	)
+	if chain.WSEndpoint != "" {
+		subscribe = time.After(0)
+	}
+	closeSubscription := func() {
+		if sub != nil {
+			sub.Unsubscribe()
+		}
+		if wsClient != nil {
+			wsClient.Close()
+		}
+		wsClient, sub, heads, subErr = nil, nil, nil, nil
+	}
+	defer closeSubscription()
+
+	var cursor uint64 // last block scanned; 0 until the first scan sets the starting point
+	for {
+		select {
+		case <-subscribe:
+			subscribe = nil
+			heads = make(chan *types.Header, 16)
+			var err error
+			wsClient, sub, err = subscribeNewHeads(ctx, chain.WSEndpoint, heads)
+			if err != nil {
+				w.logger.Warn("Failed to subscribe to new heads, polling eth_getLogs", "chain_id", chain.ChainID, "error", err)
+				closeSubscription()
+				subscribe = time.After(subscribeRetryInterval)
+				continue
+			}
+			subErr = sub.Err()
+			w.logger.Info("Subscribed to new heads", "chain_id", chain.ChainID)
+		case head := <-heads:
+			w.scan(ctx, chain.ChainID, web3Service, &cursor, head.Number)
+		case err := <-subErr:
+			w.logger.Warn("New head subscription dropped, polling eth_getLogs", "chain_id", chain.ChainID, "error", err)
+			closeSubscription()
+			subscribe = time.After(subscribeRetryInterval)
+		case <-ticker.C:
+			w.scan(ctx, chain.ChainID, web3Service, &cursor, nil)
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// subscribeNewHeads opens a WebSocket connection and subscribes to new block headers
+func subscribeNewHeads(ctx context.Context, endpoint string, heads chan<- *types.Header) (*ethclient.Client, ethereum.Subscription, error) {
+	client, err := ethclient.DialContext(ctx, endpoint)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to connect to %s: %w", redactURL(endpoint), err)
+	}
+	sub, err := client.SubscribeNewHead(ctx, heads)
+	if err != nil {
+		client.Close()
+		return nil, nil, err
+	}
+	return client, sub, nil
+}
+
+// scan looks for Transfer events in the blocks after cursor up to the
+// confirmed head and re-fetches the pairs they touched. The cursor is only
+// advanced once the logs were read, so a failed scan is retried.
+func (w *transferWatcher) scan(ctx context.Context, chainID int64, web3Service Web3Service, cursor *uint64, head *big.Int) {
+	if head == nil {
+		header, err := web3Service.HeaderByNumber(ctx, nil)
+		if err != nil {
+			w.logger.Warn("Failed to get latest block", "chain_id", chainID, "error", err)
+			return
+		}
+		head = header.Number
+	}
+
+	confirmed := confirmedBlock(head.Uint64(), w.config.Web3.Confirmations)
+
+	// Start from the current head; earlier changes are covered by the regular fetch cycle
+	if *cursor == 0 {
+		*cursor = confirmed
+		return
+	}
+	if confirmed <= *cursor {
+		return
+	}
+
+	// Only the leader re-fetches; the others keep their cursor at the head so
+	// they can take over without replaying old blocks
+	fetchCtx, done, ok := leaderContext(ctx, w.leader)
+	if !ok {
+		*cursor = confirmed
+		return
+	}
+	defer done()
+
+	from := *cursor + 1
+	if confirmed-from+1 > maxLogRange {
+		w.logger.Warn("Transfer watcher fell behind, skipping blocks", "chain_id", chainID, "from_block", from, "to_block", confirmed-maxLogRange)
+		from = confirmed - maxLogRange + 1
+	}
+
+	pairs, err := w.transferPairs(ctx, chainID, web3Service, from, confirmed)
+	if err != nil {
+		w.logger.Warn("Failed to scan for Transfer events", "chain_id", chainID, "from_block", from, "to_block", confirmed, "error", err)
+		return
+	}
+	*cursor = confirmed
+
+	if len(pairs) == 0 {
+		return
+	}
+
+	w.logger.Info("Transfer events detected, refreshing balances", "chain_id", chainID, "to_block", confirmed, "pairs", len(pairs))
+	if err := w.balanceFetcher.FetchPairs(fetchCtx, pairs); err != nil {
+		w.logger.Error("Failed to refresh balances", "chain_id", chainID, "error", err)
+	}
+}
+
+// transferPairs reads the Transfer logs of a block range for the chain's
+// tracked tokens and returns the watched wallet/token pairs they touched
+func (w *transferWatcher) transferPairs(ctx context.Context, chainID int64, web3Service Web3Service, from, to uint64) ([]BalancePair, error) {
+	wallets, err := w.watchlistRepo.GetAllWallets(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get wallets: %w", err)
+	}
+	tokens, err := w.watchlistRepo.GetAllTokens(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tokens: %w", err)
+	}
+	wallets, tokens = onChain(chainID, wallets, tokens)
+
+	var logs []types.Log
+	for _, query := range transferFilterQueries(wallets, tokens, from, to) {
+		matched, err := web3Service.FilterLogs(ctx, query)
+		if err != nil {
+			return nil, err
+		}
+		logs = append(logs, matched...)
+	}
+
+	return affectedPairs(logs, wallets, tokens), nil
+}
+
+// onChain keeps the wallets and tokens of a single chain
+func onChain(chainID int64, wallets []*models.WatchlistWallet, tokens []*models.TrackedToken) ([]*models.WatchlistWallet, []*models.TrackedToken) {
+	var chainWallets []*models.WatchlistWallet
+	for _, wallet := range wallets {
+		if wallet.ChainID == chainID {
+			chainWallets = append(chainWallets, wallet)
+		}
+	}
+	var chainTokens []*models.TrackedToken
+	for _, token := range tokens {
+		if token.ChainID == chainID {
+			chainTokens = append(chainTokens, token)
+		}
+	}
+	return chainWallets, chainTokens
+}
+
+// transferFilterQueries builds the eth_getLogs filters for Transfer events of
+// the tracked token contracts sent from or to a watched wallet
+func transferFilterQueries(wallets []*models.WatchlistWallet, tokens []*models.TrackedToken, from, to uint64) []ethereum.FilterQuery {
+	var contracts []common.Address
+	seenContracts := make(map[common.Address]bool)
+	for _, token := range tokens {
+		// Native balances do not emit logs and are left to the regular fetch cycle
+		if token.TokenAddress == nil {
+			continue
+		}
+		address := common.HexToAddress(*token.TokenAddress)
+		if !seenContracts[address] {
+			seenContracts[address] = true
+			contracts = append(contracts, address)
+		}
+	}
+
+	// Without contracts the filter would match every token
+	if len(contracts) == 0 {
+		return nil
+	}
+
+	return transferQueries(contracts, walletTopics(wallets), from, to)
+}
+
+// transferQueries builds the eth_getLogs filters for Transfer events sent
+// from or to any of the given address topics; nil contracts match every
+// token. Topics are ANDed across positions, so senders and recipients need a
+// query each.
+func transferQueries(contracts []common.Address, addressTopics []common.Hash, from, to uint64) []ethereum.FilterQuery {
+	if len(addressTopics) == 0 {
+		return nil
+	}
+
+	fromBlock := new(big.Int).SetUint64(from)
+	toBlock := new(big.Int).SetUint64(to)
+	return []ethereum.FilterQuery{
+		{FromBlock: fromBlock, ToBlock: toBlock, Addresses: contracts, Topics: [][]common.Hash{{transferTopic}, addressTopics}},
+		{FromBlock: fromBlock, ToBlock: toBlock, Addresses: contracts, Topics: [][]common.Hash{{transferTopic}, nil, addressTopics}},
+	}
+}
+
+// walletTopics returns the distinct wallet addresses as indexed event topics
+func walletTopics(wallets []*models.WatchlistWallet) []common.Hash {
+	var topics []common.Hash
+	seen := make(map[common.Hash]bool)
+	for _, wallet := range wallets {
+		topic := common.BytesToHash(common.HexToAddress(wallet.WalletAddress).Bytes())
+		if !seen[topic] {
+			seen[topic] = true
+			topics = append(topics, topic)
+		}
+	}
+	return topics
+}
+
+// affectedPairs maps Transfer logs to the watched wallet/token pairs whose
+// balance they changed. Every user watching the wallet and tracking the token
+// gets a pair; each pair is returned once.
+func affectedPairs(logs []types.Log, wallets []*models.WatchlistWallet, tokens []*models.TrackedToken) []BalancePair {
+	walletsByAddress := make(map[common.Address][]*models.WatchlistWallet)
+	for _, wallet := range wallets {
+		address := common.HexToAddress(wallet.WalletAddress)
+		walletsByAddress[address] = append(walletsByAddress[address], wallet)
+	}
+	tokensByAddress := make(map[common.Address][]*models.TrackedToken)
+	for _, token := range tokens {
+		if token.TokenAddress == nil {
+			continue
+		}
+		address := common.HexToAddress(*token.TokenAddress)
+		tokensByAddress[address] = append(tokensByAddress[address], token)
+	}
+
+	var pairs []BalancePair
+	seen := make(map[[2]uint]bool)
+	for _, log := range logs {
+		// Transfer has indexed from and to; ERC-721 transfers also index the token ID
+		if len(log.Topics) < 3 || log.Topics[0] != transferTopic || log.Removed {
+			continue
+		}
+
+		for _, topic := range log.Topics[1:3] {
+			for _, wallet := range walletsByAddress[common.BytesToAddress(topic.Bytes())] {
+				for _, token := range tokensByAddress[log.Address] {
+					key := [2]uint{wallet.ID, token.ID}
+					if token.UserID != wallet.UserID || token.ChainID != wallet.ChainID || seen[key] {
+						continue
+					}
+					seen[key] = true
+					pairs = append(pairs, BalancePair{Wallet: wallet, Token: token})
+				}
+			}
+		}
+	}
+
+	return pairs
+}
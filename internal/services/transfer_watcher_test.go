@@ -0,0 +1,209 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+
+	"cryptoportfolio/internal/config"
+	"cryptoportfolio/internal/models"
+	"cryptoportfolio/internal/repository"
+	"cryptoportfolio/pkg/logger"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeWatchedRepository serves wallets and tokens from memory
+type fakeWatchedRepository struct {
+	repository.WatchlistRepository
+	wallets []*models.WatchlistWallet
+	tokens  []*models.TrackedToken
+}
+
+func (r *fakeWatchedRepository) GetAllWallets(ctx context.Context) ([]*models.WatchlistWallet, error) {
+	return r.wallets, nil
+}
+
+func (r *fakeWatchedRepository) GetAllTokens(ctx context.Context) ([]*models.TrackedToken, error) {
+	return r.tokens, nil
+}
+
+// fakeBalanceFetcher records the pairs it was asked to fetch
+type fakeBalanceFetcher struct {
+	BalanceFetcherService
+	fetched [][]BalancePair
+}
+
+func (f *fakeBalanceFetcher) FetchPairs(ctx context.Context, pairs []BalancePair) error {
+	f.fetched = append(f.fetched, pairs)
+	return nil
+}
+
+func transferLog(token, from, to string) types.Log {
+	return types.Log{
+		Address: common.HexToAddress(token),
+		Topics: []common.Hash{
+			transferTopic,
+			common.BytesToHash(common.HexToAddress(from).Bytes()),
+			common.BytesToHash(common.HexToAddress(to).Bytes()),
+		},
+	}
+}
+
+const (
+	testUSDC     = "0xA0b86991c6218b36c1d19D4a2e9Eb0cE3606eB48"
+	testDAI      = "0x6B175474E89094C44Da98b954EedeAC495271d0F"
+	testWalletA  = "0x742d35Cc6634C0532925a3b844Bc454e4438f44e"
+	testWalletB  = "0x1111111111111111111111111111111111111111"
+	testStranger = "0x2222222222222222222222222222222222222222"
+)
+
+func TestAffectedPairs(t *testing.T) {
+	usdc, dai := testUSDC, testDAI
+	// Addresses are stored lower-cased by some clients
+	lowerWalletA := "0x742d35cc6634c0532925a3b844bc454e4438f44e"
+	walletA := &models.WatchlistWallet{ID: 1, UserID: 1, WalletAddress: testWalletA, ChainID: 1}
+	walletB := &models.WatchlistWallet{ID: 2, UserID: 1, WalletAddress: testWalletB, ChainID: 1}
+	otherUserWalletA := &models.WatchlistWallet{ID: 3, UserID: 2, WalletAddress: lowerWalletA, ChainID: 1}
+	eth := &models.TrackedToken{ID: 10, UserID: 1, ChainID: 1, TokenSymbol: "ETH"}
+	usdcUser1 := &models.TrackedToken{ID: 11, UserID: 1, ChainID: 1, TokenAddress: &usdc, TokenSymbol: "USDC"}
+	daiUser1 := &models.TrackedToken{ID: 12, UserID: 1, ChainID: 1, TokenAddress: &dai, TokenSymbol: "DAI"}
+	usdcUser2 := &models.TrackedToken{ID: 13, UserID: 2, ChainID: 1, TokenAddress: &usdc, TokenSymbol: "USDC"}
+
+	wallets := []*models.WatchlistWallet{walletA, walletB, otherUserWalletA}
+	tokens := []*models.TrackedToken{eth, usdcUser1, daiUser1, usdcUser2}
+
+	removed := transferLog(testDAI, testWalletB, testStranger)
+	removed.Removed = true
+
+	logs := []types.Log{
+		// Between two watched wallets: both sides change
+		transferLog(testUSDC, testWalletA, testWalletB),
+		// Duplicate touch of the same pair
+		transferLog(testUSDC, testStranger, testWalletA),
+		// Unwatched wallets only
+		transferLog(testDAI, testStranger, testStranger),
+		// Untracked contract
+		transferLog(testStranger, testWalletA, testStranger),
+		// Reorged out
+		removed,
+		// Not a Transfer
+		{Address: common.HexToAddress(testDAI), Topics: []common.Hash{common.HexToHash("0x01"), {}, {}}},
+	}
+
+	pairs := affectedPairs(logs, wallets, tokens)
+
+	var got [][2]uint
+	for _, pair := range pairs {
+		got = append(got, [2]uint{pair.Wallet.ID, pair.Token.ID})
+	}
+	assert.ElementsMatch(t, [][2]uint{
+		{1, 11}, // wallet A, USDC of user 1
+		{3, 13}, // wallet A watched by user 2, USDC of user 2
+		{2, 11}, // wallet B, USDC of user 1
+	}, got)
+}
+
+func TestTransferFilterQueries(t *testing.T) {
+	usdc := testUSDC
+	wallets := []*models.WatchlistWallet{
+		{ID: 1, UserID: 1, WalletAddress: testWalletA, ChainID: 1},
+		{ID: 2, UserID: 2, WalletAddress: testWalletA, ChainID: 1},
+	}
+	tokens := []*models.TrackedToken{
+		{ID: 10, UserID: 1, ChainID: 1, TokenSymbol: "ETH"},
+		{ID: 11, UserID: 1, ChainID: 1, TokenAddress: &usdc},
+		{ID: 12, UserID: 2, ChainID: 1, TokenAddress: &usdc},
+	}
+
+	queries := transferFilterQueries(wallets, tokens, 101, 110)
+	require.Len(t, queries, 2)
+
+	walletTopic := common.BytesToHash(common.HexToAddress(testWalletA).Bytes())
+	for _, query := range queries {
+		assert.Equal(t, big.NewInt(101), query.FromBlock)
+		assert.Equal(t, big.NewInt(110), query.ToBlock)
+		assert.Equal(t, []common.Address{common.HexToAddress(testUSDC)}, query.Addresses)
+	}
+	assert.Equal(t, [][]common.Hash{{transferTopic}, {walletTopic}}, queries[0].Topics)
+	assert.Equal(t, [][]common.Hash{{transferTopic}, nil, {walletTopic}}, queries[1].Topics)
+
+	// Only native tokens: nothing to filter
+	assert.Empty(t, transferFilterQueries(wallets, tokens[:1], 101, 110))
+}
+
+func TestTransferWatcher_Scan(t *testing.T) {
+	usdc := testUSDC
+	repo := &fakeWatchedRepository{
+		wallets: []*models.WatchlistWallet{
+			{ID: 1, UserID: 1, WalletAddress: testWalletA, ChainID: 1},
+			{ID: 2, UserID: 1, WalletAddress: testWalletA, ChainID: 137},
+		},
+		tokens: []*models.TrackedToken{
+			{ID: 11, UserID: 1, ChainID: 1, TokenAddress: &usdc},
+			{ID: 12, UserID: 1, ChainID: 137, TokenAddress: &usdc},
+		},
+	}
+	fetcher := &fakeBalanceFetcher{}
+	watcher := &transferWatcher{
+		watchlistRepo:  repo,
+		balanceFetcher: fetcher,
+		logger:         logger.New(),
+		config:         &config.Config{Web3: config.Web3Config{Confirmations: 2}},
+	}
+
+	var queries []ethereum.FilterQuery
+	failLogs := false
+	web3Service := &MockWeb3Service{
+		chainID: 1,
+		filterLogs: func(query ethereum.FilterQuery) ([]types.Log, error) {
+			if failLogs {
+				return nil, errors.New("rpc unavailable")
+			}
+			queries = append(queries, query)
+			return []types.Log{transferLog(testUSDC, testStranger, testWalletA)}, nil
+		},
+	}
+	ctx := context.Background()
+	var cursor uint64
+
+	// The first scan only sets the starting point
+	watcher.scan(ctx, 1, web3Service, &cursor, big.NewInt(100))
+	assert.Equal(t, uint64(98), cursor)
+	assert.Empty(t, queries)
+
+	// No new confirmed blocks
+	watcher.scan(ctx, 1, web3Service, &cursor, big.NewInt(100))
+	assert.Empty(t, queries)
+
+	// A failed scan keeps the cursor so the range is retried
+	failLogs = true
+	watcher.scan(ctx, 1, web3Service, &cursor, big.NewInt(105))
+	assert.Equal(t, uint64(98), cursor)
+	assert.Empty(t, fetcher.fetched)
+
+	failLogs = false
+	watcher.scan(ctx, 1, web3Service, &cursor, big.NewInt(105))
+	assert.Equal(t, uint64(103), cursor)
+	require.Len(t, queries, 2)
+	assert.Equal(t, big.NewInt(99), queries[0].FromBlock)
+	assert.Equal(t, big.NewInt(103), queries[0].ToBlock)
+
+	// Only the pair on the scanned chain is refreshed, once
+	require.Len(t, fetcher.fetched, 1)
+	require.Len(t, fetcher.fetched[0], 1)
+	assert.Equal(t, uint(1), fetcher.fetched[0][0].Wallet.ID)
+	assert.Equal(t, uint(11), fetcher.fetched[0][0].Token.ID)
+
+	// A watcher that fell far behind only scans the most recent blocks
+	queries = nil
+	watcher.scan(ctx, 1, web3Service, &cursor, big.NewInt(5002))
+	assert.Equal(t, uint64(5000), cursor)
+	require.NotEmpty(t, queries)
+	assert.Equal(t, big.NewInt(5000-maxLogRange+1), queries[0].FromBlock)
+}
@@ -2,6 +2,9 @@ package services
 
 import (
 	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
 	"strings"
 	"time"
@@ -23,6 +26,10 @@ var (
 	ErrInvalidCredentials = errors.New("invalid credentials")
 	ErrInvalidPassword   = errors.New("invalid password")
 	ErrTokenGeneration   = errors.New("failed to generate token")
+	ErrInvalidRefreshToken = errors.New("invalid refresh token")
+	ErrRefreshTokenReused  = errors.New("refresh token reused")
+	ErrWalletLinkedToOtherUser = errors.New("wallet is linked to another user")
+	ErrUserHasOtherWallet      = errors.New("user is linked to another wallet")
 )
 
 // Request/Response types for the service layer
@@ -42,23 +49,37 @@ type UpdateUserRequest struct {
 }
 
 type UserResponse struct {
-	ID        uint      `json:"id"`
-	Email     string    `json:"email"`
-	Name      string    `json:"name"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
+	ID            uint      `json:"id"`
+	Email         string    `json:"email,omitempty"`
+	WalletAddress *string   `json:"wallet_address,omitempty"`
+	Name          string    `json:"name"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
 }
 
 type AuthResponse struct {
-	Message string       `json:"message"`
-	Token   string       `json:"token"`
-	User    UserResponse `json:"user"`
+	Message      string       `json:"message"`
+	Token        string       `json:"token"`
+	RefreshToken string       `json:"refresh_token"`
+	ExpiresIn    int          `json:"expires_in"` // Seconds until the access token expires
+	User         UserResponse `json:"user"`
+}
+
+// RefreshResponse is a new access token and the refresh token that replaces the one presented
+type RefreshResponse struct {
+	Token        string `json:"token"`
+	RefreshToken string `json:"refresh_token"`
+	ExpiresIn    int    `json:"expires_in"` // Seconds until the access token expires
 }
 
 // UserService interface defines the contract for user-related business logic
 type UserService interface {
 	Register(ctx context.Context, req *RegisterRequest) (*AuthResponse, error)
 	Login(ctx context.Context, req *LoginRequest) (*AuthResponse, error)
+	Refresh(ctx context.Context, refreshToken string) (*RefreshResponse, error)
+	Logout(ctx context.Context, userID uint, tokenID string, expiresAt time.Time) error
+	LogoutAll(ctx context.Context, userID uint, tokenID string, expiresAt time.Time) error
+	SignInWithWallet(ctx context.Context, currentUserID uint, address string) (*AuthResponse, error)
 	GetUserByID(ctx context.Context, userID uint) (*UserResponse, error)
 	UpdateUser(ctx context.Context, userID uint, req *UpdateUserRequest) (*UserResponse, error)
 	ListUsers(ctx context.Context, opts *repository.QueryOptions) (*repository.PaginatedResult[UserResponse], error)
@@ -69,19 +90,23 @@ type UserService interface {
 
 // userService implements the UserService interface
 type userService struct {
-	userRepo   repository.UserRepository
-	userCache  cache.UserCacheProvider
-	config     *config.Config
-	logger     *logger.Logger
+	userRepo         repository.UserRepository
+	refreshTokenRepo repository.RefreshTokenRepository
+	userCache        cache.UserCacheProvider
+	denylist         cache.TokenDenylistProvider
+	config           *config.Config
+	logger           *logger.Logger
 }
 
 // NewUserService creates a new instance of UserService
-func NewUserService(userRepo repository.UserRepository, userCache cache.UserCacheProvider, config *config.Config, logger *logger.Logger) UserService {
+func NewUserService(userRepo repository.UserRepository, refreshTokenRepo repository.RefreshTokenRepository, userCache cache.UserCacheProvider, denylist cache.TokenDenylistProvider, config *config.Config, logger *logger.Logger) UserService {
 	return &userService{
-		userRepo:  userRepo,
-		userCache: userCache,
-		config:    config,
-		logger:    logger,
+		userRepo:         userRepo,
+		refreshTokenRepo: refreshTokenRepo,
+		userCache:        userCache,
+		denylist:         denylist,
+		config:           config,
+		logger:           logger,
 	}
 }
 
@@ -93,7 +118,8 @@ func (s *userService) Register(ctx context.Context, req *RegisterRequest) (*Auth
 	}
 
 	// Check if user already exists using repository
-	exists, err := s.userRepo.ExistsByEmail(ctx, strings.ToLower(req.Email))
+	email := strings.ToLower(req.Email)
+	exists, err := s.userRepo.ExistsByEmail(ctx, email)
 	if err != nil {
 		s.logger.Error("Failed to check if user exists", "error", err, "email", req.Email)
 		return nil, err
@@ -111,9 +137,10 @@ func (s *userService) Register(ctx context.Context, req *RegisterRequest) (*Auth
 
 	// Create user using repository
 	user := &models.User{
-		Email:    strings.ToLower(req.Email),
+		Email:    &email,
 		Password: string(hashedPassword),
 		Name:     strings.TrimSpace(req.Name),
+		Tier:     models.UserTierFree,
 	}
 
 	if err := s.userRepo.Create(ctx, user); err != nil {
@@ -124,24 +151,20 @@ func (s *userService) Register(ctx context.Context, req *RegisterRequest) (*Auth
 		return nil, err
 	}
 
-	// Generate JWT token
-	token, err := s.GenerateJWT(user.ID)
+	// Start a session
+	tokens, err := s.issueTokens(ctx, user.ID, "")
 	if err != nil {
 		return nil, err
 	}
 
-	s.logger.Info("User registered successfully", "user_id", user.ID, "email", user.Email)
+	s.logger.Info("User registered successfully", "user_id", user.ID, "email", email)
 
 	return &AuthResponse{
-		Message: "User registered successfully",
-		Token:   token,
-		User: UserResponse{
-			ID:        user.ID,
-			Email:     user.Email,
-			Name:      user.Name,
-			CreatedAt: user.CreatedAt,
-			UpdatedAt: user.UpdatedAt,
-		},
+		Message:      "User registered successfully",
+		Token:        tokens.Token,
+		RefreshToken: tokens.RefreshToken,
+		ExpiresIn:    tokens.ExpiresIn,
+		User:         *newUserResponse(user),
 	}, nil
 }
 
@@ -162,24 +185,244 @@ func (s *userService) Login(ctx context.Context, req *LoginRequest) (*AuthRespon
 		return nil, ErrInvalidCredentials
 	}
 
-	// Generate JWT token
-	token, err := s.GenerateJWT(user.ID)
+	// Drop the user's expired refresh tokens before starting another session
+	if err := s.refreshTokenRepo.DeleteExpired(ctx, user.ID, time.Now()); err != nil {
+		s.logger.Warn("Failed to delete expired refresh tokens", "error", err, "user_id", user.ID)
+	}
+
+	// Start a session
+	tokens, err := s.issueTokens(ctx, user.ID, "")
 	if err != nil {
 		return nil, err
 	}
 
-	s.logger.Info("User logged in successfully", "user_id", user.ID, "email", user.Email)
+	s.logger.Info("User logged in successfully", "user_id", user.ID, "email", req.Email)
 
 	return &AuthResponse{
-		Message: "Login successful",
-		Token:   token,
-		User: UserResponse{
-			ID:        user.ID,
-			Email:     user.Email,
-			Name:      user.Name,
-			CreatedAt: user.CreatedAt,
-			UpdatedAt: user.UpdatedAt,
-		},
+		Message:      "Login successful",
+		Token:        tokens.Token,
+		RefreshToken: tokens.RefreshToken,
+		ExpiresIn:    tokens.ExpiresIn,
+		User:         *newUserResponse(user),
+	}, nil
+}
+
+// SignInWithWallet starts a session for the user linked to a wallet whose
+// ownership the caller has already proven. A wallet not linked yet is linked
+// to the signed-in user, if currentUserID is set, or to a new user.
+func (s *userService) SignInWithWallet(ctx context.Context, currentUserID uint, address string) (*AuthResponse, error) {
+	user, err := s.userRepo.FindByWalletAddress(ctx, address)
+	switch {
+	case err == nil:
+		if currentUserID != 0 && user.ID != currentUserID {
+			return nil, ErrWalletLinkedToOtherUser
+		}
+	case errors.Is(err, repository.ErrRecordNotFound):
+		if user, err = s.linkWallet(ctx, currentUserID, address); err != nil {
+			return nil, err
+		}
+	default:
+		s.logger.Error("Database error finding user by wallet", "error", err, "address", address)
+		return nil, err
+	}
+
+	tokens, err := s.issueTokens(ctx, user.ID, "")
+	if err != nil {
+		return nil, err
+	}
+
+	s.logger.Info("User signed in with Ethereum", "user_id", user.ID, "address", address)
+
+	return &AuthResponse{
+		Message:      "Signed in with Ethereum",
+		Token:        tokens.Token,
+		RefreshToken: tokens.RefreshToken,
+		ExpiresIn:    tokens.ExpiresIn,
+		User:         *newUserResponse(user),
+	}, nil
+}
+
+// linkWallet links a wallet to the signed-in user, or creates a user for it
+func (s *userService) linkWallet(ctx context.Context, currentUserID uint, address string) (*models.User, error) {
+	if currentUserID == 0 {
+		user := &models.User{
+			WalletAddress: &address,
+			Name:          address[:6] + "..." + address[len(address)-4:],
+			Tier:          models.UserTierFree,
+		}
+		if err := s.userRepo.Create(ctx, user); err != nil {
+			if errors.Is(err, repository.ErrDuplicateKey) {
+				return nil, ErrWalletLinkedToOtherUser
+			}
+			s.logger.Error("Failed to create user", "error", err, "address", address)
+			return nil, err
+		}
+		s.logger.Info("User registered with Ethereum", "user_id", user.ID, "address", address)
+		return user, nil
+	}
+
+	user, err := s.userRepo.FindByID(ctx, currentUserID)
+	if err != nil {
+		if errors.Is(err, repository.ErrRecordNotFound) {
+			return nil, ErrUserNotFound
+		}
+		s.logger.Error("Database error getting user", "error", err, "user_id", currentUserID)
+		return nil, err
+	}
+	if user.WalletAddress != nil {
+		return nil, ErrUserHasOtherWallet
+	}
+
+	user.WalletAddress = &address
+	if err := s.userRepo.Update(ctx, user); err != nil {
+		s.logger.Error("Failed to link wallet", "error", err, "user_id", user.ID, "address", address)
+		return nil, err
+	}
+	if err := s.userCache.InvalidateUser(ctx, user.ID, stringValue(user.Email)); err != nil {
+		s.logger.Warn("Failed to invalidate user cache", "error", err, "user_id", user.ID)
+	}
+
+	s.logger.Info("Wallet linked to user", "user_id", user.ID, "address", address)
+	return user, nil
+}
+
+// Refresh exchanges a refresh token for a new access token and refresh
+// token. Each refresh token can be used once: presenting a used token again
+// means it leaked, so the whole session is revoked.
+func (s *userService) Refresh(ctx context.Context, refreshToken string) (*RefreshResponse, error) {
+	record, err := s.refreshTokenRepo.GetByHash(ctx, hashRefreshToken(refreshToken))
+	if err != nil {
+		if errors.Is(err, repository.ErrRecordNotFound) {
+			return nil, ErrInvalidRefreshToken
+		}
+		s.logger.Error("Database error getting refresh token", "error", err)
+		return nil, err
+	}
+
+	now := time.Now()
+	if record.RevokedAt != nil || !now.Before(record.ExpiresAt) {
+		return nil, ErrInvalidRefreshToken
+	}
+
+	rotated, err := s.refreshTokenRepo.MarkUsed(ctx, record.ID, now)
+	if err != nil {
+		s.logger.Error("Failed to mark refresh token used", "error", err, "user_id", record.UserID)
+		return nil, err
+	}
+	if !rotated {
+		s.logger.Warn("Refresh token reused, revoking session", "user_id", record.UserID, "family_id", record.FamilyID)
+		if err := s.revokeFamily(ctx, record.FamilyID); err != nil {
+			return nil, err
+		}
+		return nil, ErrRefreshTokenReused
+	}
+
+	return s.issueTokens(ctx, record.UserID, record.FamilyID)
+}
+
+// Logout ends the session the access token belongs to: its refresh tokens
+// are revoked and the access token itself is denylisted until it expires
+func (s *userService) Logout(ctx context.Context, userID uint, tokenID string, expiresAt time.Time) error {
+	record, err := s.refreshTokenRepo.GetByAccessTokenID(ctx, tokenID)
+	switch {
+	case err == nil && record.UserID == userID:
+		if err := s.revokeFamily(ctx, record.FamilyID); err != nil {
+			return err
+		}
+	case err != nil && !errors.Is(err, repository.ErrRecordNotFound):
+		s.logger.Error("Database error getting refresh token", "error", err, "user_id", userID)
+		return err
+	}
+
+	if err := s.denylist.Revoke(ctx, tokenID, time.Until(expiresAt)); err != nil {
+		s.logger.Error("Failed to revoke access token", "error", err, "user_id", userID)
+		return err
+	}
+
+	s.logger.Info("User logged out", "user_id", userID)
+	return nil
+}
+
+// LogoutAll ends every session of a user, including the current one
+func (s *userService) LogoutAll(ctx context.Context, userID uint, tokenID string, expiresAt time.Time) error {
+	revoked, err := s.refreshTokenRepo.RevokeByUserID(ctx, userID, time.Now())
+	if err != nil {
+		s.logger.Error("Failed to revoke refresh tokens", "error", err, "user_id", userID)
+		return err
+	}
+	if err := s.revokeAccessTokens(ctx, revoked); err != nil {
+		return err
+	}
+
+	if err := s.denylist.Revoke(ctx, tokenID, time.Until(expiresAt)); err != nil {
+		s.logger.Error("Failed to revoke access token", "error", err, "user_id", userID)
+		return err
+	}
+
+	if err := s.refreshTokenRepo.DeleteExpired(ctx, userID, time.Now()); err != nil {
+		s.logger.Warn("Failed to delete expired refresh tokens", "error", err, "user_id", userID)
+	}
+
+	s.logger.Info("User logged out of all sessions", "user_id", userID)
+	return nil
+}
+
+// revokeFamily revokes every refresh token of a session and the access tokens issued with them
+func (s *userService) revokeFamily(ctx context.Context, familyID string) error {
+	revoked, err := s.refreshTokenRepo.RevokeFamily(ctx, familyID, time.Now())
+	if err != nil {
+		s.logger.Error("Failed to revoke refresh tokens", "error", err, "family_id", familyID)
+		return err
+	}
+	return s.revokeAccessTokens(ctx, revoked)
+}
+
+// revokeAccessTokens denylists the access tokens issued with revoked refresh
+// tokens, so their sessions end now rather than when the tokens expire
+func (s *userService) revokeAccessTokens(ctx context.Context, revoked []*models.RefreshToken) error {
+	for _, record := range revoked {
+		if err := s.denylist.Revoke(ctx, record.AccessTokenID, time.Until(record.AccessExpiresAt)); err != nil {
+			s.logger.Error("Failed to revoke access token", "error", err, "user_id", record.UserID)
+			return err
+		}
+	}
+	return nil
+}
+
+// issueTokens signs an access token and stores the refresh token issued with
+// it in the given session. An empty familyID starts a new session.
+func (s *userService) issueTokens(ctx context.Context, userID uint, familyID string) (*RefreshResponse, error) {
+	accessToken, tokenID, accessExpiresAt, err := s.generateAccessToken(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	refreshToken, err := newRefreshToken()
+	if err != nil {
+		s.logger.Error("Failed to generate refresh token", "error", err, "user_id", userID)
+		return nil, ErrTokenGeneration
+	}
+	if familyID == "" {
+		familyID = newTokenID()
+	}
+
+	record := &models.RefreshToken{
+		UserID:          userID,
+		FamilyID:        familyID,
+		TokenHash:       hashRefreshToken(refreshToken),
+		AccessTokenID:   tokenID,
+		AccessExpiresAt: accessExpiresAt,
+		ExpiresAt:       time.Now().Add(time.Duration(s.config.JWT.RefreshTokenTTL) * time.Second),
+	}
+	if err := s.refreshTokenRepo.Create(ctx, record); err != nil {
+		s.logger.Error("Failed to store refresh token", "error", err, "user_id", userID)
+		return nil, err
+	}
+
+	return &RefreshResponse{
+		Token:        accessToken,
+		RefreshToken: refreshToken,
+		ExpiresIn:    s.config.JWT.AccessTokenTTL,
 	}, nil
 }
 
@@ -189,13 +432,7 @@ func (s *userService) GetUserByID(ctx context.Context, userID uint) (*UserRespon
 	cachedUser, err := s.userCache.GetUserByID(ctx, userID)
 	if err == nil {
 		s.logger.Debug("User found in cache", "user_id", userID)
-		return &UserResponse{
-			ID:        cachedUser.ID,
-			Email:     cachedUser.Email,
-			Name:      cachedUser.Name,
-			CreatedAt: cachedUser.CreatedAt,
-			UpdatedAt: cachedUser.UpdatedAt,
-		}, nil
+		return newUserResponse(cachedUser), nil
 	}
 
 	// Cache miss, get from database
@@ -213,13 +450,7 @@ func (s *userService) GetUserByID(ctx context.Context, userID uint) (*UserRespon
 		s.logger.Warn("Failed to cache user", "error", err, "user_id", userID)
 	}
 
-	return &UserResponse{
-		ID:        user.ID,
-		Email:     user.Email,
-		Name:      user.Name,
-		CreatedAt: user.CreatedAt,
-		UpdatedAt: user.UpdatedAt,
-	}, nil
+	return newUserResponse(user), nil
 }
 
 // UpdateUser updates a user's profile
@@ -241,19 +472,13 @@ func (s *userService) UpdateUser(ctx context.Context, userID uint, req *UpdateUs
 	}
 
 	// Invalidate cache
-	if err := s.userCache.InvalidateUser(ctx, user.ID, user.Email); err != nil {
+	if err := s.userCache.InvalidateUser(ctx, user.ID, stringValue(user.Email)); err != nil {
 		s.logger.Warn("Failed to invalidate user cache", "error", err, "user_id", userID)
 	}
 
 	s.logger.Info("User updated successfully", "user_id", user.ID)
 
-	return &UserResponse{
-		ID:        user.ID,
-		Email:     user.Email,
-		Name:      user.Name,
-		CreatedAt: user.CreatedAt,
-		UpdatedAt: user.UpdatedAt,
-	}, nil
+	return newUserResponse(user), nil
 }
 
 // ListUsers retrieves a paginated list of users
@@ -267,13 +492,7 @@ func (s *userService) ListUsers(ctx context.Context, opts *repository.QueryOptio
 	// Convert models to responses
 	userResponses := make([]*UserResponse, len(result.Data))
 	for i, user := range result.Data {
-		userResponses[i] = &UserResponse{
-			ID:        user.ID,
-			Email:     user.Email,
-			Name:      user.Name,
-			CreatedAt: user.CreatedAt,
-			UpdatedAt: user.UpdatedAt,
-		}
+		userResponses[i] = newUserResponse(user)
 	}
 
 	return &repository.PaginatedResult[UserResponse]{
@@ -297,13 +516,7 @@ func (s *userService) SearchUsers(ctx context.Context, query string, opts *repos
 	// Convert models to responses
 	userResponses := make([]*UserResponse, len(result.Data))
 	for i, user := range result.Data {
-		userResponses[i] = &UserResponse{
-			ID:        user.ID,
-			Email:     user.Email,
-			Name:      user.Name,
-			CreatedAt: user.CreatedAt,
-			UpdatedAt: user.UpdatedAt,
-		}
+		userResponses[i] = newUserResponse(user)
 	}
 
 	return &repository.PaginatedResult[UserResponse]{
@@ -328,25 +541,77 @@ func (s *userService) ValidatePassword(password string) error {
 	return nil
 }
 
-// GenerateJWT generates a JWT token for a user
+// GenerateJWT generates an access token for a user
 func (s *userService) GenerateJWT(userID uint) (string, error) {
+	token, _, _, err := s.generateAccessToken(userID)
+	return token, err
+}
+
+// generateAccessToken signs a short-lived access token. The jti claim
+// identifies it on the denylist when it is revoked before it expires.
+func (s *userService) generateAccessToken(userID uint) (string, string, time.Time, error) {
 	if s.config.JWT.Secret == "" {
 		s.logger.Error("JWT secret is empty", "user_id", userID)
-		return "", ErrTokenGeneration
+		return "", "", time.Time{}, ErrTokenGeneration
 	}
-	
+
+	now := time.Now()
+	expiresAt := now.Add(time.Duration(s.config.JWT.AccessTokenTTL) * time.Second)
+	tokenID := newTokenID()
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
 		"user_id": userID,
-		"exp":     time.Now().Add(time.Hour * 24 * 7).Unix(),
-		"iat":     time.Now().Unix(),
+		"jti":     tokenID,
+		"exp":     expiresAt.Unix(),
+		"iat":     now.Unix(),
 	})
-	
+
 	tokenString, err := token.SignedString([]byte(s.config.JWT.Secret))
 	if err != nil {
 		s.logger.Error("Failed to generate JWT token", "error", err, "user_id", userID)
-		return "", ErrTokenGeneration
+		return "", "", time.Time{}, ErrTokenGeneration
 	}
-	
-	return tokenString, nil
+
+	return tokenString, tokenID, expiresAt, nil
+}
+
+// newUserResponse converts a user to its API representation
+func newUserResponse(user *models.User) *UserResponse {
+	return &UserResponse{
+		ID:            user.ID,
+		Email:         stringValue(user.Email),
+		WalletAddress: user.WalletAddress,
+		Name:          user.Name,
+		CreatedAt:     user.CreatedAt,
+		UpdatedAt:     user.UpdatedAt,
+	}
+}
+
+// stringValue returns the string s points to, or "" when s is nil
+func stringValue(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+// newRefreshToken generates a random refresh token
+func newRefreshToken() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+// newTokenID generates a random access token or session ID
+func newTokenID() string {
+	buf := make([]byte, 16)
+	_, _ = rand.Read(buf)
+	return hex.EncodeToString(buf)
+}
+
+// hashRefreshToken is the form a refresh token is stored and looked up in
+func hashRefreshToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
 }
- 
\ No newline at end of file
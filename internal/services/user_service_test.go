@@ -8,9 +8,12 @@ import (
 	"cryptoportfolio/internal/cache"
 	"cryptoportfolio/internal/config"
 	"cryptoportfolio/internal/models"
+	"cryptoportfolio/internal/repository"
 	"cryptoportfolio/pkg/logger"
 
+	"github.com/golang-jwt/jwt/v5"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 // MockUserCache implements cache.UserCacheProvider for testing
@@ -46,7 +49,7 @@ func (m *MockUserCache) GetUserByEmail(ctx context.Context, email string) (*mode
 }
 
 func (m *MockUserCache) SetUserByEmail(ctx context.Context, user *models.User) error {
-	m.emails[user.Email] = user
+	m.emails[*user.Email] = user
 	return nil
 }
 
@@ -62,6 +65,205 @@ func (m *MockUserCache) InvalidateAllUsers(ctx context.Context) error {
 	return nil
 }
 
+// fakeRefreshTokenRepository keeps refresh tokens in memory
+type fakeRefreshTokenRepository struct {
+	repository.RefreshTokenRepository
+	tokens []*models.RefreshToken
+}
+
+func (r *fakeRefreshTokenRepository) Create(ctx context.Context, token *models.RefreshToken) error {
+	token.ID = uint(len(r.tokens) + 1)
+	r.tokens = append(r.tokens, token)
+	return nil
+}
+
+func (r *fakeRefreshTokenRepository) GetByHash(ctx context.Context, tokenHash string) (*models.RefreshToken, error) {
+	for _, token := range r.tokens {
+		if token.TokenHash == tokenHash {
+			copied := *token
+			return &copied, nil
+		}
+	}
+	return nil, repository.ErrRecordNotFound
+}
+
+func (r *fakeRefreshTokenRepository) GetByAccessTokenID(ctx context.Context, accessTokenID string) (*models.RefreshToken, error) {
+	for _, token := range r.tokens {
+		if token.AccessTokenID == accessTokenID {
+			copied := *token
+			return &copied, nil
+		}
+	}
+	return nil, repository.ErrRecordNotFound
+}
+
+func (r *fakeRefreshTokenRepository) MarkUsed(ctx context.Context, tokenID uint, at time.Time) (bool, error) {
+	token := r.tokens[tokenID-1]
+	if token.UsedAt != nil || token.RevokedAt != nil {
+		return false, nil
+	}
+	token.UsedAt = &at
+	return true, nil
+}
+
+func (r *fakeRefreshTokenRepository) RevokeFamily(ctx context.Context, familyID string, at time.Time) ([]*models.RefreshToken, error) {
+	return r.revoke(at, func(token *models.RefreshToken) bool { return token.FamilyID == familyID })
+}
+
+func (r *fakeRefreshTokenRepository) RevokeByUserID(ctx context.Context, userID uint, at time.Time) ([]*models.RefreshToken, error) {
+	return r.revoke(at, func(token *models.RefreshToken) bool { return token.UserID == userID })
+}
+
+func (r *fakeRefreshTokenRepository) revoke(at time.Time, match func(*models.RefreshToken) bool) ([]*models.RefreshToken, error) {
+	var revoked []*models.RefreshToken
+	for _, token := range r.tokens {
+		if match(token) && token.RevokedAt == nil {
+			token.RevokedAt = &at
+			revoked = append(revoked, token)
+		}
+	}
+	return revoked, nil
+}
+
+func (r *fakeRefreshTokenRepository) DeleteExpired(ctx context.Context, userID uint, before time.Time) error {
+	return nil
+}
+
+func newTestSessionService() (*userService, *fakeRefreshTokenRepository, *cache.TokenDenylist) {
+	repo := &fakeRefreshTokenRepository{}
+	denylist := cache.NewTokenDenylist(NewMockCacheProvider())
+	cfg := &config.Config{
+		JWT: config.JWTConfig{
+			Secret:          "test-secret",
+			AccessTokenTTL:  900,
+			RefreshTokenTTL: 3600,
+		},
+	}
+	service := NewUserService(nil, repo, NewMockUserCache(), denylist, cfg, logger.New()).(*userService)
+	return service, repo, denylist
+}
+
+// accessTokenID reads the jti claim of a signed access token
+func accessTokenID(t *testing.T, token string) string {
+	claims := jwt.MapClaims{}
+	_, err := jwt.ParseWithClaims(token, claims, func(*jwt.Token) (interface{}, error) {
+		return []byte("test-secret"), nil
+	})
+	require.NoError(t, err)
+	tokenID, _ := claims["jti"].(string)
+	require.NotEmpty(t, tokenID)
+	return tokenID
+}
+
+func TestUserService_RefreshRotates(t *testing.T) {
+	service, repo, _ := newTestSessionService()
+	ctx := context.Background()
+
+	session, err := service.issueTokens(ctx, 1, "")
+	require.NoError(t, err)
+	assert.Equal(t, 900, session.ExpiresIn)
+	assert.NotEqual(t, session.RefreshToken, repo.tokens[0].TokenHash, "only the hash is stored")
+
+	refreshed, err := service.Refresh(ctx, session.RefreshToken)
+	require.NoError(t, err)
+	assert.NotEqual(t, session.RefreshToken, refreshed.RefreshToken)
+	require.Len(t, repo.tokens, 2)
+	assert.Equal(t, repo.tokens[0].FamilyID, repo.tokens[1].FamilyID, "a refresh continues the session")
+	assert.Equal(t, accessTokenID(t, refreshed.Token), repo.tokens[1].AccessTokenID)
+
+	_, err = service.Refresh(ctx, "unknown")
+	assert.Equal(t, ErrInvalidRefreshToken, err)
+
+	repo.tokens[1].ExpiresAt = time.Now().Add(-time.Second)
+	_, err = service.Refresh(ctx, refreshed.RefreshToken)
+	assert.Equal(t, ErrInvalidRefreshToken, err, "expired refresh tokens are rejected")
+}
+
+func TestUserService_RefreshReuseRevokesSession(t *testing.T) {
+	service, repo, denylist := newTestSessionService()
+	ctx := context.Background()
+
+	session, err := service.issueTokens(ctx, 1, "")
+	require.NoError(t, err)
+	other, err := service.issueTokens(ctx, 1, "")
+	require.NoError(t, err)
+
+	refreshed, err := service.Refresh(ctx, session.RefreshToken)
+	require.NoError(t, err)
+
+	// The stolen token is replayed after the legitimate client rotated it
+	_, err = service.Refresh(ctx, session.RefreshToken)
+	assert.Equal(t, ErrRefreshTokenReused, err)
+
+	_, err = service.Refresh(ctx, refreshed.RefreshToken)
+	assert.Equal(t, ErrInvalidRefreshToken, err, "the rotated token is revoked with its session")
+
+	for _, token := range []string{session.Token, refreshed.Token} {
+		revoked, err := denylist.IsRevoked(ctx, accessTokenID(t, token))
+		require.NoError(t, err)
+		assert.True(t, revoked, "access tokens of the session are denylisted")
+	}
+
+	revoked, err := denylist.IsRevoked(ctx, accessTokenID(t, other.Token))
+	require.NoError(t, err)
+	assert.False(t, revoked, "other sessions are kept")
+	_, err = service.Refresh(ctx, other.RefreshToken)
+	assert.NoError(t, err)
+	assert.Len(t, repo.tokens, 4)
+}
+
+func TestUserService_Logout(t *testing.T) {
+	service, _, denylist := newTestSessionService()
+	ctx := context.Background()
+	expiresAt := time.Now().Add(15 * time.Minute)
+
+	current, err := service.issueTokens(ctx, 1, "")
+	require.NoError(t, err)
+	other, err := service.issueTokens(ctx, 1, "")
+	require.NoError(t, err)
+
+	require.NoError(t, service.Logout(ctx, 1, accessTokenID(t, current.Token), expiresAt))
+
+	revoked, err := denylist.IsRevoked(ctx, accessTokenID(t, current.Token))
+	require.NoError(t, err)
+	assert.True(t, revoked)
+	_, err = service.Refresh(ctx, current.RefreshToken)
+	assert.Equal(t, ErrInvalidRefreshToken, err)
+
+	revoked, err = denylist.IsRevoked(ctx, accessTokenID(t, other.Token))
+	require.NoError(t, err)
+	assert.False(t, revoked, "logout only ends the current session")
+
+	require.NoError(t, service.LogoutAll(ctx, 1, accessTokenID(t, other.Token), expiresAt))
+
+	revoked, err = denylist.IsRevoked(ctx, accessTokenID(t, other.Token))
+	require.NoError(t, err)
+	assert.True(t, revoked)
+	_, err = service.Refresh(ctx, other.RefreshToken)
+	assert.Equal(t, ErrInvalidRefreshToken, err)
+}
+
+func TestUserService_SignInWithWalletLinks(t *testing.T) {
+	service, _, _ := newTestSessionService()
+	email := "user@example.com"
+	userRepo := &fakeUserRepository{users: map[uint]*models.User{1: {ID: 1, Email: &email}}}
+	service.userRepo = userRepo
+	ctx := context.Background()
+	address := "0x52908400098527886E0F7030069857D2E4169EE7"
+
+	response, err := service.SignInWithWallet(ctx, 1, address)
+	require.NoError(t, err)
+	assert.Equal(t, uint(1), response.User.ID, "the wallet is linked to the signed-in user")
+	assert.Equal(t, address, *userRepo.users[1].WalletAddress)
+
+	_, err = service.SignInWithWallet(ctx, 1, "0x8617E340B3D01FA5F11F306F4090FD50E238070D")
+	assert.ErrorIs(t, err, ErrUserHasOtherWallet)
+
+	userRepo.users[2] = &models.User{ID: 2}
+	_, err = service.SignInWithWallet(ctx, 2, address)
+	assert.ErrorIs(t, err, ErrWalletLinkedToOtherUser)
+}
+
 func TestUserService_ValidatePassword(t *testing.T) {
 	// Arrange
 	service := &userService{}
@@ -170,7 +372,7 @@ func TestUserService_NewUserService(t *testing.T) {
 	mockCache := NewMockUserCache()
 
 	// Act
-	service := NewUserService(nil, mockCache, config, logger)
+	service := NewUserService(nil, nil, mockCache, cache.NewTokenDenylist(NewMockCacheProvider()), config, logger)
 
 	// Assert
 	assert.NotNil(t, service)
@@ -0,0 +1,274 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"cryptoportfolio/internal/cache"
+	"cryptoportfolio/internal/config"
+	"cryptoportfolio/internal/models"
+	"cryptoportfolio/internal/repository"
+	"cryptoportfolio/pkg/logger"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/ethereum/go-ethereum/signer/core/apitypes"
+)
+
+// Wallet verification errors
+var (
+	ErrChallengeNotFound    = errors.New("no pending challenge for wallet")
+	ErrInvalidSignatureType = errors.New("invalid signature type")
+)
+
+// Signature types accepted for a wallet ownership challenge
+const (
+	SignatureTypePersonalSign = "personal_sign" // EIP-191 signature of the challenge message
+	SignatureTypeEIP712       = "eip712"        // EIP-712 signature of the challenge typed data
+)
+
+// walletOwnershipTypes is the EIP-712 schema of a wallet ownership challenge
+var walletOwnershipTypes = apitypes.Types{
+	"EIP712Domain": {
+		{Name: "name", Type: "string"},
+		{Name: "version", Type: "string"},
+		{Name: "chainId", Type: "uint256"},
+	},
+	"WalletOwnership": {
+		{Name: "wallet", Type: "address"},
+		{Name: "statement", Type: "string"},
+		{Name: "domain", Type: "string"},
+		{Name: "nonce", Type: "string"},
+		{Name: "issuedAt", Type: "string"},
+	},
+}
+
+// walletOwnershipStatement is the statement shown to the user in both challenge formats
+const walletOwnershipStatement = "I own this wallet and want to verify it in Crypto Portfolio"
+
+type WalletChallengeResponse struct {
+	WalletID  uint               `json:"wallet_id"`
+	Message   string             `json:"message"`                         // sign with personal_sign
+	TypedData apitypes.TypedData `json:"typed_data" swaggertype:"object"` // or sign with eth_signTypedData_v4
+	ExpiresAt time.Time          `json:"expires_at"`
+}
+
+type VerifyWalletRequest struct {
+	Signature     string `json:"signature" binding:"required"`
+	SignatureType string `json:"signature_type"` // personal_sign (default) or eip712
+}
+
+// walletChallenge is the pending challenge of a wallet, kept in the cache
+type walletChallenge struct {
+	UserID   uint      `json:"user_id"`
+	Nonce    string    `json:"nonce"`
+	IssuedAt time.Time `json:"issued_at"`
+}
+
+// WalletVerificationService interface defines the contract for proving ownership of watched wallets
+type WalletVerificationService interface {
+	CreateChallenge(ctx context.Context, userID uint, walletID uint) (*WalletChallengeResponse, error)
+	VerifyWallet(ctx context.Context, userID uint, walletID uint, req *VerifyWalletRequest) (*WalletResponse, error)
+}
+
+// walletVerificationService implements WalletVerificationService
+type walletVerificationService struct {
+	watchlistRepo    repository.WatchlistRepository
+	watchlistService WatchlistService
+	web3Registry     Web3Registry
+	cacheService     cache.CacheProvider
+	logger           *logger.Logger
+	config           *config.Config
+}
+
+// NewWalletVerificationService creates a new wallet verification service
+func NewWalletVerificationService(
+	watchlistRepo repository.WatchlistRepository,
+	watchlistService WatchlistService,
+	web3Registry Web3Registry,
+	cacheService cache.CacheProvider,
+	logger *logger.Logger,
+	config *config.Config,
+) WalletVerificationService {
+	return &walletVerificationService{
+		watchlistRepo:    watchlistRepo,
+		watchlistService: watchlistService,
+		web3Registry:     web3Registry,
+		cacheService:     cacheService,
+		logger:           logger,
+		config:           config,
+	}
+}
+
+// CreateChallenge issues a challenge for the user to sign with a watched
+// wallet, replacing any pending challenge of the wallet
+func (s *walletVerificationService) CreateChallenge(ctx context.Context, userID uint, walletID uint) (*WalletChallengeResponse, error) {
+	wallet, err := s.getUserWallet(ctx, userID, walletID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Messages carry whole seconds, so the stored time must not have more
+	challenge := &walletChallenge{
+		UserID:   userID,
+		Nonce:    newTokenID(),
+		IssuedAt: time.Now().UTC().Truncate(time.Second),
+	}
+	ttl := time.Duration(s.config.WalletVerification.ChallengeTTL) * time.Second
+	if err := s.cacheService.Set(ctx, walletChallengeKey(walletID), challenge, ttl); err != nil {
+		s.logger.Error("Failed to store wallet challenge", "error", err, "wallet_id", walletID)
+		return nil, err
+	}
+
+	return &WalletChallengeResponse{
+		WalletID:  walletID,
+		Message:   s.challengeMessage(wallet.WalletAddress, wallet.ChainID, challenge),
+		TypedData: s.challengeTypedData(wallet.WalletAddress, wallet.ChainID, challenge),
+		ExpiresAt: challenge.IssuedAt.Add(ttl),
+	}, nil
+}
+
+// VerifyWallet checks the signature of the wallet's pending challenge and
+// marks the wallet verified. Signatures of externally owned accounts are
+// recovered with ecrecover; other signatures are checked with the wallet
+// contract's EIP-1271 isValidSignature.
+func (s *walletVerificationService) VerifyWallet(ctx context.Context, userID uint, walletID uint, req *VerifyWalletRequest) (*WalletResponse, error) {
+	wallet, err := s.getUserWallet(ctx, userID, walletID)
+	if err != nil {
+		return nil, err
+	}
+
+	var challenge walletChallenge
+	if err := s.cacheService.Get(ctx, walletChallengeKey(walletID), &challenge); err != nil {
+		if errors.Is(err, cache.ErrCacheMiss) {
+			return nil, ErrChallengeNotFound
+		}
+		s.logger.Error("Failed to get wallet challenge", "error", err, "wallet_id", walletID)
+		return nil, err
+	}
+	if challenge.UserID != userID {
+		return nil, ErrChallengeNotFound
+	}
+
+	hash, err := s.challengeHash(wallet.WalletAddress, wallet.ChainID, &challenge, req.SignatureType)
+	if err != nil {
+		return nil, err
+	}
+
+	signature, err := hexutil.Decode(req.Signature)
+	if err != nil {
+		return nil, ErrInvalidSignature
+	}
+
+	valid, err := s.isValidSignature(ctx, wallet.WalletAddress, wallet.ChainID, hash, signature)
+	if err != nil {
+		return nil, err
+	}
+	if !valid {
+		return nil, ErrInvalidSignature
+	}
+
+	// Challenges are single-use; failed attempts keep them. The challenge is
+	// read and deleted in one step, so of several concurrent submissions only
+	// one consumes it, and one replaced since it was read is not accepted.
+	var consumed walletChallenge
+	if err := s.cacheService.GetDel(ctx, walletChallengeKey(walletID), &consumed); err != nil {
+		if errors.Is(err, cache.ErrCacheMiss) {
+			return nil, ErrChallengeNotFound
+		}
+		s.logger.Error("Failed to consume wallet challenge", "error", err, "wallet_id", walletID)
+		return nil, err
+	}
+	if consumed.Nonce != challenge.Nonce {
+		return nil, ErrChallengeNotFound
+	}
+
+	return s.watchlistService.AddVerifiedWallet(ctx, userID, wallet.WalletAddress, wallet.ChainID)
+}
+
+// isValidSignature reports whether signature of hash was made by address
+func (s *walletVerificationService) isValidSignature(ctx context.Context, address string, chainID int64, hash common.Hash, signature []byte) (bool, error) {
+	if signer, err := recoverSigner(hash.Bytes(), signature); err == nil && strings.EqualFold(signer.Hex(), address) {
+		return true, nil
+	}
+
+	// Smart contract wallets have no key to recover; the contract decides
+	web3Service, err := s.web3Registry.Get(chainID)
+	if err != nil {
+		return false, err
+	}
+	valid, err := web3Service.IsValidSignature(ctx, address, hash, signature)
+	if err != nil {
+		s.logger.Error("Failed to check EIP-1271 signature", "error", err, "chain_id", chainID, "address", address)
+		return false, err
+	}
+	return valid, nil
+}
+
+// getUserWallet returns a watched wallet of the user
+func (s *walletVerificationService) getUserWallet(ctx context.Context, userID uint, walletID uint) (*models.WatchlistWallet, error) {
+	wallet, err := s.watchlistRepo.GetWalletByID(ctx, walletID)
+	if err != nil {
+		if errors.Is(err, repository.ErrRecordNotFound) {
+			return nil, ErrWalletNotFound
+		}
+		s.logger.Error("Failed to get wallet", "error", err, "wallet_id", walletID)
+		return nil, err
+	}
+	if wallet.UserID != userID {
+		return nil, ErrWalletNotFound
+	}
+	return wallet, nil
+}
+
+// challengeHash returns the hash the wallet signs for the given signature type
+func (s *walletVerificationService) challengeHash(address string, chainID int64, challenge *walletChallenge, signatureType string) (common.Hash, error) {
+	switch signatureType {
+	case "", SignatureTypePersonalSign:
+		return common.BytesToHash(accounts.TextHash([]byte(s.challengeMessage(address, chainID, challenge)))), nil
+	case SignatureTypeEIP712:
+		hash, _, err := apitypes.TypedDataAndHash(s.challengeTypedData(address, chainID, challenge))
+		if err != nil {
+			return common.Hash{}, err
+		}
+		return common.BytesToHash(hash), nil
+	default:
+		return common.Hash{}, ErrInvalidSignatureType
+	}
+}
+
+// challengeMessage builds the personal_sign message of a challenge
+func (s *walletVerificationService) challengeMessage(address string, chainID int64, challenge *walletChallenge) string {
+	return fmt.Sprintf("%s\n\nWallet: %s\nDomain: %s\nChain ID: %d\nNonce: %s\nIssued At: %s",
+		walletOwnershipStatement, common.HexToAddress(address).Hex(), s.config.SIWE.Domain, chainID, challenge.Nonce,
+		challenge.IssuedAt.Format(time.RFC3339))
+}
+
+// challengeTypedData builds the EIP-712 typed data of a challenge
+func (s *walletVerificationService) challengeTypedData(address string, chainID int64, challenge *walletChallenge) apitypes.TypedData {
+	return apitypes.TypedData{
+		Types:       walletOwnershipTypes,
+		PrimaryType: "WalletOwnership",
+		Domain: apitypes.TypedDataDomain{
+			Name:    "Crypto Portfolio",
+			Version: "1",
+			ChainId: math.NewHexOrDecimal256(chainID),
+		},
+		Message: apitypes.TypedDataMessage{
+			"wallet":    common.HexToAddress(address).Hex(),
+			"statement": walletOwnershipStatement,
+			"domain":    s.config.SIWE.Domain,
+			"nonce":     challenge.Nonce,
+			"issuedAt":  challenge.IssuedAt.Format(time.RFC3339),
+		},
+	}
+}
+
+func walletChallengeKey(walletID uint) string {
+	return fmt.Sprintf("wallet_challenge:%d", walletID)
+}
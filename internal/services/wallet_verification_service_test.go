@@ -0,0 +1,223 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"fmt"
+	"sync"
+	"testing"
+
+	"cryptoportfolio/internal/config"
+	"cryptoportfolio/internal/models"
+	"cryptoportfolio/internal/repository"
+	"cryptoportfolio/pkg/logger"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/signer/core/apitypes"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeWalletLookup serves watched wallets by ID
+type fakeWalletLookup struct {
+	repository.WatchlistRepository
+	wallets map[uint]*models.WatchlistWallet
+}
+
+func (r *fakeWalletLookup) GetWalletByID(ctx context.Context, walletID uint) (*models.WatchlistWallet, error) {
+	wallet, ok := r.wallets[walletID]
+	if !ok {
+		return nil, repository.ErrRecordNotFound
+	}
+	return wallet, nil
+}
+
+func newTestWalletVerificationService(web3Service *MockWeb3Service, wallets ...*models.WatchlistWallet) (WalletVerificationService, *fakeVerifiedWatchlist) {
+	repo := &fakeWalletLookup{wallets: make(map[uint]*models.WatchlistWallet)}
+	for _, wallet := range wallets {
+		repo.wallets[wallet.ID] = wallet
+	}
+
+	registry := &web3Registry{services: make(map[int64]Web3Service), defaultChainID: 1}
+	registry.Register(web3Service)
+
+	cfg := &config.Config{
+		SIWE:               config.SIWEConfig{Domain: "app.example.com"},
+		WalletVerification: config.WalletVerificationConfig{ChallengeTTL: 300},
+	}
+	watchlist := &fakeVerifiedWatchlist{}
+	return NewWalletVerificationService(repo, watchlist, registry, NewMockCacheProvider(), logger.New(), cfg), watchlist
+}
+
+// signHash signs hash with key the way wallets do, with a 27/28 recovery ID
+func signHash(t *testing.T, key *ecdsa.PrivateKey, hash []byte) string {
+	sig, err := crypto.Sign(hash, key)
+	require.NoError(t, err)
+	sig[crypto.RecoveryIDOffset] += 27
+	return hexutil.Encode(sig)
+}
+
+func TestWalletVerificationService_PersonalSign(t *testing.T) {
+	key, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	address := crypto.PubkeyToAddress(key.PublicKey).Hex()
+	wallet := &models.WatchlistWallet{ID: 7, UserID: 1, WalletAddress: address, ChainID: 1}
+	service, watchlist := newTestWalletVerificationService(&MockWeb3Service{chainID: 1}, wallet)
+	ctx := context.Background()
+
+	challenge, err := service.CreateChallenge(ctx, 1, 7)
+	require.NoError(t, err)
+	assert.Contains(t, challenge.Message, address)
+	assert.Contains(t, challenge.Message, "Domain: app.example.com")
+
+	signature := signHash(t, key, accounts.TextHash([]byte(challenge.Message)))
+	response, err := service.VerifyWallet(ctx, 1, 7, &VerifyWalletRequest{Signature: signature})
+	require.NoError(t, err)
+	assert.True(t, response.Verified)
+	assert.Equal(t, []string{fmt.Sprintf("1:%s:1", address)}, watchlist.added)
+
+	_, err = service.VerifyWallet(ctx, 1, 7, &VerifyWalletRequest{Signature: signature})
+	assert.ErrorIs(t, err, ErrChallengeNotFound, "challenges are single-use")
+}
+
+func TestWalletVerificationService_EIP712(t *testing.T) {
+	key, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	address := crypto.PubkeyToAddress(key.PublicKey).Hex()
+	wallet := &models.WatchlistWallet{ID: 7, UserID: 1, WalletAddress: address, ChainID: 1}
+	service, watchlist := newTestWalletVerificationService(&MockWeb3Service{chainID: 1}, wallet)
+	ctx := context.Background()
+
+	challenge, err := service.CreateChallenge(ctx, 1, 7)
+	require.NoError(t, err)
+	hash, _, err := apitypes.TypedDataAndHash(challenge.TypedData)
+	require.NoError(t, err)
+
+	signature := signHash(t, key, hash)
+	_, err = service.VerifyWallet(ctx, 1, 7, &VerifyWalletRequest{Signature: signature, SignatureType: SignatureTypePersonalSign})
+	assert.ErrorIs(t, err, ErrInvalidSignature, "the signature type selects what was signed")
+
+	_, err = service.VerifyWallet(ctx, 1, 7, &VerifyWalletRequest{Signature: signature, SignatureType: SignatureTypeEIP712})
+	require.NoError(t, err)
+	assert.Len(t, watchlist.added, 1)
+}
+
+func TestWalletVerificationService_EIP1271(t *testing.T) {
+	contract := "0x52908400098527886E0F7030069857D2E4169EE7"
+	contractSignature := []byte{0xde, 0xad, 0xbe, 0xef}
+	var checked common.Hash
+	web3Service := &MockWeb3Service{
+		chainID: 1,
+		isValidSignature: func(contractAddress string, hash common.Hash, signature []byte) bool {
+			checked = hash
+			return contractAddress == contract && bytes.Equal(signature, contractSignature)
+		},
+	}
+	wallet := &models.WatchlistWallet{ID: 7, UserID: 1, WalletAddress: contract, ChainID: 1}
+	service, watchlist := newTestWalletVerificationService(web3Service, wallet)
+	ctx := context.Background()
+
+	challenge, err := service.CreateChallenge(ctx, 1, 7)
+	require.NoError(t, err)
+
+	_, err = service.VerifyWallet(ctx, 1, 7, &VerifyWalletRequest{Signature: "0x01"})
+	assert.ErrorIs(t, err, ErrInvalidSignature)
+
+	_, err = service.VerifyWallet(ctx, 1, 7, &VerifyWalletRequest{Signature: hexutil.Encode(contractSignature)})
+	require.NoError(t, err)
+	assert.Equal(t, common.BytesToHash(accounts.TextHash([]byte(challenge.Message))), checked, "the contract checks the message hash")
+	assert.Len(t, watchlist.added, 1)
+}
+
+func TestWalletVerificationService_Rejects(t *testing.T) {
+	key, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	other, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	wallet := &models.WatchlistWallet{ID: 7, UserID: 1, WalletAddress: crypto.PubkeyToAddress(key.PublicKey).Hex(), ChainID: 1}
+	service, watchlist := newTestWalletVerificationService(&MockWeb3Service{chainID: 1}, wallet)
+	ctx := context.Background()
+
+	_, err = service.CreateChallenge(ctx, 2, 7)
+	assert.ErrorIs(t, err, ErrWalletNotFound, "other users' wallets cannot be challenged")
+	_, err = service.CreateChallenge(ctx, 1, 8)
+	assert.ErrorIs(t, err, ErrWalletNotFound)
+
+	_, err = service.VerifyWallet(ctx, 1, 7, &VerifyWalletRequest{Signature: "0x00"})
+	assert.ErrorIs(t, err, ErrChallengeNotFound)
+
+	challenge, err := service.CreateChallenge(ctx, 1, 7)
+	require.NoError(t, err)
+
+	_, err = service.VerifyWallet(ctx, 1, 7, &VerifyWalletRequest{Signature: "0x00", SignatureType: "eth_sign"})
+	assert.ErrorIs(t, err, ErrInvalidSignatureType)
+
+	_, err = service.VerifyWallet(ctx, 1, 7, &VerifyWalletRequest{Signature: "not hex"})
+	assert.ErrorIs(t, err, ErrInvalidSignature)
+
+	otherSignature := signHash(t, other, accounts.TextHash([]byte(challenge.Message)))
+	_, err = service.VerifyWallet(ctx, 1, 7, &VerifyWalletRequest{Signature: otherSignature})
+	assert.ErrorIs(t, err, ErrInvalidSignature)
+	assert.Empty(t, watchlist.added)
+
+	signature := signHash(t, key, accounts.TextHash([]byte(challenge.Message)))
+	_, err = service.VerifyWallet(ctx, 1, 7, &VerifyWalletRequest{Signature: signature})
+	assert.NoError(t, err, "rejected attempts do not use up the challenge")
+}
+
+func TestWalletVerificationService_ConcurrentReplay(t *testing.T) {
+	key, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	wallet := &models.WatchlistWallet{ID: 7, UserID: 1, WalletAddress: crypto.PubkeyToAddress(key.PublicKey).Hex(), ChainID: 1}
+	service, _ := newTestWalletVerificationService(&MockWeb3Service{chainID: 1}, wallet)
+	ctx := context.Background()
+
+	challenge, err := service.CreateChallenge(ctx, 1, 7)
+	require.NoError(t, err)
+	signature := signHash(t, key, accounts.TextHash([]byte(challenge.Message)))
+
+	const attempts = 8
+	errs := make(chan error, attempts)
+	var wg sync.WaitGroup
+	for i := 0; i < attempts; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := service.VerifyWallet(ctx, 1, 7, &VerifyWalletRequest{Signature: signature})
+			errs <- err
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	succeeded := 0
+	for err := range errs {
+		if err == nil {
+			succeeded++
+			continue
+		}
+		assert.ErrorIs(t, err, ErrChallengeNotFound)
+	}
+	assert.Equal(t, 1, succeeded, "a signed challenge verifies the wallet only once")
+}
+
+func TestWalletVerificationService_ReplacedChallenge(t *testing.T) {
+	key, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	wallet := &models.WatchlistWallet{ID: 7, UserID: 1, WalletAddress: crypto.PubkeyToAddress(key.PublicKey).Hex(), ChainID: 1}
+	service, _ := newTestWalletVerificationService(&MockWeb3Service{chainID: 1}, wallet)
+	ctx := context.Background()
+
+	old, err := service.CreateChallenge(ctx, 1, 7)
+	require.NoError(t, err)
+	_, err = service.CreateChallenge(ctx, 1, 7)
+	require.NoError(t, err)
+
+	signature := signHash(t, key, accounts.TextHash([]byte(old.Message)))
+	_, err = service.VerifyWallet(ctx, 1, 7, &VerifyWalletRequest{Signature: signature})
+	assert.ErrorIs(t, err, ErrInvalidSignature, "a replaced challenge cannot be signed any more")
+}
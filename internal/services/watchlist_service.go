@@ -4,12 +4,18 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"math/big"
+	"strings"
 	"time"
 
 	"cryptoportfolio/internal/cache"
 	"cryptoportfolio/internal/models"
 	"cryptoportfolio/internal/repository"
+	"cryptoportfolio/internal/tracing"
 	"cryptoportfolio/pkg/logger"
+	"cryptoportfolio/pkg/units"
+
+	"go.opentelemetry.io/otel/attribute"
 )
 
 // Common errors
@@ -19,33 +25,41 @@ var (
 	ErrInvalidAddress     = errors.New("invalid wallet address")
 	ErrWalletAlreadyExists = errors.New("wallet already exists in watchlist")
 	ErrTokenAlreadyExists  = errors.New("token already exists in watchlist")
+	ErrTokenInfoRequired   = errors.New("token symbol and name are required")
 )
 
 // Request/Response types
 type AddWalletRequest struct {
 	WalletAddress string `json:"wallet_address" binding:"required"`
+	ChainID       int64  `json:"chain_id"` // defaults to the configured default chain
 	Label         string `json:"label"`
 }
 
 type AddTokenRequest struct {
-	TokenAddress *string `json:"token_address"` // nil for ETH
-	TokenSymbol  string  `json:"token_symbol" binding:"required"`
-	TokenName    string  `json:"token_name" binding:"required"`
+	TokenAddress *string `json:"token_address"` // nil for the chain's native token
+	ChainID      int64   `json:"chain_id"`      // defaults to the configured default chain
+	TokenSymbol  string  `json:"token_symbol"`  // read from the contract for ERC-20 tokens; required for the native token
+	TokenName    string  `json:"token_name"`    // read from the contract for ERC-20 tokens; required for the native token
 }
 
 type WalletResponse struct {
-	ID            uint      `json:"id"`
-	WalletAddress string    `json:"wallet_address"`
-	Label         string    `json:"label"`
-	CreatedAt     time.Time `json:"created_at"`
-	UpdatedAt     time.Time `json:"updated_at"`
+	ID            uint       `json:"id"`
+	WalletAddress string     `json:"wallet_address"`
+	ChainID       int64      `json:"chain_id"`
+	Label         string     `json:"label"`
+	Verified      bool       `json:"verified"` // the user proved they own the wallet
+	VerifiedAt    *time.Time `json:"verified_at,omitempty"`
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
 }
 
 type TokenResponse struct {
 	ID           uint      `json:"id"`
 	TokenAddress *string   `json:"token_address"`
+	ChainID      int64     `json:"chain_id"`
 	TokenSymbol  string    `json:"token_symbol"`
 	TokenName    string    `json:"token_name"`
+	Decimals     *uint8    `json:"decimals,omitempty"`
 	CreatedAt    time.Time `json:"created_at"`
 	UpdatedAt    time.Time `json:"updated_at"`
 }
@@ -53,21 +67,31 @@ type TokenResponse struct {
 type BalanceResponse struct {
 	WalletID     uint      `json:"wallet_id"`
 	WalletAddress string   `json:"wallet_address"`
+	ChainID      int64     `json:"chain_id"`
 	TokenID      uint      `json:"token_id"`
 	TokenSymbol  string    `json:"token_symbol"`
-	Balance      string    `json:"balance"`
+	Balance      string    `json:"balance"`                    // Raw integer amount in the token's smallest unit
+	FormattedBalance string `json:"formatted_balance,omitempty"` // Balance adjusted for the token's decimals
 	BalanceUSD   *string   `json:"balance_usd,omitempty"`
+	PriceUSD     *string   `json:"price_usd,omitempty"`
+	BlockNumber  *uint64   `json:"block_number,omitempty"`
 	FetchedAt    time.Time `json:"fetched_at"`
+	FetchStatus  *FetchStatus `json:"fetch_status,omitempty"` // Shows whether fetches since FetchedAt have been failing
 }
 
 type BalanceHistoryResponse struct {
 	ID           uint      `json:"id"`
 	WalletID     uint      `json:"wallet_id"`
 	WalletAddress string   `json:"wallet_address"`
+	ChainID      int64     `json:"chain_id"`
 	TokenID      uint      `json:"token_id"`
 	TokenSymbol  string    `json:"token_symbol"`
-	Balance      string    `json:"balance"`
+	Balance      string    `json:"balance"`                    // Raw integer amount in the token's smallest unit
+	FormattedBalance string `json:"formatted_balance,omitempty"` // Balance adjusted for the token's decimals
 	BalanceUSD   *string   `json:"balance_usd,omitempty"`
+	PriceUSD     *string   `json:"price_usd,omitempty"`
+	BlockNumber  *uint64   `json:"block_number,omitempty"`
+	BlockHash    *string   `json:"block_hash,omitempty"`
 	FetchedAt    time.Time `json:"fetched_at"`
 	CreatedAt    time.Time `json:"created_at"`
 }
@@ -76,6 +100,7 @@ type BalanceHistoryResponse struct {
 type WatchlistService interface {
 	// Wallet operations
 	AddWallet(ctx context.Context, userID uint, req *AddWalletRequest) (*WalletResponse, error)
+	AddVerifiedWallet(ctx context.Context, userID uint, address string, chainID int64) (*WalletResponse, error)
 	GetWallets(ctx context.Context, userID uint) ([]*WalletResponse, error)
 	DeleteWallet(ctx context.Context, userID uint, walletID uint) error
 	
@@ -87,14 +112,16 @@ type WatchlistService interface {
 	// Balance operations
 	GetBalances(ctx context.Context, userID uint) ([]*BalanceResponse, error)
 	GetBalanceHistory(ctx context.Context, userID uint, walletID uint, tokenID uint, limit int) ([]*BalanceHistoryResponse, error)
-	RefreshBalances(ctx context.Context, userID uint) error
+	RefreshBalances(ctx context.Context, userID uint) (*JobResponse, error)
 }
 
 // watchlistService implements WatchlistService
 type watchlistService struct {
 	watchlistRepo     repository.WatchlistRepository
-	web3Service       Web3Service
-	balanceFetcher    BalanceFetcherService
+	web3Registry      Web3Registry
+	jobService        JobService
+	backfillService   BackfillService
+	scheduler         FetchScheduler
 	cacheService      cache.CacheProvider
 	logger            *logger.Logger
 }
@@ -102,15 +129,19 @@ type watchlistService struct {
 // NewWatchlistService creates a new watchlist service
 func NewWatchlistService(
 	watchlistRepo repository.WatchlistRepository,
-	web3Service Web3Service,
-	balanceFetcher BalanceFetcherService,
+	web3Registry Web3Registry,
+	jobService JobService,
+	backfillService BackfillService,
+	scheduler FetchScheduler,
 	cacheService cache.CacheProvider,
 	logger *logger.Logger,
 ) WatchlistService {
 	return &watchlistService{
-		watchlistRepo:  watchlistRepo,
-		web3Service:    web3Service,
-		balanceFetcher: balanceFetcher,
+		watchlistRepo:   watchlistRepo,
+		web3Registry:    web3Registry,
+		jobService:      jobService,
+		backfillService: backfillService,
+		scheduler:       scheduler,
 		cacheService:   cacheService,
 		logger:         logger,
 	}
@@ -118,11 +149,19 @@ func NewWatchlistService(
 
 // AddWallet adds a wallet to user's watchlist
 func (s *watchlistService) AddWallet(ctx context.Context, userID uint, req *AddWalletRequest) (*WalletResponse, error) {
+	ctx, span := tracing.Start(ctx, "WatchlistService.AddWallet", attribute.Int64("user.id", int64(userID)))
+	defer span.End()
+	
 	// Validate wallet address
-	if !s.web3Service.ValidateAddress(req.WalletAddress) {
+	if !s.web3Registry.ValidateAddress(req.WalletAddress) {
 		return nil, ErrInvalidAddress
 	}
 	
+	chainID, err := s.resolveChainID(req.ChainID)
+	if err != nil {
+		return nil, err
+	}
+	
 	// Check if wallet already exists for this user
 	wallets, err := s.watchlistRepo.GetWalletsByUserID(ctx, userID)
 	if err != nil {
@@ -131,39 +170,95 @@ func (s *watchlistService) AddWallet(ctx context.Context, userID uint, req *AddW
 	}
 	
 	for _, wallet := range wallets {
-		if wallet.WalletAddress == req.WalletAddress {
+		if wallet.ChainID == chainID && strings.EqualFold(wallet.WalletAddress, req.WalletAddress) {
 			return nil, ErrWalletAlreadyExists
 		}
 	}
 	
-	// Create wallet
+	return s.createWallet(ctx, userID, req.WalletAddress, chainID, req.Label, nil)
+}
+
+// AddVerifiedWallet adds a wallet the user proved they own to the watchlist,
+// or marks it verified when it is already watched
+func (s *watchlistService) AddVerifiedWallet(ctx context.Context, userID uint, address string, chainID int64) (*WalletResponse, error) {
+	ctx, span := tracing.Start(ctx, "WatchlistService.AddVerifiedWallet", attribute.Int64("user.id", int64(userID)))
+	defer span.End()
+	
+	chainID, err := s.resolveChainID(chainID)
+	if err != nil {
+		return nil, err
+	}
+	
+	wallets, err := s.watchlistRepo.GetWalletsByUserID(ctx, userID)
+	if err != nil {
+		s.logger.Error("Failed to get user wallets", "error", err, "user_id", userID)
+		return nil, err
+	}
+	
+	now := time.Now()
+	for _, wallet := range wallets {
+		if wallet.ChainID != chainID || !strings.EqualFold(wallet.WalletAddress, address) {
+			continue
+		}
+		if wallet.VerifiedAt == nil {
+			if err := s.watchlistRepo.MarkWalletVerified(ctx, wallet.ID, now); err != nil {
+				s.logger.Error("Failed to mark wallet verified", "error", err, "wallet_id", wallet.ID)
+				return nil, err
+			}
+			wallet.VerifiedAt = &now
+			s.invalidateUserCache(ctx, userID)
+			s.logger.Info("Wallet verified", "user_id", userID, "wallet_id", wallet.ID)
+		}
+		return newWalletResponse(wallet), nil
+	}
+	
+	return s.createWallet(ctx, userID, address, chainID, "", &now)
+}
+
+// createWallet adds a wallet to the watchlist and schedules its backfill.
+// verifiedAt is set for wallets the user proved they own.
+func (s *watchlistService) createWallet(ctx context.Context, userID uint, address string, chainID int64, label string, verifiedAt *time.Time) (*WalletResponse, error) {
 	wallet := &models.WatchlistWallet{
 		UserID:        userID,
-		WalletAddress: req.WalletAddress,
-		Label:         req.Label,
+		WalletAddress: address,
+		ChainID:       chainID,
+		Label:         label,
+		VerifiedAt:    verifiedAt,
+	}
+	
+	// Transfers are ingested from the current head; without it ingestion starts when it first sees the wallet
+	if web3Service, err := s.web3Registry.Get(chainID); err == nil {
+		if header, err := web3Service.HeaderByNumber(ctx, nil); err == nil {
+			addedBlock := header.Number.Uint64()
+			wallet.AddedBlock = &addedBlock
+		} else {
+			s.logger.Warn("Failed to get latest block", "error", err, "chain_id", chainID)
+		}
 	}
 	
 	if err := s.watchlistRepo.CreateWallet(ctx, wallet); err != nil {
-		s.logger.Error("Failed to create wallet", "error", err, "user_id", userID, "address", req.WalletAddress)
+		s.logger.Error("Failed to create wallet", "error", err, "user_id", userID, "address", address)
 		return nil, err
 	}
 	
 	// Invalidate cache
 	s.invalidateUserCache(ctx, userID)
 	
-	s.logger.Info("Wallet added to watchlist", "user_id", userID, "wallet_id", wallet.ID, "address", req.WalletAddress)
+	s.logger.Info("Wallet added to watchlist", "user_id", userID, "wallet_id", wallet.ID, "address", address, "chain_id", chainID)
 	
-	return &WalletResponse{
-		ID:            wallet.ID,
-		WalletAddress: wallet.WalletAddress,
-		Label:         wallet.Label,
-		CreatedAt:     wallet.CreatedAt,
-		UpdatedAt:     wallet.UpdatedAt,
-	}, nil
+	// Fill in the new pairs' history in the background; failing to schedule it does not fail the request
+	if err := s.backfillService.EnqueueWallet(ctx, wallet); err != nil {
+		s.logger.Warn("Failed to schedule balance backfill", "error", err, "wallet_id", wallet.ID)
+	}
+	
+	return newWalletResponse(wallet), nil
 }
 
 // GetWallets retrieves user's watchlist wallets
 func (s *watchlistService) GetWallets(ctx context.Context, userID uint) ([]*WalletResponse, error) {
+	ctx, span := tracing.Start(ctx, "WatchlistService.GetWallets", attribute.Int64("user.id", int64(userID)))
+	defer span.End()
+	
 	wallets, err := s.watchlistRepo.GetWalletsByUserID(ctx, userID)
 	if err != nil {
 		s.logger.Error("Failed to get user wallets", "error", err, "user_id", userID)
@@ -172,13 +267,7 @@ func (s *watchlistService) GetWallets(ctx context.Context, userID uint) ([]*Wall
 	
 	responses := make([]*WalletResponse, len(wallets))
 	for i, wallet := range wallets {
-		responses[i] = &WalletResponse{
-			ID:            wallet.ID,
-			WalletAddress: wallet.WalletAddress,
-			Label:         wallet.Label,
-			CreatedAt:     wallet.CreatedAt,
-			UpdatedAt:     wallet.UpdatedAt,
-		}
+		responses[i] = newWalletResponse(wallet)
 	}
 	
 	return responses, nil
@@ -186,6 +275,9 @@ func (s *watchlistService) GetWallets(ctx context.Context, userID uint) ([]*Wall
 
 // DeleteWallet removes a wallet from user's watchlist
 func (s *watchlistService) DeleteWallet(ctx context.Context, userID uint, walletID uint) error {
+	ctx, span := tracing.Start(ctx, "WatchlistService.DeleteWallet", attribute.Int64("user.id", int64(userID)))
+	defer span.End()
+	
 	if err := s.watchlistRepo.DeleteWallet(ctx, walletID, userID); err != nil {
 		s.logger.Error("Failed to delete wallet", "error", err, "user_id", userID, "wallet_id", walletID)
 		return err
@@ -198,56 +290,125 @@ func (s *watchlistService) DeleteWallet(ctx context.Context, userID uint, wallet
 	return nil
 }
 
-// AddToken adds a token to user's tracked tokens
+// AddToken adds a token to user's tracked tokens. ERC-20 metadata is read
+// from the contract; symbol and name fall back to the request when the
+// contract does not provide them.
 func (s *watchlistService) AddToken(ctx context.Context, userID uint, req *AddTokenRequest) (*TokenResponse, error) {
+	ctx, span := tracing.Start(ctx, "WatchlistService.AddToken", attribute.Int64("user.id", int64(userID)))
+	defer span.End()
+	
 	// Validate token address if provided
-	if req.TokenAddress != nil && !s.web3Service.ValidateAddress(*req.TokenAddress) {
+	if req.TokenAddress != nil && !s.web3Registry.ValidateAddress(*req.TokenAddress) {
 		return nil, ErrInvalidAddress
 	}
 	
-	// Check if token already exists for this user
-	tokens, err := s.watchlistRepo.GetTokensByUserID(ctx, userID)
+	chainID, err := s.resolveChainID(req.ChainID)
 	if err != nil {
-		s.logger.Error("Failed to get user tokens", "error", err, "user_id", userID)
 		return nil, err
 	}
 	
-	for _, token := range tokens {
-		if token.TokenSymbol == req.TokenSymbol {
-			return nil, ErrTokenAlreadyExists
-		}
-	}
-	
 	// Create token
 	token := &models.TrackedToken{
 		UserID:       userID,
 		TokenAddress: req.TokenAddress,
+		ChainID:      chainID,
 		TokenSymbol:  req.TokenSymbol,
 		TokenName:    req.TokenName,
 	}
 	
+	if req.TokenAddress == nil {
+		decimals := uint8(nativeTokenDecimals)
+		token.Decimals = &decimals
+	} else if err := s.discoverTokenMetadata(ctx, token); err != nil {
+		return nil, err
+	}
+	
+	if token.TokenSymbol == "" || token.TokenName == "" {
+		return nil, ErrTokenInfoRequired
+	}
+	
+	// Check if token already exists for this user
+	tokens, err := s.watchlistRepo.GetTokensByUserID(ctx, userID)
+	if err != nil {
+		s.logger.Error("Failed to get user tokens", "error", err, "user_id", userID)
+		return nil, err
+	}
+	
+	for _, existing := range tokens {
+		if existing.ChainID != chainID {
+			continue
+		}
+		if existing.TokenSymbol == token.TokenSymbol || sameTokenAddress(existing.TokenAddress, token.TokenAddress) {
+			return nil, ErrTokenAlreadyExists
+		}
+	}
+	
 	if err := s.watchlistRepo.CreateToken(ctx, token); err != nil {
-		s.logger.Error("Failed to create token", "error", err, "user_id", userID, "symbol", req.TokenSymbol)
+		s.logger.Error("Failed to create token", "error", err, "user_id", userID, "symbol", token.TokenSymbol)
 		return nil, err
 	}
 	
 	// Invalidate cache
 	s.invalidateUserCache(ctx, userID)
 	
-	s.logger.Info("Token added to watchlist", "user_id", userID, "token_id", token.ID, "symbol", req.TokenSymbol)
+	s.logger.Info("Token added to watchlist", "user_id", userID, "token_id", token.ID, "symbol", token.TokenSymbol, "chain_id", chainID)
+	
+	// Fill in the new pairs' history in the background; failing to schedule it does not fail the request
+	if err := s.backfillService.EnqueueToken(ctx, token); err != nil {
+		s.logger.Warn("Failed to schedule balance backfill", "error", err, "token_id", token.ID)
+	}
 	
 	return &TokenResponse{
 		ID:           token.ID,
 		TokenAddress: token.TokenAddress,
+		ChainID:      token.ChainID,
 		TokenSymbol:  token.TokenSymbol,
 		TokenName:    token.TokenName,
+		Decimals:     token.Decimals,
 		CreatedAt:    token.CreatedAt,
 		UpdatedAt:    token.UpdatedAt,
 	}, nil
 }
 
+// discoverTokenMetadata fills in a token's symbol, name and decimals from its contract
+func (s *watchlistService) discoverTokenMetadata(ctx context.Context, token *models.TrackedToken) error {
+	web3Service, err := s.web3Registry.Get(token.ChainID)
+	if err != nil {
+		return err
+	}
+	
+	metadata, err := FetchTokenMetadata(ctx, web3Service, *token.TokenAddress)
+	if err != nil {
+		if !errors.Is(err, ErrNotERC20) {
+			s.logger.Error("Failed to read token metadata", "error", err, "chain_id", token.ChainID, "token", *token.TokenAddress)
+		}
+		return err
+	}
+	
+	token.Decimals = &metadata.Decimals
+	if metadata.Symbol != "" {
+		token.TokenSymbol = metadata.Symbol
+	}
+	if metadata.Name != "" {
+		token.TokenName = metadata.Name
+	}
+	
+	return nil
+}
+
+// sameTokenAddress reports whether two token addresses refer to the same contract
+func sameTokenAddress(a, b *string) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return strings.EqualFold(*a, *b)
+}
+
 // GetTokens retrieves user's tracked tokens
 func (s *watchlistService) GetTokens(ctx context.Context, userID uint) ([]*TokenResponse, error) {
+	ctx, span := tracing.Start(ctx, "WatchlistService.GetTokens", attribute.Int64("user.id", int64(userID)))
+	defer span.End()
+	
 	tokens, err := s.watchlistRepo.GetTokensByUserID(ctx, userID)
 	if err != nil {
 		s.logger.Error("Failed to get user tokens", "error", err, "user_id", userID)
@@ -259,8 +420,10 @@ func (s *watchlistService) GetTokens(ctx context.Context, userID uint) ([]*Token
 		responses[i] = &TokenResponse{
 			ID:           token.ID,
 			TokenAddress: token.TokenAddress,
+			ChainID:      token.ChainID,
 			TokenSymbol:  token.TokenSymbol,
 			TokenName:    token.TokenName,
+			Decimals:     token.Decimals,
 			CreatedAt:    token.CreatedAt,
 			UpdatedAt:    token.UpdatedAt,
 		}
@@ -271,6 +434,9 @@ func (s *watchlistService) GetTokens(ctx context.Context, userID uint) ([]*Token
 
 // DeleteToken removes a token from user's tracked tokens
 func (s *watchlistService) DeleteToken(ctx context.Context, userID uint, tokenID uint) error {
+	ctx, span := tracing.Start(ctx, "WatchlistService.DeleteToken", attribute.Int64("user.id", int64(userID)))
+	defer span.End()
+	
 	if err := s.watchlistRepo.DeleteToken(ctx, tokenID, userID); err != nil {
 		s.logger.Error("Failed to delete token", "error", err, "user_id", userID, "token_id", tokenID)
 		return err
@@ -285,16 +451,29 @@ func (s *watchlistService) DeleteToken(ctx context.Context, userID uint, tokenID
 
 // GetBalances retrieves user's wallet balances with caching
 func (s *watchlistService) GetBalances(ctx context.Context, userID uint) ([]*BalanceResponse, error) {
+	ctx, span := tracing.Start(ctx, "WatchlistService.GetBalances", attribute.Int64("user.id", int64(userID)))
+	defer span.End()
+	
+	// A user looking at their balances gets them fetched at their minimum interval
+	if s.scheduler != nil {
+		if err := s.scheduler.Viewed(ctx, userID); err != nil {
+			s.logger.Warn("Failed to reschedule balance fetches", "error", err, "user_id", userID)
+		}
+	}
+	
 	// Try cache first
 	cacheKey := fmt.Sprintf("user_balances:%d", userID)
 	var cachedBalances []*BalanceResponse
 	
 	if err := s.cacheService.Get(ctx, cacheKey, &cachedBalances); err == nil {
+		span.SetAttributes(attribute.Bool("cache.hit", true))
 		s.logger.Debug("Balances found in cache", "user_id", userID)
+		s.attachFetchStatus(ctx, userID, cachedBalances)
 		return cachedBalances, nil
 	}
 	
 	// Cache miss, get from database
+	span.SetAttributes(attribute.Bool("cache.hit", false))
 	balances, err := s.watchlistRepo.GetLatestBalances(ctx, userID)
 	if err != nil {
 		s.logger.Error("Failed to get balances", "error", err, "user_id", userID)
@@ -307,10 +486,14 @@ func (s *watchlistService) GetBalances(ctx context.Context, userID uint) ([]*Bal
 		responses[i] = &BalanceResponse{
 			WalletID:      balance.WalletID,
 			WalletAddress: balance.Wallet.WalletAddress,
+			ChainID:       balance.Wallet.ChainID,
 			TokenID:       balance.TokenID,
 			TokenSymbol:   balance.Token.TokenSymbol,
 			Balance:       balance.Balance,
+			FormattedBalance: s.formatBalance(ctx, &balance.Token, balance.Balance),
 			BalanceUSD:    balance.BalanceUSD,
+			PriceUSD:      balance.PriceUSD,
+			BlockNumber:   balance.BlockNumber,
 			FetchedAt:     balance.FetchedAt,
 		}
 	}
@@ -320,22 +503,76 @@ func (s *watchlistService) GetBalances(ctx context.Context, userID uint) ([]*Bal
 		s.logger.Warn("Failed to cache balances", "error", err, "user_id", userID)
 	}
 	
+	s.attachFetchStatus(ctx, userID, responses)
 	return responses, nil
 }
 
-// RefreshBalances triggers a balance refresh for a user
-func (s *watchlistService) RefreshBalances(ctx context.Context, userID uint) error {
-	// Invalidate cache first
-	s.invalidateUserCache(ctx, userID)
+// attachFetchStatus adds each pair's fetch status to the balances. It is read
+// on every request rather than cached, since a failing fetch does not replace
+// the cached balances.
+func (s *watchlistService) attachFetchStatus(ctx context.Context, userID uint, balances []*BalanceResponse) {
+	if s.scheduler == nil {
+		return
+	}
+	statuses, err := s.scheduler.FetchStatuses(ctx, userID)
+	if err != nil {
+		s.logger.Warn("Failed to get fetch statuses", "error", err, "user_id", userID)
+		return
+	}
+	for _, balance := range balances {
+		balance.FetchStatus = statuses[BalancePairID{balance.WalletID, balance.TokenID}]
+	}
+}
+
+// RefreshBalances queues a balance refresh for a user. The cached balances
+// are dropped once the refresh has stored the new ones.
+func (s *watchlistService) RefreshBalances(ctx context.Context, userID uint) (*JobResponse, error) {
+	ctx, span := tracing.Start(ctx, "WatchlistService.RefreshBalances", attribute.Int64("user.id", int64(userID)))
+	defer span.End()
 	
-	// Trigger balance fetch
-	if err := s.balanceFetcher.FetchBalancesForUser(ctx, userID); err != nil {
-		s.logger.Error("Failed to refresh balances", "error", err, "user_id", userID)
-		return err
+	return s.jobService.EnqueueRefresh(ctx, userID)
+}
+
+// resolveChainID applies the default chain and checks that the chain is configured
+func (s *watchlistService) resolveChainID(chainID int64) (int64, error) {
+	if chainID == 0 {
+		chainID = s.web3Registry.DefaultChainID()
+	}
+	if !s.web3Registry.IsSupported(chainID) {
+		return 0, ErrUnsupportedChain
+	}
+	return chainID, nil
+}
+
+// newWalletResponse converts a watchlist wallet to its API representation
+func newWalletResponse(wallet *models.WatchlistWallet) *WalletResponse {
+	return &WalletResponse{
+		ID:            wallet.ID,
+		WalletAddress: wallet.WalletAddress,
+		ChainID:       wallet.ChainID,
+		Label:         wallet.Label,
+		Verified:      wallet.VerifiedAt != nil,
+		VerifiedAt:    wallet.VerifiedAt,
+		CreatedAt:     wallet.CreatedAt,
+		UpdatedAt:     wallet.UpdatedAt,
+	}
+}
+
+// formatBalance formats a raw balance with the token's decimals. An empty
+// string is returned when the decimals cannot be determined.
+func (s *watchlistService) formatBalance(ctx context.Context, token *models.TrackedToken, balance string) string {
+	amount, ok := new(big.Int).SetString(balance, 10)
+	if !ok {
+		return ""
 	}
 	
-	s.logger.Info("Balances refreshed", "user_id", userID)
-	return nil
+	decimals, err := tokenDecimals(ctx, s.web3Registry, s.cacheService, token)
+	if err != nil {
+		s.logger.Warn("Failed to get token decimals", "error", err, "token_id", token.ID)
+		return ""
+	}
+	
+	return units.FormatUnits(amount, decimals)
 }
 
 // invalidateUserCache invalidates all cache entries for a user
@@ -355,6 +592,9 @@ func (s *watchlistService) invalidateUserCache(ctx context.Context, userID uint)
 
 // GetBalanceHistory retrieves balance history for a specific wallet and token
 func (s *watchlistService) GetBalanceHistory(ctx context.Context, userID uint, walletID uint, tokenID uint, limit int) ([]*BalanceHistoryResponse, error) {
+	ctx, span := tracing.Start(ctx, "WatchlistService.GetBalanceHistory", attribute.Int64("user.id", int64(userID)))
+	defer span.End()
+	
 	// Verify the wallet belongs to the user
 	wallet, err := s.watchlistRepo.GetWalletByID(ctx, walletID)
 	if err != nil {
@@ -383,14 +623,20 @@ func (s *watchlistService) GetBalanceHistory(ctx context.Context, userID uint, w
 	// Convert to response format
 	var history []*BalanceHistoryResponse
 	for _, balance := range balances {
+		formatted := s.formatBalance(ctx, token, balance.Balance)
 		history = append(history, &BalanceHistoryResponse{
 			ID:            balance.ID,
 			WalletID:      balance.WalletID,
 			WalletAddress: wallet.WalletAddress,
+			ChainID:       wallet.ChainID,
 			TokenID:       balance.TokenID,
 			TokenSymbol:   token.TokenSymbol,
 			Balance:       balance.Balance,
+			FormattedBalance: formatted,
 			BalanceUSD:    balance.BalanceUSD,
+			PriceUSD:      balance.PriceUSD,
+			BlockNumber:   balance.BlockNumber,
+			BlockHash:     balance.BlockHash,
 			FetchedAt:     balance.FetchedAt,
 			CreatedAt:     balance.CreatedAt,
 		})
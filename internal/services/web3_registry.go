@@ -0,0 +1,154 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+
+	"cryptoportfolio/internal/config"
+	"cryptoportfolio/pkg/logger"
+)
+
+// ErrUnsupportedChain is returned when no RPC endpoint is configured for a chain
+var ErrUnsupportedChain = errors.New("unsupported chain")
+
+// Web3Registry holds one Web3Service per configured chain
+type Web3Registry interface {
+	Get(chainID int64) (Web3Service, error)
+	GetArchive(chainID int64) (Web3Service, error)
+	DefaultChainID() int64
+	ChainIDs() []int64
+	IsSupported(chainID int64) bool
+	ValidateAddress(address string) bool
+	Close()
+}
+
+// web3Registry implements Web3Registry
+type web3Registry struct {
+	mu             sync.RWMutex
+	services       map[int64]Web3Service
+	archives       map[int64]Web3Service // archive-capable services for historical reads
+	chainIDs       []int64
+	defaultChainID int64
+}
+
+// NewWeb3Registry connects to every chain in config.Web3.Chains. Chains that
+// fail to connect are logged and skipped; an error is only returned when no
+// chain could be connected at all.
+func NewWeb3Registry(cfg *config.Config, logger *logger.Logger) (Web3Registry, error) {
+	registry := &web3Registry{
+		services:       make(map[int64]Web3Service),
+		archives:       make(map[int64]Web3Service),
+		defaultChainID: cfg.Web3.ChainID,
+	}
+
+	for _, chain := range cfg.Web3.Chains {
+		service, err := NewWeb3Service(cfg, chain, logger)
+		if err != nil {
+			logger.Error("Failed to initialize Web3 service", "chain_id", chain.ChainID, "chain", chain.Name, "error", err)
+			continue
+		}
+		registry.Register(service)
+		logger.Info("Web3 service initialized", "chain_id", chain.ChainID, "chain", chain.Name)
+
+		if len(chain.ArchiveRPCEndpoints) == 0 {
+			continue
+		}
+		archiveChain := chain
+		archiveChain.RPCEndpoints = chain.ArchiveRPCEndpoints
+		archive, err := NewWeb3Service(cfg, archiveChain, logger)
+		if err != nil {
+			logger.Error("Failed to initialize archive Web3 service", "chain_id", chain.ChainID, "chain", chain.Name, "error", err)
+			continue
+		}
+		registry.archives[chain.ChainID] = archive
+	}
+
+	if len(registry.services) == 0 {
+		return registry, fmt.Errorf("no chains could be initialized")
+	}
+
+	return registry, nil
+}
+
+// Register adds a service to the registry, replacing any service for the same chain
+func (r *web3Registry) Register(service Web3Service) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.services[service.ChainID()]; !exists {
+		r.chainIDs = append(r.chainIDs, service.ChainID())
+	}
+	r.services[service.ChainID()] = service
+}
+
+// Get returns the service for the given chain
+func (r *web3Registry) Get(chainID int64) (Web3Service, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	service, ok := r.services[chainID]
+	if !ok {
+		return nil, fmt.Errorf("%w: %d", ErrUnsupportedChain, chainID)
+	}
+	return service, nil
+}
+
+// GetArchive returns the archive service for the given chain, falling back to
+// the regular service when no archive endpoint is configured
+func (r *web3Registry) GetArchive(chainID int64) (Web3Service, error) {
+	r.mu.RLock()
+	archive, ok := r.archives[chainID]
+	r.mu.RUnlock()
+
+	if ok {
+		return archive, nil
+	}
+	return r.Get(chainID)
+}
+
+// DefaultChainID returns the chain used when a request does not specify one
+func (r *web3Registry) DefaultChainID() int64 {
+	return r.defaultChainID
+}
+
+// ChainIDs returns the IDs of all connected chains
+func (r *web3Registry) ChainIDs() []int64 {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	ids := make([]int64, len(r.chainIDs))
+	copy(ids, r.chainIDs)
+	return ids
+}
+
+// IsSupported reports whether a service is registered for the chain
+func (r *web3Registry) IsSupported(chainID int64) bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	_, ok := r.services[chainID]
+	return ok
+}
+
+// ValidateAddress validates Ethereum address format
+func (r *web3Registry) ValidateAddress(address string) bool {
+	return validateAddress(address)
+}
+
+// Close closes every registered service
+func (r *web3Registry) Close() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, service := range r.archives {
+		if closer, ok := service.(interface{ Close() }); ok {
+			closer.Close()
+		}
+	}
+	for _, service := range r.services {
+		if closer, ok := service.(interface{ Close() }); ok {
+			closer.Close()
+		}
+	}
+}
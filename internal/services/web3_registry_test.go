@@ -0,0 +1,138 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// MockWeb3Service implements Web3Service for testing
+type MockWeb3Service struct {
+	chainID          int64
+	callContract     func(contractAddress string, data []byte) ([]byte, error)
+	headerByNumber   func(number *big.Int) (*BlockHeader, error)
+	getBalancesBatch func(queries []BalanceQuery, blockHash *common.Hash) ([]BalanceResult, error)
+	filterLogs       func(query ethereum.FilterQuery) ([]types.Log, error)
+	getBlocks        func(numbers []*big.Int) ([]*Block, error)
+	receiptStatuses  func(txHashes []common.Hash) ([]uint64, error)
+	isValidSignature func(contractAddress string, hash common.Hash, signature []byte) bool
+}
+
+func (m *MockWeb3Service) ChainID() int64 {
+	return m.chainID
+}
+
+func (m *MockWeb3Service) GetETHBalance(ctx context.Context, address string, blockHash *common.Hash) (*big.Int, error) {
+	return big.NewInt(0), nil
+}
+
+func (m *MockWeb3Service) GetTokenBalance(ctx context.Context, tokenAddress, walletAddress string, blockHash *common.Hash) (*big.Int, error) {
+	return big.NewInt(0), nil
+}
+
+func (m *MockWeb3Service) GetBalancesBatch(ctx context.Context, queries []BalanceQuery, blockHash *common.Hash) ([]BalanceResult, error) {
+	if m.getBalancesBatch != nil {
+		return m.getBalancesBatch(queries, blockHash)
+	}
+	results := make([]BalanceResult, len(queries))
+	for i := range queries {
+		results[i].Balance = big.NewInt(0)
+	}
+	return results, nil
+}
+
+func (m *MockWeb3Service) GetBalancesAt(ctx context.Context, query BalanceQuery, blockNumbers []*big.Int) ([]BalanceResult, error) {
+	results := make([]BalanceResult, len(blockNumbers))
+	for i := range blockNumbers {
+		results[i].Balance = big.NewInt(0)
+	}
+	return results, nil
+}
+
+func (m *MockWeb3Service) HeaderByNumber(ctx context.Context, number *big.Int) (*BlockHeader, error) {
+	if m.headerByNumber == nil {
+		return nil, errors.New("not implemented")
+	}
+	return m.headerByNumber(number)
+}
+
+func (m *MockWeb3Service) FilterLogs(ctx context.Context, query ethereum.FilterQuery) ([]types.Log, error) {
+	if m.filterLogs == nil {
+		return nil, nil
+	}
+	return m.filterLogs(query)
+}
+
+func (m *MockWeb3Service) GetBlocks(ctx context.Context, numbers []*big.Int) ([]*Block, error) {
+	if m.getBlocks == nil {
+		return nil, errors.New("not implemented")
+	}
+	return m.getBlocks(numbers)
+}
+
+func (m *MockWeb3Service) GetReceiptStatuses(ctx context.Context, txHashes []common.Hash) ([]uint64, error) {
+	if m.receiptStatuses == nil {
+		return nil, errors.New("not implemented")
+	}
+	return m.receiptStatuses(txHashes)
+}
+
+func (m *MockWeb3Service) CallContract(ctx context.Context, contractAddress string, data []byte) ([]byte, error) {
+	if m.callContract == nil {
+		return nil, errors.New("execution reverted")
+	}
+	return m.callContract(contractAddress, data)
+}
+
+func (m *MockWeb3Service) IsValidSignature(ctx context.Context, contractAddress string, hash common.Hash, signature []byte) (bool, error) {
+	if m.isValidSignature == nil {
+		return false, nil
+	}
+	return m.isValidSignature(contractAddress, hash, signature), nil
+}
+
+func (m *MockWeb3Service) ValidateAddress(address string) bool {
+	return validateAddress(address)
+}
+
+func TestWeb3Registry_Get(t *testing.T) {
+	registry := &web3Registry{
+		services:       make(map[int64]Web3Service),
+		defaultChainID: 1,
+	}
+	registry.Register(&MockWeb3Service{chainID: 1})
+	registry.Register(&MockWeb3Service{chainID: 42161})
+
+	service, err := registry.Get(42161)
+	require.NoError(t, err)
+	assert.Equal(t, int64(42161), service.ChainID())
+
+	_, err = registry.Get(10)
+	assert.True(t, errors.Is(err, ErrUnsupportedChain))
+
+	assert.True(t, registry.IsSupported(1))
+	assert.False(t, registry.IsSupported(10))
+	assert.Equal(t, int64(1), registry.DefaultChainID())
+	assert.Equal(t, []int64{1, 42161}, registry.ChainIDs())
+}
+
+func TestWeb3Registry_RegisterReplacesChain(t *testing.T) {
+	registry := &web3Registry{services: make(map[int64]Web3Service)}
+	registry.Register(&MockWeb3Service{chainID: 1})
+	registry.Register(&MockWeb3Service{chainID: 1})
+
+	assert.Equal(t, []int64{1}, registry.ChainIDs())
+}
+
+func TestValidateAddress(t *testing.T) {
+	assert.True(t, validateAddress("0x742d35Cc6634C0532925a3b844Bc454e4438f44e"))
+	assert.False(t, validateAddress("742d35Cc6634C0532925a3b844Bc454e4438f44e"))
+	assert.False(t, validateAddress("0x742d35"))
+}
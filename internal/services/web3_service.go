@@ -6,30 +6,61 @@ import (
 	"fmt"
 	"math/big"
 	"strings"
+	"sync"
 	"time"
 
 	"cryptoportfolio/internal/config"
+	"cryptoportfolio/internal/metrics"
 	"cryptoportfolio/pkg/logger"
 
 	"github.com/ethereum/go-ethereum"
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/ethclient"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
-// Web3Service handles blockchain interactions
+// Web3Service handles blockchain interactions for a single chain
 type Web3Service interface {
-	GetETHBalance(ctx context.Context, address string) (*big.Int, error)
-	GetTokenBalance(ctx context.Context, tokenAddress, walletAddress string) (*big.Int, error)
+	ChainID() int64
+	GetETHBalance(ctx context.Context, address string, blockHash *common.Hash) (*big.Int, error)
+	GetTokenBalance(ctx context.Context, tokenAddress, walletAddress string, blockHash *common.Hash) (*big.Int, error)
+	GetBalancesBatch(ctx context.Context, queries []BalanceQuery, blockHash *common.Hash) ([]BalanceResult, error)
+	GetBalancesAt(ctx context.Context, query BalanceQuery, blockNumbers []*big.Int) ([]BalanceResult, error)
+	HeaderByNumber(ctx context.Context, number *big.Int) (*BlockHeader, error)
+	GetBlocks(ctx context.Context, numbers []*big.Int) ([]*Block, error)
+	GetReceiptStatuses(ctx context.Context, txHashes []common.Hash) ([]uint64, error)
+	FilterLogs(ctx context.Context, query ethereum.FilterQuery) ([]types.Log, error)
+	CallContract(ctx context.Context, contractAddress string, data []byte) ([]byte, error)
+	IsValidSignature(ctx context.Context, contractAddress string, hash common.Hash, signature []byte) (bool, error)
 	ValidateAddress(address string) bool
 }
 
+// JSON-RPC methods, used to label the RPC metrics
+const (
+	methodGetBalance       = "eth_getBalance"
+	methodCall             = "eth_call"
+	methodGetCode          = "eth_getCode"
+	methodGetBlockByNumber = "eth_getBlockByNumber"
+	methodGetLogs          = "eth_getLogs"
+	methodBatch            = "batch" // a JSON-RPC batch mixing several methods
+)
+
 // web3Service implements Web3Service
 type web3Service struct {
-	client     *ethclient.Client
+	pool       *ProviderPool
 	config     *config.Config
+	chain      config.ChainConfig
 	logger     *logger.Logger
 	rateLimiter *RateLimiter
+
+	// Multicall3 deployment is detected lazily on first batch read
+	multicallMu      sync.Mutex
+	multicallChecked bool
+	multicallEnabled bool
 }
 
 // RateLimiter implements token bucket algorithm for rate limiting
@@ -70,28 +101,35 @@ func (rl *RateLimiter) Wait(ctx context.Context) error {
 	}
 }
 
-// NewWeb3Service creates a new Web3 service
-func NewWeb3Service(config *config.Config, logger *logger.Logger) (Web3Service, error) {
-	// Connect to Ethereum client
-	// log the rpc endpoint
-	client, err := ethclient.Dial(config.Web3.RPCEndpoint)
+// NewWeb3Service creates a new Web3 service for the given chain
+func NewWeb3Service(config *config.Config, chain config.ChainConfig, logger *logger.Logger) (Web3Service, error) {
+	// Connect to the chain's RPC endpoints
+	cooldown := time.Duration(config.Web3.ProviderCooldown) * time.Second
+	pool, err := NewProviderPool(context.Background(), chain.ChainID, chain.RPCEndpoints, cooldown, config.Web3.ProviderMaxFailures, logger)
 	if err != nil {
-		return nil, fmt.Errorf("failed to connect to Ethereum client: %w", err)
+		return nil, fmt.Errorf("failed to connect to %s client: %w", chain.Name, err)
 	}
 
-	// Create rate limiter from config
+	// Each chain gets its own rate limiter since providers limit per endpoint
 	rateLimiter := NewRateLimiter(config.Web3.RateLimit)
 
 	return &web3Service{
-		client:      client,
+		pool:        pool,
 		config:      config,
+		chain:       chain,
 		logger:      logger,
 		rateLimiter: rateLimiter,
 	}, nil
 }
 
-// GetETHBalance retrieves ETH balance with retry mechanism
-func (s *web3Service) GetETHBalance(ctx context.Context, address string) (*big.Int, error) {
+// ChainID returns the ID of the chain this service reads from
+func (s *web3Service) ChainID() int64 {
+	return s.chain.ChainID
+}
+
+// GetETHBalance retrieves ETH balance with retry mechanism. Reads are made at
+// the block with the given hash, or at the latest block when it is nil.
+func (s *web3Service) GetETHBalance(ctx context.Context, address string, blockHash *common.Hash) (*big.Int, error) {
 	if !s.ValidateAddress(address) {
 		return nil, errors.New("invalid Ethereum address")
 	}
@@ -101,72 +139,41 @@ func (s *web3Service) GetETHBalance(ctx context.Context, address string) (*big.I
 		return nil, err
 	}
 
-	// Retry with exponential backoff
 	var balance *big.Int
-	var err error
-	
-	for attempt := 1; attempt <= 5; attempt++ {
-		balance, err = s.fetchETHBalance(ctx, address)
-		if err == nil {
-			return balance, nil
-		}
-
-		// Check if it's a rate limit error
-		isRateLimit := strings.Contains(err.Error(), "429") || strings.Contains(err.Error(), "Too Many Requests")
-		
-		// Only log non-rate-limit errors or rate limit errors on final attempt
-		if !isRateLimit || attempt == 5 {
-			s.logger.Warn("Failed to fetch ETH balance", 
-				"address", address, 
-				"attempt", attempt, 
-				"error", err,
-				"is_rate_limit", isRateLimit)
-		}
-
-		// Don't retry on context cancellation
-		if ctx.Err() != nil {
-			return nil, ctx.Err()
-		}
-
-		// Adaptive backoff based on error type
-		if attempt < 5 {
-			var backoff time.Duration
-			if isRateLimit {
-				// Longer backoff for rate limits: 5s, 10s, 20s, 40s
-				backoff = time.Duration(5*(1<<(attempt-1))) * time.Second
-			} else {
-				// Standard backoff for other errors: 1s, 2s, 4s, 8s
-				backoff = time.Duration(1<<(attempt-1)) * time.Second
-			}
-			
-			s.logger.Info("Backing off before retry", 
-				"attempt", attempt, 
-				"backoff", backoff, 
-				"is_rate_limit", isRateLimit)
-			
-			select {
-			case <-time.After(backoff):
-			case <-ctx.Done():
-				return nil, ctx.Err()
-			}
-		}
+	err := s.withRetry(ctx, "ETH balance", methodGetBalance, func() error {
+		var err error
+		balance, err = s.fetchETHBalance(ctx, address, blockHash)
+		return err
+	}, "address", address)
+	if err != nil {
+		return nil, err
 	}
 
-	return nil, fmt.Errorf("failed to fetch ETH balance after 5 attempts: %w", err)
+	return balance, nil
 }
 
 // fetchETHBalance performs the actual ETH balance fetch
-func (s *web3Service) fetchETHBalance(ctx context.Context, address string) (*big.Int, error) {
+func (s *web3Service) fetchETHBalance(ctx context.Context, address string, blockHash *common.Hash) (*big.Int, error) {
 	addr := common.HexToAddress(address)
-	balance, err := s.client.BalanceAt(ctx, addr, nil)
+	var balance *big.Int
+	err := s.pool.Do(ctx, methodGetBalance, func(client *ethclient.Client) error {
+		var err error
+		if blockHash != nil {
+			balance, err = client.BalanceAtHash(ctx, addr, *blockHash)
+		} else {
+			balance, err = client.BalanceAt(ctx, addr, nil)
+		}
+		return err
+	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to get balance: %w", err)
 	}
 	return balance, nil
 }
 
-// GetTokenBalance retrieves ERC-20 token balance with retry mechanism
-func (s *web3Service) GetTokenBalance(ctx context.Context, tokenAddress, walletAddress string) (*big.Int, error) {
+// GetTokenBalance retrieves ERC-20 token balance with retry mechanism. Reads
+// are made at the block with the given hash, or at the latest block when it is nil.
+func (s *web3Service) GetTokenBalance(ctx context.Context, tokenAddress, walletAddress string, blockHash *common.Hash) (*big.Int, error) {
 	if !s.ValidateAddress(tokenAddress) || !s.ValidateAddress(walletAddress) {
 		return nil, errors.New("invalid address")
 	}
@@ -176,32 +183,172 @@ func (s *web3Service) GetTokenBalance(ctx context.Context, tokenAddress, walletA
 		return nil, err
 	}
 
-	// Retry with exponential backoff
 	var balance *big.Int
+	err := s.withRetry(ctx, "token balance", methodCall, func() error {
+		var err error
+		balance, err = s.fetchTokenBalance(ctx, tokenAddress, walletAddress, blockHash)
+		return err
+	}, "token", tokenAddress, "wallet", walletAddress)
+	if err != nil {
+		return nil, err
+	}
+
+	return balance, nil
+}
+
+// CallContract executes a read-only contract call against the latest block
+func (s *web3Service) CallContract(ctx context.Context, contractAddress string, data []byte) ([]byte, error) {
+	if !s.ValidateAddress(contractAddress) {
+		return nil, errors.New("invalid address")
+	}
+
+	// Wait for rate limiter
+	if err := s.rateLimiter.Wait(ctx); err != nil {
+		return nil, err
+	}
+
+	to := common.HexToAddress(contractAddress)
+	var result []byte
+	err := s.withRetry(ctx, "contract call", methodCall, func() error {
+		return s.pool.Do(ctx, methodCall, func(client *ethclient.Client) error {
+			var err error
+			result, err = client.CallContract(ctx, ethereum.CallMsg{
+				To:   &to,
+				Data: data,
+			}, nil)
+			return err
+		})
+	}, "contract", contractAddress)
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// IsValidSignature asks a smart contract wallet whether it accepts signature
+// for hash (EIP-1271). Accounts without code and contracts that do not
+// implement isValidSignature reject every signature.
+func (s *web3Service) IsValidSignature(ctx context.Context, contractAddress string, hash common.Hash, signature []byte) (bool, error) {
+	data, err := erc1271.Pack("isValidSignature", hash, signature)
+	if err != nil {
+		return false, err
+	}
+
+	result, err := s.CallContract(ctx, contractAddress, data)
+	if err != nil {
+		// A revert rejects the signature; provider failures are returned
+		if !isProviderError(err) && ctx.Err() == nil {
+			return false, nil
+		}
+		return false, err
+	}
+
+	return isERC1271MagicValue(result), nil
+}
+
+// BlockHeader is the part of a block header used to pin and verify reads.
+// The hash is taken from the RPC response rather than recomputed, since some
+// chains hash their headers differently from Ethereum.
+type BlockHeader struct {
+	Number     *big.Int
+	Hash       common.Hash
+	ParentHash common.Hash
+	Time       uint64
+}
+
+// rpcBlockHeader is the JSON form of BlockHeader returned by eth_getBlockByNumber
+type rpcBlockHeader struct {
+	Number     *hexutil.Big   `json:"number"`
+	Hash       common.Hash    `json:"hash"`
+	ParentHash common.Hash    `json:"parentHash"`
+	Time       hexutil.Uint64 `json:"timestamp"`
+}
+
+// HeaderByNumber retrieves a block header; a nil number returns the latest block
+func (s *web3Service) HeaderByNumber(ctx context.Context, number *big.Int) (*BlockHeader, error) {
+	// Wait for rate limiter
+	if err := s.rateLimiter.Wait(ctx); err != nil {
+		return nil, err
+	}
+
+	var header *rpcBlockHeader
+	err := s.withRetry(ctx, "block header", methodGetBlockByNumber, func() error {
+		return s.pool.Do(ctx, methodGetBlockByNumber, func(client *ethclient.Client) error {
+			return client.Client().CallContext(ctx, &header, "eth_getBlockByNumber", blockNumberArg(number), false)
+		})
+	}, "block", number)
+	if err != nil {
+		return nil, err
+	}
+	if header == nil || header.Number == nil {
+		return nil, ethereum.NotFound
+	}
+
+	return &BlockHeader{
+		Number:     (*big.Int)(header.Number),
+		Hash:       header.Hash,
+		ParentHash: header.ParentHash,
+		Time:       uint64(header.Time),
+	}, nil
+}
+
+// FilterLogs returns the logs matching a filter query (eth_getLogs)
+func (s *web3Service) FilterLogs(ctx context.Context, query ethereum.FilterQuery) ([]types.Log, error) {
+	// Wait for rate limiter
+	if err := s.rateLimiter.Wait(ctx); err != nil {
+		return nil, err
+	}
+
+	var logs []types.Log
+	err := s.withRetry(ctx, "logs", methodGetLogs, func() error {
+		return s.pool.Do(ctx, methodGetLogs, func(client *ethclient.Client) error {
+			var err error
+			logs, err = client.FilterLogs(ctx, query)
+			return err
+		})
+	}, "from_block", query.FromBlock, "to_block", query.ToBlock)
+	if err != nil {
+		return nil, err
+	}
+
+	return logs, nil
+}
+
+// withRetry runs fn with exponential backoff, backing off longer on rate limit
+// errors. Retries are counted under the JSON-RPC method fn calls.
+func (s *web3Service) withRetry(ctx context.Context, operation, method string, fn func() error, logFields ...interface{}) error {
 	var err error
-	
+
 	for attempt := 1; attempt <= 5; attempt++ {
-		balance, err = s.fetchTokenBalance(ctx, tokenAddress, walletAddress)
+		err = fn()
 		if err == nil {
-			return balance, nil
+			return nil
 		}
 
 		// Check if it's a rate limit error
-		isRateLimit := strings.Contains(err.Error(), "429") || strings.Contains(err.Error(), "Too Many Requests")
-		
+		isRateLimit := isRateLimitError(err)
+
 		// Only log non-rate-limit errors or rate limit errors on final attempt
 		if !isRateLimit || attempt == 5 {
-			s.logger.Warn("Failed to fetch token balance", 
-				"token", tokenAddress, 
-				"wallet", walletAddress, 
-				"attempt", attempt, 
+			fields := append([]interface{}{
+				"Failed to fetch " + operation,
+				"chain_id", s.chain.ChainID,
+				"attempt", attempt,
 				"error", err,
-				"is_rate_limit", isRateLimit)
+				"is_rate_limit", isRateLimit,
+			}, logFields...)
+			s.logger.Warn(fields...)
 		}
 
 		// Don't retry on context cancellation
 		if ctx.Err() != nil {
-			return nil, ctx.Err()
+			return ctx.Err()
+		}
+
+		// Reverted calls fail the same way on every attempt
+		if !isProviderError(err) {
+			return err
 		}
 
 		// Adaptive backoff based on error type
@@ -223,16 +370,26 @@ func (s *web3Service) GetTokenBalance(ctx context.Context, tokenAddress, walletA
 			select {
 			case <-time.After(backoff):
 			case <-ctx.Done():
-				return nil, ctx.Err()
+				return ctx.Err()
 			}
+			metrics.RPCRetries.WithLabelValues(metrics.ChainLabel(s.chain.ChainID), method).Inc()
+			trace.SpanFromContext(ctx).AddEvent("rpc retry", trace.WithAttributes(
+				attribute.String("rpc.method", method),
+				attribute.Int("attempt", attempt+1),
+			))
 		}
 	}
 
-	return nil, fmt.Errorf("failed to fetch token balance after 5 attempts: %w", err)
+	return fmt.Errorf("failed to fetch %s after 5 attempts: %w", operation, err)
+}
+
+// isRateLimitError reports whether the RPC provider rejected the request for rate limiting
+func isRateLimitError(err error) bool {
+	return strings.Contains(err.Error(), "429") || strings.Contains(err.Error(), "Too Many Requests")
 }
 
 // fetchTokenBalance performs the actual token balance fetch
-func (s *web3Service) fetchTokenBalance(ctx context.Context, tokenAddress, walletAddress string) (*big.Int, error) {
+func (s *web3Service) fetchTokenBalance(ctx context.Context, tokenAddress, walletAddress string, blockHash *common.Hash) (*big.Int, error) {
 	// ERC-20 balanceOf function signature
 	balanceOfSignature := []byte("balanceOf(address)")
 	hash := crypto.Keccak256(balanceOfSignature)
@@ -248,10 +405,15 @@ func (s *web3Service) fetchTokenBalance(ctx context.Context, tokenAddress, walle
 	// Make the call
 	tokenAddr := common.HexToAddress(tokenAddress)
 	
-	result, err := s.client.CallContract(ctx, ethereum.CallMsg{
-		To:   &tokenAddr,
-		Data: data,
-	}, nil)
+	var result []byte
+	err := s.pool.Do(ctx, methodCall, func(client *ethclient.Client) error {
+		var err error
+		result, err = callContractAt(ctx, client, ethereum.CallMsg{
+			To:   &tokenAddr,
+			Data: data,
+		}, blockHash)
+		return err
+	})
 	
 	if err != nil {
 		return nil, fmt.Errorf("failed to call contract: %w", err)
@@ -262,8 +424,22 @@ func (s *web3Service) fetchTokenBalance(ctx context.Context, tokenAddress, walle
 	return balance, nil
 }
 
+// callContractAt executes a call at the block with the given hash (EIP-1898),
+// or at the latest block when it is nil
+func callContractAt(ctx context.Context, client *ethclient.Client, msg ethereum.CallMsg, blockHash *common.Hash) ([]byte, error) {
+	if blockHash != nil {
+		return client.CallContractAtHash(ctx, msg, *blockHash)
+	}
+	return client.CallContract(ctx, msg, nil)
+}
+
 // ValidateAddress validates Ethereum address format
 func (s *web3Service) ValidateAddress(address string) bool {
+	return validateAddress(address)
+}
+
+// validateAddress validates Ethereum address format; the format is the same on every EVM chain
+func validateAddress(address string) bool {
 	if !strings.HasPrefix(address, "0x") {
 		return false
 	}
@@ -279,8 +455,8 @@ func (s *web3Service) ValidateAddress(address string) bool {
 
 // Close closes the Web3 service
 func (s *web3Service) Close() {
-	if s.client != nil {
-		s.client.Close()
+	if s.pool != nil {
+		s.pool.Close()
 	}
 	if s.rateLimiter != nil && s.rateLimiter.ticker != nil {
 		s.rateLimiter.ticker.Stop()
@@ -0,0 +1,690 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"cryptoportfolio/internal/cache"
+	"cryptoportfolio/internal/config"
+	"cryptoportfolio/internal/models"
+	"cryptoportfolio/internal/repository"
+	"cryptoportfolio/pkg/logger"
+)
+
+// Webhook errors
+var (
+	ErrWebhookNotFound         = errors.New("webhook endpoint not found")
+	ErrInvalidWebhook          = errors.New("invalid webhook endpoint")
+	ErrWebhookDeliveryNotFound = errors.New("webhook delivery not found")
+)
+
+// Headers sent with every webhook request
+const (
+	WebhookIDHeader        = "X-Webhook-Id"
+	WebhookEventHeader     = "X-Webhook-Event"
+	WebhookTimestampHeader = "X-Webhook-Timestamp"
+	WebhookSignatureHeader = "X-Webhook-Signature"
+)
+
+const (
+	// webhookClaimBatch is the number of due deliveries sent per outbox poll
+	webhookClaimBatch = 20
+	// webhookSubscriptionTTL is how long a user's subscribed event types are cached
+	webhookSubscriptionTTL = time.Minute
+	// maxWebhookErrorLength matches the size of WebhookDelivery.LastError
+	maxWebhookErrorLength = 500
+	// allWebhookEvents marks an endpoint subscribed to every event type
+	allWebhookEvents = "*"
+)
+
+// webhookEventTypes lists the event types endpoints can subscribe to
+var webhookEventTypes = []string{
+	models.WebhookEventBalanceChanged,
+	models.WebhookEventAlertFired,
+	models.WebhookEventFetchFailed,
+}
+
+// WebhookEvent is the JSON body of every webhook request
+type WebhookEvent struct {
+	ID        string      `json:"id"`
+	Type      string      `json:"type" example:"balance.changed"`
+	CreatedAt time.Time   `json:"created_at"`
+	Data      interface{} `json:"data"`
+}
+
+// BalanceChangedEvent is the data of a balance.changed event
+type BalanceChangedEvent struct {
+	WalletID        uint      `json:"wallet_id"`
+	WalletAddress   string    `json:"wallet_address"`
+	ChainID         int64     `json:"chain_id"`
+	TokenID         uint      `json:"token_id"`
+	TokenAddress    *string   `json:"token_address"`
+	TokenSymbol     string    `json:"token_symbol"`
+	PreviousBalance *string   `json:"previous_balance"` // Raw balance of the previous snapshot; null for the first snapshot
+	Balance         string    `json:"balance"`
+	BalanceUSD      *string   `json:"balance_usd"`
+	BlockNumber     *uint64   `json:"block_number,omitempty"`
+	FetchedAt       time.Time `json:"fetched_at"`
+}
+
+// FetchFailedEvent is the data of a fetch.failed event
+type FetchFailedEvent struct {
+	Failures []FetchFailure `json:"failures"`
+}
+
+// FetchFailure is a wallet/token pair whose balance could not be fetched or stored
+type FetchFailure struct {
+	WalletID      uint   `json:"wallet_id"`
+	WalletAddress string `json:"wallet_address"`
+	ChainID       int64  `json:"chain_id"`
+	TokenID       uint   `json:"token_id"`
+	TokenSymbol   string `json:"token_symbol"`
+	Error         string `json:"error"`
+}
+
+// WebhookEndpointRequest creates or replaces a webhook endpoint
+type WebhookEndpointRequest struct {
+	URL     string   `json:"url" binding:"required" example:"https://example.com/hooks/portfolio"`
+	Events  []string `json:"events" example:"balance.changed,alert.fired"` // Event types to receive; all events when empty
+	Enabled *bool    `json:"enabled"`                                      // Default: true
+}
+
+// WebhookEndpointResponse represents a webhook endpoint in API responses
+type WebhookEndpointResponse struct {
+	ID      uint     `json:"id"`
+	URL     string   `json:"url"`
+	Events  []string `json:"events"`
+	Enabled bool     `json:"enabled"`
+	// Secret signs the endpoint's requests; it is only returned when the endpoint is created
+	Secret    string    `json:"secret,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// WebhookDeliveryPage is a page of an endpoint's deliveries, newest first
+type WebhookDeliveryPage struct {
+	Deliveries []*models.WebhookDelivery `json:"deliveries"`
+	Total      int64                     `json:"total"`
+	Limit      int                       `json:"limit"`
+	Offset     int                       `json:"offset"`
+	HasNext    bool                      `json:"has_next"`
+}
+
+// EventPublisher queues events for delivery to a user's webhook endpoints
+type EventPublisher interface {
+	// Subscribed reports whether any enabled endpoint of the user receives the event type
+	Subscribed(ctx context.Context, userID uint, eventType string) bool
+	// Publish writes one delivery per subscribed endpoint to the outbox
+	Publish(ctx context.Context, userID uint, eventType string, data interface{}) error
+}
+
+// WebhookService manages webhook endpoints and delivers queued events to them
+type WebhookService interface {
+	EventPublisher
+	Start(ctx context.Context)
+	Stop()
+	CreateEndpoint(ctx context.Context, userID uint, req *WebhookEndpointRequest) (*WebhookEndpointResponse, error)
+	GetEndpoints(ctx context.Context, userID uint) ([]*WebhookEndpointResponse, error)
+	GetEndpoint(ctx context.Context, userID uint, endpointID uint) (*WebhookEndpointResponse, error)
+	UpdateEndpoint(ctx context.Context, userID uint, endpointID uint, req *WebhookEndpointRequest) (*WebhookEndpointResponse, error)
+	DeleteEndpoint(ctx context.Context, userID uint, endpointID uint) error
+	GetDeliveries(ctx context.Context, userID uint, endpointID uint, status string, limit, offset int) (*WebhookDeliveryPage, error)
+	ReplayDelivery(ctx context.Context, userID uint, endpointID uint, deliveryID uint) (*models.WebhookDelivery, error)
+}
+
+// webhookService implements WebhookService
+type webhookService struct {
+	webhookRepo  repository.WebhookRepository
+	cacheService cache.CacheProvider
+	httpClient   *http.Client
+	logger       *logger.Logger
+	config       *config.Config
+	wake         chan struct{}
+	stopChan     chan struct{}
+	wg           sync.WaitGroup
+}
+
+// NewWebhookService creates a new webhook service
+func NewWebhookService(
+	webhookRepo repository.WebhookRepository,
+	cacheService cache.CacheProvider,
+	logger *logger.Logger,
+	config *config.Config,
+) WebhookService {
+	return &webhookService{
+		webhookRepo:  webhookRepo,
+		cacheService: cacheService,
+		httpClient:   newWebhookHTTPClient(time.Duration(config.Webhook.Timeout)*time.Second, config.Webhook.AllowPrivateTargets),
+		logger:       logger,
+		config:       config,
+		wake:         make(chan struct{}, 1),
+		stopChan:     make(chan struct{}),
+	}
+}
+
+// Start begins delivering queued events
+func (s *webhookService) Start(ctx context.Context) {
+	s.logger.Info("Starting webhook dispatcher")
+	s.wg.Add(1)
+	go s.run(ctx)
+}
+
+// Stop gracefully stops the dispatcher; deliveries in flight are retried after their lease
+func (s *webhookService) Stop() {
+	s.logger.Info("Stopping webhook dispatcher")
+	close(s.stopChan)
+	s.wg.Wait()
+	s.logger.Info("Webhook dispatcher stopped")
+}
+
+// run polls the outbox, and is woken early when events are published in-process
+func (s *webhookService) run(ctx context.Context) {
+	defer s.wg.Done()
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go func() {
+		select {
+		case <-s.stopChan:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	interval := time.Duration(s.config.Webhook.PollInterval) * time.Second
+	if interval <= 0 {
+		interval = 5 * time.Second
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		s.dispatchDue(ctx)
+
+		select {
+		case <-ticker.C:
+		case <-s.wake:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// dispatchDue sends due deliveries until none are left
+func (s *webhookService) dispatchDue(ctx context.Context) {
+	// A claimed delivery is retried by any dispatcher once its request can no longer be in flight
+	lease := 2 * s.httpClient.Timeout
+	if lease <= 0 {
+		lease = time.Minute
+	}
+
+	for ctx.Err() == nil {
+		deliveries, err := s.webhookRepo.ClaimDue(ctx, time.Now(), lease, webhookClaimBatch)
+		if err != nil {
+			s.logger.Error("Failed to claim webhook deliveries", "error", err)
+			return
+		}
+		if len(deliveries) == 0 {
+			return
+		}
+
+		var wg sync.WaitGroup
+		for _, delivery := range deliveries {
+			wg.Add(1)
+			go func(delivery *models.WebhookDelivery) {
+				defer wg.Done()
+				s.deliver(ctx, delivery)
+			}(delivery)
+		}
+		wg.Wait()
+	}
+}
+
+// deliver makes one attempt at a delivery and schedules its retry or dead-letters it
+func (s *webhookService) deliver(ctx context.Context, delivery *models.WebhookDelivery) {
+	started := time.Now()
+	statusCode, err := s.send(ctx, &delivery.Endpoint, delivery)
+	if ctx.Err() != nil {
+		// Interrupted by shutdown; the delivery is retried once its lease expires
+		return
+	}
+
+	attempt := &models.WebhookAttempt{
+		DurationMs:  time.Since(started).Milliseconds(),
+		AttemptedAt: started,
+	}
+	if statusCode != 0 {
+		attempt.StatusCode = &statusCode
+	}
+	delivery.Attempts++
+	delivery.LastStatusCode = attempt.StatusCode
+
+	switch {
+	case err == nil:
+		now := time.Now()
+		delivery.Status = models.WebhookDeliveryDelivered
+		delivery.DeliveredAt = &now
+		delivery.LastError = ""
+	case delivery.Attempts >= s.config.Webhook.MaxAttempts:
+		attempt.Error = truncateString(err.Error(), maxWebhookErrorLength)
+		delivery.Status = models.WebhookDeliveryDead
+		delivery.LastError = attempt.Error
+		s.logger.Warn("Webhook delivery dead-lettered",
+			"delivery_id", delivery.ID,
+			"endpoint_id", delivery.EndpointID,
+			"attempts", delivery.Attempts,
+			"error", err)
+	default:
+		attempt.Error = truncateString(err.Error(), maxWebhookErrorLength)
+		delivery.LastError = attempt.Error
+		delivery.NextAttemptAt = time.Now().Add(webhookRetryDelay(s.config.Webhook, delivery.Attempts))
+		s.logger.Debug("Webhook delivery failed",
+			"delivery_id", delivery.ID,
+			"endpoint_id", delivery.EndpointID,
+			"attempts", delivery.Attempts,
+			"next_attempt_at", delivery.NextAttemptAt,
+			"error", err)
+	}
+
+	if err := s.webhookRepo.RecordAttempt(context.Background(), delivery, attempt); err != nil {
+		s.logger.Error("Failed to record webhook attempt", "delivery_id", delivery.ID, "error", err)
+	}
+}
+
+// send posts a delivery's payload to the endpoint and returns the response status
+func (s *webhookService) send(ctx context.Context, endpoint *models.WebhookEndpoint, delivery *models.WebhookDelivery) (int, error) {
+	if endpoint.ID == 0 {
+		return 0, errors.New("endpoint no longer exists")
+	}
+	if !endpoint.Enabled {
+		return 0, errors.New("endpoint is disabled")
+	}
+
+	body := []byte(delivery.Payload)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+
+	timestamp := time.Now().Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(WebhookIDHeader, delivery.EventID)
+	req.Header.Set(WebhookEventHeader, delivery.EventType)
+	req.Header.Set(WebhookTimestampHeader, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(WebhookSignatureHeader, SignWebhookPayload(endpoint.Secret, timestamp, body))
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	// Drain a little of the body so the connection can be reused
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 4096))
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, fmt.Errorf("endpoint responded with status %d", resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
+
+// SignWebhookPayload returns the signature header value of a webhook request:
+// the hex HMAC-SHA256 of "<timestamp>.<body>" keyed with the endpoint secret.
+// Receivers recompute it and reject requests with old timestamps.
+func SignWebhookPayload(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// webhookRetryDelay is the exponential backoff before the attempt after the given number of failures
+func webhookRetryDelay(cfg config.WebhookConfig, attempts int) time.Duration {
+	delay := time.Duration(cfg.RetryBase) * time.Second
+	maxDelay := time.Duration(cfg.RetryMax) * time.Second
+	for i := 1; i < attempts && delay < maxDelay; i++ {
+		delay *= 2
+	}
+	if maxDelay > 0 && delay > maxDelay {
+		delay = maxDelay
+	}
+	return delay
+}
+
+// Subscribed reports whether the user has an enabled endpoint for the event
+// type. Subscriptions are cached briefly since this is checked for every
+// stored balance.
+func (s *webhookService) Subscribed(ctx context.Context, userID uint, eventType string) bool {
+	cacheKey := fmt.Sprintf("webhook_events:%d", userID)
+	var events []string
+	if err := s.cacheService.Get(ctx, cacheKey, &events); err != nil {
+		endpoints, err := s.webhookRepo.GetEndpointsByUserID(ctx, userID)
+		if err != nil {
+			s.logger.Warn("Failed to get webhook endpoints", "error", err, "user_id", userID)
+			return false
+		}
+		events = []string{}
+		for _, endpoint := range endpoints {
+			if endpoint.Enabled {
+				events = append(events, endpointEvents(endpoint)...)
+			}
+		}
+		_ = s.cacheService.Set(ctx, cacheKey, events, webhookSubscriptionTTL)
+	}
+
+	for _, event := range events {
+		if event == eventType || event == allWebhookEvents {
+			return true
+		}
+	}
+	return false
+}
+
+// Publish writes the event to the outbox for every enabled endpoint of the
+// user that subscribes to it, and wakes the dispatcher
+func (s *webhookService) Publish(ctx context.Context, userID uint, eventType string, data interface{}) error {
+	endpoints, err := s.webhookRepo.GetEndpointsByUserID(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("failed to get webhook endpoints: %w", err)
+	}
+
+	event := WebhookEvent{ID: newWebhookEventID(), Type: eventType, CreatedAt: time.Now().UTC(), Data: data}
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to encode webhook event: %w", err)
+	}
+
+	var deliveries []*models.WebhookDelivery
+	for _, endpoint := range endpoints {
+		if !endpoint.Enabled || !subscribes(endpoint, eventType) {
+			continue
+		}
+		deliveries = append(deliveries, &models.WebhookDelivery{
+			EndpointID:    endpoint.ID,
+			UserID:        userID,
+			EventID:       event.ID,
+			EventType:     eventType,
+			Payload:       string(payload),
+			Status:        models.WebhookDeliveryPending,
+			NextAttemptAt: event.CreatedAt,
+		})
+	}
+	if len(deliveries) == 0 {
+		return nil
+	}
+
+	if err := s.webhookRepo.CreateDeliveries(ctx, deliveries); err != nil {
+		return fmt.Errorf("failed to queue webhook deliveries: %w", err)
+	}
+
+	s.notify()
+	return nil
+}
+
+// notify wakes the dispatcher without blocking
+func (s *webhookService) notify() {
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+// CreateEndpoint registers a webhook endpoint with a new signing secret
+func (s *webhookService) CreateEndpoint(ctx context.Context, userID uint, req *WebhookEndpointRequest) (*WebhookEndpointResponse, error) {
+	secret, err := newWebhookSecret()
+	if err != nil {
+		return nil, err
+	}
+
+	endpoint := &models.WebhookEndpoint{UserID: userID, Secret: secret}
+	if err := s.applyWebhookRequest(ctx, endpoint, req); err != nil {
+		return nil, err
+	}
+
+	if err := s.webhookRepo.CreateEndpoint(ctx, endpoint); err != nil {
+		s.logger.Error("Failed to create webhook endpoint", "error", err, "user_id", userID)
+		return nil, err
+	}
+	s.invalidateSubscriptions(ctx, userID)
+
+	s.logger.Info("Webhook endpoint created", "user_id", userID, "endpoint_id", endpoint.ID)
+
+	response := webhookEndpointResponse(endpoint)
+	response.Secret = endpoint.Secret
+	return response, nil
+}
+
+// GetEndpoints retrieves the user's webhook endpoints
+func (s *webhookService) GetEndpoints(ctx context.Context, userID uint) ([]*WebhookEndpointResponse, error) {
+	endpoints, err := s.webhookRepo.GetEndpointsByUserID(ctx, userID)
+	if err != nil {
+		s.logger.Error("Failed to get webhook endpoints", "error", err, "user_id", userID)
+		return nil, err
+	}
+
+	responses := make([]*WebhookEndpointResponse, len(endpoints))
+	for i, endpoint := range endpoints {
+		responses[i] = webhookEndpointResponse(endpoint)
+	}
+	return responses, nil
+}
+
+// GetEndpoint retrieves one of the user's webhook endpoints
+func (s *webhookService) GetEndpoint(ctx context.Context, userID uint, endpointID uint) (*WebhookEndpointResponse, error) {
+	endpoint, err := s.userEndpoint(ctx, userID, endpointID)
+	if err != nil {
+		return nil, err
+	}
+	return webhookEndpointResponse(endpoint), nil
+}
+
+// UpdateEndpoint replaces the settings of one of the user's webhook endpoints; the secret is kept
+func (s *webhookService) UpdateEndpoint(ctx context.Context, userID uint, endpointID uint, req *WebhookEndpointRequest) (*WebhookEndpointResponse, error) {
+	endpoint, err := s.userEndpoint(ctx, userID, endpointID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.applyWebhookRequest(ctx, endpoint, req); err != nil {
+		return nil, err
+	}
+
+	if err := s.webhookRepo.UpdateEndpoint(ctx, endpoint); err != nil {
+		s.logger.Error("Failed to update webhook endpoint", "error", err, "user_id", userID, "endpoint_id", endpointID)
+		return nil, err
+	}
+	s.invalidateSubscriptions(ctx, userID)
+
+	s.logger.Info("Webhook endpoint updated", "user_id", userID, "endpoint_id", endpointID)
+	return webhookEndpointResponse(endpoint), nil
+}
+
+// DeleteEndpoint removes one of the user's webhook endpoints and its queued deliveries
+func (s *webhookService) DeleteEndpoint(ctx context.Context, userID uint, endpointID uint) error {
+	if err := s.webhookRepo.DeleteEndpoint(ctx, endpointID, userID); err != nil {
+		if errors.Is(err, repository.ErrRecordNotFound) {
+			return ErrWebhookNotFound
+		}
+		s.logger.Error("Failed to delete webhook endpoint", "error", err, "user_id", userID, "endpoint_id", endpointID)
+		return err
+	}
+	s.invalidateSubscriptions(ctx, userID)
+
+	s.logger.Info("Webhook endpoint deleted", "user_id", userID, "endpoint_id", endpointID)
+	return nil
+}
+
+// GetDeliveries retrieves a page of an endpoint's deliveries with their attempts
+func (s *webhookService) GetDeliveries(ctx context.Context, userID uint, endpointID uint, status string, limit, offset int) (*WebhookDeliveryPage, error) {
+	if _, err := s.userEndpoint(ctx, userID, endpointID); err != nil {
+		return nil, err
+	}
+
+	page, err := s.webhookRepo.GetDeliveries(ctx, endpointID, status, repository.Pagination{Limit: limit, Offset: offset})
+	if err != nil {
+		s.logger.Error("Failed to get webhook deliveries", "error", err, "endpoint_id", endpointID)
+		return nil, err
+	}
+
+	return &WebhookDeliveryPage{
+		Deliveries: page.Data,
+		Total:      page.Total,
+		Limit:      page.Limit,
+		Offset:     page.Offset,
+		HasNext:    page.HasNext,
+	}, nil
+}
+
+// ReplayDelivery queues a delivery again with a fresh set of attempts,
+// typically one that was dead-lettered while the endpoint was down
+func (s *webhookService) ReplayDelivery(ctx context.Context, userID uint, endpointID uint, deliveryID uint) (*models.WebhookDelivery, error) {
+	if _, err := s.userEndpoint(ctx, userID, endpointID); err != nil {
+		return nil, err
+	}
+
+	delivery, err := s.webhookRepo.GetDeliveryByID(ctx, deliveryID)
+	if err != nil {
+		if errors.Is(err, repository.ErrRecordNotFound) {
+			return nil, ErrWebhookDeliveryNotFound
+		}
+		return nil, err
+	}
+	if delivery.EndpointID != endpointID {
+		return nil, ErrWebhookDeliveryNotFound
+	}
+
+	now := time.Now()
+	if err := s.webhookRepo.Requeue(ctx, delivery.ID, now); err != nil {
+		s.logger.Error("Failed to replay webhook delivery", "error", err, "delivery_id", deliveryID)
+		return nil, err
+	}
+	delivery.Status = models.WebhookDeliveryPending
+	delivery.Attempts = 0
+	delivery.NextAttemptAt = now
+	delivery.LastError = ""
+
+	s.logger.Info("Webhook delivery replayed", "user_id", userID, "delivery_id", deliveryID)
+	s.notify()
+	return delivery, nil
+}
+
+// userEndpoint loads an endpoint, hiding endpoints of other users
+func (s *webhookService) userEndpoint(ctx context.Context, userID uint, endpointID uint) (*models.WebhookEndpoint, error) {
+	endpoint, err := s.webhookRepo.GetEndpointByID(ctx, endpointID)
+	if err != nil {
+		if errors.Is(err, repository.ErrRecordNotFound) {
+			return nil, ErrWebhookNotFound
+		}
+		s.logger.Error("Failed to get webhook endpoint", "error", err, "endpoint_id", endpointID)
+		return nil, err
+	}
+	if endpoint.UserID != userID {
+		return nil, ErrWebhookNotFound
+	}
+	return endpoint, nil
+}
+
+// invalidateSubscriptions drops the user's cached event subscriptions after an endpoint change
+func (s *webhookService) invalidateSubscriptions(ctx context.Context, userID uint) {
+	if err := s.cacheService.Delete(ctx, fmt.Sprintf("webhook_events:%d", userID)); err != nil {
+		s.logger.Warn("Failed to invalidate webhook subscriptions", "error", err, "user_id", userID)
+	}
+}
+
+// applyWebhookRequest validates a request and copies it onto the endpoint.
+// URLs whose host resolves to an internal address are refused.
+func (s *webhookService) applyWebhookRequest(ctx context.Context, endpoint *models.WebhookEndpoint, req *WebhookEndpointRequest) error {
+	target, err := url.Parse(req.URL)
+	if err != nil || (target.Scheme != "http" && target.Scheme != "https") || target.Hostname() == "" {
+		return ErrInvalidWebhook
+	}
+	if !s.config.Webhook.AllowPrivateTargets {
+		if err := checkWebhookHost(ctx, target.Hostname()); err != nil {
+			return err
+		}
+	}
+
+	for _, event := range req.Events {
+		if !validWebhookEvent(event) {
+			return ErrInvalidWebhook
+		}
+	}
+
+	endpoint.URL = req.URL
+	endpoint.Events = strings.Join(req.Events, ",")
+	endpoint.Enabled = req.Enabled == nil || *req.Enabled
+	return nil
+}
+
+// validWebhookEvent reports whether event is a known event type
+func validWebhookEvent(event string) bool {
+	for _, known := range webhookEventTypes {
+		if event == known {
+			return true
+		}
+	}
+	return false
+}
+
+// endpointEvents returns the event types an endpoint subscribes to
+func endpointEvents(endpoint *models.WebhookEndpoint) []string {
+	if endpoint.Events == "" {
+		return []string{allWebhookEvents}
+	}
+	return strings.Split(endpoint.Events, ",")
+}
+
+// subscribes reports whether an endpoint receives the event type
+func subscribes(endpoint *models.WebhookEndpoint, eventType string) bool {
+	for _, event := range endpointEvents(endpoint) {
+		if event == eventType || event == allWebhookEvents {
+			return true
+		}
+	}
+	return false
+}
+
+// webhookEndpointResponse converts an endpoint without its secret
+func webhookEndpointResponse(endpoint *models.WebhookEndpoint) *WebhookEndpointResponse {
+	events := webhookEventTypes
+	if endpoint.Events != "" {
+		events = strings.Split(endpoint.Events, ",")
+	}
+	return &WebhookEndpointResponse{
+		ID:        endpoint.ID,
+		URL:       endpoint.URL,
+		Events:    events,
+		Enabled:   endpoint.Enabled,
+		CreatedAt: endpoint.CreatedAt,
+		UpdatedAt: endpoint.UpdatedAt,
+	}
+}
+
+// newWebhookSecret generates a random endpoint signing secret
+func newWebhookSecret() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate webhook secret: %w", err)
+	}
+	return "whsec_" + hex.EncodeToString(buf), nil
+}
+
+// newWebhookEventID generates a random event ID receivers can deduplicate on
+func newWebhookEventID() string {
+	buf := make([]byte, 16)
+	_, _ = rand.Read(buf)
+	return "evt_" + hex.EncodeToString(buf)
+}
@@ -0,0 +1,244 @@
+package services
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"cryptoportfolio/internal/config"
+	"cryptoportfolio/internal/models"
+	"cryptoportfolio/internal/repository"
+	"cryptoportfolio/pkg/logger"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeWebhookRepository keeps endpoints and the outbox in memory
+type fakeWebhookRepository struct {
+	repository.WebhookRepository
+	endpoints  []*models.WebhookEndpoint
+	deliveries []*models.WebhookDelivery
+	attempts   []*models.WebhookAttempt
+}
+
+func (r *fakeWebhookRepository) GetEndpointsByUserID(ctx context.Context, userID uint) ([]*models.WebhookEndpoint, error) {
+	var endpoints []*models.WebhookEndpoint
+	for _, endpoint := range r.endpoints {
+		if endpoint.UserID == userID {
+			endpoints = append(endpoints, endpoint)
+		}
+	}
+	return endpoints, nil
+}
+
+func (r *fakeWebhookRepository) CreateEndpoint(ctx context.Context, endpoint *models.WebhookEndpoint) error {
+	endpoint.ID = uint(len(r.endpoints) + 1)
+	r.endpoints = append(r.endpoints, endpoint)
+	return nil
+}
+
+func (r *fakeWebhookRepository) CreateDeliveries(ctx context.Context, deliveries []*models.WebhookDelivery) error {
+	r.deliveries = append(r.deliveries, deliveries...)
+	return nil
+}
+
+func (r *fakeWebhookRepository) RecordAttempt(ctx context.Context, delivery *models.WebhookDelivery, attempt *models.WebhookAttempt) error {
+	r.attempts = append(r.attempts, attempt)
+	return nil
+}
+
+// newTestWebhookService delivers to local test servers; newTestWebhookServiceStrict does not
+func newTestWebhookService(repo repository.WebhookRepository) *webhookService {
+	return NewWebhookService(repo, NewMockCacheProvider(), logger.New(), &config.Config{
+		Webhook: config.WebhookConfig{Timeout: 5, MaxAttempts: 3, RetryBase: 30, RetryMax: 3600, AllowPrivateTargets: true},
+	}).(*webhookService)
+}
+
+func newTestWebhookServiceStrict(repo repository.WebhookRepository) *webhookService {
+	return NewWebhookService(repo, NewMockCacheProvider(), logger.New(), &config.Config{
+		Webhook: config.WebhookConfig{Timeout: 5, MaxAttempts: 3, RetryBase: 30, RetryMax: 3600},
+	}).(*webhookService)
+}
+
+func TestSignWebhookPayload(t *testing.T) {
+	// Reference value computed with: printf '1700000000.{"id":"evt_1"}' | openssl dgst -sha256 -hmac whsec_test
+	assert.Equal(t,
+		"sha256=c89214b5b5da833daed6f0b8c5bb6bd58cea9022bd80ccc78230f3942d632925",
+		SignWebhookPayload("whsec_test", 1700000000, []byte(`{"id":"evt_1"}`)))
+}
+
+func TestWebhookRetryDelay(t *testing.T) {
+	cfg := config.WebhookConfig{RetryBase: 30, RetryMax: 300}
+	assert.Equal(t, 30*time.Second, webhookRetryDelay(cfg, 1))
+	assert.Equal(t, 60*time.Second, webhookRetryDelay(cfg, 2))
+	assert.Equal(t, 240*time.Second, webhookRetryDelay(cfg, 4))
+	assert.Equal(t, 300*time.Second, webhookRetryDelay(cfg, 5))
+	assert.Equal(t, 300*time.Second, webhookRetryDelay(cfg, 40))
+}
+
+func TestWebhookService_Publish(t *testing.T) {
+	repo := &fakeWebhookRepository{endpoints: []*models.WebhookEndpoint{
+		{ID: 1, UserID: 1, Enabled: true},
+		{ID: 2, UserID: 1, Enabled: true, Events: models.WebhookEventAlertFired},
+		{ID: 3, UserID: 1, Enabled: false},
+		{ID: 4, UserID: 2, Enabled: true},
+	}}
+	service := newTestWebhookService(repo)
+	ctx := context.Background()
+
+	assert.True(t, service.Subscribed(ctx, 1, models.WebhookEventBalanceChanged))
+	assert.False(t, service.Subscribed(ctx, 3, models.WebhookEventBalanceChanged))
+
+	require.NoError(t, service.Publish(ctx, 1, models.WebhookEventBalanceChanged, BalanceChangedEvent{WalletID: 7, Balance: "5"}))
+	require.Len(t, repo.deliveries, 1)
+	delivery := repo.deliveries[0]
+	assert.Equal(t, uint(1), delivery.EndpointID)
+	assert.Equal(t, models.WebhookDeliveryPending, delivery.Status)
+	assert.Contains(t, delivery.Payload, `"type":"balance.changed"`)
+	assert.Contains(t, delivery.Payload, `"wallet_id":7`)
+
+	// Events of one publish share an ID across endpoints
+	require.NoError(t, service.Publish(ctx, 1, models.WebhookEventAlertFired, map[string]string{}))
+	require.Len(t, repo.deliveries, 3)
+	assert.Equal(t, repo.deliveries[1].EventID, repo.deliveries[2].EventID)
+	assert.NotEqual(t, delivery.EventID, repo.deliveries[1].EventID)
+}
+
+func TestWebhookService_Deliver(t *testing.T) {
+	endpoint := models.WebhookEndpoint{ID: 1, UserID: 1, Secret: "whsec_test", Enabled: true}
+
+	var received *http.Request
+	var body []byte
+	status := http.StatusOK
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = r
+		body, _ = io.ReadAll(r.Body)
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+	endpoint.URL = server.URL
+
+	repo := &fakeWebhookRepository{}
+	service := newTestWebhookService(repo)
+	ctx := context.Background()
+
+	delivery := &models.WebhookDelivery{ID: 1, EndpointID: 1, EventID: "evt_1", EventType: models.WebhookEventBalanceChanged, Payload: `{"id":"evt_1"}`, Status: models.WebhookDeliveryPending, Endpoint: endpoint}
+	service.deliver(ctx, delivery)
+
+	// The request is signed over its timestamp and body
+	require.NotNil(t, received)
+	assert.Equal(t, `{"id":"evt_1"}`, string(body))
+	assert.Equal(t, "evt_1", received.Header.Get(WebhookIDHeader))
+	assert.Equal(t, models.WebhookEventBalanceChanged, received.Header.Get(WebhookEventHeader))
+	timestamp, err := strconv.ParseInt(received.Header.Get(WebhookTimestampHeader), 10, 64)
+	require.NoError(t, err)
+	assert.Equal(t, SignWebhookPayload("whsec_test", timestamp, body), received.Header.Get(WebhookSignatureHeader))
+
+	assert.Equal(t, models.WebhookDeliveryDelivered, delivery.Status)
+	assert.NotNil(t, delivery.DeliveredAt)
+	require.Len(t, repo.attempts, 1)
+	assert.Equal(t, http.StatusOK, *repo.attempts[0].StatusCode)
+
+	// Failures are retried with backoff until the attempts run out
+	status = http.StatusBadGateway
+	failing := &models.WebhookDelivery{ID: 2, EndpointID: 1, EventID: "evt_2", Payload: `{}`, Status: models.WebhookDeliveryPending, Endpoint: endpoint}
+
+	before := time.Now()
+	service.deliver(ctx, failing)
+	assert.Equal(t, models.WebhookDeliveryPending, failing.Status)
+	assert.Equal(t, 1, failing.Attempts)
+	assert.WithinDuration(t, before.Add(30*time.Second), failing.NextAttemptAt, 5*time.Second)
+	assert.Equal(t, "endpoint responded with status 502", failing.LastError)
+
+	service.deliver(ctx, failing)
+	assert.Equal(t, models.WebhookDeliveryPending, failing.Status)
+	assert.WithinDuration(t, before.Add(60*time.Second), failing.NextAttemptAt, 5*time.Second)
+
+	service.deliver(ctx, failing)
+	assert.Equal(t, models.WebhookDeliveryDead, failing.Status)
+	assert.Equal(t, 3, failing.Attempts)
+	assert.Len(t, repo.attempts, 4)
+}
+
+func TestAllowedWebhookIP(t *testing.T) {
+	for _, ip := range []string{"93.184.216.34", "8.8.8.8", "2606:4700:4700::1111"} {
+		assert.True(t, allowedWebhookIP(net.ParseIP(ip)), ip)
+	}
+	for _, ip := range []string{
+		"127.0.0.1", "::1", // loopback
+		"10.1.2.3", "172.16.0.1", "192.168.1.1", "fd00::1", // private
+		"169.254.169.254", "fe80::1", // link-local, including the cloud metadata service
+		"0.0.0.0", "::", // unspecified
+		"100.64.0.1",                          // carrier-grade NAT
+		"224.0.0.1",                           // multicast
+		"::ffff:127.0.0.1", "::ffff:10.0.0.1", // IPv4-mapped IPv6
+	} {
+		assert.False(t, allowedWebhookIP(net.ParseIP(ip)), ip)
+	}
+}
+
+func TestWebhookService_RejectsInternalURLs(t *testing.T) {
+	service := newTestWebhookServiceStrict(&fakeWebhookRepository{})
+	ctx := context.Background()
+
+	for _, target := range []string{
+		"http://127.0.0.1:8080/hook",
+		"http://localhost/hook",
+		"http://169.254.169.254/latest/meta-data/",
+		"http://10.0.0.5/hook",
+		"http://[::1]/hook",
+		"http://0.0.0.0/hook",
+	} {
+		_, err := service.CreateEndpoint(ctx, 1, &WebhookEndpointRequest{URL: target})
+		assert.ErrorIs(t, err, ErrWebhookTargetNotAllowed, target)
+	}
+
+	_, err := service.CreateEndpoint(ctx, 1, &WebhookEndpointRequest{URL: "https://93.184.216.34/hook"})
+	assert.NoError(t, err, "public addresses are accepted")
+}
+
+func TestWebhookService_RefusesInternalConnections(t *testing.T) {
+	hit := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hit = true
+	}))
+	defer server.Close()
+
+	// An endpoint saved while its host resolved to a public address, then rebound to loopback
+	endpoint := models.WebhookEndpoint{ID: 1, UserID: 1, Secret: "whsec_test", Enabled: true, URL: server.URL}
+	repo := &fakeWebhookRepository{}
+	service := newTestWebhookServiceStrict(repo)
+
+	delivery := &models.WebhookDelivery{ID: 1, EndpointID: 1, EventID: "evt_1", Payload: `{}`, Status: models.WebhookDeliveryPending, Endpoint: endpoint}
+	service.deliver(context.Background(), delivery)
+
+	assert.False(t, hit, "the connection is refused at dial time")
+	assert.Equal(t, models.WebhookDeliveryPending, delivery.Status)
+	assert.Contains(t, delivery.LastError, "private, loopback or link-local")
+}
+
+func TestWebhookService_DoesNotFollowRedirects(t *testing.T) {
+	redirected := false
+	internal := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		redirected = true
+	}))
+	defer internal.Close()
+	server := httptest.NewServer(http.RedirectHandler(internal.URL, http.StatusTemporaryRedirect))
+	defer server.Close()
+
+	endpoint := models.WebhookEndpoint{ID: 1, UserID: 1, Secret: "whsec_test", Enabled: true, URL: server.URL}
+	repo := &fakeWebhookRepository{}
+	service := newTestWebhookService(repo)
+
+	delivery := &models.WebhookDelivery{ID: 1, EndpointID: 1, EventID: "evt_1", Payload: `{}`, Status: models.WebhookDeliveryPending, Endpoint: endpoint}
+	service.deliver(context.Background(), delivery)
+
+	assert.False(t, redirected)
+	assert.Equal(t, "endpoint responded with status 307", delivery.LastError)
+}
@@ -0,0 +1,88 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"syscall"
+	"time"
+)
+
+// ErrWebhookTargetNotAllowed is returned for webhook URLs that resolve to
+// addresses on the server's own network
+var ErrWebhookTargetNotAllowed = errors.New("webhook URL resolves to a private, loopback or link-local address")
+
+// sharedAddressSpace is the carrier-grade NAT range (RFC 6598), which is
+// internal to providers' networks like the private ranges
+var sharedAddressSpace = &net.IPNet{IP: net.IPv4(100, 64, 0, 0), Mask: net.CIDRMask(10, 32)}
+
+// allowedWebhookIP reports whether webhooks may be delivered to ip. Loopback,
+// private, link-local, multicast and unspecified addresses are refused so
+// users cannot make the server send requests into its own network, e.g. to
+// the cloud metadata service at 169.254.169.254.
+func allowedWebhookIP(ip net.IP) bool {
+	return !(ip.IsLoopback() ||
+		ip.IsPrivate() ||
+		ip.IsLinkLocalUnicast() ||
+		ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() ||
+		ip.IsMulticast() ||
+		ip.IsUnspecified() ||
+		sharedAddressSpace.Contains(ip))
+}
+
+// checkWebhookHost resolves a webhook URL's host and fails unless every
+// address it resolves to is allowed
+func checkWebhookHost(ctx context.Context, host string) error {
+	addrs, err := net.DefaultResolver.LookupIPAddr(ctx, host)
+	if err != nil || len(addrs) == 0 {
+		return ErrInvalidWebhook
+	}
+	for _, addr := range addrs {
+		if !allowedWebhookIP(addr.IP) {
+			return ErrWebhookTargetNotAllowed
+		}
+	}
+	return nil
+}
+
+// webhookDialControl refuses connections to addresses webhooks may not be
+// delivered to. It runs on the resolved address of every connection, so a
+// host that resolved to a public address when the endpoint was saved cannot
+// later be rebound to an internal one.
+func webhookDialControl(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	ip := net.ParseIP(host)
+	if ip == nil || !allowedWebhookIP(ip) {
+		return fmt.Errorf("%w: %s", ErrWebhookTargetNotAllowed, host)
+	}
+	return nil
+}
+
+// newWebhookHTTPClient builds the client deliveries are sent with. Redirects
+// are not followed, since they could lead to an internal address, and
+// unless allowPrivate is set connections are only made to allowed addresses.
+func newWebhookHTTPClient(timeout time.Duration, allowPrivate bool) *http.Client {
+	dialer := &net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second}
+	if !allowPrivate {
+		dialer.Control = webhookDialControl
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	// A proxy would make the connection checks apply to the proxy rather than the endpoint
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+
+	return &http.Client{
+		Timeout:   timeout,
+		Transport: transport,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
@@ -0,0 +1,142 @@
+// Package units converts between raw integer token amounts and decimal
+// strings using exact arithmetic.
+package units
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+// ErrInvalidDecimal is returned when a string is not a plain decimal number
+var ErrInvalidDecimal = errors.New("invalid decimal number")
+
+// ErrTooManyDecimals is returned when a value has more fractional digits than the token supports
+var ErrTooManyDecimals = errors.New("too many decimal places")
+
+// Pow10 returns 10^n
+func Pow10(n uint8) *big.Int {
+	return new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(n)), nil)
+}
+
+// FormatUnits formats a raw amount with the given number of decimals, e.g.
+// 1500000 with 6 decimals is "1.5". The result is exact; trailing zeros of
+// the fraction are dropped and whole numbers have no decimal point.
+func FormatUnits(amount *big.Int, decimals uint8) string {
+	if amount == nil {
+		return "0"
+	}
+
+	digits := new(big.Int).Abs(amount).String()
+	sign := ""
+	if amount.Sign() < 0 {
+		sign = "-"
+	}
+
+	if decimals == 0 {
+		return sign + digits
+	}
+
+	// Left-pad so there is at least one integer digit
+	if len(digits) <= int(decimals) {
+		digits = strings.Repeat("0", int(decimals)-len(digits)+1) + digits
+	}
+
+	split := len(digits) - int(decimals)
+	whole, fraction := digits[:split], strings.TrimRight(digits[split:], "0")
+	if fraction == "" {
+		return sign + whole
+	}
+	return sign + whole + "." + fraction
+}
+
+// ParseUnits parses a decimal string into a raw amount with the given number
+// of decimals, e.g. "1.5" with 6 decimals is 1500000. Values with more
+// fractional digits than decimals are rejected rather than rounded.
+func ParseUnits(value string, decimals uint8) (*big.Int, error) {
+	value = strings.TrimSpace(value)
+
+	negative := false
+	switch {
+	case strings.HasPrefix(value, "-"):
+		negative = true
+		value = value[1:]
+	case strings.HasPrefix(value, "+"):
+		value = value[1:]
+	}
+
+	whole, fraction, _ := strings.Cut(value, ".")
+	if whole == "" && fraction == "" || !isDigits(whole) || !isDigits(fraction) {
+		return nil, fmt.Errorf("%w: %q", ErrInvalidDecimal, value)
+	}
+
+	fraction = strings.TrimRight(fraction, "0")
+	if len(fraction) > int(decimals) {
+		return nil, fmt.Errorf("%w: %q has more than %d", ErrTooManyDecimals, value, decimals)
+	}
+
+	digits := whole + fraction + strings.Repeat("0", int(decimals)-len(fraction))
+	amount, ok := new(big.Int).SetString(digits, 10)
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrInvalidDecimal, value)
+	}
+	if negative {
+		amount.Neg(amount)
+	}
+	return amount, nil
+}
+
+// ToRat converts a raw amount into an exact rational number of whole tokens
+func ToRat(amount *big.Int, decimals uint8) *big.Rat {
+	if amount == nil {
+		return new(big.Rat)
+	}
+	return new(big.Rat).SetFrac(amount, Pow10(decimals))
+}
+
+// ParseDecimal parses a plain decimal string such as "-12.345" into an exact
+// rational number. Fractions ("1/3") and exponents ("1e3") are rejected.
+func ParseDecimal(value string) (*big.Rat, error) {
+	trimmed := strings.TrimSpace(value)
+	unsigned := strings.TrimPrefix(strings.TrimPrefix(trimmed, "-"), "+")
+
+	whole, fraction, _ := strings.Cut(unsigned, ".")
+	if whole == "" && fraction == "" || !isDigits(whole) || !isDigits(fraction) {
+		return nil, fmt.Errorf("%w: %q", ErrInvalidDecimal, value)
+	}
+
+	rat, ok := new(big.Rat).SetString(trimmed)
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrInvalidDecimal, value)
+	}
+	return rat, nil
+}
+
+// FormatDecimal formats a rational number with exactly places fractional
+// digits, rounding half away from zero (1.005 → "1.01", -1.005 → "-1.01").
+// Values that round to zero are formatted without a sign.
+func FormatDecimal(value *big.Rat, places int) string {
+	if value == nil {
+		value = new(big.Rat)
+	}
+	if places < 0 {
+		places = 0
+	}
+
+	formatted := value.FloatString(places)
+	if strings.HasPrefix(formatted, "-") && strings.Trim(formatted[1:], "0.") == "" {
+		return formatted[1:]
+	}
+	return formatted
+}
+
+// isDigits reports whether s consists only of ASCII digits; the empty string qualifies
+func isDigits(s string) bool {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,219 @@
+package units
+
+import (
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func mustInt(t *testing.T, value string) *big.Int {
+	t.Helper()
+	amount, ok := new(big.Int).SetString(value, 10)
+	require.True(t, ok, "invalid integer %q", value)
+	return amount
+}
+
+// maxUint256 is the largest balance an ERC-20 contract can report
+const maxUint256 = "115792089237316195423570985008687907853269984665640564039457584007913129639935"
+
+func TestFormatUnits(t *testing.T) {
+	tests := []struct {
+		name     string
+		amount   string
+		decimals uint8
+		want     string
+	}{
+		{"zero", "0", 18, "0"},
+		{"zero without decimals", "0", 0, "0"},
+		{"one wei", "1", 18, "0.000000000000000001"},
+		{"one ether", "1000000000000000000", 18, "1"},
+		{"one and a half ether", "1500000000000000000", 18, "1.5"},
+		{"one usdc", "1000000", 6, "1"},
+		{"smallest usdc", "1", 6, "0.000001"},
+		{"usdc with cents", "1234567", 6, "1.234567"},
+		{"trailing zeros trimmed", "1230000", 6, "1.23"},
+		{"no decimals", "42", 0, "42"},
+		{"exactly decimals digits", "123456", 6, "0.123456"},
+		{"one digit short", "12345", 6, "0.012345"},
+		{"wbtc", "2100000000000000", 8, "21000000"},
+		{"negative", "-1500000", 6, "-1.5"},
+		{"negative fraction", "-1", 18, "-0.000000000000000001"},
+		{"max decimals", "1", 77, "0." + strings.Repeat("0", 76) + "1"},
+		{"max uint256 with 18 decimals", maxUint256, 18, "115792089237316195423570985008687907853269984665640564039457.584007913129639935"},
+		{"max uint256 with 0 decimals", maxUint256, 0, maxUint256},
+		{"max uint256 with 77 decimals", maxUint256, 77, "1.15792089237316195423570985008687907853269984665640564039457584007913129639935"},
+		{"larger than uint256", maxUint256 + "000", 18, "115792089237316195423570985008687907853269984665640564039457584.007913129639935"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, FormatUnits(mustInt(t, tt.amount), tt.decimals))
+		})
+	}
+
+	assert.Equal(t, "0", FormatUnits(nil, 18))
+}
+
+func TestFormatUnits_DoesNotModifyInput(t *testing.T) {
+	amount := big.NewInt(-1500000)
+	FormatUnits(amount, 6)
+	assert.Equal(t, "-1500000", amount.String())
+}
+
+func TestParseUnits(t *testing.T) {
+	tests := []struct {
+		name     string
+		value    string
+		decimals uint8
+		want     string
+	}{
+		{"zero", "0", 18, "0"},
+		{"integer", "1", 18, "1000000000000000000"},
+		{"fraction", "1.5", 6, "1500000"},
+		{"leading point", ".5", 6, "500000"},
+		{"trailing point", "5.", 6, "5000000"},
+		{"smallest unit", "0.000001", 6, "1"},
+		{"trailing zeros beyond decimals", "1.5000000000", 6, "1500000"},
+		{"leading zeros", "0001.25", 2, "125"},
+		{"whitespace", "  2.5 ", 1, "25"},
+		{"explicit plus", "+3", 0, "3"},
+		{"negative", "-1.5", 6, "-1500000"},
+		{"max uint256", "115792089237316195423570985008687907853269984665640564039457.584007913129639935", 18, maxUint256},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			amount, err := ParseUnits(tt.value, tt.decimals)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, amount.String())
+		})
+	}
+}
+
+func TestParseUnits_Invalid(t *testing.T) {
+	tests := []struct {
+		value    string
+		decimals uint8
+		wantErr  error
+	}{
+		{"", 18, ErrInvalidDecimal},
+		{".", 18, ErrInvalidDecimal},
+		{"-", 18, ErrInvalidDecimal},
+		{"abc", 18, ErrInvalidDecimal},
+		{"1.2.3", 18, ErrInvalidDecimal},
+		{"1e18", 18, ErrInvalidDecimal},
+		{"1/2", 18, ErrInvalidDecimal},
+		{"--1", 18, ErrInvalidDecimal},
+		{"1,5", 18, ErrInvalidDecimal},
+		{"0x10", 18, ErrInvalidDecimal},
+		{"1.0000001", 6, ErrTooManyDecimals},
+		{"0.5", 0, ErrTooManyDecimals},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			_, err := ParseUnits(tt.value, tt.decimals)
+			assert.ErrorIs(t, err, tt.wantErr)
+		})
+	}
+}
+
+func TestParseUnits_RoundTrip(t *testing.T) {
+	amounts := []string{"0", "1", "10", "999999", "1000000", "1000001", "123456789012345678901234567890", maxUint256}
+	for _, decimals := range []uint8{0, 1, 6, 8, 18, 24, 77} {
+		for _, raw := range amounts {
+			amount := mustInt(t, raw)
+			parsed, err := ParseUnits(FormatUnits(amount, decimals), decimals)
+			require.NoError(t, err)
+			assert.Equal(t, 0, amount.Cmp(parsed), "round trip of %s with %d decimals", raw, decimals)
+		}
+	}
+}
+
+func TestToRat(t *testing.T) {
+	assert.Equal(t, "3/2", ToRat(big.NewInt(1500000), 6).RatString())
+	assert.Equal(t, "1/1000000000000000000", ToRat(big.NewInt(1), 18).RatString())
+	assert.Equal(t, "0", ToRat(nil, 18).RatString())
+
+	// Exact for values beyond float64 precision
+	amount := mustInt(t, maxUint256)
+	assert.Equal(t, FormatUnits(amount, 18), strings.TrimRight(strings.TrimRight(ToRat(amount, 18).FloatString(18), "0"), "."))
+}
+
+func TestPow10(t *testing.T) {
+	assert.Equal(t, "1", Pow10(0).String())
+	assert.Equal(t, "1000000", Pow10(6).String())
+	assert.Equal(t, "1"+strings.Repeat("0", 77), Pow10(77).String())
+}
+
+func TestParseDecimal(t *testing.T) {
+	valid := map[string]string{
+		"0":        "0",
+		"1.5":      "3/2",
+		"-1.5":     "-3/2",
+		"+2":       "2",
+		".25":      "1/4",
+		"3.":       "3",
+		" 0.1 ":    "1/10",
+		"0.000001": "1/1000000",
+		maxUint256: maxUint256,
+	}
+	for value, want := range valid {
+		rat, err := ParseDecimal(value)
+		require.NoError(t, err, value)
+		assert.Equal(t, want, rat.RatString(), value)
+	}
+
+	for _, value := range []string{"", ".", "-", "abc", "1/3", "1e3", "1.2.3", "--1", "0x10", "Inf", "NaN"} {
+		_, err := ParseDecimal(value)
+		assert.ErrorIs(t, err, ErrInvalidDecimal, value)
+	}
+}
+
+func TestFormatDecimal(t *testing.T) {
+	tests := []struct {
+		value  string
+		places int
+		want   string
+	}{
+		{"0", 2, "0.00"},
+		{"1", 0, "1"},
+		{"1.5", 0, "2"},
+		{"2.5", 0, "3"},
+		{"-1.5", 0, "-2"},
+		{"-2.5", 0, "-3"},
+		{"1.004", 2, "1.00"},
+		{"1.005", 2, "1.01"},
+		{"1.0049999999999999999999", 2, "1.00"},
+		{"-1.005", 2, "-1.01"},
+		{"-1.004", 2, "-1.00"},
+		{"0.125", 2, "0.13"},
+		{"0.135", 2, "0.14"},
+		{"-0.001", 2, "0.00"},
+		{"-0.004", 2, "0.00"},
+		{"-0.005", 2, "-0.01"},
+		{"0.0000005", 6, "0.000001"},
+		{"0.00000049", 6, "0.000000"},
+		{"123.456", 6, "123.456000"},
+		{"99.995", 2, "100.00"},
+		{"999999999999999999999999999999.999", 2, "1000000000000000000000000000000.00"},
+		{"5", -1, "5"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			value, err := ParseDecimal(tt.value)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, FormatDecimal(value, tt.places))
+		})
+	}
+
+	// Repeating fractions are rounded, not truncated
+	assert.Equal(t, "0.33", FormatDecimal(big.NewRat(1, 3), 2))
+	assert.Equal(t, "0.67", FormatDecimal(big.NewRat(2, 3), 2))
+	assert.Equal(t, "-0.67", FormatDecimal(big.NewRat(-2, 3), 2))
+	assert.Equal(t, "0.00", FormatDecimal(nil, 2))
+}
@@ -99,6 +99,7 @@ fi
 # Step 8: Build the application
 print_status "Step 8: Building application..."
 go build -o bin/server cmd/server/main.go
+go build -o bin/worker cmd/worker/main.go
 print_success "Build completed"
 
 # Step 9: Run integration tests (if they exist)